@@ -0,0 +1,518 @@
+/**
+ * FILE HEADER: cmd/cli.go
+ *
+ * PURPOSE:
+ * Subcommand CLI SIMDOKPOL untuk menjalankan server tanpa system tray (mis. sebagai
+ * service di Linux headless) dan untuk tugas administrasi: migrasi, pengguna, backup,
+ * pengaturan, log audit, kunci JWT, dan sertifikat HTTPS. Semua subcommand memakai
+ * wiring dependensi yang sama dengan server web (setupDependencies).
+ */
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"simdokpol/internal/config"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"simdokpol/internal/utils"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// printUsage menulis daftar subcommand ke stderr dan mengembalikan exit code 2.
+func printUsage() int {
+	fmt.Fprintln(os.Stderr, `Penggunaan: simdokpol [perintah]
+
+Tanpa perintah, aplikasi berjalan di system tray seperti "simdokpol tray".
+
+Perintah:
+  serve [opsi server]                    Menjalankan server web tanpa system tray dan browser
+  tray [opsi server]                     Menjalankan server web dari system tray
+  migrate up                             Menerapkan semua migrasi database yang belum dijalankan
+  migrate down [N] --yes                 Membatalkan N migrasi terakhir (bawaan 1)
+  migrate version                        Menampilkan versi migrasi database
+  user create --nrp NRP --nama NAMA --peran PERAN [--pangkat P] [--jabatan J] [--regu R] [--password-stdin]
+                                         Membuat pengguna; tanpa --password-stdin diberi kata sandi sementara
+  user reset-password NRP                Memberikan kata sandi sementara yang wajib diganti saat login
+  backup create                          Membuat backup database di folder backup
+  backup restore FILE --yes              Memulihkan database dari file backup (hentikan server terlebih dahulu)
+  config get [KUNCI]                     Menampilkan pengaturan sistem
+  config set KUNCI=NILAI [KUNCI=NILAI...] Mengubah pengaturan sistem
+  audit <verify|anchor|retention|signing-key>
+                                         Memverifikasi, menjangkarkan, mengarsipkan log audit, atau
+                                         menampilkan kunci publik penanda tangan ekspor
+  jwt <list|rotate [--now]>              Menampilkan atau merotasi kunci penandatanganan JWT
+  tls <export-ca PATH|renew>             Mengekspor CA lokal atau menerbitkan ulang sertifikat server
+
+Opsi server (menimpa nilai dari environment dan .env):
+  --host HOST        Alamat listen (HOST), kosong berarti semua antarmuka
+  --port PORT        Port HTTP (PORT)
+  --tls-port PORT    Port HTTPS (TLS_PORT)
+  --base-url URL     URL publik aplikasi (BASE_URL)`)
+	return 2
+}
+
+// runCommand menjalankan subcommand CLI dan mengembalikan exit code proses.
+func runCommand(args []string) int {
+	switch args[0] {
+	case "serve":
+		if !applyServerFlags(args) {
+			return 2
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		runWebServer(ctx)
+		return 0
+	case "tray":
+		if !applyServerFlags(args) {
+			return 2
+		}
+		runTray()
+		return 0
+	case "migrate":
+		return runMigrateCommand(args[1:])
+	case "tls":
+		if len(args) < 2 {
+			return printUsage()
+		}
+		return runTLSCommand(loadConfig(getExecutableDir()).TLS, args[1:])
+	case "help", "-h", "--help":
+		printUsage()
+		return 0
+	case "audit", "jwt", "user", "backup", "config":
+		if len(args) < 2 {
+			return printUsage()
+		}
+	default:
+		return printUsage()
+	}
+
+	exeDir := getExecutableDir()
+	cfg, db := initApp(exeDir)
+	closeDB := sync.OnceFunc(func() { closeDatabase(db) })
+	defer closeDB()
+	repos, svcs, _ := setupDependencies(db, cfg, exeDir)
+	appAuditService = svcs.AuditService
+	flushAudit := sync.OnceFunc(flushAuditLog)
+	defer flushAudit()
+
+	// Ctrl+C membatalkan query yang sedang berjalan sehingga perintah berhenti dengan rapi.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	switch args[0] {
+	case "audit":
+		return runAuditCommand(ctx, svcs.AuditService, svcs.ConfigService, args[1:])
+	case "jwt":
+		return runJWTCommand(ctx, svcs.JWTKeyService, args[1:])
+	case "user":
+		return runUserCommand(ctx, repos, svcs, args[1:])
+	case "backup":
+		if args[1] == "restore" {
+			// Antrean log audit ditulis ke database lama lebih dulu, lalu koneksi ditutup
+			// agar file dapat ditimpa dengan aman
+			flushAudit()
+			closeDB()
+		}
+		return runBackupCommand(ctx, svcs.BackupService, args[1:])
+	default:
+		return runConfigCommand(ctx, svcs.ConfigService, args[1:])
+	}
+}
+
+// applyServerFlags membaca opsi server milik "serve" dan "tray" lalu menuliskannya ke environment.
+// godotenv tidak menimpa variabel yang sudah ada, sehingga flag lebih diutamakan daripada .env.
+func applyServerFlags(args []string) bool {
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	envByFlag := map[string]string{"host": "HOST", "port": "PORT", "tls-port": "TLS_PORT", "base-url": "BASE_URL"}
+	for name, env := range envByFlag {
+		fs.String(name, "", "menimpa "+env)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		return false
+	}
+	if fs.NArg() > 0 {
+		fmt.Fprintf(os.Stderr, "Argumen tidak dikenal: %s\n", strings.Join(fs.Args(), " "))
+		return false
+	}
+	fs.Visit(func(f *flag.Flag) {
+		os.Setenv(envByFlag[f.Name], f.Value.String())
+	})
+	return true
+}
+
+// runAuditCommand menjalankan subcommand "audit".
+func runAuditCommand(ctx context.Context, auditService services.AuditLogService, configService services.ConfigService, args []string) int {
+	switch args[0] {
+	case "verify":
+		report, err := auditService.VerifyChain(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal memverifikasi rantai log audit: %v\n", err)
+			return 1
+		}
+		if !report.Valid {
+			fmt.Printf("RANTAI RUSAK pada entri ID %d: %s\n", report.BrokenAtID, report.Reason)
+			fmt.Printf("Entri valid sebelum titik rusak: %d\n", report.EntriesChecked)
+			return 1
+		}
+		fmt.Printf("Rantai log audit VALID: %d entri, %d arsip, %d jangkar diperiksa. Hash terakhir (ID %d): %s\n",
+			report.EntriesChecked, report.ArchivesChecked, report.AnchorsChecked, report.LastID, report.LastHash)
+		return 0
+	case "anchor":
+		anchorPath, err := auditService.ExportAnchor(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal membuat jangkar log audit: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Jangkar log audit disimpan di: %s\n", anchorPath)
+		return 0
+	case "signing-key":
+		key, err := auditService.SigningKey()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal memuat kunci penanda tangan: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Algoritma   : %s\nKunci publik: %s\nFingerprint : %s\n", key.Algorithm, key.PublicKey, key.Fingerprint)
+		return 0
+	case "retention":
+		appConfig, err := configService.GetConfig(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal membaca konfigurasi: %v\n", err)
+			return 1
+		}
+		archives, err := auditService.ApplyRetention(ctx, appConfig.AuditRetentionMonths)
+		for _, archive := range archives {
+			fmt.Printf("%d entri log audit (ID %d-%d) dipindahkan ke arsip %s\n",
+				archive.EntryCount, archive.FirstID, archive.LastID, archive.FileName)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal menerapkan retensi log audit: %v\n", err)
+			return 1
+		}
+		if len(archives) == 0 {
+			fmt.Println("Tidak ada log audit yang melewati masa retensi.")
+		}
+		return 0
+	default:
+		return printUsage()
+	}
+}
+
+// runMigrateCommand menjalankan subcommand "migrate". Database dibuka tanpa migrasi otomatis
+// agar versi dapat diperiksa atau diturunkan.
+func runMigrateCommand(args []string) int {
+	if len(args) < 1 {
+		return printUsage()
+	}
+	exeDir := getExecutableDir()
+	cfg := loadConfig(exeDir)
+	db, err := openDatabase(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: Gagal membuka database: %v\n", err)
+		return 1
+	}
+	m, err := newMigrator(db, exeDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return 1
+	}
+	defer m.Close()
+
+	switch args[0] {
+	case "up":
+		err = m.Up()
+	case "down":
+		steps, confirmed := 1, false
+		for _, arg := range args[1:] {
+			if arg == "--yes" {
+				confirmed = true
+				continue
+			}
+			n, convErr := strconv.Atoi(arg)
+			if convErr != nil || n < 1 {
+				return printUsage()
+			}
+			steps = n
+		}
+		if !confirmed {
+			fmt.Fprintf(os.Stderr, "Membatalkan %d migrasi dapat menghapus tabel beserta datanya. Buat backup dengan \"simdokpol backup create\", lalu ulangi dengan --yes.\n", steps)
+			return 2
+		}
+		err = m.Steps(-steps)
+	case "version":
+	default:
+		return printUsage()
+	}
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Println("Tidak ada migrasi yang perlu dijalankan.")
+	} else if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: Migrasi gagal: %v\n", err)
+		return 1
+	}
+
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Println("Versi migrasi: belum ada migrasi yang diterapkan")
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "ERROR: Gagal membaca versi migrasi: %v\n", err)
+		return 1
+	default:
+		fmt.Printf("Versi migrasi: %d (dirty: %t)\n", version, dirty)
+	}
+	return 0
+}
+
+// runUserCommand menjalankan subcommand "user", misalnya untuk membuat Super Admin pada server
+// headless atau memulihkan akses admin yang lupa kata sandi.
+func runUserCommand(ctx context.Context, repos Repositories, svcs Services, args []string) int {
+	switch args[0] {
+	case "create":
+		fs := flag.NewFlagSet("user create", flag.ContinueOnError)
+		nrp := fs.String("nrp", "", "NRP pengguna")
+		nama := fs.String("nama", "", "Nama lengkap")
+		peran := fs.String("peran", models.RoleOperator, "Peran pengguna")
+		pangkat := fs.String("pangkat", "", "Pangkat")
+		jabatan := fs.String("jabatan", "", "Jabatan")
+		regu := fs.String("regu", "", "Regu jaga")
+		passwordStdin := fs.Bool("password-stdin", false, "Baca kata sandi dari stdin")
+		if err := fs.Parse(args[1:]); err != nil {
+			return 2
+		}
+		if strings.TrimSpace(*nrp) == "" || strings.TrimSpace(*nama) == "" {
+			fmt.Fprintln(os.Stderr, "ERROR: --nrp dan --nama wajib diisi")
+			return 2
+		}
+
+		password, temporary := "", !*passwordStdin
+		if *passwordStdin {
+			line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil && !errors.Is(err, io.EOF) {
+				fmt.Fprintf(os.Stderr, "ERROR: Gagal membaca kata sandi dari stdin: %v\n", err)
+				return 1
+			}
+			password = strings.TrimRight(line, "\r\n")
+		} else {
+			generated, err := svcs.PasswordPolicy.GenerateTemporary(ctx)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "ERROR: Gagal membuat kata sandi sementara: %v\n", err)
+				return 1
+			}
+			password = generated
+		}
+
+		user := &models.User{
+			NRP:         strings.TrimSpace(*nrp),
+			NamaLengkap: strings.ToUpper(strings.TrimSpace(*nama)),
+			Peran:       strings.ToUpper(*peran),
+			Pangkat:     strings.ToUpper(*pangkat),
+			Jabatan:     strings.ToUpper(*jabatan),
+			Regu:        strings.ToUpper(*regu),
+			KataSandi:   password,
+			// Kata sandi sementara wajib diganti; tanda ini ikut tersimpan saat pengguna dibuat
+			MustChangePassword: temporary,
+		}
+		if err := svcs.UserService.Create(ctx, user); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal membuat pengguna: %v\n", err)
+			return 1
+		}
+		if temporary {
+			fmt.Printf("Pengguna %s (NRP %s, peran %s) dibuat. Kata sandi sementara: %s\n", user.NamaLengkap, user.NRP, user.Peran, password)
+			fmt.Println("Kata sandi ini wajib diganti saat login pertama.")
+			return 0
+		}
+		fmt.Printf("Pengguna %s (NRP %s, peran %s) dibuat.\n", user.NamaLengkap, user.NRP, user.Peran)
+		return 0
+	case "reset-password":
+		if len(args) < 2 {
+			return printUsage()
+		}
+		user, err := repos.UserRepo.FindByNRP(ctx, args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Pengguna dengan NRP %s tidak ditemukan\n", args[1])
+			return 1
+		}
+		password, err := svcs.UserService.IssueTemporaryPassword(ctx, user.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal memberikan kata sandi sementara: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Kata sandi sementara untuk %s (NRP %s): %s\n", user.NamaLengkap, user.NRP, password)
+		fmt.Println("Semua sesi pengguna dicabut dan kata sandi wajib diganti saat login berikutnya.")
+		return 0
+	default:
+		return printUsage()
+	}
+}
+
+// runBackupCommand menjalankan subcommand "backup".
+func runBackupCommand(ctx context.Context, backupService services.BackupService, args []string) int {
+	switch args[0] {
+	case "create":
+		backupPath, err := backupService.CreateBackup(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal membuat backup: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Backup database disimpan di: %s\n", backupPath)
+		return 0
+	case "restore":
+		if len(args) < 2 {
+			return printUsage()
+		}
+		if len(args) < 3 || args[2] != "--yes" {
+			fmt.Fprintln(os.Stderr, "Restore menimpa database saat ini. Hentikan server SIMDOKPOL, lalu ulangi dengan --yes.")
+			return 2
+		}
+		file, err := os.Open(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal membuka file backup: %v\n", err)
+			return 1
+		}
+		defer file.Close()
+		if err := backupService.RestoreBackup(ctx, file); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal memulihkan database: %v\n", err)
+			return 1
+		}
+		fmt.Println("Database berhasil dipulihkan. Migrasi yang belum diterapkan akan dijalankan saat server dimulai.")
+		return 0
+	default:
+		return printUsage()
+	}
+}
+
+// runConfigCommand menjalankan subcommand "config". Kunci dan aturan nilainya mengikuti skema
+// pengaturan yang sama dengan API /settings. Server yang sedang berjalan memakai nilai baru
+// setelah di-restart.
+func runConfigCommand(ctx context.Context, configService services.ConfigService, args []string) int {
+	current, err := configService.GetSettings(ctx)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: Gagal membaca pengaturan: %v\n", err)
+		return 1
+	}
+
+	switch args[0] {
+	case "get":
+		if len(args) > 1 {
+			if _, ok := services.LookupSetting(args[1]); !ok {
+				fmt.Fprintf(os.Stderr, "ERROR: Kunci pengaturan %s tidak dikenal\n", args[1])
+				return 1
+			}
+			fmt.Println(current[args[1]])
+			return 0
+		}
+		for _, def := range services.SettingsSchema() {
+			fmt.Printf("%s=%s\n", def.Key, current[def.Key])
+		}
+		return 0
+	case "set":
+		if len(args) < 2 {
+			return printUsage()
+		}
+		settings := map[string]string{}
+		for _, pair := range args[1:] {
+			key, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				fmt.Fprintf(os.Stderr, "ERROR: %s bukan pasangan KUNCI=NILAI\n", pair)
+				return 2
+			}
+			settings[key] = value
+		}
+		if err := services.ValidateSettings(settings); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return 1
+		}
+		if err := configService.SaveConfig(ctx, settings); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal menyimpan pengaturan: %v\n", err)
+			return 1
+		}
+		log.Printf("INFO: Pengaturan sistem diperbarui melalui CLI: %s", strings.Join(args[1:], ", "))
+		fmt.Println("Pengaturan disimpan. Restart server yang sedang berjalan agar nilai baru dipakai.")
+		return 0
+	default:
+		return printUsage()
+	}
+}
+
+// runJWTCommand menjalankan subcommand "jwt". Rotasi dari CLI terlihat oleh server yang
+// sedang berjalan paling lambat satu menit kemudian.
+func runJWTCommand(ctx context.Context, jwtKeyService services.JWTKeyService, args []string) int {
+	switch args[0] {
+	case "list":
+		keys, err := jwtKeyService.FindAll(ctx)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal mengambil daftar kunci JWT: %v\n", err)
+			return 1
+		}
+		for _, key := range keys {
+			validUntil := "-"
+			if key.ValidUntil != nil {
+				validUntil = key.ValidUntil.Local().Format("2006-01-02 15:04")
+			}
+			fmt.Printf("%-18s %-8s dibuat %s  berlaku s.d. %s\n", key.KID, key.Status, key.CreatedAt.Local().Format("2006-01-02 15:04"), validUntil)
+		}
+		return 0
+	case "rotate":
+		immediate := len(args) > 1 && args[1] == "--now"
+		key, err := jwtKeyService.Rotate(ctx, immediate)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal merotasi kunci JWT: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Kunci JWT baru aktif dengan kid %s\n", key.KID)
+		if immediate {
+			fmt.Println("Kunci lama sudah dicabut; semua pengguna harus login ulang.")
+		}
+		return 0
+	default:
+		return printUsage()
+	}
+}
+
+// runTLSCommand menjalankan subcommand "tls". Sertifikat dibuat jika belum ada, walaupun TLS_ENABLED
+// belum diaktifkan, agar CA dapat dibagikan ke komputer klien sebelum HTTPS dinyalakan.
+func runTLSCommand(tlsCfg config.TLSConfig, args []string) int {
+	localCA := utils.NewLocalCA(tlsCfg.CertDir, tlsCfg.ExtraHosts)
+
+	switch args[0] {
+	case "export-ca":
+		if len(args) < 2 {
+			return printUsage()
+		}
+		if err := localCA.Ensure(); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal menyiapkan CA lokal: %v\n", err)
+			return 1
+		}
+		data, err := os.ReadFile(localCA.CACertPath())
+		if err == nil {
+			err = os.WriteFile(args[1], data, 0644)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal mengekspor sertifikat CA: %v\n", err)
+			return 1
+		}
+		fmt.Printf("Sertifikat CA diekspor ke %s. Pasang sebagai Trusted Root Certification Authority di komputer klien.\n", args[1])
+		return 0
+	case "renew":
+		if err := localCA.Renew(); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: Gagal menerbitkan ulang sertifikat server: %v\n", err)
+			return 1
+		}
+		fmt.Println("Sertifikat server diterbitkan ulang. Server yang sedang berjalan memakainya pada pemeriksaan berikutnya atau setelah restart.")
+		return 0
+	default:
+		return printUsage()
+	}
+}
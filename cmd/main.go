@@ -13,25 +13,35 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
 	"html/template"
 	"io"
 	"log"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
 	"runtime"
 	"simdokpol/internal/config"
 	"simdokpol/internal/controllers"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/logging"
+	"simdokpol/internal/metrics"
 	"simdokpol/internal/middleware"
+	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
 	"simdokpol/internal/services"
 	"simdokpol/internal/utils"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	_ "simdokpol/docs"
@@ -48,19 +58,45 @@ import (
 	ginSwagger "github.com/swaggo/gin-swagger"
 	gormsqlite "gorm.io/driver/sqlite"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 var version = "dev"
 
 const (
-	defaultPort = ":8080"
-	defaultURL  = "http://localhost:8080"
+	// auditAnchorInterval adalah jeda antar ekspor jangkar rantai log audit.
+	auditAnchorInterval = 24 * time.Hour
+
+	// auditRetentionInterval adalah jeda antar pemeriksaan retensi log audit.
+	auditRetentionInterval = 24 * time.Hour
+
+	// sessionPurgeInterval adalah jeda antar pembersihan sesi login yang sudah kedaluwarsa.
+	sessionPurgeInterval = 6 * time.Hour
+
+	// jwtKeyRotationInterval adalah jeda antar pemeriksaan umur kunci penandatanganan JWT.
+	jwtKeyRotationInterval = time.Hour
+
+	// auditFlushTimeout adalah batas waktu menunggu antrean log audit kosong saat aplikasi ditutup.
+	auditFlushTimeout = 10 * time.Second
+
+	// shutdownTimeout adalah batas waktu menunggu request yang sedang diproses selesai saat aplikasi dimatikan.
+	shutdownTimeout = 15 * time.Second
+
+	// tlsRenewalInterval adalah jeda antar pemeriksaan masa berlaku dan cakupan host sertifikat server.
+	tlsRenewalInterval = 12 * time.Hour
 )
 
 var (
-	vhostSetup *utils.VHostSetup
-	appURL     string
+	vhostSetup      *utils.VHostSetup
+	runningInTray   bool
+	appURL          string
+	appAuditService services.AuditLogService
+	// appReady ditutup setelah appURL final dan server web siap menerima request
+	appReady = make(chan struct{})
+	// appContext dibatalkan oleh stopApp untuk mematikan server web pada mode tray;
+	// appStopped ditutup setelah server selesai dimatikan dan database ditutup.
+	appContext context.Context
+	stopApp    context.CancelFunc
+	appStopped = make(chan struct{})
 )
 
 func getExecutableDir() string {
@@ -72,25 +108,79 @@ func getExecutableDir() string {
 }
 
 func main() {
+	// Tanpa argumen (misalnya dibuka dengan klik ganda) aplikasi berjalan di system tray
+	if len(os.Args) < 2 {
+		runTray()
+		return
+	}
+	os.Exit(runCommand(os.Args[1:]))
+}
+
+// runTray menyiapkan virtual host lalu menjalankan server web dari system tray dan membuka browser.
+func runTray() {
+	runningInTray = true
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	appContext, stopApp = ctx, stop
+
 	// Inisialisasi vhost setup
 	vhostSetup = utils.NewVHostSetup()
 	
 	// Cek dan setup vhost jika diperlukan
 	setupVirtualHost()
 	
-	// Tentukan URL yang akan digunakan
-	isSetup, _ := vhostSetup.IsSetup()
-	if isSetup {
-		appURL = vhostSetup.GetURL("8080")
-		log.Printf("INFO: Aplikasi akan diakses melalui: %s", appURL)
-	} else {
-		appURL = defaultURL
-		log.Printf("INFO: Aplikasi akan diakses melalui: %s", appURL)
-	}
-	
 	systray.Run(onReady, onExit)
 }
 
+// resolveAppURL menentukan URL yang dibuka di browser: BASE_URL jika diisi, lalu host yang
+// dikonfigurasi, domain virtual host, atau localhost, dengan skema HTTPS jika TLS bawaan aktif.
+func resolveAppURL(cfg *config.Config) string {
+	if cfg.Server.BaseURL != "" {
+		return cfg.Server.BaseURL
+	}
+	scheme, port := "http", cfg.Server.Port
+	if cfg.TLS.Enabled {
+		scheme, port = "https", cfg.TLS.Port
+	}
+
+	switch cfg.Server.Host {
+	case "", "0.0.0.0", "::", "127.0.0.1", "localhost":
+		// Virtual host hanya menunjuk ke 127.0.0.1, jadi dipakai jika server juga mendengarkan di sana
+		if vhostSetup != nil {
+			if isSetup, _ := vhostSetup.IsSetup(); isSetup {
+				return vhostSetup.GetURL(scheme, port)
+			}
+		}
+		return (&url.URL{Scheme: scheme, Host: net.JoinHostPort("localhost", port)}).String()
+	default:
+		return (&url.URL{Scheme: scheme, Host: net.JoinHostPort(cfg.Server.Host, port)}).String()
+	}
+}
+
+// listen membuka port server lebih awal agar bentrok port terdeteksi sebelum browser dibuka.
+// setting adalah nama pengaturan .env yang mengatur port tersebut.
+func listen(addr string, setting string) net.Listener {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		fatalStartup(fmt.Sprintf("Tidak dapat membuka alamat %s: %v. Port kemungkinan sudah dipakai aplikasi lain "+
+			"atau instance SIMDOKPOL lain. Ubah %s di file .env atau jalankan dengan --%s.",
+			addr, err, setting, strings.ReplaceAll(strings.ToLower(setting), "_", "-")))
+	}
+	return listener
+}
+
+// fatalStartup menghentikan aplikasi karena server gagal dimulai. Dalam mode system tray pesan
+// juga ditampilkan sebagai dialog karena log konsol tidak terlihat pengguna.
+func fatalStartup(message string) {
+	if runningInTray {
+		iconPath := filepath.Join(getExecutableDir(), "web", "static", "img", "icon.png")
+		if err := beeep.Alert("SIMDOKPOL gagal dimulai", message, iconPath); err != nil {
+			log.Printf("PERINGATAN: Gagal menampilkan peringatan: %v", err)
+		}
+	}
+	log.Fatalf("FATAL: %s", message)
+}
+
 func setupVirtualHost() {
 	log.Println("INFO: Memeriksa konfigurasi virtual host...")
 	
@@ -167,10 +257,15 @@ func onReady() {
 	
 	mQuit := systray.AddMenuItem("Keluar", "Tutup aplikasi")
 
-	go startWebServer()
+	go func() {
+		runWebServer(appContext)
+		close(appStopped)
+		// Server berhenti karena sinyal atau error, system tray ikut ditutup
+		systray.Quit()
+	}()
 
 	go func() {
-		time.Sleep(1 * time.Second)
+		<-appReady
 		notifyIconPath := filepath.Join(exeDir, "web", "static", "img", "icon.png")
 		notifyMsg := fmt.Sprintf("Aplikasi berjalan di: %s", appURL)
 		if err := beeep.Notify("SIMDOKPOL", notifyMsg, notifyIconPath); err != nil {
@@ -179,7 +274,8 @@ func onReady() {
 	}()
 
 	go func() {
-		time.Sleep(2 * time.Second)
+		<-appReady
+		time.Sleep(1 * time.Second)
 		openBrowser(appURL)
 	}()
 
@@ -187,6 +283,7 @@ func onReady() {
 		for {
 			select {
 			case <-mOpen.ClickedCh:
+				<-appReady
 				openBrowser(appURL)
 			case <-mQuit.ClickedCh:
 				systray.Quit()
@@ -195,13 +292,193 @@ func onReady() {
 	}()
 }
 
+// onExit dipanggil setelah system tray ditutup, termasuk lewat menu Keluar. Server web
+// dimatikan dengan tertib sebelum proses berakhir.
 func onExit() {
+	stopApp()
+	select {
+	case <-appStopped:
+	case <-time.After(shutdownTimeout + auditFlushTimeout + 5*time.Second):
+		log.Println("PERINGATAN: Server web belum selesai dimatikan, aplikasi tetap ditutup.")
+	}
 	log.Println("INFO: Aplikasi SIMDOKPOL ditutup.")
 }
 
-func startWebServer() {
+// flushAuditLog menunggu semua entri log audit di antrean selesai ditulis.
+func flushAuditLog() {
+	if appAuditService == nil {
+		return
+	}
+	if err := appAuditService.Close(auditFlushTimeout); err != nil {
+		log.Printf("PERINGATAN: Antrean log audit belum kosong saat ditutup: %v", err)
+	}
+	if failures := appAuditService.FailureCount(); failures > 0 {
+		log.Printf("PERINGATAN: %d entri log audit gagal ditulis selama aplikasi berjalan", failures)
+	}
+}
+
+// runWebServer menyiapkan dependensi, menjalankan server web dan job latar belakang, lalu
+// mematikan semuanya dengan tertib setelah ctx dibatalkan (sinyal SIGINT/SIGTERM atau menu
+// Keluar) atau salah satu server berhenti karena error.
+func runWebServer(ctx context.Context) {
 	exeDir := getExecutableDir()
 
+	cfg, db := initApp(exeDir)
+
+	repos, svcs, ctrls := setupDependencies(db, cfg, exeDir)
+	appAuditService = svcs.AuditService
+
+	metrics.RegisterAuditFailures(svcs.AuditService.FailureCount)
+	if sqlDB, err := db.DB(); err == nil {
+		metrics.RegisterDatabase(sqlDB)
+	}
+
+	if sealed, err := svcs.AuditService.SealLegacyEntries(ctx); err != nil {
+		log.Printf("PERINGATAN: Gagal menyegel entri log audit lama: %v", err)
+	} else if sealed > 0 {
+		log.Printf("INFO: %d entri log audit lama berhasil dirantai ke dalam hash chain", sealed)
+	}
+
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	var jobs sync.WaitGroup
+	startJob := func(job func(context.Context)) {
+		jobs.Add(1)
+		go func() {
+			defer jobs.Done()
+			job(jobsCtx)
+		}()
+	}
+	startJob(func(ctx context.Context) { runAuditAnchorScheduler(ctx, svcs.AuditService, auditAnchorInterval) })
+	startJob(func(ctx context.Context) {
+		runAuditRetentionScheduler(ctx, svcs.AuditService, svcs.ConfigService, auditRetentionInterval)
+	})
+	startJob(func(ctx context.Context) { runSessionPurgeScheduler(ctx, svcs.SessionService, sessionPurgeInterval) })
+	startJob(func(ctx context.Context) {
+		runJWTKeyRotationScheduler(ctx, svcs.JWTKeyService, svcs.ConfigService, jwtKeyRotationInterval)
+	})
+
+	var localCA *utils.LocalCA
+	if cfg.TLS.Enabled {
+		localCA = utils.NewLocalCA(cfg.TLS.CertDir, cfg.TLS.ExtraHosts)
+		if err := localCA.Ensure(); err != nil {
+			log.Fatalf("FATAL: Gagal menyiapkan sertifikat HTTPS: %v", err)
+		}
+		startJob(func(ctx context.Context) { localCA.RunRenewal(ctx, tlsRenewalInterval) })
+	}
+
+	router := setupRouter(cfg, repos.UserRepo, svcs, ctrls, exeDir)
+	if localCA != nil {
+		router.GET("/ca.crt", caCertHandler(localCA.CACertPath()))
+	}
+
+	httpListener := listen(cfg.Server.Addr(), "PORT")
+	var httpsListener net.Listener
+	if localCA != nil {
+		httpsListener = listen(net.JoinHostPort(cfg.Server.Host, cfg.TLS.Port), "TLS_PORT")
+	}
+
+	appURL = resolveAppURL(cfg)
+	close(appReady)
+	log.Printf("INFO: Server web dimulai di %s", appURL)
+
+	var servers []*http.Server
+	serverErrors := make(chan error, 2)
+	serve := func(server *http.Server, run func() error) {
+		servers = append(servers, server)
+		go func() {
+			if err := run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				serverErrors <- err
+			}
+		}()
+	}
+
+	if localCA == nil {
+		server := &http.Server{Handler: router}
+		log.Printf("INFO: Server mendengarkan di %s", httpListener.Addr())
+		serve(server, func() error { return server.Serve(httpListener) })
+	} else {
+		// Server HTTP tetap berjalan untuk klien yang belum memasang CA, atau hanya mengalihkan ke HTTPS
+		httpServer := &http.Server{Handler: router}
+		if cfg.TLS.HTTPRedirect {
+			httpServer.Handler = httpsRedirectHandler(cfg.TLS.Port, caCertHandler(localCA.CACertPath()))
+		}
+		log.Printf("INFO: Server HTTP mendengarkan di %s", httpListener.Addr())
+		serve(httpServer, func() error { return httpServer.Serve(httpListener) })
+
+		httpsServer := &http.Server{Handler: router, TLSConfig: localCA.TLSConfig()}
+		log.Printf("INFO: Server HTTPS mendengarkan di %s", httpsListener.Addr())
+		log.Printf("INFO: Sertifikat CA untuk komputer klien tersedia di %s dan di /ca.crt", localCA.CACertPath())
+		serve(httpsServer, func() error { return httpsServer.ServeTLS(httpsListener, "", "") })
+	}
+
+	select {
+	case <-ctx.Done():
+		log.Println("INFO: Permintaan berhenti diterima, mematikan server...")
+	case err := <-serverErrors:
+		log.Printf("ERROR: Server web berhenti karena error: %v", err)
+	}
+
+	// Request yang sedang diproses diselesaikan dulu sebelum job latar belakang dan database ditutup
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	for _, server := range servers {
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("PERINGATAN: Sebagian request belum selesai saat batas waktu shutdown habis: %v", err)
+		}
+	}
+
+	stopJobs()
+	jobs.Wait()
+	flushAuditLog()
+	closeDatabase(db)
+	log.Println("INFO: Server web dimatikan dengan bersih.")
+}
+
+// caCertHandler menyajikan sertifikat CA lokal untuk diunduh dan dipasang di komputer klien.
+func caCertHandler(caCertPath string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Content-Type", "application/x-x509-ca-cert")
+		c.FileAttachment(caCertPath, "simdokpol-ca.crt")
+	}
+}
+
+// httpsRedirectHandler mengalihkan request HTTP ke alamat yang sama di port HTTPS. Sertifikat CA
+// tetap bisa diunduh lewat HTTP karena klien baru belum memercayai sertifikat server.
+func httpsRedirectHandler(httpsPort string, caCert gin.HandlerFunc) http.Handler {
+	caRouter := gin.New()
+	caRouter.GET("/ca.crt", caCert)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ca.crt" {
+			caRouter.ServeHTTP(w, r)
+			return
+		}
+		host := strings.Trim(r.Host, "[]")
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+		target := "https://" + net.JoinHostPort(host, httpsPort) + r.URL.RequestURI()
+		// Bukan 301 agar browser tidak menyimpan pengalihan jika HTTPS kelak dinonaktifkan
+		http.Redirect(w, r, target, http.StatusTemporaryRedirect)
+	})
+}
+
+// initApp memuat .env dan konfigurasi, lalu membuka database dan menjalankan migrasi.
+// Dipakai bersama oleh server web dan subcommand CLI.
+func initApp(exeDir string) (*config.Config, *gorm.DB) {
+	cfg := loadConfig(exeDir)
+
+	db, err := setupDatabase(cfg, exeDir)
+	if err != nil {
+		log.Fatalf("FATAL: Gagal setup database: %v", err)
+	}
+
+	return cfg, db
+}
+
+// loadConfig memastikan .env ada, memuatnya, lalu mengubah path relatif di konfigurasi
+// menjadi path absolut terhadap folder aplikasi.
+func loadConfig(exeDir string) *config.Config {
 	if err := ensureEnvFile(exeDir); err != nil {
 		log.Printf("PERINGATAN: Gagal memastikan file .env: %v", err)
 	}
@@ -226,20 +503,139 @@ func startWebServer() {
 		cfg.DBDSN = absDbPath + queryParams
 		log.Printf("INFO: Menggunakan path database absolut: %s", cfg.DBDSN)
 	}
+	if !filepath.IsAbs(cfg.TLS.CertDir) {
+		cfg.TLS.CertDir = filepath.Join(exeDir, cfg.TLS.CertDir)
+	}
+	if !filepath.IsAbs(cfg.Log.Dir) {
+		cfg.Log.Dir = filepath.Join(exeDir, cfg.Log.Dir)
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		log.Printf("PERINGATAN: Gagal menyiapkan file log, log hanya ditulis ke konsol: %v", err)
+	}
+	return cfg
+}
+
+// waitNextTick menunggu tick berikutnya. Hasil false berarti aplikasi sedang dimatikan.
+func waitNextTick(ctx context.Context, ticker *time.Ticker) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-ticker.C:
+		return true
+	}
+}
 
-	db, err := setupDatabase(cfg.DBDSN, exeDir)
+// waitNextRun seperti waitNextTick, tetapi juga kembali lebih awal saat wake menerima sinyal.
+func waitNextRun(ctx context.Context, ticker *time.Ticker, wake <-chan struct{}) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-ticker.C:
+		return true
+	case <-wake:
+		return true
+	}
+}
+
+// watchConfig mengembalikan channel yang menerima sinyal setiap kali changed melaporkan
+// perubahan pengaturan yang relevan. Sinyal yang belum diproses digabung menjadi satu, dan
+// langganan dilepas saat ctx selesai.
+func watchConfig(ctx context.Context, configService services.ConfigService, changed func(previous, current *dto.AppConfig) bool) <-chan struct{} {
+	wake := make(chan struct{}, 1)
+	unsubscribe := configService.Subscribe(func(previous, current *dto.AppConfig) {
+		if previous != nil && !changed(previous, current) {
+			return
+		}
+		select {
+		case wake <- struct{}{}:
+		default:
+		}
+	})
+	context.AfterFunc(ctx, unsubscribe)
+	return wake
+}
+
+// runAuditAnchorScheduler mengekspor jangkar rantai log audit secara berkala.
+func runAuditAnchorScheduler(ctx context.Context, auditService services.AuditLogService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for waitNextTick(ctx, ticker) {
+		anchorPath, err := auditService.ExportAnchor(ctx)
+		if err != nil {
+			log.Printf("PERINGATAN: Gagal membuat jangkar log audit terjadwal: %v", err)
+			continue
+		}
+		log.Printf("INFO: Jangkar log audit terjadwal disimpan di: %s", anchorPath)
+	}
+}
+
+// runAuditRetentionScheduler memindahkan log audit yang melewati masa retensi ke berkas arsip,
+// sekali saat aplikasi dimulai, secara berkala, dan segera setelah masa retensi diubah.
+func runAuditRetentionScheduler(ctx context.Context, auditService services.AuditLogService, configService services.ConfigService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	retentionChanged := watchConfig(ctx, configService, func(previous, current *dto.AppConfig) bool {
+		return previous.AuditRetentionMonths != current.AuditRetentionMonths
+	})
+
+	for {
+		applyAuditRetention(ctx, auditService, configService)
+		if !waitNextRun(ctx, ticker, retentionChanged) {
+			return
+		}
+	}
+}
+
+func applyAuditRetention(ctx context.Context, auditService services.AuditLogService, configService services.ConfigService) {
+	appConfig, err := configService.GetConfig(ctx)
 	if err != nil {
-		log.Fatalf("FATAL: Gagal setup database: %v", err)
+		log.Printf("PERINGATAN: Gagal membaca konfigurasi retensi log audit: %v", err)
+		return
+	}
+	archives, err := auditService.ApplyRetention(ctx, appConfig.AuditRetentionMonths)
+	for _, archive := range archives {
+		log.Printf("INFO: %d entri log audit (ID %d-%d) dipindahkan ke arsip %s",
+			archive.EntryCount, archive.FirstID, archive.LastID, archive.FileName)
 	}
+	if err != nil {
+		log.Printf("PERINGATAN: Gagal menerapkan retensi log audit: %v", err)
+	}
+}
 
-	repos, svcs, ctrls := setupDependencies(db, cfg)
-	router := setupRouter(repos.UserRepo, svcs, ctrls, exeDir)
+func runSessionPurgeScheduler(ctx context.Context, sessionService services.SessionService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
 
-	log.Printf("INFO: Server web dimulai di %s", appURL)
-	log.Printf("INFO: Server mendengarkan pada port %s", defaultPort)
+	for {
+		if purged, err := sessionService.PurgeExpired(ctx); err != nil {
+			log.Printf("PERINGATAN: Gagal membersihkan sesi kedaluwarsa: %v", err)
+		} else if purged > 0 {
+			log.Printf("INFO: %d sesi login kedaluwarsa dibersihkan", purged)
+		}
+		if !waitNextTick(ctx, ticker) {
+			return
+		}
+	}
+}
+
+// runJWTKeyRotationScheduler mengganti kunci JWT yang sudah melewati interval rotasi dan
+// menghapus kunci lama yang masa tenggangnya habis, sekali saat aplikasi dimulai, berkala, dan
+// segera setelah interval rotasi atau masa tenggang diubah.
+func runJWTKeyRotationScheduler(ctx context.Context, jwtKeyService services.JWTKeyService, configService services.ConfigService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	rotationChanged := watchConfig(ctx, configService, func(previous, current *dto.AppConfig) bool {
+		return previous.JWTRotationDays != current.JWTRotationDays || previous.JWTKeyGraceHours != current.JWTKeyGraceHours
+	})
 
-	if err := router.Run(defaultPort); err != nil {
-		log.Fatalf("FATAL: Gagal menjalankan server: %v", err)
+	for {
+		if _, err := jwtKeyService.RotateIfDue(ctx); err != nil {
+			log.Printf("PERINGATAN: Gagal memeriksa rotasi kunci JWT: %v", err)
+		}
+		if !waitNextRun(ctx, ticker, rotationChanged) {
+			return
+		}
 	}
 }
 
@@ -256,6 +652,10 @@ func ensureEnvFile(exeDir string) error {
 		return createEnvFile(envPath)
 	}
 
+	if err := migrateEnvDSN(envPath); err != nil {
+		log.Printf("PERINGATAN: Gagal memperbarui DB_DSN di file .env: %v", err)
+	}
+
 	jwtSecret := os.Getenv("JWT_SECRET_KEY")
 	if jwtSecret == "" || jwtSecret == "ganti-dengan-secret-key-yang-kuat" || jwtSecret == "will-be-auto-generated" {
 		log.Println("INFO: JWT_SECRET_KEY tidak valid, melakukan regenerasi...")
@@ -280,11 +680,16 @@ func createEnvFile(envPath string) error {
 JWT_SECRET_KEY=%s
 
 # Database Configuration
-DB_DSN=simdokpol.db?_foreign_keys=on
+DB_DSN=simdokpol.db?_foreign_keys=on&%s
 
 # Server Port
 PORT=8080
-`, time.Now().Format("2006-01-02 15:04:05"), jwtSecret)
+
+# HTTPS dengan CA lokal (port HTTPS 8443). Pasang certs/ca.crt di komputer klien,
+# lalu set TLS_HTTP_REDIRECT=true agar semua akses HTTP dialihkan ke HTTPS.
+TLS_ENABLED=true
+TLS_HTTP_REDIRECT=false
+`, time.Now().Format("2006-01-02 15:04:05"), jwtSecret, dbBusyTimeoutParam)
 
 	if err := os.WriteFile(envPath, []byte(content), 0600); err != nil {
 		return fmt.Errorf("gagal menulis file .env: %w", err)
@@ -295,6 +700,48 @@ PORT=8080
 	return nil
 }
 
+// dbBusyTimeoutParam membuat koneksi SQLite menunggu hingga 5 detik saat database sedang dikunci
+// penulis lain, alih-alih langsung gagal dengan "database is locked".
+const dbBusyTimeoutParam = "_busy_timeout=5000"
+
+// migrateEnvDSN menambahkan dbBusyTimeoutParam ke DB_DSN pada file .env lama yang belum
+// memilikinya, lalu memperbarui environment agar konfigurasi yang dimuat ikut memakainya.
+func migrateEnvDSN(envPath string) error {
+	content, err := os.ReadFile(envPath)
+	if err != nil {
+		return err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "DB_DSN=") {
+			continue
+		}
+		dsn := strings.TrimPrefix(trimmed, "DB_DSN=")
+		if dsn == "" || strings.Contains(dsn, "_busy_timeout=") {
+			return nil
+		}
+
+		separator := "?"
+		if strings.Contains(dsn, "?") {
+			separator = "&"
+		}
+		migrated := dsn + separator + dbBusyTimeoutParam
+		lines[i] = "DB_DSN=" + migrated
+
+		if err := os.WriteFile(envPath, []byte(strings.Join(lines, "\n")), 0600); err != nil {
+			return err
+		}
+		if os.Getenv("DB_DSN") == dsn {
+			os.Setenv("DB_DSN", migrated)
+		}
+		log.Printf("INFO: DB_DSN di file .env diperbarui menjadi: %s", migrated)
+		return nil
+	}
+	return nil
+}
+
 func updateEnvFile(envPath string) error {
 	content, err := os.ReadFile(envPath)
 	if err != nil {
@@ -430,22 +877,60 @@ func openBrowser(url string) {
 	}
 }
 
-func setupDatabase(dsn string, exeDir string) (*gorm.DB, error) {
-	db, err := gorm.Open(gormsqlite.Open(dsn), &gorm.Config{
-		Logger: logger.New(
-			log.New(os.Stdout, "\r\n", log.LstdFlags),
-			logger.Config{
-				SlowThreshold:             200 * time.Millisecond,
-				LogLevel:                  logger.Info,
-				IgnoreRecordNotFoundError: true,
-				Colorful:                  true,
-			},
-		),
+// closeDatabase menutup koneksi database. Database memakai journal mode bawaan SQLite (bukan
+// WAL) karena backup dan restore menyalin file .db secara langsung.
+func closeDatabase(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Printf("PERINGATAN: Gagal mendapatkan koneksi database untuk ditutup: %v", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		log.Printf("PERINGATAN: Gagal menutup database: %v", err)
+	}
+}
+
+// setupDatabase membuka database lalu menjalankan semua migrasi yang belum diterapkan.
+func setupDatabase(cfg *config.Config, exeDir string) (*gorm.DB, error) {
+	db, err := openDatabase(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := newMigrator(db, exeDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return nil, fmt.Errorf("gagal menjalankan migrasi: %w", err)
+	}
+
+	version, dirty, err := m.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		log.Printf("PERINGATAN: Gagal memeriksa versi migrasi: %v", err)
+	} else if err == nil {
+		log.Printf("INFO: Migrasi database berhasil. Versi: %d, Dirty: %t", version, dirty)
+	} else {
+		log.Println("INFO: Migrasi database berhasil (tidak ada versi diterapkan).")
+	}
+
+	return db, nil
+}
+
+// openDatabase membuka koneksi GORM ke database SQLite tanpa menjalankan migrasi.
+func openDatabase(cfg *config.Config) (*gorm.DB, error) {
+	db, err := gorm.Open(gormsqlite.Open(cfg.DBDSN), &gorm.Config{
+		Logger: logging.NewGormLogger(cfg.Log.SQL),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("gagal membuka koneksi gorm: %w", err)
 	}
+	return db, nil
+}
 
+// newMigrator membuat instance golang-migrate untuk folder migrations di samping executable.
+func newMigrator(db *gorm.DB, exeDir string) (*migrate.Migrate, error) {
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("gagal mendapatkan instance sql.DB: %w", err)
@@ -464,29 +949,47 @@ func setupDatabase(dsn string, exeDir string) (*gorm.DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("gagal membuat instance migrasi dari '%s': %w", migrationsURL, err)
 	}
+	return m, nil
+}
 
-	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
-		return nil, fmt.Errorf("gagal menjalankan migrasi: %w", err)
+// latestMigrationVersion mengembalikan nomor migrasi tertinggi di folder migrations, yaitu versi
+// skema yang diharapkan oleh executable ini.
+func latestMigrationVersion(exeDir string) uint {
+	files, err := filepath.Glob(filepath.Join(exeDir, "migrations", "*.up.sql"))
+	if err != nil {
+		return 0
 	}
-
-	version, dirty, err := m.Version()
-	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
-		log.Printf("PERINGATAN: Gagal memeriksa versi migrasi: %v", err)
-	} else if err == nil {
-		log.Printf("INFO: Migrasi database berhasil. Versi: %d, Dirty: %t", version, dirty)
-	} else {
-		log.Println("INFO: Migrasi database berhasil (tidak ada versi diterapkan).")
+	var latest uint
+	for _, file := range files {
+		prefix, _, _ := strings.Cut(filepath.Base(file), "_")
+		if n, err := strconv.ParseUint(prefix, 10, 64); err == nil && uint(n) > latest {
+			latest = uint(n)
+		}
 	}
-
-	return db, nil
+	return latest
 }
 
-func setupRouter(userRepo repositories.UserRepository, svcs Services, ctrls Controllers, exeDir string) *gin.Engine {
+func setupRouter(cfg *config.Config, userRepo repositories.UserRepository, svcs Services, ctrls Controllers, exeDir string) *gin.Engine {
 	if os.Getenv("GIN_MODE") != "debug" {
 		gin.SetMode(gin.ReleaseMode)
 		gin.DefaultWriter = io.Discard
 	}
-	router := gin.Default()
+	router := gin.New()
+	// ID request dipasang paling awal agar respons dari panic yang dipulihkan pun membawa X-Request-ID
+	router.Use(middleware.RequestIDMiddleware())
+	router.Use(gin.Recovery())
+	router.Use(middleware.RequestLogMiddleware())
+	// Header X-Forwarded-For hanya dipercaya dari TRUSTED_PROXIES; tanpa pengaturan itu aplikasi
+	// dianggap diakses langsung. Jika tidak, alamat IP untuk penghitung login gagal dapat dipalsukan.
+	if err := router.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatalf("FATAL: TRUSTED_PROXIES tidak valid: %v", err)
+	}
+	cookies := middleware.NewCookiePolicy(cfg.Security)
+	router.Use(middleware.MetricsMiddleware())
+	// Backup, restore, dan ekspor log audit dapat berjalan lama untuk database besar
+	router.Use(middleware.RequestTimeoutMiddleware(cfg.Server.RequestTimeout, "/api/backups", "/api/restore", "/api/audit-logs/export"))
+	router.Use(middleware.SecurityHeadersMiddleware(cfg.Security))
+	router.Use(middleware.CSRFMiddleware(cookies))
 
 	templatePath := filepath.Join(exeDir, "web", "templates")
 	templates := template.Must(
@@ -502,6 +1005,9 @@ func setupRouter(userRepo repositories.UserRepository, svcs Services, ctrls Cont
 	router.Static("/static", filepath.Join(exeDir, "web", "static"))
 
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+	router.GET("/healthz", ctrls.HealthController.Healthz)
+	router.GET("/readyz", ctrls.HealthController.Readyz)
+	router.GET("/metrics", middleware.MetricsAccessMiddleware(cfg.Metrics), gin.WrapH(metrics.Handler()))
 	router.GET("/setup", ctrls.ConfigController.ShowSetupPage)
 	router.POST("/api/setup", ctrls.ConfigController.SaveSetup)
 
@@ -512,10 +1018,13 @@ func setupRouter(userRepo repositories.UserRepository, svcs Services, ctrls Cont
 			c.HTML(http.StatusOK, "login.html", gin.H{"Title": "Login"})
 		})
 		app.POST("/api/login", ctrls.AuthController.Login)
+		app.POST("/api/login/2fa", ctrls.AuthController.VerifyTwoFactor)
+		app.POST("/api/login/2fa/setup", ctrls.AuthController.BeginTwoFactorEnrollment)
 		app.POST("/api/logout", ctrls.AuthController.Logout)
 
 		protected := app.Group("")
-		protected.Use(middleware.AuthMiddleware(userRepo))
+		protected.Use(middleware.AuthMiddleware(userRepo, svcs.SessionService, svcs.JWTKeyService, svcs.APITokenService, cookies))
+		protected.Use(middleware.PasswordChangeMiddleware(svcs.PasswordPolicy))
 		{
 			setupPageRoutes(protected, svcs)
 			setupAPIRoutes(protected, ctrls)
@@ -524,34 +1033,57 @@ func setupRouter(userRepo repositories.UserRepository, svcs Services, ctrls Cont
 	return router
 }
 
-func setupDependencies(db *gorm.DB, cfg *config.Config) (Repositories, Services, Controllers) {
+func setupDependencies(db *gorm.DB, cfg *config.Config, exeDir string) (Repositories, Services, Controllers) {
 	userRepo := repositories.NewUserRepository(db)
 	residentRepo := repositories.NewResidentRepository(db)
 	docRepo := repositories.NewLostDocumentRepository(db)
 	configRepo := repositories.NewConfigRepository(db)
 	auditRepo := repositories.NewAuditLogRepository(db)
-
-	services.JWTSecretKey = []byte(cfg.JWTSecretKey)
+	rosterRepo := repositories.NewDutyRosterRepository(db)
+	throttleRepo := repositories.NewLoginThrottleRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	recoveryRepo := repositories.NewRecoveryCodeRepository(db)
+	historyRepo := repositories.NewPasswordHistoryRepository(db)
+	jwtKeyRepo := repositories.NewJWTKeyRepository(db)
+	apiTokenRepo := repositories.NewAPITokenRepository(db)
 
 	configService := services.NewConfigService(configRepo)
-	auditService := services.NewAuditLogService(auditRepo)
-	authService := services.NewAuthService(userRepo)
+	auditService := services.NewAuditLogService(auditRepo, filepath.Join(exeDir, "audit"))
+	sessionService := services.NewSessionService(sessionRepo, userRepo, auditService, configService)
+	twoFactorService := services.NewTwoFactorService(userRepo, recoveryRepo, auditService, configService)
+	jwtKeyService := services.NewJWTKeyService(jwtKeyRepo, configService, auditService, cfg.JWTSecretKey)
+	var authenticator services.Authenticator = services.NewLocalAuthenticator(userRepo)
+	if cfg.AuthBackend == config.AuthBackendLDAP {
+		authenticator = services.NewLDAPAuthenticator(cfg.LDAP, userRepo, authenticator, auditService)
+		log.Printf("INFO: Login diverifikasi ke direktori LDAP %s", cfg.LDAP.URL)
+	}
+	authService := services.NewAuthService(userRepo, authenticator, throttleRepo, sessionService, twoFactorService, jwtKeyService, auditService)
 	dashboardService := services.NewDashboardService(docRepo, userRepo, configService)
-	docService := services.NewLostDocumentService(db, docRepo, residentRepo, userRepo, auditService, configService)
-	userService := services.NewUserService(userRepo, auditService, cfg)
+	docService := services.NewLostDocumentService(db, docRepo, residentRepo, userRepo, rosterRepo, auditService, configService)
+	rosterService := services.NewDutyRosterService(rosterRepo, userRepo, auditService, configService)
+	passwordPolicy := services.NewPasswordPolicyService(historyRepo, configService)
+	userService := services.NewUserService(userRepo, sessionService, passwordPolicy, auditService, cfg)
 	backupService := services.NewBackupService(cfg, configService, auditService)
+	apiTokenService := services.NewAPITokenService(apiTokenRepo, userRepo, auditService)
+	healthService := services.NewHealthService(db, cfg, configService, latestMigrationVersion(exeDir), version)
 
-	authController := controllers.NewAuthController(authService)
+	authController := controllers.NewAuthController(authService, middleware.NewCookiePolicy(cfg.Security))
 	dashboardController := controllers.NewDashboardController(dashboardService)
 	docController := controllers.NewLostDocumentController(docService)
 	userController := controllers.NewUserController(userService)
-	configController := controllers.NewConfigController(configService, userService)
-	auditController := controllers.NewAuditLogController(auditService)
+	configController := controllers.NewConfigController(configService, userService, auditService)
+	auditController := controllers.NewAuditLogController(auditService, configService)
 	backupController := controllers.NewBackupController(backupService)
 	settingsController := controllers.NewSettingsController(configService, auditService)
+	rosterController := controllers.NewDutyRosterController(rosterService)
+	sessionController := controllers.NewSessionController(sessionService)
+	twoFactorController := controllers.NewTwoFactorController(twoFactorService)
+	jwtKeyController := controllers.NewJWTKeyController(jwtKeyService)
+	apiTokenController := controllers.NewAPITokenController(apiTokenService)
+	healthController := controllers.NewHealthController(healthService)
 
 	return Repositories{UserRepo: userRepo},
-		Services{ConfigService: configService, DocService: docService},
+		Services{ConfigService: configService, DocService: docService, AuditService: auditService, SessionService: sessionService, PasswordPolicy: passwordPolicy, JWTKeyService: jwtKeyService, APITokenService: apiTokenService, UserService: userService, BackupService: backupService},
 		Controllers{
 			AuthController:      authController,
 			DashboardController: dashboardController,
@@ -561,6 +1093,12 @@ func setupDependencies(db *gorm.DB, cfg *config.Config) (Repositories, Services,
 			AuditController:     auditController,
 			BackupController:    backupController,
 			SettingsController:  settingsController,
+			RosterController:    rosterController,
+			SessionController:   sessionController,
+			TwoFactorController: twoFactorController,
+			JWTKeyController:    jwtKeyController,
+			APITokenController:  apiTokenController,
+			HealthController:    healthController,
 		}
 }
 
@@ -569,6 +1107,7 @@ func setupPageRoutes(router *gin.RouterGroup, svcs Services) {
 		user, _ := c.Get("currentUser")
 		return user
 	}
+	perm := middleware.RequirePermission
 
 	router.GET("/", func(c *gin.Context) {
 		c.HTML(http.StatusOK, "dashboard.html", gin.H{"Title": "Dasbor", "CurrentUser": getUser(c)})
@@ -582,11 +1121,11 @@ func setupPageRoutes(router *gin.RouterGroup, svcs Services) {
 		c.HTML(http.StatusOK, "document_list.html", gin.H{"Title": "Arsip Dokumen", "CurrentUser": getUser(c), "PageType": "archived"})
 	})
 
-	router.GET("/documents/new", func(c *gin.Context) {
+	router.GET("/documents/new", perm(models.PermDocumentCreate), func(c *gin.Context) {
 		c.HTML(http.StatusOK, "document_form.html", gin.H{"Title": "Buat Surat Baru", "CurrentUser": getUser(c), "IsEdit": false, "DocID": 0})
 	})
 
-	router.GET("/documents/:id/edit", func(c *gin.Context) {
+	router.GET("/documents/:id/edit", perm(models.PermDocumentUpdate), func(c *gin.Context) {
 		id := c.Param("id")
 		c.HTML(http.StatusOK, "document_form.html", gin.H{"Title": "Edit Surat", "CurrentUser": getUser(c), "IsEdit": true, "DocID": id})
 	})
@@ -597,7 +1136,12 @@ func setupPageRoutes(router *gin.RouterGroup, svcs Services) {
 	})
 
 	router.GET("/profile", func(c *gin.Context) {
-		c.HTML(http.StatusOK, "profile.html", gin.H{"Title": "Profil Pengguna", "CurrentUser": getUser(c)})
+		// Scope API token yang bisa dipilih terbatas pada hak akses peran pengguna
+		var permissions []string
+		if user, ok := getUser(c).(*models.User); ok {
+			permissions = models.PermissionsForRole(user.Peran)
+		}
+		c.HTML(http.StatusOK, "profile.html", gin.H{"Title": "Profil Pengguna", "CurrentUser": getUser(c), "PasswordChangeRequired": c.GetBool("passwordChangeRequired"), "Permissions": permissions})
 	})
 
 	router.GET("/panduan", func(c *gin.Context) {
@@ -608,14 +1152,14 @@ func setupPageRoutes(router *gin.RouterGroup, svcs Services) {
 		c.HTML(http.StatusOK, "tentang.html", gin.H{"Title": "Tentang Aplikasi", "CurrentUser": getUser(c), "AppVersion": version})
 	})
 
-	router.GET("/documents/:id/print", func(c *gin.Context) {
+	router.GET("/documents/:id/print", perm(models.PermDocumentRead), func(c *gin.Context) {
 		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 		if err != nil {
 			c.HTML(http.StatusBadRequest, "error.html", gin.H{"Title": "Error", "CurrentUser": getUser(c), "ErrorMessage": "ID Dokumen tidak valid."})
 			return
 		}
 
-		doc, err := svcs.DocService.FindByID(uint(id), c.GetUint("userID"))
+		doc, err := svcs.DocService.FindByID(c.Request.Context(), uint(id))
 		if err != nil {
 			status := http.StatusNotFound
 			message := "Dokumen tidak ditemukan."
@@ -627,7 +1171,7 @@ func setupPageRoutes(router *gin.RouterGroup, svcs Services) {
 			return
 		}
 
-		appConfig, err := svcs.ConfigService.GetConfig()
+		appConfig, err := svcs.ConfigService.GetConfig(c.Request.Context())
 		if err != nil {
 			c.HTML(http.StatusInternalServerError, "error.html", gin.H{"Title": "Error", "CurrentUser": getUser(c), "ErrorMessage": "Gagal memuat konfigurasi aplikasi."})
 			return
@@ -636,64 +1180,103 @@ func setupPageRoutes(router *gin.RouterGroup, svcs Services) {
 		c.HTML(http.StatusOK, "print_preview.html", gin.H{"Document": doc, "Now": time.Now(), "CurrentUser": getUser(c), "Config": appConfig})
 	})
 
-	adminRoutes := router.Group("")
-	adminRoutes.Use(middleware.AdminAuthMiddleware())
-	{
-		adminRoutes.GET("/users", func(c *gin.Context) {
-			c.HTML(http.StatusOK, "user_list.html", gin.H{"Title": "Manajemen Pengguna", "CurrentUser": getUser(c)})
-		})
+	router.GET("/users", perm(models.PermUserManage), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user_list.html", gin.H{"Title": "Manajemen Pengguna", "CurrentUser": getUser(c)})
+	})
 
-		adminRoutes.GET("/users/new", func(c *gin.Context) {
-			c.HTML(http.StatusOK, "user_form.html", gin.H{"Title": "Tambah Pengguna", "CurrentUser": getUser(c), "IsEdit": false, "UserID": 0})
-		})
+	router.GET("/users/new", perm(models.PermUserManage), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "user_form.html", gin.H{"Title": "Tambah Pengguna", "CurrentUser": getUser(c), "IsEdit": false, "UserID": 0})
+	})
 
-		adminRoutes.GET("/users/:id/edit", func(c *gin.Context) {
-			id, _ := strconv.Atoi(c.Param("id"))
-			c.HTML(http.StatusOK, "user_form.html", gin.H{"Title": "Edit Pengguna", "CurrentUser": getUser(c), "IsEdit": true, "UserID": id})
-		})
+	router.GET("/users/:id/edit", perm(models.PermUserManage), func(c *gin.Context) {
+		id, _ := strconv.Atoi(c.Param("id"))
+		c.HTML(http.StatusOK, "user_form.html", gin.H{"Title": "Edit Pengguna", "CurrentUser": getUser(c), "IsEdit": true, "UserID": id})
+	})
 
-		adminRoutes.GET("/audit-logs", func(c *gin.Context) {
-			c.HTML(http.StatusOK, "audit_log_list.html", gin.H{"Title": "Log Audit Sistem", "CurrentUser": getUser(c)})
-		})
+	router.GET("/audit-logs", perm(models.PermAuditRead), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "audit_log_list.html", gin.H{"Title": "Log Audit Sistem", "CurrentUser": getUser(c)})
+	})
 
-		adminRoutes.GET("/settings", func(c *gin.Context) {
-			c.HTML(http.StatusOK, "settings.html", gin.H{"Title": "Pengaturan Sistem", "CurrentUser": getUser(c)})
-		})
-	}
+	router.GET("/settings", perm(models.PermSettingsManage), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "settings.html", gin.H{"Title": "Pengaturan Sistem", "CurrentUser": getUser(c)})
+	})
+
+	router.GET("/duty-rosters", perm(models.PermRosterManage), func(c *gin.Context) {
+		c.HTML(http.StatusOK, "duty_roster_list.html", gin.H{"Title": "Jadwal Jaga", "CurrentUser": getUser(c)})
+	})
 }
 
 func setupAPIRoutes(router *gin.RouterGroup, ctrls Controllers) {
+	perm := middleware.RequirePermission
+	noToken := middleware.RejectAPIToken()
+
 	api := router.Group("/api")
 	{
-		api.GET("/stats", ctrls.DashboardController.GetStats)
-		api.GET("/stats/monthly-issuance", ctrls.DashboardController.GetMonthlyChart)
-		api.GET("/stats/item-composition", ctrls.DashboardController.GetItemCompositionChart)
-		api.GET("/notifications/expiring-documents", ctrls.DashboardController.GetExpiringDocuments)
-		api.PUT("/profile", ctrls.UserController.UpdateProfile)
-		api.PUT("/profile/password", ctrls.UserController.ChangePassword)
-		api.GET("/search", ctrls.DocController.SearchGlobal)
-		api.POST("/documents", ctrls.DocController.Create)
-		api.GET("/documents", ctrls.DocController.FindAll)
-		api.GET("/documents/:id", ctrls.DocController.FindByID)
-		api.PUT("/documents/:id", ctrls.DocController.Update)
-		api.DELETE("/documents/:id", ctrls.DocController.Delete)
-
-		adminAPI := router.Group("/api")
-		adminAPI.Use(middleware.AdminAuthMiddleware())
-		{
-			adminAPI.GET("/users/operators", ctrls.UserController.FindOperators)
-			adminAPI.POST("/users", ctrls.UserController.Create)
-			adminAPI.GET("/users", ctrls.UserController.FindAll)
-			adminAPI.GET("/users/:id", ctrls.UserController.FindByID)
-			adminAPI.PUT("/users/:id", ctrls.UserController.Update)
-			adminAPI.DELETE("/users/:id", ctrls.UserController.Delete)
-			adminAPI.POST("/users/:id/activate", ctrls.UserController.Activate)
-			adminAPI.GET("/audit-logs", ctrls.AuditController.FindAll)
-			adminAPI.POST("/backups", ctrls.BackupController.CreateBackup)
-			adminAPI.POST("/restore", ctrls.BackupController.RestoreBackup)
-			adminAPI.GET("/settings", ctrls.SettingsController.GetSettings)
-			adminAPI.PUT("/settings", ctrls.SettingsController.UpdateSettings)
-		}
+		api.GET("/stats", perm(models.PermDashboardRead), ctrls.DashboardController.GetStats)
+		api.GET("/stats/monthly-issuance", perm(models.PermDashboardRead), ctrls.DashboardController.GetMonthlyChart)
+		api.GET("/stats/item-composition", perm(models.PermDashboardRead), ctrls.DashboardController.GetItemCompositionChart)
+		api.GET("/stats/regu", perm(models.PermDashboardRead), ctrls.DashboardController.GetReguBreakdown)
+		api.GET("/notifications/expiring-documents", perm(models.PermDashboardRead), ctrls.DashboardController.GetExpiringDocuments)
+		api.PUT("/profile", noToken, ctrls.UserController.UpdateProfile)
+		api.PUT("/profile/password", noToken, ctrls.UserController.ChangePassword)
+		api.GET("/profile/2fa", noToken, ctrls.TwoFactorController.Status)
+		api.POST("/profile/2fa/setup", noToken, ctrls.TwoFactorController.BeginEnrollment)
+		api.POST("/profile/2fa/enable", noToken, ctrls.TwoFactorController.Enable)
+		api.POST("/profile/2fa/disable", noToken, ctrls.TwoFactorController.Disable)
+		api.POST("/profile/2fa/recovery-codes", noToken, ctrls.TwoFactorController.RegenerateRecoveryCodes)
+		api.GET("/profile/api-tokens", noToken, ctrls.APITokenController.FindMine)
+		api.POST("/profile/api-tokens", noToken, ctrls.APITokenController.CreateMine)
+		api.DELETE("/profile/api-tokens/:id", noToken, ctrls.APITokenController.RevokeMine)
+		api.GET("/password-policy", ctrls.SettingsController.GetPasswordPolicy)
+		api.GET("/sessions", noToken, ctrls.SessionController.FindMine)
+		api.DELETE("/sessions/:id", noToken, ctrls.SessionController.Revoke)
+		api.GET("/search", perm(models.PermDocumentRead), ctrls.DocController.SearchGlobal)
+		api.POST("/documents", perm(models.PermDocumentCreate), ctrls.DocController.Create)
+		api.GET("/documents", perm(models.PermDocumentRead), ctrls.DocController.FindAll)
+		api.GET("/documents/handover", perm(models.PermDocumentRead), ctrls.DocController.Handover)
+		api.GET("/documents/:id", perm(models.PermDocumentRead), ctrls.DocController.FindByID)
+		api.PUT("/documents/:id", perm(models.PermDocumentUpdate), ctrls.DocController.Update)
+		api.DELETE("/documents/:id", perm(models.PermDocumentDelete), ctrls.DocController.Delete)
+
+		// Daftar petugas dibutuhkan oleh formulir dokumen, bukan hanya oleh manajemen pengguna
+		api.GET("/users/operators", perm(models.PermDocumentCreate), ctrls.UserController.FindOperators)
+		api.GET("/roles", perm(models.PermUserRead), ctrls.UserController.FindRoles)
+		api.POST("/users", perm(models.PermUserManage), ctrls.UserController.Create)
+		api.GET("/users", perm(models.PermUserRead), ctrls.UserController.FindAll)
+		api.GET("/users/:id", perm(models.PermUserRead), ctrls.UserController.FindByID)
+		api.PUT("/users/:id", perm(models.PermUserManage), ctrls.UserController.Update)
+		api.PUT("/users/:id/role", perm(models.PermUserManage), ctrls.UserController.AssignRole)
+		api.DELETE("/users/:id", perm(models.PermUserManage), ctrls.UserController.Delete)
+		api.POST("/users/:id/activate", perm(models.PermUserManage), ctrls.UserController.Activate)
+		api.POST("/users/:id/unlock", perm(models.PermUserManage), ctrls.AuthController.UnlockUser)
+		api.POST("/users/:id/logout", perm(models.PermUserManage), ctrls.SessionController.ForceLogout)
+		api.DELETE("/users/:id/2fa", perm(models.PermUserManage), ctrls.TwoFactorController.Reset)
+		api.POST("/users/:id/temporary-password", perm(models.PermUserManage), ctrls.UserController.IssueTemporaryPassword)
+		api.POST("/users/:id/api-tokens", perm(models.PermUserManage), noToken, ctrls.APITokenController.CreateForUser)
+		api.GET("/api-tokens", perm(models.PermUserManage), ctrls.APITokenController.FindAll)
+		api.DELETE("/api-tokens/:id", perm(models.PermUserManage), ctrls.APITokenController.Revoke)
+		api.GET("/login-lockouts", perm(models.PermUserManage), ctrls.AuthController.FindLockouts)
+		api.DELETE("/login-lockouts", perm(models.PermUserManage), ctrls.AuthController.Unlock)
+		api.GET("/audit-logs", perm(models.PermAuditRead), ctrls.AuditController.FindAll)
+		api.GET("/audit-logs/verify", perm(models.PermAuditRead), ctrls.AuditController.VerifyChain)
+		api.POST("/audit-logs/anchors", perm(models.PermAuditManage), ctrls.AuditController.ExportAnchor)
+		api.GET("/audit-logs/export", perm(models.PermAuditRead), ctrls.AuditController.Export)
+		api.GET("/audit-logs/signing-key", perm(models.PermAuditRead), ctrls.AuditController.SigningKey)
+		api.POST("/backups", perm(models.PermBackupCreate), ctrls.BackupController.CreateBackup)
+		api.POST("/restore", perm(models.PermBackupRestore), ctrls.BackupController.RestoreBackup)
+		api.GET("/settings", perm(models.PermSettingsManage), ctrls.SettingsController.GetSettings)
+		api.PUT("/settings", perm(models.PermSettingsManage), ctrls.SettingsController.UpdateSettings)
+		api.GET("/jwt-keys", perm(models.PermSettingsManage), ctrls.JWTKeyController.FindAll)
+		api.POST("/jwt-keys/rotate", perm(models.PermSettingsManage), ctrls.JWTKeyController.Rotate)
+		api.GET("/diagnostics", perm(models.PermSettingsManage), ctrls.HealthController.Diagnostics)
+		api.GET("/log-level", perm(models.PermSettingsManage), ctrls.SettingsController.GetLogLevel)
+		api.PUT("/log-level", perm(models.PermSettingsManage), ctrls.SettingsController.UpdateLogLevel)
+		api.GET("/duty-rosters", perm(models.PermDashboardRead), ctrls.RosterController.FindInRange)
+		api.GET("/duty-rosters/current", perm(models.PermDocumentCreate), ctrls.RosterController.FindCurrent)
+		api.GET("/duty-rosters/:id", perm(models.PermDashboardRead), ctrls.RosterController.FindByID)
+		api.POST("/duty-rosters", perm(models.PermRosterManage), ctrls.RosterController.Create)
+		api.PUT("/duty-rosters/:id", perm(models.PermRosterManage), ctrls.RosterController.Update)
+		api.DELETE("/duty-rosters/:id", perm(models.PermRosterManage), ctrls.RosterController.Delete)
 	}
 }
 
@@ -702,8 +1285,15 @@ type Repositories struct {
 }
 
 type Services struct {
-	ConfigService services.ConfigService
-	DocService    services.LostDocumentService
+	ConfigService   services.ConfigService
+	DocService      services.LostDocumentService
+	AuditService    services.AuditLogService
+	SessionService  services.SessionService
+	PasswordPolicy  services.PasswordPolicyService
+	JWTKeyService   services.JWTKeyService
+	APITokenService services.APITokenService
+	UserService     services.UserService
+	BackupService   services.BackupService
 }
 
 type Controllers struct {
@@ -715,4 +1305,10 @@ type Controllers struct {
 	AuditController     *controllers.AuditLogController
 	BackupController    *controllers.BackupController
 	SettingsController  *controllers.SettingsController
+	RosterController    *controllers.DutyRosterController
+	SessionController   *controllers.SessionController
+	TwoFactorController *controllers.TwoFactorController
+	JWTKeyController    *controllers.JWTKeyController
+	APITokenController  *controllers.APITokenController
+	HealthController    *controllers.HealthController
 }
\ No newline at end of file
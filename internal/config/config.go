@@ -2,7 +2,13 @@ package config
 
 import (
 	"log"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"simdokpol/internal/models"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -14,6 +20,138 @@ type Config struct {
 	JWTSecretKey string
 	DBDSN        string
 	BcryptCost   int // Biaya bcrypt yang sudah dihitung
+
+	// AuthBackend memilih cara verifikasi login: "local" (bawaan) atau "ldap"
+	AuthBackend string
+	LDAP        LDAPConfig
+
+	Server   ServerConfig
+	Security SecurityConfig
+	TLS      TLSConfig
+	Metrics  MetricsConfig
+	Log      LogConfig
+}
+
+// Format keluaran log yang dikenal.
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// LogConfig mengatur log aplikasi terstruktur dan rotasi file log.
+type LogConfig struct {
+	// Level awal: debug, info, warn, atau error. Dapat diubah saat aplikasi berjalan.
+	Level  string
+	Format string
+	// Dir menyimpan file log; path relatif dihitung dari folder aplikasi
+	Dir        string
+	MaxSizeMB  int
+	MaxAgeDays int
+	MaxBackups int
+	// SQL mencatat setiap query database pada level debug, tanpa nilai parameternya
+	SQL bool
+}
+
+// ServerConfig mengatur alamat server web dan URL publik aplikasi.
+type ServerConfig struct {
+	// Host kosong berarti mendengarkan di semua antarmuka jaringan agar dapat diakses dari LAN
+	Host string
+	Port string
+	// BaseURL adalah alamat yang dibuka browser dan dibagikan ke pengguna, misalnya
+	// https://simdokpol.polres.local. Kosong berarti dihitung dari host, port, dan virtual host.
+	BaseURL string
+	// TrustedProxies adalah IP atau CIDR reverse proxy yang header X-Forwarded-For-nya dipercaya.
+	// Kosong berarti aplikasi diakses langsung dan header tersebut diabaikan.
+	TrustedProxies []string
+	// RequestTimeout adalah batas waktu query dan pekerjaan lain dalam satu request API.
+	// Nol berarti tanpa batas.
+	RequestTimeout time.Duration
+}
+
+// Addr mengembalikan alamat listen HTTP dalam format host:port.
+func (s ServerConfig) Addr() string {
+	return net.JoinHostPort(s.Host, s.Port)
+}
+
+// TLSConfig mengatur HTTPS bawaan dengan sertifikat dari CA lokal.
+type TLSConfig struct {
+	Enabled bool
+	// Port adalah port HTTPS; server HTTP tetap berjalan di port bawaan
+	Port string
+	// HTTPRedirect mengalihkan semua request HTTP ke HTTPS, kecuali unduhan sertifikat CA
+	HTTPRedirect bool
+	// CertDir menyimpan CA lokal dan sertifikat server; path relatif dihitung dari folder aplikasi
+	CertDir string
+	// ExtraHosts adalah nama DNS atau IP tambahan untuk sertifikat server, misalnya nama komputer
+	ExtraHosts []string
+}
+
+// MetricsConfig mengatur akses ke endpoint /metrics untuk Prometheus.
+type MetricsConfig struct {
+	Enabled bool
+	// Token adalah bearer token yang harus dikirim scraper. Kosong berarti hanya AllowedNetworks
+	// yang menentukan akses.
+	Token string
+	// AllowedNetworks adalah alamat IP atau CIDR yang boleh mengakses tanpa token
+	AllowedNetworks []*net.IPNet
+}
+
+// DefaultContentSecurityPolicy mengizinkan aset dari aplikasi sendiri saja. Skrip dan style
+// inline masih diizinkan karena template memakai blok <script> dan atribut style.
+const DefaultContentSecurityPolicy = "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; " +
+	"img-src 'self' data: blob:; font-src 'self' data:; connect-src 'self'; object-src 'none'; " +
+	"frame-ancestors 'self'; base-uri 'self'; form-action 'self'"
+
+// SecurityConfig mengatur atribut cookie dan header keamanan HTTP. Nilainya dapat dibedakan
+// per lingkungan, misalnya CSP mode report-only saat pengembangan.
+type SecurityConfig struct {
+	// CookieSecure hanya mengirim cookie melalui HTTPS
+	CookieSecure bool
+	// HSTS mengirim header Strict-Transport-Security. Dengan CA lokal, aktifkan hanya setelah
+	// CA terpasang di semua klien karena browser tidak lagi mengizinkan melewati peringatan sertifikat.
+	HSTS           bool
+	CookieSameSite http.SameSite
+	CSP            string
+	// CSPReportOnly mengirim CSP sebagai Content-Security-Policy-Report-Only agar pelanggaran
+	// hanya dilaporkan di konsol browser tanpa diblokir
+	CSPReportOnly  bool
+	FrameOptions   string
+	ReferrerPolicy string
+}
+
+// Nilai AuthBackend yang dikenal.
+const (
+	AuthBackendLocal = "local"
+	AuthBackendLDAP  = "ldap"
+)
+
+// LDAPConfig menampung pengaturan koneksi ke direktori LDAP/Active Directory.
+type LDAPConfig struct {
+	URL                string // ldap://host:389 atau ldaps://host:636
+	StartTLS           bool
+	InsecureSkipVerify bool
+	// BindDN dan BindPassword adalah akun layanan untuk mencari entri pengguna.
+	// Kosong berarti pencarian dilakukan secara anonim.
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	// UserFilter mencari entri berdasarkan NRP; %s diganti NRP yang sudah di-escape.
+	UserFilter  string
+	AttrName    string
+	AttrPangkat string
+	AttrJabatan string
+	AttrGroups  string
+	// GroupRoles dievaluasi berurutan; grup pertama yang dimiliki pengguna menentukan perannya.
+	GroupRoles []LDAPGroupRole
+	// DefaultRole dipakai jika pengguna tidak termasuk grup mana pun. Kosong berarti login ditolak.
+	DefaultRole string
+	Timeout     time.Duration
+}
+
+// LDAPGroupRole memetakan DN grup direktori ke peran aplikasi.
+type LDAPGroupRole struct {
+	GroupDN string
+	Role    string
 }
 
 // determineBcryptCost menjalankan benchmark kecil untuk menemukan biaya bcrypt yang optimal.
@@ -51,6 +189,7 @@ func Load() (*Config, error) {
 		JWTSecretKey: os.Getenv("JWT_SECRET_KEY"),
 		DBDSN:        os.Getenv("DB_DSN"),
 		BcryptCost:   chosenBcryptCost,
+		AuthBackend:  strings.ToLower(getEnv("AUTH_BACKEND", AuthBackendLocal)),
 	}
 	
 	if cfg.JWTSecretKey == "" {
@@ -60,5 +199,214 @@ func Load() (*Config, error) {
 		log.Fatal("FATAL: DB_DSN tidak di-set di environment atau file .env")
 	}
 
+	cfg.Server = loadServerConfig()
+	cfg.TLS = loadTLSConfig()
+	if cfg.TLS.Enabled && cfg.TLS.Port == cfg.Server.Port {
+		log.Fatalf("FATAL: TLS_PORT dan PORT tidak boleh sama (%s)", cfg.Server.Port)
+	}
+	cfg.Security = loadSecurityConfig(cfg.TLS)
+	cfg.Metrics = loadMetricsConfig()
+	cfg.Log = loadLogConfig()
+
+	switch cfg.AuthBackend {
+	case AuthBackendLocal:
+	case AuthBackendLDAP:
+		cfg.LDAP = loadLDAPConfig()
+	default:
+		log.Fatalf("FATAL: AUTH_BACKEND '%s' tidak dikenal, gunakan 'local' atau 'ldap'", cfg.AuthBackend)
+	}
+
 	return cfg, nil
-}
\ No newline at end of file
+}
+
+// loadLDAPConfig membaca pengaturan LDAP_* dan menghentikan aplikasi jika tidak lengkap.
+func loadLDAPConfig() LDAPConfig {
+	ldapCfg := LDAPConfig{
+		URL:                os.Getenv("LDAP_URL"),
+		StartTLS:           os.Getenv("LDAP_START_TLS") == "true",
+		InsecureSkipVerify: os.Getenv("LDAP_INSECURE_SKIP_VERIFY") == "true",
+		BindDN:             os.Getenv("LDAP_BIND_DN"),
+		BindPassword:       os.Getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:             os.Getenv("LDAP_BASE_DN"),
+		UserFilter:         getEnv("LDAP_USER_FILTER", "(&(objectClass=person)(uid=%s))"),
+		AttrName:           getEnv("LDAP_ATTR_NAME", "cn"),
+		AttrPangkat:        getEnv("LDAP_ATTR_PANGKAT", "employeeType"),
+		AttrJabatan:        getEnv("LDAP_ATTR_JABATAN", "title"),
+		AttrGroups:         getEnv("LDAP_ATTR_GROUPS", "memberOf"),
+		DefaultRole:        strings.ToUpper(os.Getenv("LDAP_DEFAULT_ROLE")),
+		Timeout:            10 * time.Second,
+	}
+	if ldapCfg.URL == "" || ldapCfg.BaseDN == "" {
+		log.Fatal("FATAL: AUTH_BACKEND=ldap memerlukan LDAP_URL dan LDAP_BASE_DN")
+	}
+	if !strings.Contains(ldapCfg.UserFilter, "%s") {
+		log.Fatalf("FATAL: LDAP_USER_FILTER harus memuat %%s sebagai tempat NRP")
+	}
+	if ldapCfg.DefaultRole != "" && !models.IsValidRole(ldapCfg.DefaultRole) {
+		log.Fatalf("FATAL: LDAP_DEFAULT_ROLE '%s' bukan peran yang valid", ldapCfg.DefaultRole)
+	}
+
+	// Format: "<DN grup>:<PERAN>;<DN grup>:<PERAN>", diurutkan dari peran tertinggi
+	for _, entry := range strings.Split(os.Getenv("LDAP_GROUP_ROLES"), ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		sep := strings.LastIndex(entry, ":")
+		if sep <= 0 {
+			log.Fatalf("FATAL: Entri LDAP_GROUP_ROLES '%s' harus berformat <DN grup>:<PERAN>", entry)
+		}
+		role := strings.ToUpper(strings.TrimSpace(entry[sep+1:]))
+		if !models.IsValidRole(role) {
+			log.Fatalf("FATAL: Peran '%s' pada LDAP_GROUP_ROLES tidak valid", role)
+		}
+		ldapCfg.GroupRoles = append(ldapCfg.GroupRoles, LDAPGroupRole{GroupDN: strings.TrimSpace(entry[:sep]), Role: role})
+	}
+	if len(ldapCfg.GroupRoles) == 0 && ldapCfg.DefaultRole == "" {
+		log.Fatal("FATAL: AUTH_BACKEND=ldap memerlukan LDAP_GROUP_ROLES atau LDAP_DEFAULT_ROLE")
+	}
+	return ldapCfg
+}
+
+// loadServerConfig membaca pengaturan HOST, PORT, BASE_URL, TRUSTED_PROXIES, dan REQUEST_TIMEOUT_SECONDS.
+func loadServerConfig() ServerConfig {
+	serverCfg := ServerConfig{
+		Host:    strings.TrimSpace(os.Getenv("HOST")),
+		Port:    strings.TrimPrefix(getEnv("PORT", "8080"), ":"),
+		BaseURL: strings.TrimRight(strings.TrimSpace(os.Getenv("BASE_URL")), "/"),
+	}
+	if port, err := strconv.Atoi(serverCfg.Port); err != nil || port < 1 || port > 65535 {
+		log.Fatalf("FATAL: PORT '%s' tidak valid, gunakan angka 1-65535", serverCfg.Port)
+	}
+	if serverCfg.BaseURL != "" {
+		parsed, err := url.Parse(serverCfg.BaseURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			log.Fatalf("FATAL: BASE_URL '%s' harus berupa URL lengkap, misalnya https://simdokpol.local:8443", serverCfg.BaseURL)
+		}
+	}
+	for _, proxy := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			serverCfg.TrustedProxies = append(serverCfg.TrustedProxies, proxy)
+		}
+	}
+	timeoutSeconds, err := strconv.Atoi(getEnv("REQUEST_TIMEOUT_SECONDS", "30"))
+	if err != nil || timeoutSeconds < 0 {
+		log.Fatal("FATAL: REQUEST_TIMEOUT_SECONDS harus berupa angka positif")
+	}
+	serverCfg.RequestTimeout = time.Duration(timeoutSeconds) * time.Second
+	return serverCfg
+}
+
+// loadTLSConfig membaca pengaturan TLS_*.
+func loadTLSConfig() TLSConfig {
+	tlsCfg := TLSConfig{
+		Enabled:      os.Getenv("TLS_ENABLED") == "true",
+		Port:         strings.TrimPrefix(getEnv("TLS_PORT", "8443"), ":"),
+		HTTPRedirect: os.Getenv("TLS_HTTP_REDIRECT") == "true",
+		CertDir:      getEnv("TLS_CERT_DIR", "certs"),
+	}
+	if port, err := strconv.Atoi(tlsCfg.Port); err != nil || port < 1 || port > 65535 {
+		log.Fatalf("FATAL: TLS_PORT '%s' tidak valid, gunakan angka 1-65535", tlsCfg.Port)
+	}
+	for _, host := range strings.Split(os.Getenv("TLS_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			tlsCfg.ExtraHosts = append(tlsCfg.ExtraHosts, host)
+		}
+	}
+	return tlsCfg
+}
+
+// loadLogConfig membaca pengaturan LOG_*.
+func loadLogConfig() LogConfig {
+	logCfg := LogConfig{
+		Level:  strings.ToLower(getEnv("LOG_LEVEL", "info")),
+		Format: strings.ToLower(getEnv("LOG_FORMAT", LogFormatText)),
+		Dir:    getEnv("LOG_DIR", "logs"),
+		SQL:    os.Getenv("LOG_SQL") == "true",
+	}
+	switch logCfg.Level {
+	case "debug", "info", "warn", "error":
+	default:
+		log.Fatalf("FATAL: LOG_LEVEL '%s' tidak dikenal, gunakan debug, info, warn, atau error", logCfg.Level)
+	}
+	if logCfg.Format != LogFormatText && logCfg.Format != LogFormatJSON {
+		log.Fatalf("FATAL: LOG_FORMAT '%s' tidak dikenal, gunakan 'text' atau 'json'", logCfg.Format)
+	}
+	for _, setting := range []struct {
+		key      string
+		fallback int
+		target   *int
+	}{
+		{"LOG_MAX_SIZE_MB", 50, &logCfg.MaxSizeMB},
+		{"LOG_MAX_AGE_DAYS", 30, &logCfg.MaxAgeDays},
+		{"LOG_MAX_BACKUPS", 10, &logCfg.MaxBackups},
+	} {
+		value, err := strconv.Atoi(getEnv(setting.key, strconv.Itoa(setting.fallback)))
+		if err != nil || value < 0 {
+			log.Fatalf("FATAL: %s harus berupa angka positif", setting.key)
+		}
+		*setting.target = value
+	}
+	return logCfg
+}
+
+// loadMetricsConfig membaca pengaturan METRICS_*. Tanpa METRICS_TOKEN maupun METRICS_ALLOWED_IPS,
+// /metrics hanya dapat diakses dari komputer server itu sendiri.
+func loadMetricsConfig() MetricsConfig {
+	metricsCfg := MetricsConfig{
+		Enabled: getEnv("METRICS_ENABLED", "true") == "true",
+		Token:   strings.TrimSpace(os.Getenv("METRICS_TOKEN")),
+	}
+	for _, entry := range strings.Split(getEnv("METRICS_ALLOWED_IPS", "127.0.0.1,::1"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			log.Fatalf("FATAL: Entri METRICS_ALLOWED_IPS '%s' bukan IP atau CIDR yang valid", entry)
+		}
+		metricsCfg.AllowedNetworks = append(metricsCfg.AllowedNetworks, network)
+	}
+	return metricsCfg
+}
+
+// loadSecurityConfig membaca pengaturan COOKIE_* dan SECURITY_*. Jika COOKIE_SECURE tidak diisi,
+// cookie Secure aktif saat HTTPS bawaan menangani semua request (TLS dengan pengalihan HTTP).
+func loadSecurityConfig(tlsCfg TLSConfig) SecurityConfig {
+	cookieSecure := tlsCfg.Enabled && tlsCfg.HTTPRedirect
+	if value := os.Getenv("COOKIE_SECURE"); value != "" {
+		cookieSecure = value == "true"
+	}
+	securityCfg := SecurityConfig{
+		CookieSecure:   cookieSecure,
+		HSTS:           getEnv("SECURITY_HSTS", os.Getenv("COOKIE_SECURE")) == "true",
+		CookieSameSite: http.SameSiteStrictMode,
+		CSP:            getEnv("SECURITY_CSP", DefaultContentSecurityPolicy),
+		CSPReportOnly:  os.Getenv("SECURITY_CSP_REPORT_ONLY") == "true",
+		FrameOptions:   getEnv("SECURITY_FRAME_OPTIONS", "SAMEORIGIN"),
+		ReferrerPolicy: getEnv("SECURITY_REFERRER_POLICY", "same-origin"),
+	}
+	switch strings.ToLower(getEnv("COOKIE_SAMESITE", "strict")) {
+	case "strict":
+	case "lax":
+		securityCfg.CookieSameSite = http.SameSiteLaxMode
+	default:
+		log.Fatalf("FATAL: COOKIE_SAMESITE '%s' tidak dikenal, gunakan 'strict' atau 'lax'", os.Getenv("COOKIE_SAMESITE"))
+	}
+	return securityCfg
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,155 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APITokenController struct {
+	apiTokenService services.APITokenService
+}
+
+func NewAPITokenController(apiTokenService services.APITokenService) *APITokenController {
+	return &APITokenController{apiTokenService: apiTokenService}
+}
+
+// @Summary Daftar API Token Saya
+// @Description Mengambil API token milik pengguna yang sedang login. Token asli tidak pernah ditampilkan ulang.
+// @Tags Profile
+// @Produce json
+// @Success 200 {array} models.APIToken
+// @Security BearerAuth
+// @Router /profile/api-tokens [get]
+func (c *APITokenController) FindMine(ctx *gin.Context) {
+	c.findByUser(ctx, ctx.GetUint("userID"))
+}
+
+// @Summary Membuat API Token Saya
+// @Description Membuat API token untuk pengguna yang sedang login. Scope harus dimiliki peran pengguna. Token hanya ditampilkan sekali pada respons ini.
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateAPITokenRequest true "Data API token"
+// @Success 201 {object} dto.APITokenCreated
+// @Failure 400 {object} map[string]string "Error: Data tidak valid"
+// @Security BearerAuth
+// @Router /profile/api-tokens [post]
+func (c *APITokenController) CreateMine(ctx *gin.Context) {
+	c.create(ctx, ctx.GetUint("userID"))
+}
+
+// @Summary Mencabut API Token Saya
+// @Description Mencabut API token milik pengguna yang sedang login.
+// @Tags Profile
+// @Produce json
+// @Param id path int true "ID API Token"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 404 {object} map[string]string "Error: Token tidak ditemukan"
+// @Security BearerAuth
+// @Router /profile/api-tokens/{id} [delete]
+func (c *APITokenController) RevokeMine(ctx *gin.Context) {
+	c.revoke(ctx, ctx.GetUint("userID"))
+}
+
+// @Summary Daftar Semua API Token
+// @Description Mengambil API token seluruh pengguna. Hanya bisa diakses oleh pengguna dengan hak akses user.manage.
+// @Tags Users
+// @Produce json
+// @Success 200 {array} models.APIToken
+// @Security BearerAuth
+// @Router /api-tokens [get]
+func (c *APITokenController) FindAll(ctx *gin.Context) {
+	c.findByUser(ctx, 0)
+}
+
+// @Summary Membuat API Token untuk Pengguna
+// @Description Membuat API token untuk pengguna lain, misalnya akun layanan integrasi. Hanya bisa diakses oleh pengguna dengan hak akses user.manage.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Pengguna"
+// @Param request body dto.CreateAPITokenRequest true "Data API token"
+// @Success 201 {object} dto.APITokenCreated
+// @Failure 400 {object} map[string]string "Error: Data tidak valid"
+// @Failure 404 {object} map[string]string "Error: Pengguna tidak ditemukan"
+// @Security BearerAuth
+// @Router /users/{id}/api-tokens [post]
+func (c *APITokenController) CreateForUser(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
+		return
+	}
+	c.create(ctx, uint(id))
+}
+
+// @Summary Mencabut API Token Pengguna
+// @Description Mencabut API token milik pengguna mana pun. Hanya bisa diakses oleh pengguna dengan hak akses user.manage.
+// @Tags Users
+// @Produce json
+// @Param id path int true "ID API Token"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 404 {object} map[string]string "Error: Token tidak ditemukan"
+// @Security BearerAuth
+// @Router /api-tokens/{id} [delete]
+func (c *APITokenController) Revoke(ctx *gin.Context) {
+	c.revoke(ctx, 0)
+}
+
+func (c *APITokenController) findByUser(ctx *gin.Context, userID uint) {
+	tokens, err := c.apiTokenService.FindByUser(ctx.Request.Context(), userID)
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil daftar API token", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil daftar API token.")
+		return
+	}
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+func (c *APITokenController) create(ctx *gin.Context, ownerID uint) {
+	var req dto.CreateAPITokenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Data tidak valid: "+err.Error())
+		return
+	}
+
+	created, err := c.apiTokenService.Create(ctx.Request.Context(), ownerID, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAPITokenRequest):
+			APIError(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrNotFound):
+			APIError(ctx, http.StatusNotFound, "Pengguna tidak ditemukan")
+		default:
+			slog.ErrorContext(ctx.Request.Context(), "Gagal membuat API token untuk pengguna", "user_id", ownerID, "error", err)
+			APIError(ctx, http.StatusInternalServerError, "Gagal membuat API token.")
+		}
+		return
+	}
+	APIResponse(ctx, http.StatusCreated, "API token berhasil dibuat. Salin sekarang, token tidak akan ditampilkan lagi.", created)
+}
+
+func (c *APITokenController) revoke(ctx *gin.Context, ownerID uint) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID API token tidak valid")
+		return
+	}
+
+	if err := c.apiTokenService.Revoke(ctx.Request.Context(), uint(id), ownerID); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			APIError(ctx, http.StatusNotFound, "API token tidak ditemukan")
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mencabut API token", "api_token_id", id, "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mencabut API token.")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "API token berhasil dicabut.", nil)
+}
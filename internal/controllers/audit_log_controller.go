@@ -1,19 +1,27 @@
 package controllers
 
 import (
-	"log"
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"os"
+	"simdokpol/internal/models"
 	"simdokpol/internal/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuditLogController struct {
-	service services.AuditLogService
+	service       services.AuditLogService
+	configService services.ConfigService
 }
 
-func NewAuditLogController(service services.AuditLogService) *AuditLogController {
-	return &AuditLogController{service: service}
+func NewAuditLogController(service services.AuditLogService, configService services.ConfigService) *AuditLogController {
+	return &AuditLogController{service: service, configService: configService}
 }
 
 // @Summary Mendapatkan Semua Log Audit
@@ -25,11 +33,150 @@ func NewAuditLogController(service services.AuditLogService) *AuditLogController
 // @Security BearerAuth
 // @Router /audit-logs [get]
 func (c *AuditLogController) FindAll(ctx *gin.Context) {
-	logs, err := c.service.FindAll()
+	logs, err := c.service.FindAll(ctx.Request.Context())
 	if err != nil {
-		log.Printf("ERROR: Gagal mengambil data log audit: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil data log audit", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data log audit")
 		return
 	}
 	ctx.JSON(http.StatusOK, logs)
-}
\ No newline at end of file
+}
+
+// @Summary Mengekspor Log Audit
+// @Description Mengunduh log audit dalam rentang tanggal sebagai CSV atau JSON Lines. Berkas JSON Lines diakhiri baris tanda tangan Ed25519 agar keasliannya dapat diperiksa. Hanya bisa diakses oleh Super Admin.
+// @Tags Audit Log
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param from query string true "Tanggal awal (YYYY-MM-DD)"
+// @Param to query string true "Tanggal akhir, inklusif (YYYY-MM-DD)"
+// @Param format query string false "Format berkas: csv atau jsonl (default csv)"
+// @Success 200 {file} file "Berkas ekspor log audit"
+// @Failure 400 {object} map[string]string "Error: Parameter tidak valid"
+// @Failure 500 {object} map[string]string "Error: Gagal mengekspor log audit"
+// @Security BearerAuth
+// @Router /audit-logs/export [get]
+func (c *AuditLogController) Export(ctx *gin.Context) {
+	// Tanggal mengikuti zona waktu kantor, sama seperti tanggal pada dokumen dan jadwal jaga
+	loc, err := c.configService.GetLocation(ctx.Request.Context())
+	if err != nil {
+		loc = time.UTC
+	}
+	from, errFrom := time.ParseInLocation("2006-01-02", ctx.Query("from"), loc)
+	to, errTo := time.ParseInLocation("2006-01-02", ctx.Query("to"), loc)
+	if errFrom != nil || errTo != nil {
+		APIError(ctx, http.StatusBadRequest, "Parameter from dan to wajib diisi dengan format YYYY-MM-DD")
+		return
+	}
+	if to.Before(from) {
+		APIError(ctx, http.StatusBadRequest, "Tanggal akhir tidak boleh sebelum tanggal awal")
+		return
+	}
+	// Tanggal akhir bersifat inklusif hingga akhir hari
+	end := to.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	format := ctx.DefaultQuery("format", services.AuditExportCSV)
+	contentType := "text/csv; charset=utf-8"
+	if format == services.AuditExportJSONL {
+		contentType = "application/x-ndjson"
+	}
+
+	// Ekspor ditulis ke file sementara, bukan memori, lalu dikirim setelah selesai agar kegagalan
+	// di tengah ekspor tetap dilaporkan sebagai error dan bukan berkas yang terpotong
+	file, err := os.CreateTemp("", "simdokpol-audit-export-*")
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membuat file sementara ekspor log audit", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengekspor log audit")
+		return
+	}
+	defer func() {
+		file.Close()
+		os.Remove(file.Name())
+	}()
+
+	out := bufio.NewWriter(file)
+	err = c.service.Export(ctx.Request.Context(), from, end, format, out)
+	if err == nil {
+		err = out.Flush()
+	}
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedExportFormat) {
+			APIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengekspor log audit", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengekspor log audit")
+		return
+	}
+	size, err := file.Seek(0, io.SeekCurrent)
+	if err == nil {
+		_, err = file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membaca file sementara ekspor log audit", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengekspor log audit")
+		return
+	}
+
+	actorID := ctx.GetUint("userID")
+	c.service.LogActivity(ctx.Request.Context(), actorID, models.AuditExportAuditLog,
+		fmt.Sprintf("Mengekspor log audit periode %s s/d %s (%s)", ctx.Query("from"), ctx.Query("to"), format))
+
+	fileName := fmt.Sprintf("log-audit-%s-%s.%s", from.Format("20060102"), to.Format("20060102"), format)
+	ctx.DataFromReader(http.StatusOK, size, contentType, file, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", fileName),
+	})
+}
+
+// @Summary Kunci Publik Penanda Tangan Ekspor Log Audit
+// @Description Mengembalikan kunci publik Ed25519 yang menandatangani ekspor JSON Lines. Pemeriksa menyimpan (pin) kunci ini dan memakainya untuk memverifikasi berkas ekspor; berkas ekspor sendiri tidak memuat kunci publik.
+// @Tags Audit Log
+// @Produce json
+// @Success 200 {object} dto.AuditSigningKey
+// @Failure 500 {object} map[string]string "Error: Gagal memuat kunci penanda tangan"
+// @Security BearerAuth
+// @Router /audit-logs/signing-key [get]
+func (c *AuditLogController) SigningKey(ctx *gin.Context) {
+	key, err := c.service.SigningKey()
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal memuat kunci penanda tangan log audit", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal memuat kunci penanda tangan")
+		return
+	}
+	ctx.JSON(http.StatusOK, key)
+}
+
+// @Summary Memverifikasi Rantai Hash Log Audit
+// @Description Menelusuri rantai hash log audit dari entri pertama dan melaporkan mata rantai pertama yang rusak, termasuk pencocokan dengan file jangkar. Hanya bisa diakses oleh Super Admin.
+// @Tags Audit Log
+// @Produce json
+// @Success 200 {object} dto.AuditChainReport
+// @Failure 500 {object} map[string]string "Error: Gagal memverifikasi rantai log audit"
+// @Security BearerAuth
+// @Router /audit-logs/verify [get]
+func (c *AuditLogController) VerifyChain(ctx *gin.Context) {
+	report, err := c.service.VerifyChain(ctx.Request.Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal memverifikasi rantai log audit", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal memverifikasi rantai log audit")
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// @Summary Membuat Jangkar Rantai Log Audit
+// @Description Mengekspor hash entri log audit terakhir ke file jangkar di server sebagai titik pemeriksaan. Hanya bisa diakses oleh Super Admin.
+// @Tags Audit Log
+// @Produce json
+// @Success 200 {object} map[string]interface{} "Pesan sukses dan path file jangkar"
+// @Failure 500 {object} map[string]string "Error: Gagal membuat jangkar log audit"
+// @Security BearerAuth
+// @Router /audit-logs/anchors [post]
+func (c *AuditLogController) ExportAnchor(ctx *gin.Context) {
+	anchorPath, err := c.service.ExportAnchor(ctx.Request.Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membuat jangkar log audit", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal membuat jangkar log audit")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Jangkar log audit berhasil dibuat", gin.H{"path": anchorPath})
+}
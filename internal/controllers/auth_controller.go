@@ -1,18 +1,26 @@
 package controllers
 
 import (
+	"errors"
+	"log/slog"
+	"math"
 	"net/http"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/middleware"
 	"simdokpol/internal/services"
+	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthController struct {
 	service services.AuthService
+	cookies middleware.CookiePolicy
 }
 
-func NewAuthController(service services.AuthService) *AuthController {
-	return &AuthController{service: service}
+func NewAuthController(service services.AuthService, cookies middleware.CookiePolicy) *AuthController {
+	return &AuthController{service: service, cookies: cookies}
 }
 
 type LoginRequest struct {
@@ -21,14 +29,15 @@ type LoginRequest struct {
 }
 
 // @Summary Login Pengguna
-// @Description Melakukan otentikasi pengguna berdasarkan NRP dan kata sandi, lalu mengembalikan token JWT dalam HttpOnly cookie.
+// @Description Melakukan otentikasi pengguna berdasarkan NRP dan kata sandi, lalu mengembalikan token JWT dalam HttpOnly cookie. Jika verifikasi dua faktor aktif atau diwajibkan, respons berisi challenge_token untuk langkah /login/2fa dan cookie belum diberikan.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param login body LoginRequest true "Data Login Pengguna"
-// @Success 200 {object} map[string]string "Contoh: {\"message\": \"Login berhasil\"}"
+// @Success 200 {object} map[string]interface{} "Contoh: {\"message\": \"Login berhasil\"}"
 // @Failure 400 {object} map[string]string "Contoh: {\"error\": \"NRP dan Kata Sandi diperlukan\"}"
 // @Failure 401 {object} map[string]string "Contoh: {\"error\": \"NRP atau kata sandi salah\"}"
+// @Failure 429 {object} map[string]string "Login dikunci sementara karena terlalu banyak percobaan gagal"
 // @Router /login [post]
 func (c *AuthController) Login(ctx *gin.Context) {
 	var req LoginRequest
@@ -37,20 +46,177 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		return
 	}
 
-	token, err := c.service.Login(req.NRP, req.Password)
+	result, err := c.service.Login(ctx.Request.Context(), req.NRP, req.Password, ctx.ClientIP(), ctx.Request.UserAgent())
 	if err != nil {
-		APIError(ctx, http.StatusUnauthorized, err.Error())
+		c.handleLoginError(ctx, err)
+		return
+	}
+	if result.TwoFactorRequired {
+		APIResponse(ctx, http.StatusOK, "Masukkan kode verifikasi dua faktor", result)
+		return
+	}
+
+	c.setTokenCookie(ctx, result.Token)
+	APIResponse(ctx, http.StatusOK, "Login berhasil", nil)
+}
+
+// @Summary Verifikasi Dua Faktor saat Login
+// @Description Langkah kedua login: menukar challenge_token dan kode TOTP (atau kode pemulihan) dengan token JWT dalam HttpOnly cookie. Bagi pengguna yang sedang mendaftar, kode pertama sekaligus mengaktifkan 2FA dan respons berisi kode pemulihan.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param verification body dto.TwoFactorLoginRequest true "Challenge dan Kode"
+// @Success 200 {object} map[string]interface{} "Pesan Sukses"
+// @Failure 401 {object} map[string]string "Error: Kode salah"
+// @Failure 410 {object} map[string]string "Error: Challenge kedaluwarsa, ulangi login"
+// @Failure 429 {object} map[string]string "Login dikunci sementara karena terlalu banyak percobaan gagal"
+// @Router /login/2fa [post]
+func (c *AuthController) VerifyTwoFactor(ctx *gin.Context) {
+	var req dto.TwoFactorLoginRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Kode verifikasi diperlukan")
 		return
 	}
 
-	// Untuk production, 'secure' harus true dan 'domain' disesuaikan
-	ctx.SetCookie("token", token, 3600*24, "/", "localhost", false, true)
+	result, err := c.service.VerifyTwoFactor(ctx.Request.Context(), req.ChallengeToken, req.Code, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		c.handleLoginError(ctx, err)
+		return
+	}
 
+	c.setTokenCookie(ctx, result.Token)
+	if len(result.RecoveryCodes) > 0 {
+		APIResponse(ctx, http.StatusOK, "Verifikasi dua faktor berhasil diaktifkan", gin.H{"recovery_codes": result.RecoveryCodes})
+		return
+	}
 	APIResponse(ctx, http.StatusOK, "Login berhasil", nil)
 }
 
+// @Summary Memulai Pendaftaran Dua Faktor saat Login
+// @Description Untuk pengguna yang wajib 2FA tetapi belum mendaftar: mengembalikan secret dan QR code untuk aplikasi autentikator.
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param challenge body map[string]string true "Contoh: {\"challenge_token\": \"...\"}"
+// @Success 200 {object} dto.TwoFactorSetup
+// @Failure 410 {object} map[string]string "Error: Challenge kedaluwarsa, ulangi login"
+// @Router /login/2fa/setup [post]
+func (c *AuthController) BeginTwoFactorEnrollment(ctx *gin.Context) {
+	var req struct {
+		ChallengeToken string `json:"challenge_token" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Challenge token diperlukan")
+		return
+	}
+
+	setup, err := c.service.BeginTwoFactorEnrollment(ctx.Request.Context(), req.ChallengeToken)
+	if err != nil {
+		c.handleLoginError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, setup)
+}
+
+func (c *AuthController) handleLoginError(ctx *gin.Context, err error) {
+	var lockedErr *services.LoginLockedError
+	switch {
+	case errors.As(err, &lockedErr):
+		retryAfter := int(math.Ceil(time.Until(lockedErr.Until).Seconds()))
+		ctx.Header("Retry-After", strconv.Itoa(max(retryAfter, 1)))
+		APIError(ctx, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, services.ErrTwoFactorChallengeInvalid):
+		// 410 memberi tahu klien agar mengulang dari langkah kata sandi
+		APIError(ctx, http.StatusGone, err.Error())
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled):
+		APIError(ctx, http.StatusConflict, err.Error())
+	default:
+		APIError(ctx, http.StatusUnauthorized, err.Error())
+	}
+}
+
+func (c *AuthController) setTokenCookie(ctx *gin.Context, token string) {
+	c.cookies.Set(ctx, "token", token, 3600*24, true)
+}
+
 // Logout tidak memerlukan dokumentasi Swagger
 func (c *AuthController) Logout(ctx *gin.Context) {
-	ctx.SetCookie("token", "", -1, "/", "localhost", false, true)
+	if token, err := ctx.Cookie("token"); err == nil && token != "" {
+		if err := c.service.Logout(ctx.Request.Context(), token); err != nil {
+			slog.WarnContext(ctx.Request.Context(), "Gagal mencabut sesi saat logout", "error", err)
+		}
+	}
+	c.cookies.Clear(ctx, "token")
 	APIResponse(ctx, http.StatusOK, "Logout berhasil", nil)
-}
\ No newline at end of file
+}
+
+// @Summary Daftar Penguncian Login
+// @Description Mengambil NRP dan alamat IP yang sedang dikunci karena terlalu banyak percobaan login gagal.
+// @Tags Authentication
+// @Produce json
+// @Success 200 {array} models.LoginThrottle
+// @Security BearerAuth
+// @Router /login-lockouts [get]
+func (c *AuthController) FindLockouts(ctx *gin.Context) {
+	lockouts, err := c.service.FindLockouts(ctx.Request.Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil daftar penguncian login", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil daftar penguncian login.")
+		return
+	}
+	ctx.JSON(http.StatusOK, lockouts)
+}
+
+// @Summary Membuka Kunci Login Berdasarkan Key
+// @Description Menghapus penguncian dan penghitung percobaan gagal untuk sebuah key, misalnya ip:192.168.1.10 atau nrp:12345.
+// @Tags Authentication
+// @Produce json
+// @Param key query string true "Key penguncian"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 404 {object} map[string]string "Error: Penguncian tidak ditemukan"
+// @Security BearerAuth
+// @Router /login-lockouts [delete]
+func (c *AuthController) Unlock(ctx *gin.Context) {
+	key := ctx.Query("key")
+	if key == "" {
+		APIError(ctx, http.StatusBadRequest, "Parameter key wajib diisi")
+		return
+	}
+	if err := c.service.Unlock(ctx.Request.Context(), key); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			APIError(ctx, http.StatusNotFound, "Penguncian tidak ditemukan")
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membuka kunci login", "throttle_key", key, "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal membuka kunci login.")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Kunci login berhasil dibuka.", nil)
+}
+
+// @Summary Membuka Kunci Login Pengguna
+// @Description Menghapus penguncian dan penghitung percobaan gagal milik seorang pengguna. Hanya bisa diakses oleh pengguna dengan hak akses user.manage.
+// @Tags Users
+// @Produce json
+// @Param id path int true "ID Pengguna"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 404 {object} map[string]string "Error: Pengguna tidak ditemukan"
+// @Security BearerAuth
+// @Router /users/{id}/unlock [post]
+func (c *AuthController) UnlockUser(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
+		return
+	}
+	if err := c.service.UnlockUser(ctx.Request.Context(), uint(id)); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			APIError(ctx, http.StatusNotFound, "Pengguna tidak ditemukan")
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membuka kunci login pengguna", "user_id", id, "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal membuka kunci login pengguna.")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Kunci login pengguna berhasil dibuka.", nil)
+}
@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"path/filepath"
 	"simdokpol/internal/services"
@@ -28,9 +28,9 @@ func NewBackupController(service services.BackupService) *BackupController {
 // @Router /backups [post]
 func (c *BackupController) CreateBackup(ctx *gin.Context) {
 	actorID := ctx.GetUint("userID")
-	backupPath, err := c.service.CreateBackup(actorID)
+	backupPath, err := c.service.CreateBackup(ctx.Request.Context())
 	if err != nil {
-		log.Printf("ERROR: Gagal membuat backup oleh user id %d: %v", actorID, err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membuat backup", "user_id", actorID, "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal memproses backup.")
 		return
 	}
@@ -64,15 +64,15 @@ func (c *BackupController) RestoreBackup(ctx *gin.Context) {
 
 	src, err := file.Open()
 	if err != nil {
-		log.Printf("ERROR: Gagal membuka file restore yang diunggah: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membuka file restore yang diunggah", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal memproses file yang diunggah.")
 		return
 	}
 	defer src.Close()
 
 	actorID := ctx.GetUint("userID")
-	if err := c.service.RestoreBackup(src, actorID); err != nil {
-		log.Printf("ERROR: Gagal melakukan restore oleh user id %d: %v", actorID, err)
+	if err := c.service.RestoreBackup(ctx.Request.Context(), src); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal melakukan restore", "user_id", actorID, "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal memulihkan database.")
 		return
 	}
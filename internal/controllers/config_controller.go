@@ -1,7 +1,9 @@
 package controllers
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"simdokpol/internal/models"
 	"simdokpol/internal/services"
@@ -12,12 +14,14 @@ import (
 type ConfigController struct {
 	configService services.ConfigService
 	userService   services.UserService
+	auditService  services.AuditLogService
 }
 
-func NewConfigController(configService services.ConfigService, userService services.UserService) *ConfigController {
+func NewConfigController(configService services.ConfigService, userService services.UserService, auditService services.AuditLogService) *ConfigController {
 	return &ConfigController{
 		configService: configService,
 		userService:   userService,
+		auditService:  auditService,
 	}
 }
 
@@ -38,7 +42,7 @@ type SaveSetupRequest struct {
 }
 
 func (c *ConfigController) SaveSetup(ctx *gin.Context) {
-	isSetup, _ := c.configService.IsSetupComplete()
+	isSetup, _ := c.configService.IsSetupComplete(ctx.Request.Context())
 	if isSetup {
 		APIError(ctx, http.StatusForbidden, "Aplikasi sudah dikonfigurasi.")
 		return
@@ -60,12 +64,9 @@ func (c *ConfigController) SaveSetup(ctx *gin.Context) {
 		"nomor_surat_terakhir":  req.NomorSuratTerakhir,
 		"zona_waktu":            req.ZonaWaktu,
 		"archive_duration_days": req.ArchiveDurationDays,
-		services.IsSetupCompleteKey: "true",
 	}
-
-	if err := c.configService.SaveConfig(configData); err != nil {
-		log.Printf("ERROR: Gagal menyimpan konfigurasi sistem saat setup: %v", err)
-		APIError(ctx, http.StatusInternalServerError, "Gagal menyimpan konfigurasi sistem.")
+	if err := services.ValidateSettings(configData); err != nil {
+		respondSettingsError(ctx, err)
 		return
 	}
 
@@ -78,18 +79,34 @@ func (c *ConfigController) SaveSetup(ctx *gin.Context) {
 		Jabatan:     models.RoleSuperAdmin, // Jabatan default untuk Super Admin
 	}
 
-	// Buat super admin pertama dengan actorID = 0 (menandakan aksi sistem)
-	if err := c.userService.Create(superAdmin, 0); err != nil {
-		log.Printf("ERROR: Gagal membuat akun Super Admin saat setup: %v", err)
-		APIError(ctx, http.StatusInternalServerError, "Gagal membuat akun Super Admin.")
+	// Super Admin, pengaturan, dan tanda setup selesai disimpan dalam satu transaksi. Kata sandi
+	// yang ditolak kebijakan tidak menyimpan apa pun, sehingga masih bisa diperbaiki dari halaman setup.
+	if err := c.userService.CreateInitialSuperAdmin(ctx.Request.Context(), superAdmin, configData); err != nil {
+		switch {
+		case errors.Is(err, services.ErrPasswordPolicy):
+			APIError(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrSetupComplete):
+			APIError(ctx, http.StatusForbidden, "Aplikasi sudah dikonfigurasi.")
+		default:
+			slog.ErrorContext(ctx.Request.Context(), "Gagal menyimpan setup awal", "error", err)
+			APIError(ctx, http.StatusInternalServerError, "Gagal menyimpan konfigurasi sistem.")
+		}
 		return
 	}
+	// Pengaturan ditulis langsung ke database; muat ulang cache agar listener menerima nilainya.
+	if err := c.configService.Reload(ctx.Request.Context()); err != nil {
+		slog.WarnContext(ctx.Request.Context(), "Gagal memuat ulang pengaturan setelah setup", "error", err)
+	}
+
+	// Setup awal dilakukan oleh orang yang menjadi Super Admin, jadi entri audit pertama dicatat atas namanya
+	c.auditService.LogActivity(ctx.Request.Context(), superAdmin.ID, models.AuditSystemSetup,
+		fmt.Sprintf("Setup awal selesai. Akun Super Admin '%s' (NRP: %s) dibuat melalui halaman setup.", superAdmin.NamaLengkap, superAdmin.NRP))
 
 	APIResponse(ctx, http.StatusOK, "Konfigurasi berhasil disimpan. Silakan login menggunakan akun Super Admin yang baru dibuat.", nil)
 }
 
 func (c *ConfigController) ShowSetupPage(ctx *gin.Context) {
-	isSetup, _ := c.configService.IsSetupComplete()
+	isSetup, _ := c.configService.IsSetupComplete(ctx.Request.Context())
 	if isSetup {
 		ctx.Redirect(http.StatusFound, "/login")
 		return
@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"log"
+	"log/slog"
 	"net/http"
 	"simdokpol/internal/services"
 
@@ -29,9 +29,9 @@ func (c *DashboardController) GetExpiringDocuments(ctx *gin.Context) {
 	// Jendela notifikasi diatur ke 3 hari sebelum kedaluwarsa.
 	// Angka ini bisa dibuat dinamis dari konfigurasi jika diperlukan.
 	notificationWindowDays := 3
-	documents, err := c.service.GetExpiringDocumentsForUser(userID, notificationWindowDays)
+	documents, err := c.service.GetExpiringDocumentsForUser(ctx.Request.Context(), userID, notificationWindowDays)
 	if err != nil {
-		log.Printf("ERROR: Gagal mengambil notifikasi dokumen kedaluwarsa untuk user ID %d: %v", userID, err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil notifikasi dokumen kedaluwarsa", "user_id", userID, "error", err)
 		// Kembalikan array kosong agar tidak merusak UI frontend
 		ctx.JSON(http.StatusOK, []string{})
 		return
@@ -49,9 +49,9 @@ func (c *DashboardController) GetExpiringDocuments(ctx *gin.Context) {
 // @Security BearerAuth
 // @Router /stats [get]
 func (c *DashboardController) GetStats(ctx *gin.Context) {
-	stats, err := c.service.GetDashboardStats()
+	stats, err := c.service.GetDashboardStats(ctx.Request.Context())
 	if err != nil {
-		log.Printf("ERROR: Gagal mengambil statistik dasbor: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil statistik dasbor", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data statistik")
 		return
 	}
@@ -67,9 +67,9 @@ func (c *DashboardController) GetStats(ctx *gin.Context) {
 // @Security BearerAuth
 // @Router /stats/monthly-issuance [get]
 func (c *DashboardController) GetMonthlyChart(ctx *gin.Context) {
-	chartData, err := c.service.GetMonthlyIssuanceChartData()
+	chartData, err := c.service.GetMonthlyIssuanceChartData(ctx.Request.Context())
 	if err != nil {
-		log.Printf("ERROR: Gagal mengambil data grafik bulanan: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil data grafik bulanan", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data grafik")
 		return
 	}
@@ -85,11 +85,28 @@ func (c *DashboardController) GetMonthlyChart(ctx *gin.Context) {
 // @Security BearerAuth
 // @Router /stats/item-composition [get]
 func (c *DashboardController) GetItemCompositionChart(ctx *gin.Context) {
-	pieData, err := c.service.GetItemCompositionPieChartData()
+	pieData, err := c.service.GetItemCompositionPieChartData(ctx.Request.Context())
 	if err != nil {
-		log.Printf("ERROR: Gagal mengambil data komposisi barang: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil data komposisi barang", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data komposisi barang")
 		return
 	}
 	ctx.JSON(http.StatusOK, pieData)
-}
\ No newline at end of file
+}
+// @Summary Mendapatkan Statistik per Regu
+// @Description Mengambil jumlah dokumen yang diterbitkan hari ini dan bulan ini untuk setiap regu jaga.
+// @Tags Dashboard & Stats
+// @Produce json
+// @Success 200 {array} services.ReguStatDTO
+// @Failure 500 {object} map[string]string "Error: Gagal mengambil data statistik regu"
+// @Security BearerAuth
+// @Router /stats/regu [get]
+func (c *DashboardController) GetReguBreakdown(ctx *gin.Context) {
+	stats, err := c.service.GetReguBreakdown(ctx.Request.Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil statistik per regu", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data statistik regu")
+		return
+	}
+	ctx.JSON(http.StatusOK, stats)
+}
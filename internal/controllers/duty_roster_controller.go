@@ -0,0 +1,198 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DutyRosterController struct {
+	rosterService services.DutyRosterService
+}
+
+func NewDutyRosterController(rosterService services.DutyRosterService) *DutyRosterController {
+	return &DutyRosterController{rosterService: rosterService}
+}
+
+// @Summary Mendapatkan Jadwal Jaga (Kalender)
+// @Description Mengambil jadwal jaga dalam rentang tanggal untuk tampilan kalender. Bawaan: bulan berjalan.
+// @Tags Duty Rosters
+// @Produce json
+// @Param from query string false "Tanggal awal (YYYY-MM-DD)"
+// @Param to query string false "Tanggal akhir (YYYY-MM-DD)"
+// @Param regu query string false "Filter regu"
+// @Success 200 {array} models.DutyRoster
+// @Failure 400 {object} map[string]string "Error: Parameter tidak valid"
+// @Security BearerAuth
+// @Router /duty-rosters [get]
+func (c *DutyRosterController) FindInRange(ctx *gin.Context) {
+	now := time.Now()
+	firstOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	from := ctx.DefaultQuery("from", firstOfMonth.Format("2006-01-02"))
+	to := ctx.DefaultQuery("to", firstOfMonth.AddDate(0, 1, -1).Format("2006-01-02"))
+
+	rosters, err := c.rosterService.FindInRange(ctx.Request.Context(), from, to, ctx.Query("regu"))
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRosterTime) {
+			APIError(ctx, http.StatusBadRequest, "Format tanggal tidak valid, gunakan YYYY-MM-DD")
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil jadwal jaga", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil jadwal jaga.")
+		return
+	}
+	ctx.JSON(http.StatusOK, rosters)
+}
+
+// @Summary Mendapatkan Jadwal Jaga yang Sedang Berlangsung
+// @Description Mengambil jadwal jaga aktif menurut zona waktu kantor, mengutamakan regu pengguna yang login.
+// @Tags Duty Rosters
+// @Produce json
+// @Success 200 {object} models.DutyRoster
+// @Failure 404 {object} map[string]string "Error: Tidak ada jadwal jaga yang berlangsung"
+// @Security BearerAuth
+// @Router /duty-rosters/current [get]
+func (c *DutyRosterController) FindCurrent(ctx *gin.Context) {
+	regu := ctx.Query("regu")
+	if regu == "" {
+		if user, ok := ctx.Get("currentUser"); ok {
+			regu = user.(*models.User).Regu
+		}
+	}
+
+	roster, err := c.rosterService.FindCurrent(ctx.Request.Context(), regu)
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			APIError(ctx, http.StatusNotFound, "Tidak ada jadwal jaga yang sedang berlangsung")
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil jadwal jaga aktif", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil jadwal jaga aktif.")
+		return
+	}
+	ctx.JSON(http.StatusOK, roster)
+}
+
+// @Summary Mendapatkan Jadwal Jaga Berdasarkan ID
+// @Tags Duty Rosters
+// @Produce json
+// @Param id path int true "ID Jadwal"
+// @Success 200 {object} models.DutyRoster
+// @Failure 404 {object} map[string]string "Error: Jadwal tidak ditemukan"
+// @Security BearerAuth
+// @Router /duty-rosters/{id} [get]
+func (c *DutyRosterController) FindByID(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID jadwal tidak valid")
+		return
+	}
+	roster, err := c.rosterService.FindByID(ctx.Request.Context(), uint(id))
+	if err != nil {
+		APIError(ctx, http.StatusNotFound, "Jadwal jaga tidak ditemukan")
+		return
+	}
+	ctx.JSON(http.StatusOK, roster)
+}
+
+// @Summary Membuat Jadwal Jaga
+// @Description Menetapkan petugas pelapor dan pejabat persetuju yang berjaga pada satu shift. Hanya bisa diakses oleh pengguna dengan hak akses roster.manage.
+// @Tags Duty Rosters
+// @Accept json
+// @Produce json
+// @Param roster body dto.DutyRosterInput true "Data Jadwal Jaga"
+// @Success 201 {object} models.DutyRoster
+// @Failure 400 {object} map[string]string "Error: Input tidak valid"
+// @Failure 409 {object} map[string]string "Error: Jadwal beririsan"
+// @Security BearerAuth
+// @Router /duty-rosters [post]
+func (c *DutyRosterController) Create(ctx *gin.Context) {
+	var req dto.DutyRosterInput
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	roster, err := c.rosterService.Create(ctx.Request.Context(), req)
+	if err != nil {
+		c.handleWriteError(ctx, err, "membuat")
+		return
+	}
+	ctx.JSON(http.StatusCreated, roster)
+}
+
+// @Summary Memperbarui Jadwal Jaga
+// @Tags Duty Rosters
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Jadwal"
+// @Param roster body dto.DutyRosterInput true "Data Jadwal Jaga"
+// @Success 200 {object} models.DutyRoster
+// @Failure 400 {object} map[string]string "Error: Input tidak valid"
+// @Failure 404 {object} map[string]string "Error: Jadwal tidak ditemukan"
+// @Failure 409 {object} map[string]string "Error: Jadwal beririsan"
+// @Security BearerAuth
+// @Router /duty-rosters/{id} [put]
+func (c *DutyRosterController) Update(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID jadwal tidak valid")
+		return
+	}
+	var req dto.DutyRosterInput
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Input tidak valid: "+err.Error())
+		return
+	}
+
+	roster, err := c.rosterService.Update(ctx.Request.Context(), uint(id), req)
+	if err != nil {
+		c.handleWriteError(ctx, err, "memperbarui")
+		return
+	}
+	ctx.JSON(http.StatusOK, roster)
+}
+
+// @Summary Menghapus Jadwal Jaga
+// @Tags Duty Rosters
+// @Produce json
+// @Param id path int true "ID Jadwal"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 404 {object} map[string]string "Error: Jadwal tidak ditemukan"
+// @Security BearerAuth
+// @Router /duty-rosters/{id} [delete]
+func (c *DutyRosterController) Delete(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID jadwal tidak valid")
+		return
+	}
+	if err := c.rosterService.Delete(ctx.Request.Context(), uint(id)); err != nil {
+		c.handleWriteError(ctx, err, "menghapus")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Jadwal jaga berhasil dihapus.", nil)
+}
+
+func (c *DutyRosterController) handleWriteError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		APIError(ctx, http.StatusNotFound, "Jadwal jaga tidak ditemukan")
+	case errors.Is(err, services.ErrRosterOverlap):
+		APIError(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrInvalidRosterOfficer), errors.Is(err, services.ErrInvalidApprover):
+		APIError(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrInvalidRosterTime):
+		APIError(ctx, http.StatusBadRequest, "Tanggal harus YYYY-MM-DD dan jam harus HH:MM")
+	default:
+		slog.ErrorContext(ctx.Request.Context(), "Gagal "+action+" jadwal jaga", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal "+action+" jadwal jaga.")
+	}
+}
@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"simdokpol/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HealthController struct {
+	service services.HealthService
+}
+
+func NewHealthController(service services.HealthService) *HealthController {
+	return &HealthController{service: service}
+}
+
+// Healthz adalah pemeriksaan liveness: selalu 200 selama proses berjalan dan bisa melayani HTTP.
+// Berada di luar basePath /api dan tidak memerlukan login.
+func (c *HealthController) Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readyz memeriksa koneksi database, versi migrasi, status setup awal, dan ruang disk untuk backup.
+// Mengembalikan 503 jika instance belum siap. Karena tidak memerlukan login, hanya nama dan status
+// tiap pemeriksaan yang dikirim; rinciannya tersedia di /api/diagnostics.
+func (c *HealthController) Readyz(ctx *gin.Context) {
+	report := c.service.Readiness(ctx.Request.Context()).WithoutDetails()
+	if !report.Ready {
+		ctx.JSON(http.StatusServiceUnavailable, report)
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
+// @Summary Informasi Diagnostik
+// @Description Versi aplikasi dan Go, ukuran database, jumlah baris per tabel, backup terakhir, biaya bcrypt yang dipakai, serta rincian pemeriksaan kesiapan /readyz.
+// @Tags Settings
+// @Produce json
+// @Success 200 {object} dto.DiagnosticsReport
+// @Failure 500 {object} map[string]string "Error: Gagal mengumpulkan informasi diagnostik"
+// @Security BearerAuth
+// @Router /diagnostics [get]
+func (c *HealthController) Diagnostics(ctx *gin.Context) {
+	report, err := c.service.Diagnostics(ctx.Request.Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengumpulkan informasi diagnostik", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengumpulkan informasi diagnostik.")
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
@@ -8,7 +8,12 @@
  */
 package controllers
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"simdokpol/internal/reqctx"
+
+	"github.com/gin-gonic/gin"
+)
 
 // APIResponse mengirimkan respons JSON standar untuk operasi yang sukses.
 //
@@ -31,6 +36,23 @@ func APIResponse(ctx *gin.Context, statusCode int, message string, data interfac
 // - ctx (*gin.Context): Konteks request Gin.
 // - statusCode (int): Kode status HTTP error (misalnya, 400, 403, 404, 500).
 // - errorMessage (string): Pesan error yang aman untuk ditampilkan ke klien.
+//
+// Respons menyertakan request_id agar laporan error dari pengguna dapat dicocokkan
+// dengan log aplikasi dan log audit.
 func APIError(ctx *gin.Context, statusCode int, errorMessage string) {
-	ctx.JSON(statusCode, gin.H{"error": errorMessage})
-}
\ No newline at end of file
+	response := gin.H{"error": errorMessage}
+	if requestID := reqctx.RequestID(ctx.Request.Context()); requestID != "" {
+		response["request_id"] = requestID
+	}
+	ctx.JSON(statusCode, response)
+}
+
+// APIFieldErrors mengirimkan respons 400 untuk input yang gagal divalidasi, dengan pesan
+// kesalahan per field pada "fields" agar formulir dapat menandai isian yang salah.
+func APIFieldErrors(ctx *gin.Context, errorMessage string, fields map[string]string) {
+	response := gin.H{"error": errorMessage, "fields": fields}
+	if requestID := reqctx.RequestID(ctx.Request.Context()); requestID != "" {
+		response["request_id"] = requestID
+	}
+	ctx.JSON(http.StatusBadRequest, response)
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"log/slog"
+	"net/http"
+	"simdokpol/internal/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWTKeyController struct {
+	jwtKeyService services.JWTKeyService
+}
+
+func NewJWTKeyController(jwtKeyService services.JWTKeyService) *JWTKeyController {
+	return &JWTKeyController{jwtKeyService: jwtKeyService}
+}
+
+// RotateJWTKeyRequest adalah body opsional untuk rotasi kunci JWT.
+type RotateJWTKeyRequest struct {
+	// Immediate mencabut semua kunci lama sekaligus, misalnya saat kunci diduga bocor.
+	Immediate bool `json:"immediate"`
+}
+
+// @Summary Daftar Kunci Penandatanganan JWT
+// @Description Mengambil key ring JWT beserta status tiap kunci (active, grace, expired) tanpa bahan rahasianya.
+// @Tags Settings
+// @Produce json
+// @Success 200 {array} dto.JWTKeyInfo
+// @Security BearerAuth
+// @Router /jwt-keys [get]
+func (c *JWTKeyController) FindAll(ctx *gin.Context) {
+	keys, err := c.jwtKeyService.FindAll(ctx.Request.Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil daftar kunci JWT", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil daftar kunci JWT.")
+		return
+	}
+	ctx.JSON(http.StatusOK, keys)
+}
+
+// @Summary Rotasi Kunci Penandatanganan JWT
+// @Description Membuat kunci aktif baru. Kunci lama tetap diterima selama masa tenggang, kecuali immediate bernilai true.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Param request body RotateJWTKeyRequest false "Opsi rotasi"
+// @Success 200 {object} map[string]interface{} "Pesan Sukses dan kunci baru"
+// @Security BearerAuth
+// @Router /jwt-keys/rotate [post]
+func (c *JWTKeyController) Rotate(ctx *gin.Context) {
+	var req RotateJWTKeyRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			APIError(ctx, http.StatusBadRequest, "Format data tidak valid")
+			return
+		}
+	}
+
+	key, err := c.jwtKeyService.Rotate(ctx.Request.Context(), req.Immediate)
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal merotasi kunci JWT", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal merotasi kunci JWT.")
+		return
+	}
+	message := "Kunci JWT berhasil dirotasi. Sesi yang ada tetap berlaku selama masa tenggang."
+	if req.Immediate {
+		message = "Kunci JWT berhasil dirotasi. Semua pengguna, termasuk Anda, harus login ulang."
+	}
+	APIResponse(ctx, http.StatusOK, message, gin.H{"key": key})
+}
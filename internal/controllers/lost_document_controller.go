@@ -2,7 +2,7 @@ package controllers
 
 import (
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"simdokpol/internal/models"
 	"simdokpol/internal/services"
@@ -22,8 +22,8 @@ type DocumentRequest struct {
 	Pekerjaan          string `json:"pekerjaan" binding:"required" example:"Karyawan Swasta"`
 	Alamat             string `json:"alamat" binding:"required" example:"JL. MERDEKA NO. 10, JAKARTA"`
 	LokasiHilang       string `json:"lokasi_hilang" binding:"required" example:"Sekitar Pasar Senen"`
-	PetugasPelaporID   uint   `json:"petugas_pelapor_id" binding:"required" example:"2"`
-	PejabatPersetujuID uint   `json:"pejabat_persetuju_id" binding:"required" example:"1"`
+	PetugasPelaporID   uint   `json:"petugas_pelapor_id" example:"2"`   // Kosong: diisi dari jadwal jaga aktif
+	PejabatPersetujuID uint   `json:"pejabat_persetuju_id" example:"1"` // Kosong: diisi dari jadwal jaga aktif
 	Items              []struct {
 		NamaBarang string `json:"nama_barang" binding:"required" example:"KTP"`
 		Deskripsi  string `json:"deskripsi" example:"NIK: 3171234567890001"`
@@ -56,9 +56,7 @@ func (c *LostDocumentController) FindByID(ctx *gin.Context) {
 		return
 	}
 
-	loggedInUserID := ctx.GetUint("userID")
-
-	document, err := c.docService.FindByID(uint(id), loggedInUserID)
+	document, err := c.docService.FindByID(ctx.Request.Context(), uint(id))
 	if err != nil {
 		if errors.Is(err, services.ErrAccessDenied) {
 			APIError(ctx, http.StatusForbidden, "Akses ditolak: Anda tidak memiliki izin untuk melihat dokumen ini.")
@@ -82,9 +80,9 @@ func (c *LostDocumentController) FindByID(ctx *gin.Context) {
 // @Router /search [get]
 func (c *LostDocumentController) SearchGlobal(ctx *gin.Context) {
 	query := ctx.Query("q")
-	documents, err := c.docService.SearchGlobal(query)
+	documents, err := c.docService.SearchGlobal(ctx.Request.Context(), query)
 	if err != nil {
-		log.Printf("ERROR: Gagal melakukan pencarian global: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal melakukan pencarian global", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal melakukan pencarian dokumen.")
 		return
 	}
@@ -105,15 +103,76 @@ func (c *LostDocumentController) FindAll(ctx *gin.Context) {
 	query := ctx.Query("q")
 	status := ctx.DefaultQuery("status", "active")
 
-	documents, err := c.docService.FindAll(query, status)
+	documents, err := c.docService.FindAll(ctx.Request.Context(), query, status)
 	if err != nil {
-		log.Printf("ERROR: Gagal mengambil data dokumen: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil data dokumen", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data dokumen.")
 		return
 	}
 	ctx.JSON(http.StatusOK, documents)
 }
 
+// @Summary Serah Terima Regu Jaga
+// @Description Mengambil dokumen yang dibuat atau diperbarui oleh sebuah regu selama jam jaganya, untuk ditindaklanjuti regu berikutnya. Tanpa parameter, regu pengguna dan 24 jam terakhir yang digunakan.
+// @Tags Documents
+// @Produce json
+// @Param regu query string false "Regu yang menyerahkan (I, II, III)"
+// @Param start query string false "Awal jam jaga (RFC3339)"
+// @Param end query string false "Akhir jam jaga (RFC3339)"
+// @Success 200 {object} dto.HandoverReport
+// @Failure 400 {object} map[string]string "Error: Parameter tidak valid"
+// @Failure 403 {object} map[string]string "Error: Regu lain tanpa hak melihat semua dokumen"
+// @Failure 500 {object} map[string]string "Error: Gagal mengambil data serah terima"
+// @Security BearerAuth
+// @Router /documents/handover [get]
+func (c *LostDocumentController) Handover(ctx *gin.Context) {
+	regu := ctx.Query("regu")
+	if regu == "" {
+		if user, ok := ctx.Get("currentUser"); ok {
+			regu = user.(*models.User).Regu
+		}
+	}
+	if regu == "" {
+		APIError(ctx, http.StatusBadRequest, "Parameter regu wajib diisi")
+		return
+	}
+
+	end := time.Now()
+	if v := ctx.Query("end"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			APIError(ctx, http.StatusBadRequest, "Format end tidak valid, gunakan RFC3339")
+			return
+		}
+		end = parsed
+	}
+	start := end.Add(-24 * time.Hour)
+	if v := ctx.Query("start"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			APIError(ctx, http.StatusBadRequest, "Format start tidak valid, gunakan RFC3339")
+			return
+		}
+		start = parsed
+	}
+	if !start.Before(end) {
+		APIError(ctx, http.StatusBadRequest, "Awal jam jaga harus sebelum akhir jam jaga")
+		return
+	}
+
+	report, err := c.docService.GetHandover(ctx.Request.Context(), regu, start, end)
+	if err != nil {
+		if errors.Is(err, services.ErrAccessDenied) {
+			APIError(ctx, http.StatusForbidden, "Akses ditolak: Anda hanya dapat melihat serah terima regu Anda sendiri.")
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil data serah terima", "regu", regu, "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data serah terima.")
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
+
 // @Summary Menghapus Dokumen
 // @Description Menghapus (soft delete) sebuah surat keterangan hilang. Hanya bisa diakses oleh Super Admin atau operator yang membuatnya.
 // @Tags Documents
@@ -132,14 +191,12 @@ func (c *LostDocumentController) Delete(ctx *gin.Context) {
 		return
 	}
 
-	loggedInUserID := ctx.GetUint("userID")
-
-	if err := c.docService.DeleteLostDocument(uint(id), loggedInUserID); err != nil {
+	if err := c.docService.DeleteLostDocument(ctx.Request.Context(), uint(id)); err != nil {
 		if errors.Is(err, services.ErrAccessDenied) {
 			APIError(ctx, http.StatusForbidden, err.Error())
 			return
 		}
-		log.Printf("ERROR: Gagal menghapus dokumen id %d: %v", id, err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal menghapus dokumen", "document_id", id, "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal menghapus dokumen.")
 		return
 	}
@@ -179,8 +236,6 @@ func (c *LostDocumentController) Update(ctx *gin.Context) {
 		return
 	}
 
-	loggedInUserID := ctx.GetUint("userID")
-
 	residentData := models.Resident{
 		NamaLengkap:  req.NamaLengkap,
 		TempatLahir:  req.TempatLahir,
@@ -196,13 +251,17 @@ func (c *LostDocumentController) Update(ctx *gin.Context) {
 		lostItems = append(lostItems, models.LostItem{NamaBarang: item.NamaBarang, Deskripsi: item.Deskripsi})
 	}
 
-	updatedDoc, err := c.docService.UpdateLostDocument(uint(id), residentData, lostItems, req.LokasiHilang, req.PetugasPelaporID, req.PejabatPersetujuID, loggedInUserID)
+	updatedDoc, err := c.docService.UpdateLostDocument(ctx.Request.Context(), uint(id), residentData, lostItems, req.LokasiHilang, req.PetugasPelaporID, req.PejabatPersetujuID)
 	if err != nil {
 		if errors.Is(err, services.ErrAccessDenied) {
 			APIError(ctx, http.StatusForbidden, err.Error())
 			return
 		}
-		log.Printf("ERROR: Gagal memperbarui dokumen id %d: %v", id, err)
+		if errors.Is(err, services.ErrInvalidApprover) {
+			APIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal memperbarui dokumen", "document_id", id, "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal memperbarui dokumen.")
 		return
 	}
@@ -211,7 +270,7 @@ func (c *LostDocumentController) Update(ctx *gin.Context) {
 }
 
 // @Summary Membuat Dokumen Baru
-// @Description Membuat surat keterangan hilang baru.
+// @Description Membuat surat keterangan hilang baru. Petugas pelapor dan pejabat persetuju yang dikosongkan diisi dari jadwal jaga yang sedang berlangsung.
 // @Tags Documents
 // @Accept json
 // @Produce json
@@ -234,8 +293,6 @@ func (c *LostDocumentController) Create(ctx *gin.Context) {
 		return
 	}
 
-	operatorID := ctx.GetUint("userID")
-
 	residentData := models.Resident{
 		NamaLengkap:  req.NamaLengkap,
 		TempatLahir:  req.TempatLahir,
@@ -251,9 +308,13 @@ func (c *LostDocumentController) Create(ctx *gin.Context) {
 		lostItems = append(lostItems, models.LostItem{NamaBarang: item.NamaBarang, Deskripsi: item.Deskripsi})
 	}
 
-	createdDoc, err := c.docService.CreateLostDocument(residentData, lostItems, operatorID, req.LokasiHilang, req.PetugasPelaporID, req.PejabatPersetujuID)
+	createdDoc, err := c.docService.CreateLostDocument(ctx.Request.Context(), residentData, lostItems, req.LokasiHilang, req.PetugasPelaporID, req.PejabatPersetujuID)
 	if err != nil {
-		log.Printf("ERROR: Gagal membuat dokumen: %v", err)
+		if errors.Is(err, services.ErrOfficerRequired) || errors.Is(err, services.ErrInvalidApprover) {
+			APIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membuat dokumen", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal membuat dokumen.")
 		return
 	}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"simdokpol/internal/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController struct {
+	sessionService services.SessionService
+}
+
+func NewSessionController(sessionService services.SessionService) *SessionController {
+	return &SessionController{sessionService: sessionService}
+}
+
+// @Summary Daftar Sesi Login Saya
+// @Description Mengambil semua sesi login aktif milik pengguna yang sedang login. Sesi yang sedang dipakai ditandai current.
+// @Tags Profile
+// @Produce json
+// @Success 200 {array} models.Session
+// @Security BearerAuth
+// @Router /sessions [get]
+func (c *SessionController) FindMine(ctx *gin.Context) {
+	sessions, err := c.sessionService.FindByUser(ctx.Request.Context(), ctx.GetUint("userID"), ctx.GetString("sessionID"))
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil daftar sesi", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil daftar sesi.")
+		return
+	}
+	ctx.JSON(http.StatusOK, sessions)
+}
+
+// @Summary Mencabut Sesi Login Saya
+// @Description Mengakhiri salah satu sesi login milik pengguna yang sedang login, misalnya sesi di komputer lain.
+// @Tags Profile
+// @Produce json
+// @Param id path string true "ID Sesi"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 404 {object} map[string]string "Error: Sesi tidak ditemukan"
+// @Security BearerAuth
+// @Router /sessions/{id} [delete]
+func (c *SessionController) Revoke(ctx *gin.Context) {
+	if err := c.sessionService.Revoke(ctx.Request.Context(), ctx.Param("id"), ctx.GetUint("userID")); err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			APIError(ctx, http.StatusNotFound, "Sesi tidak ditemukan")
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mencabut sesi", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mencabut sesi.")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Sesi berhasil dicabut.", nil)
+}
+
+// @Summary Memaksa Logout Pengguna
+// @Description Mencabut semua sesi login seorang pengguna. Hanya bisa diakses oleh pengguna dengan hak akses user.manage.
+// @Tags Users
+// @Produce json
+// @Param id path int true "ID Pengguna"
+// @Success 200 {object} map[string]interface{} "Pesan Sukses dan jumlah sesi yang dicabut"
+// @Failure 404 {object} map[string]string "Error: Pengguna tidak ditemukan"
+// @Security BearerAuth
+// @Router /users/{id}/logout [post]
+func (c *SessionController) ForceLogout(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
+		return
+	}
+	revoked, err := c.sessionService.ForceLogout(ctx.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			APIError(ctx, http.StatusNotFound, "Pengguna tidak ditemukan")
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal memaksa logout pengguna", "user_id", id, "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal memaksa logout pengguna.")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Semua sesi pengguna telah dicabut.", gin.H{"revoked": revoked})
+}
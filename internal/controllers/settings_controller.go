@@ -1,10 +1,16 @@
 package controllers
 
 import (
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/logging"
 	"simdokpol/internal/models"
 	"simdokpol/internal/services"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
@@ -22,22 +28,34 @@ func NewSettingsController(configService services.ConfigService, auditService se
 	}
 }
 
+// SettingsResponse berisi nilai semua kunci pengaturan sesuai tipenya beserta skemanya.
+type SettingsResponse struct {
+	Values map[string]any               `json:"values"`
+	Schema []services.SettingDefinition `json:"schema"`
+}
+
 // @Summary Mendapatkan Semua Pengaturan Sistem
-// @Description Mengambil semua data konfigurasi sistem yang sedang aktif. Hanya bisa diakses oleh Super Admin.
+// @Description Mengambil nilai semua kunci pengaturan beserta skemanya (tipe, nilai bawaan, batas, nilai yang diizinkan, dan status hanya-baca). Hanya bisa diakses oleh Super Admin.
 // @Tags Settings
 // @Produce json
-// @Success 200 {object} dto.AppConfig
+// @Success 200 {object} SettingsResponse
 // @Failure 500 {object} map[string]string "Error: Gagal mengambil data pengaturan"
 // @Security BearerAuth
 // @Router /settings [get]
 func (c *SettingsController) GetSettings(ctx *gin.Context) {
-	config, err := c.configService.GetConfig()
+	values, err := c.configService.GetSettings(ctx.Request.Context())
 	if err != nil {
-		log.Printf("ERROR: Gagal mengambil data pengaturan: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil data pengaturan", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data pengaturan.")
 		return
 	}
-	ctx.JSON(http.StatusOK, config)
+
+	schema := services.SettingsSchema()
+	response := SettingsResponse{Values: make(map[string]any, len(schema)), Schema: schema}
+	for _, def := range schema {
+		response.Values[def.Key] = def.TypedValue(values[def.Key])
+	}
+	ctx.JSON(http.StatusOK, response)
 }
 
 // @Summary Memperbarui Pengaturan Sistem
@@ -45,35 +63,144 @@ func (c *SettingsController) GetSettings(ctx *gin.Context) {
 // @Tags Settings
 // @Accept json
 // @Produce json
-// @Param settings body dto.AppConfig true "Data Pengaturan Baru"
+// @Param settings body map[string]any true "Kunci pengaturan yang diubah beserta nilainya"
 // @Success 200 {object} map[string]string "Pesan Sukses"
-// @Failure 400 {object} map[string]string "Error: Format data tidak valid"
+// @Failure 400 {object} map[string]any "Error: Format data tidak valid, atau pesan kesalahan per kunci pada fields"
 // @Failure 500 {object} map[string]string "Error: Gagal menyimpan pengaturan"
 // @Security BearerAuth
 // @Router /settings [put]
 func (c *SettingsController) UpdateSettings(ctx *gin.Context) {
-	var settings map[string]string
-	if err := ctx.ShouldBindJSON(&settings); err != nil {
+	var body map[string]any
+	if err := ctx.ShouldBindJSON(&body); err != nil || len(body) == 0 {
 		APIError(ctx, http.StatusBadRequest, "Format data tidak valid")
 		return
 	}
 
-	// Validasi keamanan sederhana untuk path traversal
-	if path, exists := settings["backup_path"]; exists {
-		if strings.Contains(path, "..") {
-			APIError(ctx, http.StatusBadRequest, "Path tidak valid. Tidak boleh mengandung '..'")
-			return
-		}
+	settings, fields := settingsFromJSON(body)
+	if len(fields) > 0 {
+		APIFieldErrors(ctx, settingsInvalidMessage, fields)
+		return
+	}
+	if err := services.ValidateSettings(settings); err != nil {
+		respondSettingsError(ctx, err)
+		return
 	}
 
-	if err := c.configService.SaveConfig(settings); err != nil {
-		log.Printf("ERROR: Gagal menyimpan pengaturan: %v", err)
+	if err := c.configService.SaveConfig(ctx.Request.Context(), settings); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal menyimpan pengaturan", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal menyimpan pengaturan.")
 		return
 	}
 
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 	actorID := ctx.GetUint("userID")
-	c.auditService.LogActivity(actorID, models.AuditSettingsUpdated, "Pengaturan sistem telah diperbarui.")
+	c.auditService.LogActivity(ctx.Request.Context(), actorID, models.AuditSettingsUpdated, fmt.Sprintf("Pengaturan sistem telah diperbarui: %s.", strings.Join(keys, ", ")))
 
 	APIResponse(ctx, http.StatusOK, "Pengaturan berhasil disimpan", nil)
-}
\ No newline at end of file
+}
+
+const settingsInvalidMessage = "Pengaturan tidak valid. Periksa isian yang ditandai."
+
+// settingsFromJSON mengubah nilai JSON (teks, angka, atau boolean) menjadi teks agar dapat
+// divalidasi terhadap skema pengaturan. Nilai bertipe lain dilaporkan per kunci.
+func settingsFromJSON(body map[string]any) (map[string]string, map[string]string) {
+	settings := make(map[string]string, len(body))
+	fields := map[string]string{}
+	for key, value := range body {
+		switch v := value.(type) {
+		case string:
+			settings[key] = v
+		case float64:
+			settings[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			settings[key] = strconv.FormatBool(v)
+		default:
+			fields[key] = "harus berupa teks, angka, atau boolean"
+		}
+	}
+	return settings, fields
+}
+
+// respondSettingsError mengirim kesalahan validasi pengaturan per kunci jika tersedia.
+func respondSettingsError(ctx *gin.Context, err error) {
+	var validationErr *services.SettingsValidationError
+	if errors.As(err, &validationErr) {
+		APIFieldErrors(ctx, settingsInvalidMessage, validationErr.Fields)
+		return
+	}
+	APIError(ctx, http.StatusBadRequest, err.Error())
+}
+
+// @Summary Mendapatkan Kebijakan Kata Sandi
+// @Description Mengambil aturan kata sandi yang berlaku untuk ditampilkan pada formulir penggantian kata sandi.
+// @Tags Settings
+// @Produce json
+// @Success 200 {object} dto.PasswordPolicy
+// @Security BearerAuth
+// @Router /password-policy [get]
+func (c *SettingsController) GetPasswordPolicy(ctx *gin.Context) {
+	config, err := c.configService.GetConfig(ctx.Request.Context())
+	if err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil kebijakan kata sandi", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil kebijakan kata sandi.")
+		return
+	}
+	ctx.JSON(http.StatusOK, dto.PasswordPolicy{
+		MinLength:     max(config.PasswordMinLength, services.DefaultPasswordMinLength),
+		RequireUpper:  config.PasswordRequireUpper,
+		RequireLower:  config.PasswordRequireLower,
+		RequireDigit:  config.PasswordRequireDigit,
+		RequireSymbol: config.PasswordRequireSymbol,
+		HistoryCount:  config.PasswordHistoryCount,
+		MaxAgeDays:    config.PasswordMaxAgeDays,
+	})
+}
+
+// LogLevelRequest adalah body untuk mengubah level log aplikasi.
+type LogLevelRequest struct {
+	Level string `json:"level" binding:"required" example:"debug"`
+}
+
+// @Summary Mendapatkan Level Log
+// @Description Mengambil level log aplikasi yang sedang berlaku.
+// @Tags Settings
+// @Produce json
+// @Success 200 {object} LogLevelRequest
+// @Security BearerAuth
+// @Router /log-level [get]
+func (c *SettingsController) GetLogLevel(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, LogLevelRequest{Level: logging.LevelName()})
+}
+
+// @Summary Mengubah Level Log
+// @Description Mengubah level log (debug, info, warn, error) tanpa restart. Perubahan tidak disimpan; setelah restart level kembali ke LOG_LEVEL.
+// @Tags Settings
+// @Accept json
+// @Produce json
+// @Param request body LogLevelRequest true "Level log baru"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 400 {object} map[string]string "Error: Level log tidak dikenal"
+// @Security BearerAuth
+// @Router /log-level [put]
+func (c *SettingsController) UpdateLogLevel(ctx *gin.Context) {
+	var req LogLevelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Format data tidak valid")
+		return
+	}
+
+	previous := logging.LevelName()
+	if err := logging.SetLevel(req.Level); err != nil {
+		APIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	actorID := ctx.GetUint("userID")
+	c.auditService.LogActivity(ctx.Request.Context(), actorID, models.AuditChangeLogLevel, fmt.Sprintf("Level log diubah dari %s menjadi %s.", previous, logging.LevelName()))
+
+	APIResponse(ctx, http.StatusOK, "Level log berhasil diubah", gin.H{"level": logging.LevelName()})
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/services"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorController struct {
+	twoFactorService services.TwoFactorService
+}
+
+func NewTwoFactorController(twoFactorService services.TwoFactorService) *TwoFactorController {
+	return &TwoFactorController{twoFactorService: twoFactorService}
+}
+
+// @Summary Status Verifikasi Dua Faktor Saya
+// @Tags Profile
+// @Produce json
+// @Success 200 {object} dto.TwoFactorStatus
+// @Security BearerAuth
+// @Router /profile/2fa [get]
+func (c *TwoFactorController) Status(ctx *gin.Context) {
+	status, err := c.twoFactorService.Status(ctx.Request.Context(), ctx.GetUint("userID"))
+	if err != nil {
+		c.handleError(ctx, err, "mengambil status")
+		return
+	}
+	ctx.JSON(http.StatusOK, status)
+}
+
+// @Summary Memulai Pendaftaran Verifikasi Dua Faktor
+// @Description Membuat secret TOTP baru beserta QR code. 2FA baru aktif setelah kode pertama dikonfirmasi.
+// @Tags Profile
+// @Produce json
+// @Success 200 {object} dto.TwoFactorSetup
+// @Failure 409 {object} map[string]string "Error: 2FA sudah aktif"
+// @Security BearerAuth
+// @Router /profile/2fa/setup [post]
+func (c *TwoFactorController) BeginEnrollment(ctx *gin.Context) {
+	setup, err := c.twoFactorService.BeginEnrollment(ctx.Request.Context(), ctx.GetUint("userID"))
+	if err != nil {
+		c.handleError(ctx, err, "memulai pendaftaran")
+		return
+	}
+	ctx.JSON(http.StatusOK, setup)
+}
+
+// @Summary Mengaktifkan Verifikasi Dua Faktor
+// @Description Mengonfirmasi pendaftaran dengan kode TOTP dan mengembalikan kode pemulihan yang hanya ditampilkan sekali.
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param code body dto.TwoFactorCodeRequest true "Kode TOTP"
+// @Success 200 {object} map[string]interface{} "Pesan Sukses dan kode pemulihan"
+// @Failure 400 {object} map[string]string "Error: Kode salah"
+// @Security BearerAuth
+// @Router /profile/2fa/enable [post]
+func (c *TwoFactorController) Enable(ctx *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Kode verifikasi diperlukan")
+		return
+	}
+	codes, err := c.twoFactorService.Enable(ctx.Request.Context(), ctx.GetUint("userID"), req.Code)
+	if err != nil {
+		c.handleError(ctx, err, "mengaktifkan")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Verifikasi dua faktor berhasil diaktifkan.", gin.H{"recovery_codes": codes})
+}
+
+// @Summary Menonaktifkan Verifikasi Dua Faktor
+// @Description Menonaktifkan 2FA milik sendiri. Ditolak jika 2FA diwajibkan untuk peran pengguna.
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param password body map[string]string true "Contoh: {\"password\": \"...\"}"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 400 {object} map[string]string "Error: Kata sandi salah"
+// @Failure 403 {object} map[string]string "Error: 2FA wajib untuk peran ini"
+// @Security BearerAuth
+// @Router /profile/2fa/disable [post]
+func (c *TwoFactorController) Disable(ctx *gin.Context) {
+	var req struct {
+		Password string `json:"password" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Kata sandi diperlukan")
+		return
+	}
+	if err := c.twoFactorService.Disable(ctx.Request.Context(), ctx.GetUint("userID"), req.Password); err != nil {
+		c.handleError(ctx, err, "menonaktifkan")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Verifikasi dua faktor berhasil dinonaktifkan.", nil)
+}
+
+// @Summary Membuat Ulang Kode Pemulihan
+// @Description Mengganti semua kode pemulihan lama setelah kode TOTP diverifikasi.
+// @Tags Profile
+// @Accept json
+// @Produce json
+// @Param code body dto.TwoFactorCodeRequest true "Kode TOTP"
+// @Success 200 {object} map[string]interface{} "Pesan Sukses dan kode pemulihan"
+// @Failure 400 {object} map[string]string "Error: Kode salah"
+// @Security BearerAuth
+// @Router /profile/2fa/recovery-codes [post]
+func (c *TwoFactorController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req dto.TwoFactorCodeRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, "Kode verifikasi diperlukan")
+		return
+	}
+	codes, err := c.twoFactorService.RegenerateRecoveryCodes(ctx.Request.Context(), ctx.GetUint("userID"), req.Code)
+	if err != nil {
+		c.handleError(ctx, err, "membuat ulang kode pemulihan")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Kode pemulihan baru berhasil dibuat.", gin.H{"recovery_codes": codes})
+}
+
+// @Summary Mereset Verifikasi Dua Faktor Pengguna
+// @Description Menghapus secret TOTP dan kode pemulihan seorang pengguna, misalnya saat perangkatnya hilang. Hanya bisa diakses oleh pengguna dengan hak akses user.manage.
+// @Tags Users
+// @Produce json
+// @Param id path int true "ID Pengguna"
+// @Success 200 {object} map[string]string "Pesan Sukses"
+// @Failure 404 {object} map[string]string "Error: Pengguna tidak ditemukan"
+// @Security BearerAuth
+// @Router /users/{id}/2fa [delete]
+func (c *TwoFactorController) Reset(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
+		return
+	}
+	if err := c.twoFactorService.Reset(ctx.Request.Context(), uint(id)); err != nil {
+		c.handleError(ctx, err, "mereset")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Verifikasi dua faktor pengguna berhasil direset.", nil)
+}
+
+func (c *TwoFactorController) handleError(ctx *gin.Context, err error, action string) {
+	switch {
+	case errors.Is(err, services.ErrNotFound):
+		APIError(ctx, http.StatusNotFound, "Pengguna tidak ditemukan")
+	case errors.Is(err, services.ErrInvalidTwoFactorCode), errors.Is(err, services.ErrOldPasswordMismatch):
+		APIError(ctx, http.StatusBadRequest, err.Error())
+	case errors.Is(err, services.ErrTwoFactorAlreadyEnabled), errors.Is(err, services.ErrTwoFactorNotEnabled):
+		APIError(ctx, http.StatusConflict, err.Error())
+	case errors.Is(err, services.ErrTwoFactorRequired):
+		APIError(ctx, http.StatusForbidden, err.Error())
+	default:
+		slog.ErrorContext(ctx.Request.Context(), "Gagal "+action+" verifikasi dua faktor", "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal "+action+" verifikasi dua faktor.")
+	}
+}
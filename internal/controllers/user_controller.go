@@ -2,7 +2,7 @@ package controllers
 
 import (
 	"errors"
-	"log"
+	"log/slog"
 	"net/http"
 	"simdokpol/internal/models"
 	"simdokpol/internal/services"
@@ -24,7 +24,7 @@ type CreateUserRequest struct {
 	NRP         string `json:"nrp" binding:"required" example:"98765"`
 	KataSandi   string `json:"kata_sandi" binding:"required,min=8" example:"password123"`
 	Pangkat     string `json:"pangkat" binding:"required" example:"BRIPDA"`
-	Peran       string `json:"peran" binding:"required" enums:"OPERATOR,SUPER_ADMIN"`
+	Peran       string `json:"peran" binding:"required" enums:"SUPER_ADMIN,KANIT,OPERATOR,AUDITOR,VIEWER"`
 	Jabatan     string `json:"jabatan" binding:"required" example:"ANGGOTA JAGA REGU"`
 	Regu        string `json:"regu" example:"I"`
 }
@@ -34,11 +34,20 @@ type UpdateUserRequest struct {
 	NRP         string `json:"nrp" binding:"required"`
 	KataSandi   string `json:"kata_sandi"`
 	Pangkat     string `json:"pangkat" binding:"required"`
-	Peran       string `json:"peran" binding:"required"`
 	Jabatan     string `json:"jabatan" binding:"required"`
 	Regu        string `json:"regu"`
 }
 
+type AssignRoleRequest struct {
+	Peran string `json:"peran" binding:"required" enums:"SUPER_ADMIN,KANIT,OPERATOR,AUDITOR,VIEWER"`
+}
+
+// RoleInfo menjelaskan satu peran beserta hak aksesnya.
+type RoleInfo struct {
+	Peran       string   `json:"peran"`
+	Permissions []string `json:"permissions"`
+}
+
 type ChangePasswordRequest struct {
 	OldPassword     string `json:"old_password" binding:"required" example:"password_lama123"`
 	NewPassword     string `json:"new_password" binding:"required,min=8" example:"password_baru123"`
@@ -77,9 +86,13 @@ func (c *UserController) UpdateProfile(ctx *gin.Context) {
 		Pangkat:     req.Pangkat,
 	}
 
-	updatedUser, err := c.userService.UpdateProfile(userID, dataToUpdate)
+	updatedUser, err := c.userService.UpdateProfile(ctx.Request.Context(), userID, dataToUpdate)
 	if err != nil {
-		log.Printf("ERROR: Gagal memperbarui profil untuk user ID %d: %v", userID, err)
+		if errors.Is(err, services.ErrDirectoryManagedAccount) {
+			APIError(ctx, http.StatusConflict, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal memperbarui profil", "user_id", userID, "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal memperbarui profil.")
 		return
 	}
@@ -111,11 +124,15 @@ func (c *UserController) ChangePassword(ctx *gin.Context) {
 
 	userID := ctx.GetUint("userID")
 
-	err := c.userService.ChangePassword(userID, req.OldPassword, req.NewPassword)
+	err := c.userService.ChangePassword(ctx.Request.Context(), userID, req.OldPassword, req.NewPassword, ctx.GetString("sessionID"))
 	if err != nil {
-		log.Printf("Gagal mengubah password untuk user ID %d: %v", userID, err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengubah password", "user_id", userID, "error", err)
 		if errors.Is(err, services.ErrOldPasswordMismatch) {
 			APIError(ctx, http.StatusConflict, err.Error())
+		} else if errors.Is(err, services.ErrPasswordPolicy) {
+			APIError(ctx, http.StatusBadRequest, err.Error())
+		} else if errors.Is(err, services.ErrDirectoryManagedAccount) {
+			APIError(ctx, http.StatusConflict, err.Error())
 		} else {
 			APIError(ctx, http.StatusInternalServerError, "Gagal mengubah kata sandi.")
 		}
@@ -142,7 +159,6 @@ func (c *UserController) Create(ctx *gin.Context) {
 		APIError(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
-	actorID := ctx.GetUint("userID")
 
 	user := models.User{
 		NamaLengkap: req.NamaLengkap,
@@ -154,8 +170,16 @@ func (c *UserController) Create(ctx *gin.Context) {
 		Regu:        req.Regu,
 	}
 
-	if err := c.userService.Create(&user, actorID); err != nil {
-		log.Printf("ERROR: Gagal membuat pengguna: %v", err)
+	if err := c.userService.Create(ctx.Request.Context(), &user); err != nil {
+		if errors.Is(err, services.ErrInvalidRole) {
+			APIError(ctx, http.StatusBadRequest, "Peran tidak valid.")
+			return
+		}
+		if errors.Is(err, services.ErrPasswordPolicy) {
+			APIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membuat pengguna", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal membuat pengguna.")
 		return
 	}
@@ -177,36 +201,125 @@ func (c *UserController) Update(ctx *gin.Context) {
 		APIError(ctx, http.StatusBadRequest, "Kata sandi baru minimal 8 karakter")
 		return
 	}
-	actorID := ctx.GetUint("userID")
 
 	user := models.User{
 		ID:          uint(id),
 		NamaLengkap: req.NamaLengkap,
 		NRP:         req.NRP,
 		Pangkat:     req.Pangkat,
-		Peran:       req.Peran,
 		Jabatan:     req.Jabatan,
 		Regu:        req.Regu,
 	}
 
-	if err := c.userService.Update(&user, req.KataSandi, actorID); err != nil {
-		log.Printf("ERROR: Gagal memperbarui pengguna id %d: %v", id, err)
+	if err := c.userService.Update(ctx.Request.Context(), &user, req.KataSandi); err != nil {
+		if errors.Is(err, services.ErrPasswordPolicy) || errors.Is(err, services.ErrDirectoryManagedAccount) {
+			APIError(ctx, http.StatusBadRequest, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal memperbarui pengguna", "user_id", id, "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal memperbarui pengguna.")
 		return
 	}
 	ctx.JSON(http.StatusOK, user)
 }
 
+// @Summary Mengubah Peran Pengguna
+// @Description Mengganti peran (dan dengan demikian hak akses) seorang pengguna. Hanya bisa diakses oleh pengguna dengan hak akses user.manage.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param id path int true "ID Pengguna"
+// @Param role body AssignRoleRequest true "Peran Baru"
+// @Success 200 {object} models.User
+// @Failure 400 {object} map[string]string "Error: Peran tidak valid"
+// @Failure 404 {object} map[string]string "Error: Pengguna tidak ditemukan"
+// @Security BearerAuth
+// @Router /users/{id}/role [put]
+func (c *UserController) AssignRole(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
+		return
+	}
+	var req AssignRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		APIError(ctx, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, err := c.userService.AssignRole(ctx.Request.Context(), uint(id), req.Peran)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidRole), errors.Is(err, services.ErrSelfRoleChange):
+			APIError(ctx, http.StatusBadRequest, err.Error())
+		case errors.Is(err, services.ErrNotFound):
+			APIError(ctx, http.StatusNotFound, "Pengguna tidak ditemukan")
+		default:
+			slog.ErrorContext(ctx.Request.Context(), "Gagal mengubah peran pengguna", "user_id", id, "error", err)
+			APIError(ctx, http.StatusInternalServerError, "Gagal mengubah peran pengguna.")
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, user)
+}
+
+// @Summary Memberikan Kata Sandi Sementara
+// @Description Mengganti kata sandi pengguna dengan kata sandi acak yang wajib diganti saat login berikutnya, lalu mencabut semua sesinya. Hanya bisa diakses oleh pengguna dengan hak akses user.manage.
+// @Tags Users
+// @Produce json
+// @Param id path int true "ID Pengguna"
+// @Success 200 {object} map[string]interface{} "Pesan Sukses dan kata sandi sementara"
+// @Failure 404 {object} map[string]string "Error: Pengguna tidak ditemukan"
+// @Security BearerAuth
+// @Router /users/{id}/temporary-password [post]
+func (c *UserController) IssueTemporaryPassword(ctx *gin.Context) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
+	if err != nil {
+		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
+		return
+	}
+
+	password, err := c.userService.IssueTemporaryPassword(ctx.Request.Context(), uint(id))
+	if err != nil {
+		if errors.Is(err, services.ErrNotFound) {
+			APIError(ctx, http.StatusNotFound, "Pengguna tidak ditemukan")
+			return
+		}
+		if errors.Is(err, services.ErrDirectoryManagedAccount) {
+			APIError(ctx, http.StatusConflict, err.Error())
+			return
+		}
+		slog.ErrorContext(ctx.Request.Context(), "Gagal membuat kata sandi sementara untuk pengguna", "user_id", id, "error", err)
+		APIError(ctx, http.StatusInternalServerError, "Gagal membuat kata sandi sementara.")
+		return
+	}
+	APIResponse(ctx, http.StatusOK, "Kata sandi sementara berhasil dibuat. Sampaikan kepada pengguna secara langsung.", gin.H{"password": password})
+}
+
+// @Summary Mendapatkan Daftar Peran
+// @Description Mengambil semua peran yang dikenal sistem beserta hak akses masing-masing.
+// @Tags Users
+// @Produce json
+// @Success 200 {array} RoleInfo
+// @Security BearerAuth
+// @Router /roles [get]
+func (c *UserController) FindRoles(ctx *gin.Context) {
+	roles := make([]RoleInfo, 0, len(models.Roles()))
+	for _, role := range models.Roles() {
+		roles = append(roles, RoleInfo{Peran: role, Permissions: models.PermissionsForRole(role)})
+	}
+	ctx.JSON(http.StatusOK, roles)
+}
+
 func (c *UserController) Delete(ctx *gin.Context) {
 	id, err := strconv.ParseUint(ctx.Param("id"), 10, 32)
 	if err != nil {
 		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
 		return
 	}
-	actorID := ctx.GetUint("userID")
 
-	if err := c.userService.Deactivate(uint(id), actorID); err != nil {
-		log.Printf("ERROR: Gagal menonaktifkan pengguna id %d: %v", id, err)
+	if err := c.userService.Deactivate(ctx.Request.Context(), uint(id)); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal menonaktifkan pengguna", "user_id", id, "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal menonaktifkan pengguna.")
 		return
 	}
@@ -219,10 +332,9 @@ func (c *UserController) Activate(ctx *gin.Context) {
 		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
 		return
 	}
-	actorID := ctx.GetUint("userID")
 
-	if err := c.userService.Activate(uint(id), actorID); err != nil {
-		log.Printf("ERROR: Gagal mengaktifkan pengguna id %d: %v", id, err)
+	if err := c.userService.Activate(ctx.Request.Context(), uint(id)); err != nil {
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengaktifkan pengguna", "user_id", id, "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengaktifkan pengguna.")
 		return
 	}
@@ -240,9 +352,9 @@ func (c *UserController) Activate(ctx *gin.Context) {
 // @Router /users [get]
 func (c *UserController) FindAll(ctx *gin.Context) {
 	statusFilter := ctx.DefaultQuery("status", "active")
-	users, err := c.userService.FindAll(statusFilter)
+	users, err := c.userService.FindAll(ctx.Request.Context(), statusFilter)
 	if err != nil {
-		log.Printf("ERROR: Gagal mengambil data semua pengguna: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil data semua pengguna", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data pengguna.")
 		return
 	}
@@ -255,7 +367,7 @@ func (c *UserController) FindByID(ctx *gin.Context) {
 		APIError(ctx, http.StatusBadRequest, "ID Pengguna tidak valid")
 		return
 	}
-	user, err := c.userService.FindByID(uint(id))
+	user, err := c.userService.FindByID(ctx.Request.Context(), uint(id))
 	if err != nil {
 		APIError(ctx, http.StatusNotFound, "Pengguna tidak ditemukan")
 		return
@@ -264,9 +376,9 @@ func (c *UserController) FindByID(ctx *gin.Context) {
 }
 
 func (c *UserController) FindOperators(ctx *gin.Context) {
-	operators, err := c.userService.FindOperators()
+	operators, err := c.userService.FindOperators(ctx.Request.Context())
 	if err != nil {
-		log.Printf("ERROR: Gagal mengambil data operator: %v", err)
+		slog.ErrorContext(ctx.Request.Context(), "Gagal mengambil data operator", "error", err)
 		APIError(ctx, http.StatusInternalServerError, "Gagal mengambil data operator.")
 		return
 	}
@@ -24,6 +24,7 @@ import (
 	"simdokpol/internal/middleware"
 	"simdokpol/internal/mocks"
 	"simdokpol/internal/models"
+	"simdokpol/internal/reqctx"
 	"testing"
 
 	"github.com/gin-gonic/gin"
@@ -32,7 +33,8 @@ import (
 )
 
 // setupTestRouter membuat instance Gin baru dan menerapkan middleware yang relevan untuk pengujian.
-func setupTestRouter(mockUserService *mocks.UserService) (*gin.Engine, *mocks.UserService) { // nolint: unparam
+// currentUser (boleh nil) disuntikkan ke konteks seperti yang dilakukan AuthMiddleware.
+func setupTestRouter(mockUserService *mocks.UserService, currentUser *models.User) (*gin.Engine, *mocks.UserService) { // nolint: unparam
 	gin.SetMode(gin.TestMode)
 
 	if mockUserService == nil {
@@ -43,17 +45,22 @@ func setupTestRouter(mockUserService *mocks.UserService) (*gin.Engine, *mocks.Us
 
 	router := gin.New()
 	// Middleware ini menyuntikkan user ke konteks, mensimulasikan AuthMiddleware
+	// Middleware harus dipasang sebelum rute didaftarkan agar ikut dijalankan
 	router.Use(func(c *gin.Context) {
-		// User akan di-set di setiap test case individu
+		if currentUser != nil {
+			c.Set("currentUser", currentUser)
+			c.Set("userID", currentUser.ID)
+			c.Request = c.Request.WithContext(reqctx.WithActor(c.Request.Context(), currentUser.ID))
+		}
 		c.Next()
 	})
 
 	// Rute publik/non-admin
 	router.PUT("/api/profile", userController.UpdateProfile)
 
-	// Grup rute yang dilindungi oleh AdminAuthMiddleware
+	// Grup rute yang dilindungi hak akses seperti di router aplikasi
 	adminRoutes := router.Group("/api")
-	adminRoutes.Use(middleware.AdminAuthMiddleware())
+	adminRoutes.Use(middleware.RequirePermission(models.PermUserManage))
 	{
 		adminRoutes.POST("/users", userController.Create)
 	}
@@ -91,7 +98,7 @@ func TestUserController_Create(t *testing.T) {
 			userInContext: adminUser,
 			requestBody:   validRequestBody,
 			mockSetup: func(mockSvc *mocks.UserService) {
-				mockSvc.On("Create", mock.AnythingOfType("*models.User"), adminUser.ID).Return(nil).Once()
+				mockSvc.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil).Once()
 			},
 			expectedStatusCode: http.StatusCreated,
 			expectedBody: `{"nama_lengkap":"USER BARU","nrp":"99999","pangkat":"BRIPDA","peran":"OPERATOR","jabatan":"ANGGOTA JAGA REGU","regu":"I"}`,
@@ -123,7 +130,7 @@ func TestUserController_Create(t *testing.T) {
 			userInContext: adminUser,
 			requestBody:   validRequestBody,
 			mockSetup: func(mockSvc *mocks.UserService) {
-				mockSvc.On("Create", mock.AnythingOfType("*models.User"), adminUser.ID).Return(errors.New("NRP sudah terdaftar")).Once()
+				mockSvc.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(errors.New("NRP sudah terdaftar")).Once()
 			},
 			expectedStatusCode: http.StatusInternalServerError,
 			expectedBody:       `{"error":"Gagal membuat pengguna."}`,
@@ -134,7 +141,7 @@ func TestUserController_Create(t *testing.T) {
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUserService := new(mocks.UserService)
-			router, _ := setupTestRouter(mockUserService)
+			router, _ := setupTestRouter(mockUserService, tc.userInContext)
 			tc.mockSetup(mockUserService)
 
 			jsonBody, err := json.Marshal(tc.requestBody)
@@ -144,13 +151,6 @@ func TestUserController_Create(t *testing.T) {
 			req.Header.Set("Content-Type", "application/json")
 			recorder := httptest.NewRecorder()
 
-			router.Use(func(c *gin.Context) {
-				if tc.userInContext != nil {
-					c.Set("currentUser", tc.userInContext)
-					c.Set("userID", tc.userInContext.ID)
-				}
-			})
-			
 			router.ServeHTTP(recorder, req)
 
 			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
@@ -203,10 +203,10 @@ func TestUserController_UpdateProfile(t *testing.T) {
 					NRP:         validRequestBody.NRP,
 					Pangkat:     validRequestBody.Pangkat,
 				}
-				mockSvc.On("UpdateProfile", loggedInUser.ID, mock.AnythingOfType("*models.User")).Return(updatedUser, nil).Once()
+				mockSvc.On("UpdateProfile", mock.Anything, loggedInUser.ID, mock.AnythingOfType("*models.User")).Return(updatedUser, nil).Once()
 			},
 			expectedStatusCode: http.StatusOK,
-			expectedBody:       `{"message":"Profil berhasil diperbarui.", "data": {"user": {"ID":5, "nama_lengkap":"USER BARU", "nrp":"55555-NEW", "pangkat":"BRIPKA", "peran":"", "jabatan":"", "regu":"", "created_at":"0001-01-01T00:00:00Z", "updated_at":"0001-01-01T00:00:00Z"}}}`,
+			expectedBody:       `{"message":"Profil berhasil diperbarui.", "data": {"user": {"id":5, "nama_lengkap":"USER BARU", "nrp":"55555-NEW", "pangkat":"BRIPKA", "peran":"", "jabatan":"", "regu":"", "auth_source":"", "totp_enabled":false, "must_change_password":false, "password_changed_at":null, "created_at":"0001-01-01T00:00:00Z", "updated_at":"0001-01-01T00:00:00Z"}}}`,
 		},
 		{
 			name:          "Failure - Invalid Request Body (Missing Pangkat)",
@@ -224,7 +224,7 @@ func TestUserController_UpdateProfile(t *testing.T) {
 			userInContext: loggedInUser,
 			requestBody:   validRequestBody,
 			mockSetup: func(mockSvc *mocks.UserService) {
-				mockSvc.On("UpdateProfile", loggedInUser.ID, mock.AnythingOfType("*models.User")).Return(nil, errors.New("database connection error")).Once()
+				mockSvc.On("UpdateProfile", mock.Anything, loggedInUser.ID, mock.AnythingOfType("*models.User")).Return(nil, errors.New("database connection error")).Once()
 			},
 			expectedStatusCode: http.StatusInternalServerError,
 			expectedBody:       `{"error":"Gagal memperbarui profil."}`,
@@ -236,7 +236,7 @@ func TestUserController_UpdateProfile(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			mockUserService := new(mocks.UserService)
 			// Rute /api/profile tidak memerlukan middleware admin, jadi kita bisa pakai router biasa
-			router, _ := setupTestRouter(mockUserService) 
+			router, _ := setupTestRouter(mockUserService, tc.userInContext)
 			tc.mockSetup(mockUserService)
 
 			jsonBody, err := json.Marshal(tc.requestBody)
@@ -246,13 +246,6 @@ func TestUserController_UpdateProfile(t *testing.T) {
 			req.Header.Set("Content-Type", "application/json")
 			recorder := httptest.NewRecorder()
 
-			router.Use(func(c *gin.Context) {
-				if tc.userInContext != nil {
-					c.Set("currentUser", tc.userInContext)
-					c.Set("userID", tc.userInContext.ID)
-				}
-			})
-			
 			router.ServeHTTP(recorder, req)
 
 			assert.Equal(t, tc.expectedStatusCode, recorder.Code)
@@ -0,0 +1,17 @@
+package dto
+
+import "simdokpol/internal/models"
+
+// CreateAPITokenRequest adalah data untuk membuat API token baru.
+type CreateAPITokenRequest struct {
+	Name string `json:"name" binding:"required" example:"Integrasi SIAK"`
+	// Scopes adalah hak akses yang diizinkan, harus dimiliki oleh peran pemilik token.
+	Scopes        []string `json:"scopes" binding:"required" example:"document.read"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"required" example:"90"`
+}
+
+// APITokenCreated berisi token dalam bentuk teks asli. Token hanya ditampilkan sekali.
+type APITokenCreated struct {
+	Token    string           `json:"token"`
+	APIToken *models.APIToken `json:"api_token"`
+}
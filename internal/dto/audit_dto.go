@@ -0,0 +1,61 @@
+package dto
+
+import "time"
+
+// AuditChainReport adalah hasil verifikasi rantai hash log audit.
+type AuditChainReport struct {
+	Valid           bool      `json:"valid"`
+	EntriesChecked  int       `json:"entries_checked"`
+	ArchivesChecked int       `json:"archives_checked"`
+	AnchorsChecked  int       `json:"anchors_checked"`
+	LastID          uint      `json:"last_id"`
+	LastHash        string    `json:"last_hash"`
+	BrokenAtID      uint      `json:"broken_at_id,omitempty"`
+	Reason          string    `json:"reason,omitempty"`
+	CheckedAt       time.Time `json:"checked_at"`
+}
+
+// AuditAnchor adalah titik jangkar (checkpoint) rantai log audit yang diekspor ke file.
+// Jangkar memungkinkan deteksi penghapusan entri di ujung rantai atau
+// penulisan ulang seluruh rantai.
+type AuditAnchor struct {
+	LastID     uint      `json:"last_id"`
+	LastHash   string    `json:"last_hash"`
+	EntryCount int64     `json:"entry_count"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AuditExportRecord adalah satu baris log audit pada berkas ekspor dan arsip.
+// Field yang ikut dihitung dalam hash disertakan apa adanya agar rantai dapat
+// diverifikasi ulang di luar aplikasi.
+type AuditExportRecord struct {
+	ID          uint      `json:"id"`
+	Timestamp   time.Time `json:"timestamp"`
+	UserID      uint      `json:"user_id"`
+	NRP         string    `json:"nrp,omitempty"`
+	NamaLengkap string    `json:"nama_lengkap,omitempty"`
+	Aksi        string    `json:"aksi"`
+	Detail      string    `json:"detail"`
+	PrevHash    string    `json:"prev_hash"`
+	Hash        string    `json:"hash"`
+	RequestID   string    `json:"request_id,omitempty"`
+}
+
+// AuditExportSignature adalah baris penutup berkas ekspor JSON Lines.
+// Signature adalah tanda tangan Ed25519 atas SHA256 seluruh baris sebelumnya. Kunci publik
+// sengaja tidak disertakan; pemeriksa memakai AuditSigningKey yang diambil langsung dari server.
+type AuditExportSignature struct {
+	Type      string `json:"type"`
+	Algorithm string `json:"algorithm"`
+	SHA256    string `json:"sha256"`
+	Signature string `json:"signature"`
+	Entries   int    `json:"entries"`
+}
+
+// AuditSigningKey adalah kunci publik penanda tangan ekspor log audit. Fingerprint adalah
+// SHA256 dari kunci publik mentah, untuk dicocokkan saat menyimpan (pin) kunci.
+type AuditSigningKey struct {
+	Algorithm   string `json:"algorithm"`
+	PublicKey   string `json:"public_key"`
+	Fingerprint string `json:"fingerprint"`
+}
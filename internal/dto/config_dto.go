@@ -1,5 +1,10 @@
 package dto
 
+import (
+	"strings"
+	"time"
+)
+
 // AppConfig adalah Data Transfer Object untuk konfigurasi aplikasi.
 // Didefinisikan di sini agar dapat digunakan oleh berbagai paket tanpa menyebabkan import cycle.
 type AppConfig struct {
@@ -14,4 +19,69 @@ type AppConfig struct {
 	ZonaWaktu           string `json:"zona_waktu"`
 	BackupPath          string `json:"backup_path"`
 	ArchiveDurationDays int    `json:"archive_duration_days"`
-}
\ No newline at end of file
+	// AuditRetentionMonths adalah umur maksimal log audit di database sebelum dipindahkan
+	// ke berkas arsip. Nilai 0 berarti retensi dinonaktifkan.
+	AuditRetentionMonths int `json:"audit_retention_months"`
+	// DocumentVisibility menentukan dokumen yang terlihat oleh pengguna tanpa hak akses
+	// document.read_all: "own" (default), "regu", atau "all".
+	DocumentVisibility string `json:"document_visibility"`
+	// SessionIdleMinutes adalah batas menit tanpa aktivitas sebelum sesi login berakhir.
+	// Nilai 0 berarti batas idle dinonaktifkan (sesi tetap berakhir setelah 24 jam).
+	SessionIdleMinutes int `json:"session_idle_minutes"`
+	// TwoFactorRoles adalah daftar peran dipisah koma yang wajib memakai verifikasi dua faktor.
+	TwoFactorRoles string `json:"two_factor_roles"`
+	// Kebijakan kata sandi. PasswordHistoryCount adalah jumlah kata sandi terakhir yang tidak
+	// boleh dipakai ulang; PasswordMaxAgeDays 0 berarti kata sandi tidak pernah kedaluwarsa.
+	PasswordMinLength     int  `json:"password_min_length"`
+	PasswordRequireUpper  bool `json:"password_require_upper"`
+	PasswordRequireLower  bool `json:"password_require_lower"`
+	PasswordRequireDigit  bool `json:"password_require_digit"`
+	PasswordRequireSymbol bool `json:"password_require_symbol"`
+	PasswordHistoryCount  int  `json:"password_history_count"`
+	PasswordMaxAgeDays    int  `json:"password_max_age_days"`
+	// JWTRotationDays adalah umur kunci penandatanganan JWT sebelum diganti otomatis (0 = manual).
+	// JWTKeyGraceHours adalah lama kunci lama tetap diterima setelah digantikan.
+	JWTRotationDays  int `json:"jwt_rotation_days"`
+	JWTKeyGraceHours int `json:"jwt_key_grace_hours"`
+}
+
+// ArchiveDuration mengembalikan umur dokumen sebelum dianggap diarsipkan.
+func (c *AppConfig) ArchiveDuration() time.Duration {
+	return time.Duration(c.ArchiveDurationDays) * 24 * time.Hour
+}
+
+// SessionIdleTimeout mengembalikan batas tanpa aktivitas sebelum sesi berakhir (0 = nonaktif).
+func (c *AppConfig) SessionIdleTimeout() time.Duration {
+	return time.Duration(c.SessionIdleMinutes) * time.Minute
+}
+
+// JWTRotationInterval mengembalikan umur kunci JWT sebelum diganti otomatis (0 = manual).
+func (c *AppConfig) JWTRotationInterval() time.Duration {
+	return time.Duration(c.JWTRotationDays) * 24 * time.Hour
+}
+
+// JWTKeyGracePeriod mengembalikan lama kunci JWT lama tetap diterima setelah digantikan.
+func (c *AppConfig) JWTKeyGracePeriod() time.Duration {
+	return time.Duration(c.JWTKeyGraceHours) * time.Hour
+}
+
+// RequiresTwoFactor melaporkan apakah peran termasuk dalam daftar peran wajib 2FA.
+func (c *AppConfig) RequiresTwoFactor(role string) bool {
+	for _, required := range strings.Split(c.TwoFactorRoles, ",") {
+		if strings.TrimSpace(required) == role {
+			return true
+		}
+	}
+	return false
+}
+
+// PasswordPolicy adalah ringkasan kebijakan kata sandi yang ditampilkan pada formulir kata sandi.
+type PasswordPolicy struct {
+	MinLength     int  `json:"min_length"`
+	RequireUpper  bool `json:"require_upper"`
+	RequireLower  bool `json:"require_lower"`
+	RequireDigit  bool `json:"require_digit"`
+	RequireSymbol bool `json:"require_symbol"`
+	HistoryCount  int  `json:"history_count"`
+	MaxAgeDays    int  `json:"max_age_days"`
+}
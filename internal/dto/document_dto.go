@@ -0,0 +1,17 @@
+package dto
+
+import (
+	"simdokpol/internal/models"
+	"time"
+)
+
+// HandoverReport adalah daftar dokumen yang ditangani sebuah regu selama jam jaganya,
+// diserahkan kepada regu berikutnya untuk ditindaklanjuti.
+type HandoverReport struct {
+	Regu         string                `json:"regu"`
+	Start        time.Time             `json:"start"`
+	End          time.Time             `json:"end"`
+	TotalCreated int                   `json:"total_created"`
+	TotalUpdated int                   `json:"total_updated"`
+	Documents    []models.LostDocument `json:"documents"`
+}
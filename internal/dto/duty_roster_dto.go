@@ -0,0 +1,15 @@
+package dto
+
+// DutyRosterInput adalah data masukan untuk membuat atau memperbarui jadwal jaga.
+// JamMulai dan JamSelesai dalam format HH:MM menurut zona waktu kantor; jika jam selesai
+// tidak lebih besar dari jam mulai, shift dianggap berakhir keesokan harinya.
+type DutyRosterInput struct {
+	Tanggal            string `json:"tanggal" binding:"required" example:"2025-01-31"`
+	Shift              string `json:"shift" binding:"required" example:"PAGI"`
+	Regu               string `json:"regu" binding:"required" example:"I"`
+	JamMulai           string `json:"jam_mulai" binding:"required" example:"08:00"`
+	JamSelesai         string `json:"jam_selesai" binding:"required" example:"20:00"`
+	PetugasPelaporID   uint   `json:"petugas_pelapor_id" binding:"required" example:"2"`
+	PejabatPersetujuID uint   `json:"pejabat_persetuju_id" binding:"required" example:"1"`
+	Keterangan         string `json:"keterangan"`
+}
@@ -0,0 +1,45 @@
+package dto
+
+import "time"
+
+// ReadinessCheck adalah hasil satu pemeriksaan kesiapan.
+type ReadinessCheck struct {
+	Name   string `json:"name"`
+	OK     bool   `json:"ok"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// ReadinessReport menyatakan apakah instance siap melayani permintaan beserta rincian tiap pemeriksaan.
+type ReadinessReport struct {
+	Ready  bool             `json:"ready"`
+	Checks []ReadinessCheck `json:"checks"`
+}
+
+// WithoutDetails mengembalikan salinan laporan tanpa Detail, untuk endpoint /readyz yang
+// tidak memerlukan login. Detail dapat memuat pesan error database dan path di server.
+func (r *ReadinessReport) WithoutDetails() *ReadinessReport {
+	public := &ReadinessReport{Ready: r.Ready, Checks: make([]ReadinessCheck, len(r.Checks))}
+	for i, check := range r.Checks {
+		public.Checks[i] = ReadinessCheck{Name: check.Name, OK: check.OK}
+	}
+	return public
+}
+
+// DiagnosticsReport berisi informasi teknis instance untuk administrator.
+type DiagnosticsReport struct {
+	AppVersion       string           `json:"app_version"`
+	GoVersion        string           `json:"go_version"`
+	OS               string           `json:"os"`
+	Arch             string           `json:"arch"`
+	StartedAt        time.Time        `json:"started_at"`
+	DBPath           string           `json:"db_path"`
+	DBSizeBytes      int64            `json:"db_size_bytes"`
+	MigrationVersion uint             `json:"migration_version"`
+	MigrationDirty   bool             `json:"migration_dirty"`
+	TableRowCounts   map[string]int64 `json:"table_row_counts"`
+	BackupPath       string           `json:"backup_path"`
+	LastBackupFile   string           `json:"last_backup_file"`
+	LastBackupAt     *time.Time       `json:"last_backup_at"`
+	BcryptCost       int              `json:"bcrypt_cost"`
+	Readiness        []ReadinessCheck `json:"readiness"`
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// Status kunci penandatanganan JWT.
+const (
+	JWTKeyStatusActive  = "active"
+	JWTKeyStatusGrace   = "grace"
+	JWTKeyStatusExpired = "expired"
+)
+
+// JWTKeyInfo menggambarkan satu kunci dalam key ring tanpa menyertakan bahan rahasianya.
+type JWTKeyInfo struct {
+	KID       string     `json:"kid"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at"`
+	// ValidUntil adalah batas akhir token bertanda tangan kunci ini diterima; kosong untuk kunci aktif.
+	ValidUntil *time.Time `json:"valid_until"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+// LoginResult adalah hasil satu langkah login. Jika TwoFactorRequired bernilai true,
+// klien harus mengirim kode TOTP bersama ChallengeToken ke langkah verifikasi.
+type LoginResult struct {
+	Token              string `json:"-"`
+	TwoFactorRequired  bool   `json:"two_factor_required"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+	ChallengeToken     string `json:"challenge_token,omitempty"`
+	// RecoveryCodes hanya terisi saat pendaftaran 2FA diselesaikan pada langkah login.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// TwoFactorSetup berisi data provisioning untuk aplikasi autentikator.
+type TwoFactorSetup struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	// QRCode adalah gambar PNG dalam bentuk data URI yang siap dipakai pada tag img.
+	QRCode string `json:"qr_code"`
+}
+
+// TwoFactorStatus menggambarkan status 2FA seorang pengguna.
+type TwoFactorStatus struct {
+	Enabled                bool `json:"enabled"`
+	Required               bool `json:"required"`
+	RecoveryCodesRemaining int  `json:"recovery_codes_remaining"`
+}
+
+// TwoFactorCodeRequest dipakai oleh endpoint yang meminta kode TOTP atau kode pemulihan.
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// TwoFactorLoginRequest adalah langkah kedua login.
+type TwoFactorLoginRequest struct {
+	ChallengeToken string `json:"challenge_token" binding:"required"`
+	Code           string `json:"code" binding:"required" example:"123456"`
+}
@@ -0,0 +1,63 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold adalah batas lama query yang dicatat sebagai peringatan.
+const slowQueryThreshold = 200 * time.Millisecond
+
+// gormLogger meneruskan log GORM ke slog. Nilai parameter query tidak pernah ditulis karena
+// dapat berisi data pribadi pemohon seperti NIK dan alamat.
+type gormLogger struct {
+	traceSQL bool
+}
+
+// NewGormLogger membuat logger GORM. Query yang gagal dicatat sebagai ERROR dan query lambat
+// sebagai PERINGATAN; jika traceSQL aktif, setiap query juga dicatat pada level DEBUG.
+func NewGormLogger(traceSQL bool) gormlogger.Interface {
+	return &gormLogger{traceSQL: traceSQL}
+}
+
+func (l *gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	slog.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	slog.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	slog.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// ParamsFilter membuang nilai parameter sehingga SQL yang dicatat hanya berisi placeholder.
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		slog.ErrorContext(ctx, "Query database gagal", "error", err, "sql", sql, "rows", rows, "elapsed", elapsed)
+	case elapsed > slowQueryThreshold:
+		sql, rows := fc()
+		slog.WarnContext(ctx, "Query database lambat", "sql", sql, "rows", rows, "elapsed", elapsed)
+	case l.traceSQL && slog.Default().Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		slog.DebugContext(ctx, "Query database", "sql", sql, "rows", rows, "elapsed", elapsed)
+	}
+}
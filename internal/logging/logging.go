@@ -0,0 +1,173 @@
+// Package logging menyiapkan log aplikasi terstruktur berbasis slog dengan level yang dapat
+// diubah saat aplikasi berjalan, rotasi file log, dan penyamaran data pribadi.
+package logging
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/config"
+	"simdokpol/internal/reqctx"
+	"strings"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// LogFileName adalah nama file log aktif di dalam LogConfig.Dir.
+const LogFileName = "simdokpol.log"
+
+// LevelFatal dipakai untuk pesan log.Fatal lama sebelum aplikasi berhenti.
+const LevelFatal = slog.LevelError + 4
+
+// redactedValue menggantikan nilai atribut yang berisi data pribadi atau rahasia.
+const redactedValue = "[DISAMARKAN]"
+
+// redactedKeys adalah nama atribut yang nilainya tidak boleh tertulis di log. Nama mengikuti
+// field JSON model agar atribut yang disalin dari request atau entitas ikut tersamarkan.
+var redactedKeys = map[string]bool{
+	"nik":           true,
+	"nama_lengkap":  true,
+	"tempat_lahir":  true,
+	"tanggal_lahir": true,
+	"alamat":        true,
+	"password":      true,
+	"kata_sandi":    true,
+	"token":         true,
+	"secret":        true,
+	"totp_secret":   true,
+	"authorization": true,
+	"cookie":        true,
+}
+
+// legacyPrefixes memetakan awalan pesan log.Printf lama ke level slog.
+var legacyPrefixes = []struct {
+	prefix string
+	level  slog.Level
+}{
+	{"DEBUG:", slog.LevelDebug},
+	{"INFO:", slog.LevelInfo},
+	{"PERINGATAN:", slog.LevelWarn},
+	{"ERROR:", slog.LevelError},
+	{"FATAL:", LevelFatal},
+}
+
+var level = new(slog.LevelVar)
+
+// Setup memasang logger slog sebagai logger bawaan. Keluaran ditulis ke stderr dan ke file
+// log berotasi di cfg.Dir. Service, controller, dan middleware menulis log lewat
+// slog.*Context(ctx, ...) agar request_id ikut tercatat dan atribut data pribadi disamarkan.
+// Pesan paket log standar dari kode startup dan CLI, yang tidak memiliki context request,
+// diteruskan ke slog dengan level sesuai awalannya (INFO:, PERINGATAN:, ERROR:, FATAL:).
+func Setup(cfg config.LogConfig) error {
+	if err := SetLevel(cfg.Level); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return fmt.Errorf("gagal membuat folder log '%s': %w", cfg.Dir, err)
+	}
+
+	file := &lumberjack.Logger{
+		Filename:   filepath.Join(cfg.Dir, LogFileName),
+		MaxSize:    cfg.MaxSizeMB,
+		MaxAge:     cfg.MaxAgeDays,
+		MaxBackups: cfg.MaxBackups,
+		LocalTime:  true,
+		Compress:   true,
+	}
+	handler := NewHandler(io.MultiWriter(os.Stderr, file), cfg.Format)
+
+	slog.SetDefault(slog.New(handler))
+	// SetDefault mengarahkan paket log ke handler pada level INFO; ganti dengan writer yang
+	// membaca awalan pesan agar PERINGATAN dan ERROR tetap pada levelnya.
+	log.SetFlags(0)
+	log.SetOutput(legacyWriter{handler})
+	return nil
+}
+
+// NewHandler membuat handler slog dengan level yang dapat diubah lewat SetLevel, penyamaran
+// atribut data pribadi, dan atribut request_id dari context.
+func NewHandler(w io.Writer, format string) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: replaceAttr}
+	if format == config.LogFormatJSON {
+		return contextHandler{slog.NewJSONHandler(w, opts)}
+	}
+	return contextHandler{slog.NewTextHandler(w, opts)}
+}
+
+// SetLevel mengubah level log minimum saat aplikasi berjalan.
+func SetLevel(name string) error {
+	var parsed slog.Level
+	if err := parsed.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("level log '%s' tidak dikenal, gunakan debug, info, warn, atau error", name)
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// LevelName mengembalikan level log yang sedang berlaku dalam huruf kecil.
+func LevelName() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// contextHandler menambahkan atribut request_id dari context (lihat reqctx) ke setiap record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if requestID := reqctx.RequestID(ctx); requestID != "" {
+		r.AddAttrs(slog.String("request_id", requestID))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+func replaceAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) == 0 && a.Key == slog.LevelKey {
+		if lvl, ok := a.Value.Any().(slog.Level); ok && lvl == LevelFatal {
+			return slog.String(slog.LevelKey, "FATAL")
+		}
+		return a
+	}
+	if redactedKeys[strings.ToLower(a.Key)] {
+		return slog.String(a.Key, redactedValue)
+	}
+	return a
+}
+
+// legacyWriter meneruskan keluaran paket log standar ke handler slog.
+type legacyWriter struct {
+	handler slog.Handler
+}
+
+func (w legacyWriter) Write(p []byte) (int, error) {
+	msg := string(bytes.TrimRight(p, "\r\n"))
+	lvl := slog.LevelInfo
+	for _, legacy := range legacyPrefixes {
+		if len(msg) >= len(legacy.prefix) && strings.EqualFold(msg[:len(legacy.prefix)], legacy.prefix) {
+			lvl = legacy.level
+			msg = strings.TrimSpace(msg[len(legacy.prefix):])
+			break
+		}
+	}
+	if !w.handler.Enabled(context.Background(), lvl) {
+		return len(p), nil
+	}
+	if err := w.handler.Handle(context.Background(), slog.NewRecord(time.Now(), lvl, msg, 0)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,126 @@
+// Package metrics mengumpulkan metrik Prometheus aplikasi dalam satu registry
+// yang disajikan oleh endpoint /metrics.
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "simdokpol"
+
+// Nilai label yang dipakai bersama oleh service.
+const (
+	DocumentCreated = "created"
+	DocumentDeleted = "deleted"
+
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+	LoginLocked  = "locked"
+
+	BackupCreate  = "create"
+	BackupRestore = "restore"
+)
+
+// Registry menampung semua metrik aplikasi. Registry terpisah dari bawaan Prometheus
+// agar metrik dari dependensi tidak ikut terekspos tanpa disengaja.
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Jumlah request HTTP per method, route, dan kode status.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Lama pemrosesan request HTTP per method dan route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	documents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "documents_total",
+		Help:      "Jumlah surat keterangan hilang yang dibuat dan dihapus.",
+	}, []string{"action"})
+
+	logins = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "logins_total",
+		Help:      "Jumlah percobaan login menurut hasilnya.",
+	}, []string{"result"})
+
+	backupDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "backup_duration_seconds",
+		Help:      "Lama proses backup dan restore database menurut hasilnya.",
+		Buckets:   []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"operation", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests,
+		httpDuration,
+		documents,
+		logins,
+		backupDuration,
+	)
+}
+
+// Handler menyajikan isi Registry dalam format eksposisi Prometheus.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+// ObserveHTTPRequest mencatat satu request HTTP. route adalah pola route Gin, bukan path
+// mentah, agar jumlah deret waktu tidak bertambah untuk setiap ID dokumen.
+func ObserveHTTPRequest(method string, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// DocumentEvent menambah penghitung dokumen untuk aksi DocumentCreated atau DocumentDeleted.
+func DocumentEvent(action string) {
+	documents.WithLabelValues(action).Inc()
+}
+
+// LoginAttempt menambah penghitung login untuk hasil LoginSuccess, LoginFailure, atau LoginLocked.
+func LoginAttempt(result string) {
+	logins.WithLabelValues(result).Inc()
+}
+
+// ObserveBackup mencatat lama dan hasil satu operasi backup atau restore.
+func ObserveBackup(operation string, err error, duration time.Duration) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	backupDuration.WithLabelValues(operation, result).Observe(duration.Seconds())
+}
+
+// RegisterDatabase mengekspos statistik connection pool SQLite.
+func RegisterDatabase(db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, "sqlite"))
+}
+
+// RegisterAuditFailures mengekspos jumlah entri log audit yang gagal ditulis.
+func RegisterAuditFailures(failureCount func() int64) {
+	Registry.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "audit_write_failures_total",
+		Help:      "Jumlah entri log audit yang gagal ditulis setelah semua percobaan ulang.",
+	}, func() float64 {
+		return float64(failureCount())
+	}))
+}
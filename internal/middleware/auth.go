@@ -1,9 +1,11 @@
 package middleware
 
 import (
-	"fmt"
+	"errors"
+	"log/slog"
 	"net/http"
 	"simdokpol/internal/repositories" // <-- IMPORT BARU
+	"simdokpol/internal/reqctx"
 	"simdokpol/internal/services"
 	"strings"
 
@@ -11,9 +13,24 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// Middleware sekarang menerima UserRepository untuk mengambil data pengguna
-func AuthMiddleware(userRepo repositories.UserRepository) gin.HandlerFunc {
+// Middleware sekarang menerima UserRepository untuk mengambil data pengguna,
+// SessionService untuk memastikan sesi token belum dicabut atau idle,
+// JWTKeyService untuk memverifikasi tanda tangan token berdasarkan kid,
+// APITokenService untuk API token yang dikirim lewat header Authorization: Bearer,
+// dan CookiePolicy untuk menghapus cookie sesi yang sudah tidak berlaku.
+func AuthMiddleware(userRepo repositories.UserRepository, sessionService services.SessionService, jwtKeys services.JWTKeyService, apiTokens services.APITokenService, cookies CookiePolicy) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Header Authorization didahulukan agar skrip dan integrasi tidak memerlukan cookie
+		if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok {
+			bearer = strings.TrimSpace(bearer)
+			if strings.HasPrefix(bearer, services.APITokenPrefix) {
+				authenticateAPIToken(c, apiTokens, bearer)
+				return
+			}
+			authenticateJWT(c, userRepo, sessionService, jwtKeys, cookies, bearer)
+			return
+		}
+
 		tokenString, err := c.Cookie("token")
 
 		if err != nil {
@@ -34,36 +51,86 @@ func AuthMiddleware(userRepo repositories.UserRepository) gin.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("signing method tidak terduga: %v", token.Header["alg"])
+		authenticateJWT(c, userRepo, sessionService, jwtKeys, cookies, tokenString)
+	}
+}
+
+// authenticateAPIToken memvalidasi API token dan membatasi hak akses pengguna sesuai scope token.
+func authenticateAPIToken(c *gin.Context, apiTokens services.APITokenService, plaintext string) {
+	token, user, err := apiTokens.Authenticate(c.Request.Context(), plaintext, c.ClientIP())
+	if err != nil {
+		if !errors.Is(err, services.ErrAPITokenInvalid) {
+			slog.ErrorContext(c.Request.Context(), "Gagal memvalidasi API token", "error", err)
+		}
+		c.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrAPITokenInvalid.Error()})
+		c.Abort()
+		return
+	}
+	c.Set("userID", user.ID)
+	c.Set("apiTokenID", token.ID)
+	c.Set("currentUser", user)
+	c.Request = c.Request.WithContext(reqctx.WithActor(c.Request.Context(), user.ID))
+	c.Next()
+}
+
+// authenticateJWT memvalidasi token sesi dari cookie atau header Authorization.
+func authenticateJWT(c *gin.Context, userRepo repositories.UserRepository, sessionService services.SessionService, jwtKeys services.JWTKeyService, cookies CookiePolicy, tokenString string) {
+	token, err := jwtKeys.Parse(c.Request.Context(), tokenString)
+
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid"})
+		c.Abort()
+		return
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		userID := uint(claims["userID"].(float64))
+
+		// Token tanpa jti atau dengan sesi yang sudah berakhir harus login ulang
+		sessionID, _ := claims["jti"].(string)
+		if _, err := sessionService.Validate(c.Request.Context(), sessionID, userID); err != nil {
+			if !errors.Is(err, services.ErrSessionInvalid) {
+				slog.ErrorContext(c.Request.Context(), "Gagal memvalidasi sesi", "error", err)
+			}
+			cookies.Clear(c, "token")
+			if !strings.HasPrefix(c.Request.URL.Path, "/api") {
+				c.Redirect(http.StatusFound, "/login")
+			} else {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": services.ErrSessionInvalid.Error()})
 			}
-			return services.JWTSecretKey, nil
-		})
+			c.Abort()
+			return
+		}
 
+		// Ambil data lengkap pengguna dan simpan di context
+		user, err := userRepo.FindByID(c.Request.Context(), userID)
 		if err != nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid"})
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Pengguna tidak ditemukan"})
 			c.Abort()
 			return
 		}
+		c.Set("userID", userID)
+		c.Set("sessionID", sessionID)
+		c.Set("currentUser", user) // Simpan objek user lengkap
+		// Service membaca aktor dari context request untuk log audit
+		c.Request = c.Request.WithContext(reqctx.WithActor(c.Request.Context(), userID))
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			userID := uint(claims["userID"].(float64))
-
-			// Ambil data lengkap pengguna dan simpan di context
-			user, err := userRepo.FindByID(userID)
-			if err != nil {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Pengguna tidak ditemukan"})
-				c.Abort()
-				return
-			}
-			c.Set("userID", userID)
-			c.Set("currentUser", user) // Simpan objek user lengkap
+		c.Next()
+	} else {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid"})
+		c.Abort()
+	}
+}
 
-			c.Next()
-		} else {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Token tidak valid"})
+// RejectAPIToken menolak request yang diautentikasi dengan API token. Dipasang pada rute
+// pengelolaan akun (profil, sesi, 2FA, API token) yang tidak tercakup oleh scope token.
+func RejectAPIToken() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetUint("apiTokenID") != 0 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Rute ini tidak dapat diakses dengan API token. Silakan login."})
 			c.Abort()
+			return
 		}
+		c.Next()
 	}
-}
\ No newline at end of file
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+	"simdokpol/internal/config"
+	"simdokpol/internal/metrics"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsMiddleware mencatat jumlah dan lama request per route Gin. Request yang tidak cocok
+// dengan route mana pun digabung dalam satu label agar path acak tidak menambah deret waktu.
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAccessMiddleware mengizinkan akses /metrics dari alamat IP yang diizinkan atau dengan
+// bearer token METRICS_TOKEN.
+func MetricsAccessMiddleware(cfg config.MetricsConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !cfg.Enabled {
+			c.AbortWithStatus(http.StatusNotFound)
+			return
+		}
+
+		if cfg.Token != "" {
+			token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if found && subtle.ConstantTimeCompare([]byte(token), []byte(cfg.Token)) == 1 {
+				c.Next()
+				return
+			}
+		}
+
+		if ip := net.ParseIP(c.ClientIP()); ip != nil {
+			for _, network := range cfg.AllowedNetworks {
+				if network.Contains(ip) {
+					c.Next()
+					return
+				}
+			}
+		}
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "Akses ditolak. Alamat IP atau token metrik tidak diizinkan."})
+		c.Abort()
+	}
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"simdokpol/internal/models"
+	"simdokpol/internal/services"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PasswordChangeMiddleware memaksa pengguna dengan kata sandi sementara atau kedaluwarsa
+// untuk menggantinya sebelum memakai fitur lain. Harus dipasang setelah AuthMiddleware.
+func PasswordChangeMiddleware(passwordPolicy services.PasswordPolicyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userInterface, exists := c.Get("currentUser")
+		// API token tidak membawa kata sandi sehingga tidak ikut dipaksa mengganti kata sandi
+		if !exists || c.GetUint("apiTokenID") != 0 {
+			c.Next()
+			return
+		}
+		user := userInterface.(*models.User)
+		if !user.MustChangePassword && !passwordPolicy.IsExpired(c.Request.Context(), user) {
+			c.Next()
+			return
+		}
+		c.Set("passwordChangeRequired", true)
+
+		// Halaman profil dan API-nya tetap dapat diakses agar kata sandi bisa diganti
+		allowedPaths := []string{"/profile", "/api/profile", "/api/sessions", "/api/password-policy"}
+		for _, path := range allowedPaths {
+			if strings.HasPrefix(c.Request.URL.Path, path) {
+				c.Next()
+				return
+			}
+		}
+
+		if strings.HasPrefix(c.Request.URL.Path, "/api") {
+			c.JSON(http.StatusForbidden, gin.H{"error": services.ErrPasswordChangeRequired.Error()})
+		} else {
+			c.Redirect(http.StatusFound, "/profile")
+		}
+		c.Abort()
+	}
+}
@@ -3,11 +3,14 @@ package middleware
 import (
 	"net/http"
 	"simdokpol/internal/models"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 )
 
-func AdminAuthMiddleware() gin.HandlerFunc {
+// RequirePermission membatasi rute hanya untuk pengguna yang perannya memiliki hak akses tertentu.
+// Harus dipasang setelah AuthMiddleware.
+func RequirePermission(permission string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userInterface, exists := c.Get("currentUser")
 		if !exists {
@@ -23,9 +26,8 @@ func AdminAuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		// Gunakan konstanta untuk peran
-		if currentUser.Peran != models.RoleSuperAdmin {
-			if c.Request.Header.Get("Accept") == "application/json" {
+		if !currentUser.HasPermission(permission) {
+			if strings.HasPrefix(c.Request.URL.Path, "/api") || c.Request.Header.Get("Accept") == "application/json" {
 				c.JSON(http.StatusForbidden, gin.H{"error": "Akses ditolak. Anda tidak memiliki hak akses yang cukup."})
 			} else {
 				c.Redirect(http.StatusFound, "/")
@@ -36,4 +38,4 @@ func AdminAuthMiddleware() gin.HandlerFunc {
 
 		c.Next()
 	}
-}
\ No newline at end of file
+}
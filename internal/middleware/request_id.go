@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"regexp"
+	"simdokpol/internal/reqctx"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader adalah header yang membawa ID request dari klien atau proxy dan yang
+// dikembalikan pada setiap respons.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID membatasi ID dari luar agar tidak bisa menyisipkan baris atau teks panjang ke log.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware memakai X-Request-ID dari request jika formatnya valid atau membuat ID
+// baru, lalu menyimpannya di context request dan header respons. ID ini ikut tercatat di log
+// aplikasi, log audit, dan respons error sehingga laporan pengguna dapat ditelusuri.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(requestID) {
+			requestID = newRequestID()
+		}
+		c.Request = c.Request.WithContext(reqctx.WithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
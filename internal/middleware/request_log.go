@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"log/slog"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestLogMiddleware mencatat setiap request setelah selesai beserta ID request dari
+// RequestIDMiddleware, sehingga harus dipasang sesudahnya. Yang dicatat adalah pola route, bukan path dan query string
+// mentah, karena query pencarian dapat berisi nama atau NIK pemohon.
+func RequestLogMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := c.Writer.Status()
+		level := slog.LevelInfo
+		if status >= 500 {
+			level = slog.LevelError
+		}
+		slog.LogAttrs(c.Request.Context(), level, "Request HTTP",
+			slog.String("method", c.Request.Method),
+			slog.String("route", route),
+			slog.Int("status", status),
+			slog.Duration("elapsed", time.Since(start)),
+			slog.String("client_ip", c.ClientIP()),
+			slog.Uint64("user_id", uint64(c.GetUint("userID"))),
+		)
+	}
+}
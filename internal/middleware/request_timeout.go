@@ -0,0 +1,29 @@
+package middleware
+
+import (
+	"context"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestTimeoutMiddleware memasang batas waktu pada context request sehingga query database
+// yang melewatinya dibatalkan dan koneksi kembali ke pool. Route pada exemptRoutes (misalnya
+// unggah restore atau ekspor besar) tidak diberi batas waktu, tetapi tetap berhenti saat klien
+// memutus koneksi. Timeout nol menonaktifkan middleware ini.
+func RequestTimeoutMiddleware(timeout time.Duration, exemptRoutes ...string) gin.HandlerFunc {
+	exempt := make(map[string]bool, len(exemptRoutes))
+	for _, route := range exemptRoutes {
+		exempt[route] = true
+	}
+	return func(c *gin.Context) {
+		if timeout <= 0 || exempt[c.FullPath()] {
+			c.Next()
+			return
+		}
+		ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+		defer cancel()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
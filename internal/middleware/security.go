@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"simdokpol/internal/config"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// CSRFCookieName menyimpan token CSRF yang dibaca JavaScript lalu dikirim ulang lewat CSRFHeaderName.
+	CSRFCookieName = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+	// csrfTokenLength adalah panjang token CSRF dalam karakter hex.
+	csrfTokenLength = 64
+)
+
+// CookiePolicy menulis cookie dengan atribut Secure dan SameSite sesuai konfigurasi. Cookie
+// selalu host-only (tanpa atribut Domain) agar berlaku untuk localhost maupun virtual host.
+type CookiePolicy struct {
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// NewCookiePolicy membuat CookiePolicy dari konfigurasi keamanan.
+func NewCookiePolicy(cfg config.SecurityConfig) CookiePolicy {
+	return CookiePolicy{Secure: cfg.CookieSecure, SameSite: cfg.CookieSameSite}
+}
+
+// Set menulis cookie. maxAge negatif menghapus cookie.
+func (p CookiePolicy) Set(c *gin.Context, name, value string, maxAge int, httpOnly bool) {
+	c.SetSameSite(p.SameSite)
+	c.SetCookie(name, value, maxAge, "/", "", p.Secure, httpOnly)
+}
+
+// Clear menghapus cookie yang sebelumnya ditulis dengan Set.
+func (p CookiePolicy) Clear(c *gin.Context, name string) {
+	p.Set(c, name, "", -1, true)
+}
+
+// CSRFMiddleware menerapkan pola double-submit cookie. Setiap respons memastikan cookie
+// csrf_token ada, dan request yang mengubah data (selain GET, HEAD, OPTIONS) wajib mengirim
+// nilai yang sama pada header X-CSRF-Token. Request dengan header Authorization: Bearer (API
+// token atau JWT) dikecualikan karena browser tidak melampirkan header itu secara otomatis.
+// Skema Authorization lain tidak dikecualikan, sebab AuthMiddleware lalu memakai cookie sesi.
+func CSRFMiddleware(policy CookiePolicy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		cookieToken, err := c.Cookie(CSRFCookieName)
+		if err != nil || len(cookieToken) != csrfTokenLength {
+			cookieToken = ""
+			raw := make([]byte, csrfTokenLength/2)
+			if _, err := rand.Read(raw); err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Gagal membuat token CSRF"})
+				c.Abort()
+				return
+			}
+			// Tidak HttpOnly karena harus dibaca JavaScript untuk diisikan ke header
+			policy.Set(c, CSRFCookieName, hex.EncodeToString(raw), 0, false)
+		}
+
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+		if bearer, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); ok && strings.TrimSpace(bearer) != "" {
+			c.Next()
+			return
+		}
+
+		headerToken := c.GetHeader(CSRFHeaderName)
+		if cookieToken == "" || subtle.ConstantTimeCompare([]byte(headerToken), []byte(cookieToken)) != 1 {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Token CSRF tidak valid. Muat ulang halaman lalu coba lagi."})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// SecurityHeadersMiddleware menambahkan header keamanan (CSP, X-Frame-Options, Referrer-Policy,
+// X-Content-Type-Options) ke setiap respons. HSTS hanya dikirim jika diaktifkan, secara bawaan
+// mengikuti COOKIE_SECURE yang diisi eksplisit.
+func SecurityHeadersMiddleware(cfg config.SecurityConfig) gin.HandlerFunc {
+	cspHeader := "Content-Security-Policy"
+	if cfg.CSPReportOnly {
+		cspHeader = "Content-Security-Policy-Report-Only"
+	}
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		if cfg.CSP != "" {
+			header.Set(cspHeader, cfg.CSP)
+		}
+		if cfg.FrameOptions != "" {
+			header.Set("X-Frame-Options", cfg.FrameOptions)
+		}
+		if cfg.ReferrerPolicy != "" {
+			header.Set("Referrer-Policy", cfg.ReferrerPolicy)
+		}
+		header.Set("X-Content-Type-Options", "nosniff")
+		if cfg.HSTS {
+			header.Set("Strict-Transport-Security", "max-age=31536000")
+		}
+		c.Next()
+	}
+}
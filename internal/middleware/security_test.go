@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CSRFMiddleware(CookiePolicy{SameSite: http.SameSiteLaxMode}))
+	router.GET("/api/data", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.POST("/api/data", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	validToken := strings.Repeat("a1", csrfTokenLength/2)
+
+	testCases := []struct {
+		name           string
+		method         string
+		cookie         string
+		header         string
+		authorization  string
+		expectedStatus int
+	}{
+		{name: "GET Tanpa Token", method: http.MethodGet, expectedStatus: http.StatusOK},
+		{name: "Token Tidak Ada", method: http.MethodPost, cookie: validToken, expectedStatus: http.StatusForbidden},
+		{name: "Cookie Tidak Ada", method: http.MethodPost, header: validToken, expectedStatus: http.StatusForbidden},
+		{name: "Token Tidak Cocok", method: http.MethodPost, cookie: validToken, header: strings.Repeat("b2", csrfTokenLength/2), expectedStatus: http.StatusForbidden},
+		{name: "Token Valid", method: http.MethodPost, cookie: validToken, header: validToken, expectedStatus: http.StatusOK},
+		{name: "Bearer Dikecualikan", method: http.MethodPost, authorization: "Bearer sdp_token", expectedStatus: http.StatusOK},
+		{name: "Bearer Kosong Tidak Dikecualikan", method: http.MethodPost, cookie: validToken, authorization: "Bearer ", expectedStatus: http.StatusForbidden},
+		{name: "Skema Lain Tidak Dikecualikan", method: http.MethodPost, cookie: validToken, authorization: "Basic dXNlcjpwYXNz", expectedStatus: http.StatusForbidden},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tc.method, "/api/data", nil)
+			if tc.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tc.cookie})
+			}
+			if tc.header != "" {
+				req.Header.Set(CSRFHeaderName, tc.header)
+			}
+			if tc.authorization != "" {
+				req.Header.Set("Authorization", tc.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.expectedStatus, w.Code)
+			// Cookie baru hanya diterbitkan jika request belum membawa token yang valid
+			assert.Equal(t, tc.cookie == "", strings.Contains(w.Header().Get("Set-Cookie"), CSRFCookieName+"="))
+		})
+	}
+}
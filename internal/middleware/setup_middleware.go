@@ -12,7 +12,7 @@ import (
 // Jika belum, semua request akan dialihkan ke halaman setup.
 func SetupMiddleware(configService services.ConfigService) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		isSetup, err := configService.IsSetupComplete()
+		isSetup, err := configService.IsSetupComplete(c.Request.Context())
 		if err != nil {
 			// Jika ada error saat cek konfigurasi, tampilkan halaman error.
 			c.HTML(http.StatusInternalServerError, "error.html", gin.H{
@@ -0,0 +1,53 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type APITokenRepository struct {
+	mock.Mock
+}
+
+func (_m *APITokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	return _m.Called(ctx, token).Error(0)
+}
+
+func (_m *APITokenRepository) FindByID(ctx context.Context, id uint) (*models.APIToken, error) {
+	ret := _m.Called(ctx, id)
+	var r0 *models.APIToken
+	if rf, ok := ret.Get(0).(*models.APIToken); ok {
+		r0 = rf
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *APITokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	ret := _m.Called(ctx, tokenHash)
+	var r0 *models.APIToken
+	if rf, ok := ret.Get(0).(*models.APIToken); ok {
+		r0 = rf
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *APITokenRepository) FindByUser(ctx context.Context, userID uint) ([]models.APIToken, error) {
+	ret := _m.Called(ctx, userID)
+	var r0 []models.APIToken
+	if rf, ok := ret.Get(0).([]models.APIToken); ok {
+		r0 = rf
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *APITokenRepository) Revoke(ctx context.Context, id uint, t time.Time) (bool, error) {
+	ret := _m.Called(ctx, id, t)
+	return ret.Bool(0), ret.Error(1)
+}
+
+func (_m *APITokenRepository) TouchLastUsed(ctx context.Context, id uint, t time.Time, clientIP string) error {
+	return _m.Called(ctx, id, t, clientIP).Error(0)
+}
@@ -0,0 +1,77 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// AuditLogRepository adalah mock untuk repositories.AuditLogRepository
+type AuditLogRepository struct {
+	mock.Mock
+}
+
+func (_m *AuditLogRepository) Create(ctx context.Context, tx *gorm.DB, log *models.AuditLog) error {
+	return _m.Called(ctx, tx, log).Error(0)
+}
+
+func (_m *AuditLogRepository) FindAll(ctx context.Context) ([]models.AuditLog, error) {
+	ret := _m.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.AuditLog), ret.Error(1)
+}
+
+func (_m *AuditLogRepository) FindLast(ctx context.Context) (*models.AuditLog, error) {
+	ret := _m.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*models.AuditLog), ret.Error(1)
+}
+
+func (_m *AuditLogRepository) FindBatchAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditLog, error) {
+	ret := _m.Called(ctx, afterID, limit)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.AuditLog), ret.Error(1)
+}
+
+func (_m *AuditLogRepository) FindInRange(ctx context.Context, start time.Time, end time.Time, afterID uint, limit int) ([]models.AuditLog, error) {
+	ret := _m.Called(ctx, start, end, afterID, limit)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.AuditLog), ret.Error(1)
+}
+
+func (_m *AuditLogRepository) FindLastIDBefore(ctx context.Context, cutoff time.Time) (uint, error) {
+	ret := _m.Called(ctx, cutoff)
+	return ret.Get(0).(uint), ret.Error(1)
+}
+
+func (_m *AuditLogRepository) UpdateHash(ctx context.Context, id uint, prevHash string, hash string) error {
+	return _m.Called(ctx, id, prevHash, hash).Error(0)
+}
+
+func (_m *AuditLogRepository) Count(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+	return ret.Get(0).(int64), ret.Error(1)
+}
+
+func (_m *AuditLogRepository) FindArchives(ctx context.Context) ([]models.AuditArchive, error) {
+	ret := _m.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.AuditArchive), ret.Error(1)
+}
+
+func (_m *AuditLogRepository) ArchiveUpTo(ctx context.Context, lastID uint, archive *models.AuditArchive) error {
+	return _m.Called(ctx, lastID, archive).Error(0)
+}
@@ -1,22 +1,79 @@
 package mocks
 
 import (
+	"context"
+	"io"
+	"simdokpol/internal/dto"
 	"simdokpol/internal/models" // <-- BARIS INI YANG DITAMBAHKAN
+	"time"
+
 	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
 )
 
 type AuditLogService struct {
 	mock.Mock
 }
 
-func (_m *AuditLogService) LogActivity(userID uint, action string, details string) {
-	_m.Called(userID, action, details)
+func (_m *AuditLogService) LogActivity(ctx context.Context, userID uint, action string, details string) {
+	_m.Called(ctx, userID, action, details)
 }
 
-func (_m *AuditLogService) FindAll() ([]models.AuditLog, error) {
-	ret := _m.Called()
+func (_m *AuditLogService) LogActivityTx(ctx context.Context, tx *gorm.DB, userID uint, action string, details string) error {
+	return _m.Called(ctx, tx, userID, action, details).Error(0)
+}
+
+func (_m *AuditLogService) FindAll(ctx context.Context) ([]models.AuditLog, error) {
+	ret := _m.Called(ctx)
 	if ret.Get(0) == nil {
 		return nil, ret.Error(1)
 	}
 	return ret.Get(0).([]models.AuditLog), ret.Error(1)
-}
\ No newline at end of file
+}
+
+func (_m *AuditLogService) VerifyChain(ctx context.Context) (*dto.AuditChainReport, error) {
+	ret := _m.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*dto.AuditChainReport), ret.Error(1)
+}
+
+func (_m *AuditLogService) ExportAnchor(ctx context.Context) (string, error) {
+	ret := _m.Called(ctx)
+	return ret.String(0), ret.Error(1)
+}
+
+func (_m *AuditLogService) SealLegacyEntries(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+	return ret.Int(0), ret.Error(1)
+}
+
+func (_m *AuditLogService) Export(ctx context.Context, start time.Time, end time.Time, format string, w io.Writer) error {
+	return _m.Called(ctx, start, end, format, w).Error(0)
+}
+
+func (_m *AuditLogService) SigningKey() (*dto.AuditSigningKey, error) {
+	ret := _m.Called()
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*dto.AuditSigningKey), ret.Error(1)
+}
+
+func (_m *AuditLogService) ApplyRetention(ctx context.Context, retentionMonths int) ([]models.AuditArchive, error) {
+	ret := _m.Called(ctx, retentionMonths)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.AuditArchive), ret.Error(1)
+}
+
+func (_m *AuditLogService) Close(timeout time.Duration) error {
+	return _m.Called(timeout).Error(0)
+}
+
+func (_m *AuditLogService) FailureCount() int64 {
+	ret := _m.Called()
+	return ret.Get(0).(int64)
+}
@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type ConfigRepository struct {
+	mock.Mock
+}
+
+func (_m *ConfigRepository) Get(ctx context.Context, key string) (*models.Configuration, error) {
+	ret := _m.Called(ctx, key)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*models.Configuration), ret.Error(1)
+}
+
+func (_m *ConfigRepository) GetAll(ctx context.Context) (map[string]string, error) {
+	ret := _m.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(map[string]string), ret.Error(1)
+}
+
+func (_m *ConfigRepository) Set(ctx context.Context, key, value string) error {
+	return _m.Called(ctx, key, value).Error(0)
+}
+
+func (_m *ConfigRepository) SetMultiple(ctx context.Context, configs map[string]string) error {
+	return _m.Called(ctx, configs).Error(0)
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"simdokpol/internal/dto" // <-- IMPORT DIUBAH
 	"time"
 
@@ -11,27 +12,47 @@ type ConfigService struct {
 	mock.Mock
 }
 
-func (_m *ConfigService) IsSetupComplete() (bool, error) {
-	ret := _m.Called()
+func (_m *ConfigService) IsSetupComplete(ctx context.Context) (bool, error) {
+	ret := _m.Called(ctx)
 	return ret.Get(0).(bool), ret.Error(1)
 }
 
-func (_m *ConfigService) GetConfig() (*dto.AppConfig, error) { // <-- RETURN VALUE DIUBAH
-	ret := _m.Called()
+func (_m *ConfigService) GetConfig(ctx context.Context) (*dto.AppConfig, error) { // <-- RETURN VALUE DIUBAH
+	ret := _m.Called(ctx)
 	if ret.Get(0) == nil {
 		return nil, ret.Error(1)
 	}
 	return ret.Get(0).(*dto.AppConfig), ret.Error(1)
 }
 
-func (_m *ConfigService) SaveConfig(configData map[string]string) error {
-	return _m.Called(configData).Error(0)
+func (_m *ConfigService) GetSettings(ctx context.Context) (map[string]string, error) {
+	ret := _m.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(map[string]string), ret.Error(1)
 }
 
-func (_m *ConfigService) GetLocation() (*time.Location, error) {
-	ret := _m.Called()
+func (_m *ConfigService) SaveConfig(ctx context.Context, configData map[string]string) error {
+	return _m.Called(ctx, configData).Error(0)
+}
+
+func (_m *ConfigService) GetLocation(ctx context.Context) (*time.Location, error) {
+	ret := _m.Called(ctx)
 	if ret.Get(0) == nil {
 		return nil, ret.Error(1)
 	}
 	return ret.Get(0).(*time.Location), ret.Error(1)
-}
\ No newline at end of file
+}
+
+func (_m *ConfigService) Reload(ctx context.Context) error {
+	return _m.Called(ctx).Error(0)
+}
+
+func (_m *ConfigService) Subscribe(listener func(previous, current *dto.AppConfig)) func() {
+	ret := _m.Called(listener)
+	if rf, ok := ret.Get(0).(func()); ok {
+		return rf
+	}
+	return func() {}
+}
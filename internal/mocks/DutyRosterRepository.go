@@ -0,0 +1,52 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type DutyRosterRepository struct {
+	mock.Mock
+}
+
+func (_m *DutyRosterRepository) Create(ctx context.Context, roster *models.DutyRoster) error {
+	ret := _m.Called(ctx, roster)
+	return ret.Error(0)
+}
+
+func (_m *DutyRosterRepository) Update(ctx context.Context, roster *models.DutyRoster) error {
+	ret := _m.Called(ctx, roster)
+	return ret.Error(0)
+}
+
+func (_m *DutyRosterRepository) Delete(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
+	return ret.Error(0)
+}
+
+func (_m *DutyRosterRepository) FindByID(ctx context.Context, id uint) (*models.DutyRoster, error) {
+	ret := _m.Called(ctx, id)
+	var r0 *models.DutyRoster
+	if rf, ok := ret.Get(0).(*models.DutyRoster); ok {
+		r0 = rf
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *DutyRosterRepository) FindInRange(ctx context.Context, from string, to string, regu string) ([]models.DutyRoster, error) {
+	ret := _m.Called(ctx, from, to, regu)
+	return ret.Get(0).([]models.DutyRoster), ret.Error(1)
+}
+
+func (_m *DutyRosterRepository) FindActiveAt(ctx context.Context, t time.Time) ([]models.DutyRoster, error) {
+	ret := _m.Called(ctx, t)
+	return ret.Get(0).([]models.DutyRoster), ret.Error(1)
+}
+
+func (_m *DutyRosterRepository) CountOverlapping(ctx context.Context, regu string, start time.Time, end time.Time, excludeID uint) (int64, error) {
+	ret := _m.Called(ctx, regu, start, end, excludeID)
+	return ret.Get(0).(int64), ret.Error(1)
+}
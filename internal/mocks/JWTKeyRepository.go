@@ -0,0 +1,36 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type JWTKeyRepository struct {
+	mock.Mock
+}
+
+func (_m *JWTKeyRepository) FindAll(ctx context.Context) ([]models.JWTSigningKey, error) {
+	ret := _m.Called(ctx)
+	var r0 []models.JWTSigningKey
+	if rf, ok := ret.Get(0).([]models.JWTSigningKey); ok {
+		r0 = rf
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *JWTKeyRepository) Rotate(ctx context.Context, key *models.JWTSigningKey, retiredAt time.Time) error {
+	return _m.Called(ctx, key, retiredAt).Error(0)
+}
+
+func (_m *JWTKeyRepository) DeleteRetiredBefore(ctx context.Context, t time.Time) (int64, error) {
+	ret := _m.Called(ctx, t)
+	return ret.Get(0).(int64), ret.Error(1)
+}
+
+func (_m *JWTKeyRepository) DeleteRetired(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+	return ret.Get(0).(int64), ret.Error(1)
+}
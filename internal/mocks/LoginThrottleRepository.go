@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type LoginThrottleRepository struct {
+	mock.Mock
+}
+
+func (_m *LoginThrottleRepository) Find(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	ret := _m.Called(ctx, key)
+	var r0 *models.LoginThrottle
+	if rf, ok := ret.Get(0).(*models.LoginThrottle); ok {
+		r0 = rf
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *LoginThrottleRepository) Save(ctx context.Context, throttle *models.LoginThrottle) error {
+	ret := _m.Called(ctx, throttle)
+	return ret.Error(0)
+}
+
+func (_m *LoginThrottleRepository) Delete(ctx context.Context, key string) error {
+	ret := _m.Called(ctx, key)
+	return ret.Error(0)
+}
+
+func (_m *LoginThrottleRepository) FindLocked(ctx context.Context, t time.Time) ([]models.LoginThrottle, error) {
+	ret := _m.Called(ctx, t)
+	return ret.Get(0).([]models.LoginThrottle), ret.Error(1)
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
 	"time"
@@ -13,56 +14,66 @@ type LostDocumentRepository struct {
 	mock.Mock
 }
 
-func (_m *LostDocumentRepository) Create(tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error) {
-	ret := _m.Called(tx, doc)
+func (_m *LostDocumentRepository) Create(ctx context.Context, tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error) {
+	ret := _m.Called(ctx, tx, doc)
 	return ret.Get(0).(*models.LostDocument), ret.Error(1)
 }
 
-func (_m *LostDocumentRepository) FindByID(id uint) (*models.LostDocument, error) {
-	ret := _m.Called(id)
+func (_m *LostDocumentRepository) FindByID(ctx context.Context, id uint) (*models.LostDocument, error) {
+	ret := _m.Called(ctx, id)
 	return ret.Get(0).(*models.LostDocument), ret.Error(1)
 }
 
-func (_m *LostDocumentRepository) FindAll(query string, statusFilter string, archiveDurationDays int) ([]models.LostDocument, error) {
-	ret := _m.Called(query, statusFilter, archiveDurationDays)
+func (_m *LostDocumentRepository) FindAll(ctx context.Context, query string, statusFilter string, archiveDurationDays int, scope repositories.DocumentScope) ([]models.LostDocument, error) {
+	ret := _m.Called(ctx, query, statusFilter, archiveDurationDays, scope)
 	return ret.Get(0).([]models.LostDocument), ret.Error(1)
 }
 
-func (_m *LostDocumentRepository) SearchGlobal(query string) ([]models.LostDocument, error) {
-	ret := _m.Called(query)
+func (_m *LostDocumentRepository) SearchGlobal(ctx context.Context, query string, scope repositories.DocumentScope) ([]models.LostDocument, error) {
+	ret := _m.Called(ctx, query, scope)
 	return ret.Get(0).([]models.LostDocument), ret.Error(1)
 }
 
-func (_m *LostDocumentRepository) Update(tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error) {
-	ret := _m.Called(tx, doc)
+func (_m *LostDocumentRepository) Update(ctx context.Context, tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error) {
+	ret := _m.Called(ctx, tx, doc)
 	return ret.Get(0).(*models.LostDocument), ret.Error(1)
 }
 
-func (_m *LostDocumentRepository) Delete(tx *gorm.DB, id uint) error {
-	return _m.Called(tx, id).Error(0)
+func (_m *LostDocumentRepository) Delete(ctx context.Context, tx *gorm.DB, id uint) error {
+	return _m.Called(ctx, tx, id).Error(0)
 }
 
-func (_m *LostDocumentRepository) GetLastDocumentOfYear(year int) (*models.LostDocument, error) {
-	ret := _m.Called(year)
+func (_m *LostDocumentRepository) GetLastDocumentOfYear(ctx context.Context, year int) (*models.LostDocument, error) {
+	ret := _m.Called(ctx, year)
 	return ret.Get(0).(*models.LostDocument), ret.Error(1)
 }
 
-func (_m *LostDocumentRepository) CountByDateRange(start time.Time, end time.Time) (int64, error) {
-	ret := _m.Called(start, end)
+func (_m *LostDocumentRepository) CountByDateRange(ctx context.Context, start time.Time, end time.Time) (int64, error) {
+	ret := _m.Called(ctx, start, end)
 	return ret.Get(0).(int64), ret.Error(1)
 }
 
-func (_m *LostDocumentRepository) GetMonthlyIssuanceForYear(year int) ([]repositories.MonthlyCount, error) {
-	ret := _m.Called(year)
+func (_m *LostDocumentRepository) GetMonthlyIssuanceForYear(ctx context.Context, year int) ([]repositories.MonthlyCount, error) {
+	ret := _m.Called(ctx, year)
 	return ret.Get(0).([]repositories.MonthlyCount), ret.Error(1)
 }
 
-func (_m *LostDocumentRepository) GetItemCompositionStats() ([]repositories.ItemCompositionStat, error) {
-	ret := _m.Called()
+func (_m *LostDocumentRepository) GetItemCompositionStats(ctx context.Context) ([]repositories.ItemCompositionStat, error) {
+	ret := _m.Called(ctx)
 	return ret.Get(0).([]repositories.ItemCompositionStat), ret.Error(1)
 }
 
-func (_m *LostDocumentRepository) FindExpiringDocumentsForUser(userID uint, expiryDateStart time.Time, expiryDateEnd time.Time) ([]models.LostDocument, error) {
-	ret := _m.Called(userID, expiryDateStart, expiryDateEnd)
+func (_m *LostDocumentRepository) FindExpiringDocumentsForUser(ctx context.Context, userID uint, expiryDateStart time.Time, expiryDateEnd time.Time) ([]models.LostDocument, error) {
+	ret := _m.Called(ctx, userID, expiryDateStart, expiryDateEnd)
 	return ret.Get(0).([]models.LostDocument), ret.Error(1)
-}
\ No newline at end of file
+}
+
+func (_m *LostDocumentRepository) FindByReguInRange(ctx context.Context, regu string, start time.Time, end time.Time, scope repositories.DocumentScope) ([]models.LostDocument, error) {
+	ret := _m.Called(ctx, regu, start, end, scope)
+	return ret.Get(0).([]models.LostDocument), ret.Error(1)
+}
+
+func (_m *LostDocumentRepository) CountByReguInRange(ctx context.Context, start time.Time, end time.Time) ([]repositories.ReguCount, error) {
+	ret := _m.Called(ctx, start, end)
+	return ret.Get(0).([]repositories.ReguCount), ret.Error(1)
+}
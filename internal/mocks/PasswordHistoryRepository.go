@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type PasswordHistoryRepository struct {
+	mock.Mock
+}
+
+func (_m *PasswordHistoryRepository) Create(ctx context.Context, entry *models.PasswordHistory) error {
+	return _m.Called(ctx, entry).Error(0)
+}
+
+func (_m *PasswordHistoryRepository) FindRecent(ctx context.Context, userID uint, limit int) ([]models.PasswordHistory, error) {
+	ret := _m.Called(ctx, userID, limit)
+	var r0 []models.PasswordHistory
+	if rf, ok := ret.Get(0).([]models.PasswordHistory); ok {
+		r0 = rf
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *PasswordHistoryRepository) Prune(ctx context.Context, userID uint, keep int) error {
+	return _m.Called(ctx, userID, keep).Error(0)
+}
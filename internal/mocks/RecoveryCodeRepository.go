@@ -0,0 +1,35 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type RecoveryCodeRepository struct {
+	mock.Mock
+}
+
+func (_m *RecoveryCodeRepository) Replace(ctx context.Context, userID uint, codeHashes []string) error {
+	return _m.Called(ctx, userID, codeHashes).Error(0)
+}
+
+func (_m *RecoveryCodeRepository) FindUnusedByUser(ctx context.Context, userID uint) ([]models.RecoveryCode, error) {
+	ret := _m.Called(ctx, userID)
+	var r0 []models.RecoveryCode
+	if rf, ok := ret.Get(0).([]models.RecoveryCode); ok {
+		r0 = rf
+	}
+	return r0, ret.Error(1)
+}
+
+func (_m *RecoveryCodeRepository) MarkUsed(ctx context.Context, id uint, t time.Time) (bool, error) {
+	ret := _m.Called(ctx, id, t)
+	return ret.Bool(0), ret.Error(1)
+}
+
+func (_m *RecoveryCodeRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	return _m.Called(ctx, userID).Error(0)
+}
@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"simdokpol/internal/models"
 
 	"github.com/stretchr/testify/mock"
@@ -11,12 +12,12 @@ type ResidentRepository struct {
 	mock.Mock
 }
 
-func (_m *ResidentRepository) FindByNIK(tx *gorm.DB, nik string) (*models.Resident, error) {
-	ret := _m.Called(tx, nik)
+func (_m *ResidentRepository) FindByNIK(ctx context.Context, tx *gorm.DB, nik string) (*models.Resident, error) {
+	ret := _m.Called(ctx, tx, nik)
 	return ret.Get(0).(*models.Resident), ret.Error(1)
 }
 
-func (_m *ResidentRepository) Create(tx *gorm.DB, resident *models.Resident) (*models.Resident, error) {
-	ret := _m.Called(tx, resident)
+func (_m *ResidentRepository) Create(ctx context.Context, tx *gorm.DB, resident *models.Resident) (*models.Resident, error) {
+	ret := _m.Called(ctx, tx, resident)
 	return ret.Get(0).(*models.Resident), ret.Error(1)
 }
\ No newline at end of file
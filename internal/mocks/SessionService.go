@@ -0,0 +1,59 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type SessionService struct {
+	mock.Mock
+}
+
+func (_m *SessionService) Create(ctx context.Context, userID uint, clientIP string, userAgent string) (*models.Session, error) {
+	ret := _m.Called(ctx, userID, clientIP, userAgent)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*models.Session), ret.Error(1)
+}
+
+func (_m *SessionService) Validate(ctx context.Context, sessionID string, userID uint) (*models.Session, error) {
+	ret := _m.Called(ctx, sessionID, userID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*models.Session), ret.Error(1)
+}
+
+func (_m *SessionService) FindByUser(ctx context.Context, userID uint, currentID string) ([]models.Session, error) {
+	ret := _m.Called(ctx, userID, currentID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.Session), ret.Error(1)
+}
+
+func (_m *SessionService) Revoke(ctx context.Context, sessionID string, userID uint) error {
+	return _m.Called(ctx, sessionID, userID).Error(0)
+}
+
+func (_m *SessionService) End(ctx context.Context, sessionID string, userID uint) error {
+	return _m.Called(ctx, sessionID, userID).Error(0)
+}
+
+func (_m *SessionService) RevokeAllForUser(ctx context.Context, userID uint, exceptID string) (int64, error) {
+	ret := _m.Called(ctx, userID, exceptID)
+	return ret.Get(0).(int64), ret.Error(1)
+}
+
+func (_m *SessionService) ForceLogout(ctx context.Context, userID uint) (int64, error) {
+	ret := _m.Called(ctx, userID)
+	return ret.Get(0).(int64), ret.Error(1)
+}
+
+func (_m *SessionService) PurgeExpired(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+	return ret.Get(0).(int64), ret.Error(1)
+}
@@ -0,0 +1,61 @@
+package mocks
+
+import (
+	"context"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type TwoFactorService struct {
+	mock.Mock
+}
+
+func (_m *TwoFactorService) Status(ctx context.Context, userID uint) (*dto.TwoFactorStatus, error) {
+	ret := _m.Called(ctx, userID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*dto.TwoFactorStatus), ret.Error(1)
+}
+
+func (_m *TwoFactorService) IsRequired(ctx context.Context, user *models.User) bool {
+	return _m.Called(ctx, user).Bool(0)
+}
+
+func (_m *TwoFactorService) BeginEnrollment(ctx context.Context, userID uint) (*dto.TwoFactorSetup, error) {
+	ret := _m.Called(ctx, userID)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*dto.TwoFactorSetup), ret.Error(1)
+}
+
+func (_m *TwoFactorService) Enable(ctx context.Context, userID uint, code string) ([]string, error) {
+	ret := _m.Called(ctx, userID, code)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]string), ret.Error(1)
+}
+
+func (_m *TwoFactorService) Disable(ctx context.Context, userID uint, password string) error {
+	return _m.Called(ctx, userID, password).Error(0)
+}
+
+func (_m *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	ret := _m.Called(ctx, userID, code)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]string), ret.Error(1)
+}
+
+func (_m *TwoFactorService) Verify(ctx context.Context, user *models.User, code string) error {
+	return _m.Called(ctx, user, code).Error(0)
+}
+
+func (_m *TwoFactorService) Reset(ctx context.Context, userID uint) error {
+	return _m.Called(ctx, userID).Error(0)
+}
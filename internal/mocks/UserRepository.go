@@ -1,6 +1,7 @@
 package mocks
 
 import (
+	"context"
 	"simdokpol/internal/models"
 	"github.com/stretchr/testify/mock"
 )
@@ -10,13 +11,13 @@ type UserRepository struct {
 	mock.Mock
 }
 
-func (_m *UserRepository) Create(user *models.User) error {
-	ret := _m.Called(user)
+func (_m *UserRepository) Create(ctx context.Context, user *models.User) error {
+	ret := _m.Called(ctx, user)
 	return ret.Error(0)
 }
 
-func (_m *UserRepository) FindAll(statusFilter string) ([]models.User, error) {
-	ret := _m.Called(statusFilter)
+func (_m *UserRepository) FindAll(ctx context.Context, statusFilter string) ([]models.User, error) {
+	ret := _m.Called(ctx, statusFilter)
 	var r0 []models.User
 	if rf, ok := ret.Get(0).(func(string) []models.User); ok {
 		r0 = rf(statusFilter)
@@ -34,8 +35,8 @@ func (_m *UserRepository) FindAll(statusFilter string) ([]models.User, error) {
 	return r0, r1
 }
 
-func (_m *UserRepository) FindByID(id uint) (*models.User, error) {
-	ret := _m.Called(id)
+func (_m *UserRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	ret := _m.Called(ctx, id)
 	var r0 *models.User
 	if rf, ok := ret.Get(0).(func(uint) *models.User); ok {
 		r0 = rf(id)
@@ -53,8 +54,8 @@ func (_m *UserRepository) FindByID(id uint) (*models.User, error) {
 	return r0, r1
 }
 
-func (_m *UserRepository) FindByNRP(nrp string) (*models.User, error) {
-	ret := _m.Called(nrp)
+func (_m *UserRepository) FindByNRP(ctx context.Context, nrp string) (*models.User, error) {
+	ret := _m.Called(ctx, nrp)
 	var r0 *models.User
 	if rf, ok := ret.Get(0).(func(string) *models.User); ok {
 		r0 = rf(nrp)
@@ -72,8 +73,8 @@ func (_m *UserRepository) FindByNRP(nrp string) (*models.User, error) {
 	return r0, r1
 }
 
-func (_m *UserRepository) FindOperators() ([]models.User, error) {
-	ret := _m.Called()
+func (_m *UserRepository) FindOperators(ctx context.Context) ([]models.User, error) {
+	ret := _m.Called(ctx)
 	var r0 []models.User
 	if rf, ok := ret.Get(0).(func() []models.User); ok {
 		r0 = rf()
@@ -91,23 +92,33 @@ func (_m *UserRepository) FindOperators() ([]models.User, error) {
 	return r0, r1
 }
 
-func (_m *UserRepository) Update(user *models.User) error {
-	ret := _m.Called(user)
+func (_m *UserRepository) Update(ctx context.Context, user *models.User) error {
+	ret := _m.Called(ctx, user)
 	return ret.Error(0)
 }
 
-func (_m *UserRepository) Delete(id uint) error {
-	ret := _m.Called(id)
+func (_m *UserRepository) Delete(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
 	return ret.Error(0)
 }
 
-func (_m *UserRepository) Restore(id uint) error {
-	ret := _m.Called(id)
+func (_m *UserRepository) Restore(ctx context.Context, id uint) error {
+	ret := _m.Called(ctx, id)
 	return ret.Error(0)
 }
 
-func (_m *UserRepository) CountAll() (int64, error) {
-	ret := _m.Called()
+func (_m *UserRepository) UpdateTOTPLastStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	ret := _m.Called(ctx, userID, step)
+	return ret.Bool(0), ret.Error(1)
+}
+
+func (_m *UserRepository) CreateInitialSuperAdmin(ctx context.Context, user *models.User, configs map[string]string) (bool, error) {
+	ret := _m.Called(ctx, user, configs)
+	return ret.Bool(0), ret.Error(1)
+}
+
+func (_m *UserRepository) CountAll(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
 	var r0 int64
 	if rf, ok := ret.Get(0).(func() int64); ok {
 		r0 = rf()
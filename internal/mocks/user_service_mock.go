@@ -2,33 +2,82 @@
 package mocks
 
 import (
+	"context"
 	"simdokpol/internal/models"
 
 	"github.com/stretchr/testify/mock"
 )
 
-// UserService adalah mock sederhana untuk interface service pengguna.
-// Nama harus persis "UserService" supaya test yang sekarang dapat compile.
+// UserService adalah mock untuk interface services.UserService.
 type UserService struct {
 	mock.Mock
 }
 
-// Create mock
-func (m *UserService) Create(user *models.User, createdBy int) error {
-	args := m.Called(user, createdBy)
-	if args.Get(0) == nil {
-		return nil
+func (_m *UserService) Create(ctx context.Context, user *models.User) error {
+	return _m.Called(ctx, user).Error(0)
+}
+
+func (_m *UserService) CreateInitialSuperAdmin(ctx context.Context, user *models.User, settings map[string]string) error {
+	return _m.Called(ctx, user, settings).Error(0)
+}
+
+func (_m *UserService) FindAll(ctx context.Context, statusFilter string) ([]models.User, error) {
+	ret := _m.Called(ctx, statusFilter)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).([]models.User), ret.Error(1)
+}
+
+func (_m *UserService) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	ret := _m.Called(ctx, id)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
 	}
-	return args.Error(0)
+	return ret.Get(0).(*models.User), ret.Error(1)
 }
 
-// UpdateProfile mock
-func (m *UserService) UpdateProfile(userID int, user *models.User) (*models.User, error) {
-	args := m.Called(userID, user)
-	if u := args.Get(0); u != nil {
-		if usr, ok := u.(*models.User); ok {
-			return usr, args.Error(1)
-		}
+func (_m *UserService) FindOperators(ctx context.Context) ([]models.User, error) {
+	ret := _m.Called(ctx)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
 	}
-	return nil, args.Error(1)
+	return ret.Get(0).([]models.User), ret.Error(1)
+}
+
+func (_m *UserService) Update(ctx context.Context, user *models.User, newPassword string) error {
+	return _m.Called(ctx, user, newPassword).Error(0)
+}
+
+func (_m *UserService) Deactivate(ctx context.Context, id uint) error {
+	return _m.Called(ctx, id).Error(0)
+}
+
+func (_m *UserService) Activate(ctx context.Context, id uint) error {
+	return _m.Called(ctx, id).Error(0)
+}
+
+func (_m *UserService) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string, currentSessionID string) error {
+	return _m.Called(ctx, userID, oldPassword, newPassword, currentSessionID).Error(0)
+}
+
+func (_m *UserService) UpdateProfile(ctx context.Context, userID uint, dataToUpdate *models.User) (*models.User, error) {
+	ret := _m.Called(ctx, userID, dataToUpdate)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*models.User), ret.Error(1)
+}
+
+func (_m *UserService) AssignRole(ctx context.Context, userID uint, role string) (*models.User, error) {
+	ret := _m.Called(ctx, userID, role)
+	if ret.Get(0) == nil {
+		return nil, ret.Error(1)
+	}
+	return ret.Get(0).(*models.User), ret.Error(1)
+}
+
+func (_m *UserService) IssueTemporaryPassword(ctx context.Context, userID uint) (string, error) {
+	ret := _m.Called(ctx, userID)
+	return ret.String(0), ret.Error(1)
 }
@@ -3,7 +3,23 @@ package models
 // Konstanta untuk Peran Pengguna
 const (
 	RoleSuperAdmin = "SUPER_ADMIN"
+	RoleKanit      = "KANIT"
 	RoleOperator   = "OPERATOR"
+	RoleAuditor    = "AUDITOR"
+	RoleViewer     = "VIEWER"
+)
+
+// Konstanta untuk Sumber Autentikasi Pengguna
+const (
+	AuthSourceLocal = "local" // kata sandi bcrypt di database
+	AuthSourceLDAP  = "ldap"  // kata sandi dan data diri dikelola direktori LDAP
+)
+
+// Konstanta untuk Cakupan Visibilitas Dokumen bagi pengguna tanpa hak akses document.read_all
+const (
+	VisibilityOwn  = "own"  // hanya dokumen yang dibuat sendiri
+	VisibilityRegu = "regu" // dokumen milik regu yang sama
+	VisibilityAll  = "all"  // semua dokumen
 )
 
 // Konstanta untuk Status Dokumen
@@ -14,15 +30,36 @@ const (
 
 // Konstanta untuk Aksi Audit Log
 const (
-	AuditCreateUser      = "BUAT PENGGUNA"
-	AuditUpdateUser      = "UPDATE PENGGUNA"
-	AuditDeactivateUser  = "NONAKTIFKAN PENGGUNA"
-	AuditActivateUser    = "AKTIFKAN PENGGUNA"
-	AuditCreateDocument  = "BUAT DOKUMEN"
-	AuditUpdateDocument  = "UPDATE DOKUMEN"
-	AuditDeleteDocument  = "HAPUS DOKUMEN"
-	AuditSystemSetup     = "SETUP SISTEM"
-	AuditBackupCreated   = "BUAT BACKUP"
-	AuditRestoreFromFile = "PULIHKAN DARI FILE"
-	AuditSettingsUpdated = "PERBARUI PENGATURAN"
-)
\ No newline at end of file
+	AuditCreateUser        = "BUAT PENGGUNA"
+	AuditUpdateUser        = "UPDATE PENGGUNA"
+	AuditDeactivateUser    = "NONAKTIFKAN PENGGUNA"
+	AuditActivateUser      = "AKTIFKAN PENGGUNA"
+	AuditCreateDocument    = "BUAT DOKUMEN"
+	AuditUpdateDocument    = "UPDATE DOKUMEN"
+	AuditDeleteDocument    = "HAPUS DOKUMEN"
+	AuditSystemSetup       = "SETUP SISTEM"
+	AuditBackupCreated     = "BUAT BACKUP"
+	AuditRestoreFromFile   = "PULIHKAN DARI FILE"
+	AuditSettingsUpdated   = "PERBARUI PENGATURAN"
+	AuditExportAuditLog    = "EKSPOR LOG AUDIT"
+	AuditAssignRole        = "UBAH PERAN PENGGUNA"
+	AuditCreateRoster      = "BUAT JADWAL JAGA"
+	AuditUpdateRoster      = "UPDATE JADWAL JAGA"
+	AuditDeleteRoster      = "HAPUS JADWAL JAGA"
+	AuditLoginSuccess      = "LOGIN BERHASIL"
+	AuditLoginFailed       = "LOGIN GAGAL"
+	AuditUnlockAccount     = "BUKA KUNCI LOGIN"
+	AuditRevokeSession     = "CABUT SESI"
+	AuditLogout            = "LOGOUT"
+	AuditForceLogout       = "PAKSA LOGOUT"
+	AuditEnableTwoFactor   = "AKTIFKAN 2FA"
+	AuditDisableTwoFactor  = "NONAKTIFKAN 2FA"
+	AuditResetTwoFactor    = "RESET 2FA"
+	AuditRecoveryCodes     = "BUAT KODE PEMULIHAN"
+	AuditRecoveryCodeUsed  = "PAKAI KODE PEMULIHAN"
+	AuditTemporaryPassword = "KATA SANDI SEMENTARA"
+	AuditRotateJWTKey      = "ROTASI KUNCI JWT"
+	AuditCreateAPIToken    = "BUAT API TOKEN"
+	AuditRevokeAPIToken    = "CABUT API TOKEN"
+	AuditChangeLogLevel    = "UBAH LEVEL LOG"
+)
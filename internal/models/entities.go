@@ -1,7 +1,11 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
 	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -12,12 +16,28 @@ type User struct {
 	NRP         string         `gorm:"size:20;not null;unique" json:"nrp"`
 	KataSandi   string         `gorm:"size:255;not null" json:"-"` // Kata sandi tidak diekspos di JSON
 	Pangkat     string         `gorm:"size:100" json:"pangkat"`
-	Peran       string         `gorm:"size:50;not null;default:'OPERATOR'" json:"peran"` // SUPER_ADMIN, OPERATOR
+	Peran       string         `gorm:"size:50;not null;default:'OPERATOR'" json:"peran"` // SUPER_ADMIN, KANIT, OPERATOR, AUDITOR, VIEWER
 	Jabatan     string         `gorm:"size:100" json:"jabatan"` // KANIT SPKT, ANGGOTA JAGA REGU
 	Regu        string         `gorm:"size:10" json:"regu"` // I, II, III
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// TOTPSecret terisi sejak pendaftaran 2FA dimulai; TOTPEnabled baru true setelah kode pertama dikonfirmasi
+	TOTPSecret   string `gorm:"column:totp_secret;not null;default:''" json:"-"`
+	TOTPEnabled  bool   `gorm:"column:totp_enabled;not null;default:false" json:"totp_enabled"`
+	TOTPLastStep int64  `gorm:"column:totp_last_step;not null;default:0" json:"-"`
+
+	// PasswordChangedAt kosong berarti kata sandi belum pernah diganti sejak akun dibuat
+	PasswordChangedAt *time.Time `json:"password_changed_at"`
+	// MustChangePassword diset saat admin memberikan kata sandi sementara
+	MustChangePassword bool `gorm:"not null;default:false" json:"must_change_password"`
+	// AuthSource bernilai "ldap" untuk pengguna yang dibuat atau diperbarui dari direktori
+	AuthSource string `gorm:"not null;default:'local'" json:"auth_source"`
+
+	// Scopes diisi saat request diautentikasi dengan API token dan membatasi hak akses peran
+	// ke daftar ini. Nil berarti hak akses hanya ditentukan oleh peran.
+	Scopes []string `gorm:"-" json:"-"`
 }
 
 // Resident merepresentasikan model penduduk/pemohon.
@@ -60,6 +80,8 @@ type LostDocument struct {
 	// Operator adalah pengguna yang login dan melakukan aksi Create
 	OperatorID         uint           `gorm:"not null" json:"operator_id"`
 	Operator           User           `gorm:"foreignKey:OperatorID" json:"operator"`
+	// Regu adalah regu operator saat dokumen dibuat, dipakai untuk visibilitas dan serah terima
+	Regu               string         `gorm:"size:10;index" json:"regu"`
 	
 	// LastUpdatedByID adalah pengguna yang login dan melakukan aksi Update terakhir
 	LastUpdatedByID    *uint          `json:"last_updated_by_id"`
@@ -80,6 +102,8 @@ type LostItem struct {
 }
 
 // AuditLog untuk mencatat aktivitas penting.
+// Setiap entri menyimpan hash isinya yang dirantai ke hash entri sebelumnya,
+// sehingga perubahan atau penghapusan entri di tengah rantai dapat dideteksi.
 type AuditLog struct {
 	ID        uint      `gorm:"primarykey"`
 	UserID    uint      `gorm:"not null"`
@@ -87,4 +111,132 @@ type AuditLog struct {
 	Aksi      string    `gorm:"size:255;not null"`
 	Detail    string    `gorm:"type:text"`
 	Timestamp time.Time `gorm:"not null"`
-}
\ No newline at end of file
+	PrevHash  string    `gorm:"size:64;not null;default:''"`
+	Hash      string    `gorm:"size:64;not null;default:'';index"`
+	// RequestID menghubungkan entri dengan log aplikasi dan pesan error yang dilihat pengguna
+	RequestID string `gorm:"size:64;not null;default:''"`
+}
+
+// ComputeHash menghitung hash SHA-256 dari isi entri beserta PrevHash-nya.
+// Setiap field di-quote agar pemisah di dalam Detail tidak menimbulkan ambiguitas.
+func (a *AuditLog) ComputeHash() string {
+	payload := fmt.Sprintf("%q|%d|%q|%q|%q",
+		a.PrevHash,
+		a.UserID,
+		a.Aksi,
+		a.Detail,
+		a.Timestamp.UTC().Format(time.RFC3339Nano),
+	)
+	// RequestID hanya ikut di-hash jika diisi agar hash entri lama tetap sama
+	if a.RequestID != "" {
+		payload += fmt.Sprintf("|%q", a.RequestID)
+	}
+	sum := sha256.Sum256([]byte(payload))
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditArchive mencatat berkas arsip log audit yang telah dipindahkan dari database
+// oleh kebijakan retensi. LastHash menjadi titik awal rantai bagi entri yang tersisa.
+type AuditArchive struct {
+	ID         uint   `gorm:"primarykey" json:"id"`
+	FileName   string `gorm:"size:255;not null" json:"file_name"`
+	FileSHA256 string `gorm:"size:64;not null" json:"file_sha256"`
+	FirstID    uint   `gorm:"not null" json:"first_id"`
+	LastID     uint   `gorm:"not null;index" json:"last_id"`
+	LastHash   string `gorm:"size:64;not null" json:"last_hash"`
+	EntryCount int    `gorm:"not null" json:"entry_count"`
+	// FirstTimestamp dan LastTimestamp adalah rentang waktu entri di dalam berkas, sehingga ekspor
+	// hanya membuka arsip yang beririsan. Kosong untuk arsip yang dibuat sebelum kolom ini ada.
+	FirstTimestamp *time.Time `json:"first_timestamp,omitempty"`
+	LastTimestamp  *time.Time `json:"last_timestamp,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// DutyRoster adalah jadwal jaga satu regu pada satu shift. Petugas pelapor dan pejabat
+// persetuju yang bertugas menjadi nilai bawaan saat membuat surat baru.
+type DutyRoster struct {
+	ID                 uint      `gorm:"primarykey" json:"id"`
+	Tanggal            string    `gorm:"size:10;not null;index" json:"tanggal"` // YYYY-MM-DD, tanggal mulai jaga
+	Shift              string    `gorm:"size:50;not null" json:"shift"`         // PAGI, MALAM, 24 JAM, dll.
+	Regu               string    `gorm:"size:10;not null;index" json:"regu"`
+	MulaiPada          time.Time `gorm:"not null;index" json:"mulai_pada"`
+	SelesaiPada        time.Time `gorm:"not null;index" json:"selesai_pada"`
+	PetugasPelaporID   uint      `gorm:"not null" json:"petugas_pelapor_id"`
+	PetugasPelapor     User      `gorm:"foreignKey:PetugasPelaporID" json:"petugas_pelapor"`
+	PejabatPersetujuID uint      `gorm:"not null" json:"pejabat_persetuju_id"`
+	PejabatPersetuju   User      `gorm:"foreignKey:PejabatPersetujuID" json:"pejabat_persetuju"`
+	Keterangan         string    `gorm:"type:text" json:"keterangan"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// LoginThrottle mencatat percobaan login gagal per NRP atau per alamat IP.
+// Key berbentuk "nrp:<NRP>" atau "ip:<alamat>".
+type LoginThrottle struct {
+	Key           string     `gorm:"primaryKey;size:100" json:"key"`
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null" json:"last_failure_at"`
+	LockedUntil   *time.Time `gorm:"index" json:"locked_until"`
+}
+
+// RecoveryCode adalah kode cadangan sekali pakai untuk login saat perangkat autentikator hilang.
+// Hanya hash SHA-256 yang disimpan; kode acak cukup panjang sehingga tidak perlu bcrypt.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"not null" json:"-"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// PasswordHistory menyimpan hash kata sandi lama agar tidak dipakai ulang.
+type PasswordHistory struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	UserID       uint      `gorm:"not null;index" json:"user_id"`
+	PasswordHash string    `gorm:"not null" json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// APIToken adalah token berumur panjang untuk integrasi tanpa browser, dikirim melalui header
+// Authorization: Bearer. Hanya hash SHA-256 token yang disimpan.
+type APIToken struct {
+	ID          uint       `gorm:"primarykey" json:"id"`
+	UserID      uint       `gorm:"not null;index" json:"user_id"`
+	User        User       `gorm:"foreignKey:UserID" json:"user"`
+	Name        string     `gorm:"not null" json:"name"`
+	Prefix      string     `gorm:"not null" json:"prefix"`
+	TokenHash   string     `gorm:"uniqueIndex;not null" json:"-"`
+	Scopes      string     `gorm:"not null;default:''" json:"scopes"` // hak akses dipisah koma
+	ExpiresAt   time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	LastUsedIP  string     `gorm:"column:last_used_ip;not null;default:''" json:"last_used_ip"`
+	RevokedAt   *time.Time `json:"revoked_at"`
+	CreatedByID uint       `gorm:"not null" json:"created_by_id"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// JWTSigningKey adalah satu kunci dalam key ring penandatanganan JWT. Kunci aktif adalah
+// kunci terbaru yang RetiredAt-nya kosong.
+type JWTSigningKey struct {
+	ID        uint       `gorm:"primarykey" json:"id"`
+	KID       string     `gorm:"column:kid;uniqueIndex;not null" json:"kid"`
+	Secret    string     `gorm:"not null" json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at"`
+}
+
+// Session adalah sesi login di sisi server. ID dipakai sebagai klaim jti pada JWT,
+// sehingga token dapat dicabut sebelum masa berlakunya habis.
+type Session struct {
+	ID         string     `gorm:"primaryKey;size:64" json:"id"`
+	UserID     uint       `gorm:"not null;index" json:"user_id"`
+	User       User       `gorm:"foreignKey:UserID" json:"-"`
+	IPAddress  string     `gorm:"size:64" json:"ip_address"`
+	UserAgent  string     `gorm:"type:text" json:"user_agent"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `gorm:"not null" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	// Current menandai sesi yang sedang dipakai oleh request, tidak disimpan di database
+	Current bool `gorm:"-" json:"current"`
+}
@@ -0,0 +1,107 @@
+package models
+
+import (
+	"slices"
+	"sort"
+)
+
+// Konstanta untuk Hak Akses (Permission). Rute dan service memeriksa hak akses,
+// bukan nama peran, sehingga peran baru cukup didaftarkan di rolePermissions.
+const (
+	PermDashboardRead     = "dashboard.read"
+	PermDocumentRead      = "document.read"
+	PermDocumentReadAll   = "document.read_all"
+	PermDocumentCreate    = "document.create"
+	PermDocumentUpdate    = "document.update"
+	PermDocumentDelete    = "document.delete"
+	PermDocumentManageAll = "document.manage_all"
+	PermDocumentApprove   = "document.approve"
+	PermUserRead          = "user.read"
+	PermUserManage        = "user.manage"
+	PermAuditRead         = "audit.read"
+	PermAuditManage       = "audit.manage"
+	PermBackupCreate      = "backup.create"
+	PermBackupRestore     = "backup.restore"
+	PermSettingsManage    = "settings.manage"
+	PermRosterManage      = "roster.manage"
+)
+
+// rolePermissions memetakan setiap peran ke hak akses yang dimilikinya.
+// SUPER_ADMIN tidak dicantumkan karena selalu memiliki semua hak akses.
+var rolePermissions = map[string][]string{
+	RoleKanit: {
+		PermDashboardRead, PermDocumentRead, PermDocumentReadAll, PermDocumentCreate,
+		PermDocumentUpdate, PermDocumentDelete, PermDocumentManageAll, PermDocumentApprove,
+		PermUserRead, PermAuditRead, PermRosterManage,
+	},
+	RoleOperator: {
+		PermDashboardRead, PermDocumentRead, PermDocumentCreate, PermDocumentUpdate, PermDocumentDelete,
+	},
+	RoleAuditor: {
+		PermDashboardRead, PermDocumentRead, PermDocumentReadAll, PermUserRead, PermAuditRead,
+	},
+	RoleViewer: {
+		PermDashboardRead, PermDocumentRead, PermDocumentReadAll,
+	},
+}
+
+// AllPermissions adalah daftar seluruh hak akses yang dikenal sistem.
+var AllPermissions = []string{
+	PermDashboardRead, PermDocumentRead, PermDocumentReadAll, PermDocumentCreate,
+	PermDocumentUpdate, PermDocumentDelete, PermDocumentManageAll, PermDocumentApprove,
+	PermUserRead, PermUserManage, PermAuditRead, PermAuditManage,
+	PermBackupCreate, PermBackupRestore, PermSettingsManage, PermRosterManage,
+}
+
+// Roles mengembalikan daftar peran yang valid, diurutkan berdasarkan nama.
+func Roles() []string {
+	roles := []string{RoleSuperAdmin}
+	for role := range rolePermissions {
+		roles = append(roles, role)
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// IsValidRole memeriksa apakah peran dikenal oleh sistem.
+func IsValidRole(role string) bool {
+	if role == RoleSuperAdmin {
+		return true
+	}
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+// PermissionsForRole mengembalikan hak akses milik sebuah peran.
+func PermissionsForRole(role string) []string {
+	if role == RoleSuperAdmin {
+		return AllPermissions
+	}
+	return rolePermissions[role]
+}
+
+// RoleHasPermission memeriksa apakah peran memiliki hak akses tertentu.
+func RoleHasPermission(role string, permission string) bool {
+	if role == RoleSuperAdmin {
+		return true
+	}
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// HasPermission memeriksa apakah pengguna memiliki hak akses tertentu berdasarkan perannya,
+// dibatasi oleh Scopes jika request memakai API token.
+// Dapat dipanggil langsung dari template, misalnya {{if .CurrentUser.HasPermission "user.manage"}}.
+func (u *User) HasPermission(permission string) bool {
+	if u == nil {
+		return false
+	}
+	if u.Scopes != nil && !slices.Contains(u.Scopes, permission) {
+		return false
+	}
+	return RoleHasPermission(u.Peran, permission)
+}
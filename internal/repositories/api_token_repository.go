@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APITokenRepository mendefinisikan kontrak untuk API token.
+type APITokenRepository interface {
+	Create(ctx context.Context, token *models.APIToken) error
+	FindByID(ctx context.Context, id uint) (*models.APIToken, error)
+	FindByHash(ctx context.Context, tokenHash string) (*models.APIToken, error)
+	// FindByUser mengambil token milik seorang pengguna; userID 0 mengambil token semua pengguna.
+	FindByUser(ctx context.Context, userID uint) ([]models.APIToken, error)
+	// Revoke menandai token dicabut. Nilai false berarti token sudah dicabut sebelumnya.
+	Revoke(ctx context.Context, id uint, t time.Time) (bool, error)
+	// TouchLastUsed mencatat waktu dan alamat IP pemakaian terakhir.
+	TouchLastUsed(ctx context.Context, id uint, t time.Time, clientIP string) error
+}
+
+type apiTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewAPITokenRepository adalah factory untuk APITokenRepository.
+func NewAPITokenRepository(db *gorm.DB) APITokenRepository {
+	return &apiTokenRepository{db: db}
+}
+
+func (r *apiTokenRepository) Create(ctx context.Context, token *models.APIToken) error {
+	token.ExpiresAt = token.ExpiresAt.UTC()
+	return r.db.WithContext(ctx).Omit("User").Create(token).Error
+}
+
+func (r *apiTokenRepository) FindByID(ctx context.Context, id uint) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.WithContext(ctx).First(&token, id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *apiTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*models.APIToken, error) {
+	var token models.APIToken
+	if err := r.db.WithContext(ctx).Where("token_hash = ?", tokenHash).First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *apiTokenRepository) FindByUser(ctx context.Context, userID uint) ([]models.APIToken, error) {
+	var tokens []models.APIToken
+	db := r.db.WithContext(ctx).Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Order("created_at DESC")
+	if userID != 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	err := db.Find(&tokens).Error
+	return tokens, err
+}
+
+func (r *apiTokenRepository) Revoke(ctx context.Context, id uint, t time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.APIToken{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", t.UTC())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *apiTokenRepository) TouchLastUsed(ctx context.Context, id uint, t time.Time, clientIP string) error {
+	return r.db.WithContext(ctx).Model(&models.APIToken{}).Where("id = ?", id).
+		Updates(map[string]interface{}{"last_used_at": t.UTC(), "last_used_ip": clientIP}).Error
+}
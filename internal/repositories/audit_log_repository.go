@@ -1,31 +1,151 @@
 package repositories
 
 import (
+	"context"
+	"errors"
 	"simdokpol/internal/models"
+	"sync"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type AuditLogRepository interface {
-	Create(log *models.AuditLog) error
-	FindAll() ([]models.AuditLog, error)
+	Create(ctx context.Context, tx *gorm.DB, log *models.AuditLog) error
+	FindAll(ctx context.Context) ([]models.AuditLog, error)
+	FindLast(ctx context.Context) (*models.AuditLog, error)
+	FindBatchAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditLog, error)
+	FindInRange(ctx context.Context, start time.Time, end time.Time, afterID uint, limit int) ([]models.AuditLog, error)
+	FindLastIDBefore(ctx context.Context, cutoff time.Time) (uint, error)
+	UpdateHash(ctx context.Context, id uint, prevHash string, hash string) error
+	Count(ctx context.Context) (int64, error)
+	FindArchives(ctx context.Context) ([]models.AuditArchive, error)
+	// ArchiveUpTo mencatat berkas arsip dan menghapus entri hingga lastID dalam satu transaksi.
+	ArchiveUpTo(ctx context.Context, lastID uint, archive *models.AuditArchive) error
 }
 
 type auditLogRepository struct {
 	db *gorm.DB
+	// mu menyerialkan penulisan agar setiap entri baru selalu dirantai ke entri terakhir.
+	mu sync.Mutex
 }
 
 func NewAuditLogRepository(db *gorm.DB) AuditLogRepository {
 	return &auditLogRepository{db: db}
 }
 
-func (r *auditLogRepository) Create(log *models.AuditLog) error {
-	return r.db.Create(log).Error
+// Create menyimpan entri baru dan merantainya ke hash entri terakhir.
+// Jika tx diberikan, entri ikut di-commit atau di-rollback bersama transaksi pemanggil.
+// SQLite hanya mengizinkan satu transaksi tulis pada satu waktu, sehingga pembacaan hash
+// terakhir di dalam transaksi yang sudah menulis selalu konsisten dengan urutan commit.
+func (r *auditLogRepository) Create(ctx context.Context, tx *gorm.DB, log *models.AuditLog) error {
+	if tx != nil {
+		return r.createChained(tx.WithContext(ctx), log)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return r.createChained(tx, log)
+	})
+}
+
+func (r *auditLogRepository) createChained(tx *gorm.DB, log *models.AuditLog) error {
+	var last models.AuditLog
+	err := tx.Select("id", "hash").Order("id desc").First(&last).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	prevHash := last.Hash
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Semua entri sudah diarsipkan: lanjutkan rantai dari hash arsip terakhir
+		var archive models.AuditArchive
+		err := tx.Select("last_hash").Order("last_id desc").First(&archive).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		prevHash = archive.LastHash
+	}
+	log.PrevHash = prevHash
+	log.Hash = log.ComputeHash()
+	return tx.Create(log).Error
 }
 
-func (r *auditLogRepository) FindAll() ([]models.AuditLog, error) {
+func (r *auditLogRepository) FindAll(ctx context.Context) ([]models.AuditLog, error) {
 	var logs []models.AuditLog
 	// Preload User untuk mendapatkan data pengguna yang melakukan aksi
-	err := r.db.Preload("User").Order("timestamp desc").Find(&logs).Error
+	err := r.db.WithContext(ctx).Preload("User").Order("timestamp desc").Find(&logs).Error
+	return logs, err
+}
+
+func (r *auditLogRepository) FindLast(ctx context.Context) (*models.AuditLog, error) {
+	var log models.AuditLog
+	if err := r.db.WithContext(ctx).Order("id desc").First(&log).Error; err != nil {
+		return nil, err
+	}
+	return &log, nil
+}
+
+// FindBatchAfter mengambil entri berurutan berdasarkan ID untuk menelusuri rantai secara bertahap.
+func (r *auditLogRepository) FindBatchAfter(ctx context.Context, afterID uint, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	err := r.db.WithContext(ctx).Where("id > ?", afterID).Order("id asc").Limit(limit).Find(&logs).Error
+	return logs, err
+}
+
+// FindInRange mengambil entri dalam rentang waktu secara bertahap, lengkap dengan data pengguna
+// (termasuk yang sudah dinonaktifkan) untuk keperluan ekspor.
+func (r *auditLogRepository) FindInRange(ctx context.Context, start time.Time, end time.Time, afterID uint, limit int) ([]models.AuditLog, error) {
+	var logs []models.AuditLog
+	err := r.db.WithContext(ctx).
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("id > ?", afterID).
+		Where("timestamp BETWEEN ? AND ?", start, end).
+		Order("id asc").
+		Limit(limit).
+		Find(&logs).Error
 	return logs, err
-}
\ No newline at end of file
+}
+
+func (r *auditLogRepository) FindLastIDBefore(ctx context.Context, cutoff time.Time) (uint, error) {
+	var lastID *uint
+	err := r.db.WithContext(ctx).Model(&models.AuditLog{}).Select("MAX(id)").Where("timestamp < ?", cutoff).Scan(&lastID).Error
+	if err != nil || lastID == nil {
+		return 0, err
+	}
+	return *lastID, nil
+}
+
+func (r *auditLogRepository) UpdateHash(ctx context.Context, id uint, prevHash string, hash string) error {
+	return r.db.WithContext(ctx).Model(&models.AuditLog{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"prev_hash": prevHash,
+		"hash":      hash,
+	}).Error
+}
+
+func (r *auditLogRepository) Count(ctx context.Context) (int64, error) {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&models.AuditLog{}).Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *auditLogRepository) FindArchives(ctx context.Context) ([]models.AuditArchive, error) {
+	var archives []models.AuditArchive
+	err := r.db.WithContext(ctx).Order("last_id asc").Find(&archives).Error
+	return archives, err
+}
+
+func (r *auditLogRepository) ArchiveUpTo(ctx context.Context, lastID uint, archive *models.AuditArchive) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(archive).Error; err != nil {
+			return err
+		}
+		return tx.Where("id <= ?", lastID).Delete(&models.AuditLog{}).Error
+	})
+}
@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DutyRosterRepository mendefinisikan kontrak untuk operasi data jadwal jaga.
+type DutyRosterRepository interface {
+	Create(ctx context.Context, roster *models.DutyRoster) error
+	Update(ctx context.Context, roster *models.DutyRoster) error
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.DutyRoster, error)
+	// FindInRange mengambil jadwal dengan tanggal di antara from dan to (YYYY-MM-DD, inklusif).
+	// Regu kosong berarti semua regu.
+	FindInRange(ctx context.Context, from string, to string, regu string) ([]models.DutyRoster, error)
+	// FindActiveAt mengambil jadwal yang sedang berlangsung pada waktu t.
+	FindActiveAt(ctx context.Context, t time.Time) ([]models.DutyRoster, error)
+	// CountOverlapping menghitung jadwal regu yang beririsan dengan rentang waktu, kecuali excludeID.
+	CountOverlapping(ctx context.Context, regu string, start time.Time, end time.Time, excludeID uint) (int64, error)
+}
+
+// Waktu mulai dan selesai disimpan dalam UTC agar perbandingan teks di SQLite konsisten.
+type dutyRosterRepository struct {
+	db *gorm.DB
+}
+
+// NewDutyRosterRepository adalah factory untuk DutyRosterRepository.
+func NewDutyRosterRepository(db *gorm.DB) DutyRosterRepository {
+	return &dutyRosterRepository{db: db}
+}
+
+func (r *dutyRosterRepository) Create(ctx context.Context, roster *models.DutyRoster) error {
+	roster.MulaiPada, roster.SelesaiPada = roster.MulaiPada.UTC(), roster.SelesaiPada.UTC()
+	return r.db.WithContext(ctx).Omit("PetugasPelapor", "PejabatPersetuju").Create(roster).Error
+}
+
+func (r *dutyRosterRepository) Update(ctx context.Context, roster *models.DutyRoster) error {
+	roster.MulaiPada, roster.SelesaiPada = roster.MulaiPada.UTC(), roster.SelesaiPada.UTC()
+	return r.db.WithContext(ctx).Omit("PetugasPelapor", "PejabatPersetuju").Save(roster).Error
+}
+
+func (r *dutyRosterRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.DutyRoster{}, id).Error
+}
+
+func (r *dutyRosterRepository) FindByID(ctx context.Context, id uint) (*models.DutyRoster, error) {
+	var roster models.DutyRoster
+	if err := r.withOfficers(ctx).First(&roster, id).Error; err != nil {
+		return nil, err
+	}
+	return &roster, nil
+}
+
+func (r *dutyRosterRepository) FindInRange(ctx context.Context, from string, to string, regu string) ([]models.DutyRoster, error) {
+	var rosters []models.DutyRoster
+	db := r.withOfficers(ctx).Where("tanggal BETWEEN ? AND ?", from, to)
+	if regu != "" {
+		db = db.Where("regu = ?", regu)
+	}
+	err := db.Order("mulai_pada asc").Find(&rosters).Error
+	return rosters, err
+}
+
+func (r *dutyRosterRepository) FindActiveAt(ctx context.Context, t time.Time) ([]models.DutyRoster, error) {
+	var rosters []models.DutyRoster
+	err := r.withOfficers(ctx).
+		Where("mulai_pada <= ? AND selesai_pada > ?", t.UTC(), t.UTC()).
+		Order("mulai_pada desc").
+		Find(&rosters).Error
+	return rosters, err
+}
+
+func (r *dutyRosterRepository) CountOverlapping(ctx context.Context, regu string, start time.Time, end time.Time, excludeID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.DutyRoster{}).
+		Where("regu = ? AND id <> ?", regu, excludeID).
+		Where("mulai_pada < ? AND selesai_pada > ?", end.UTC(), start.UTC()).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *dutyRosterRepository) withOfficers(ctx context.Context) *gorm.DB {
+	return r.db.WithContext(ctx).
+		Preload("PetugasPelapor", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("PejabatPersetuju", func(db *gorm.DB) *gorm.DB { return db.Unscoped() })
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// JWTKeyRepository mendefinisikan kontrak untuk key ring penandatanganan JWT.
+type JWTKeyRepository interface {
+	// FindAll mengambil semua kunci, dari yang terbaru.
+	FindAll(ctx context.Context) ([]models.JWTSigningKey, error)
+	// Rotate menyimpan kunci baru lalu mempensiunkan kunci aktif sebelumnya pada waktu
+	// retiredAt, dalam satu transaksi.
+	Rotate(ctx context.Context, key *models.JWTSigningKey, retiredAt time.Time) error
+	// DeleteRetiredBefore menghapus kunci yang sudah dipensiunkan sebelum t.
+	DeleteRetiredBefore(ctx context.Context, t time.Time) (int64, error)
+	// DeleteRetired menghapus semua kunci yang sudah dipensiunkan.
+	DeleteRetired(ctx context.Context) (int64, error)
+}
+
+type jwtKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewJWTKeyRepository adalah factory untuk JWTKeyRepository.
+func NewJWTKeyRepository(db *gorm.DB) JWTKeyRepository {
+	return &jwtKeyRepository{db: db}
+}
+
+func (r *jwtKeyRepository) FindAll(ctx context.Context) ([]models.JWTSigningKey, error) {
+	var keys []models.JWTSigningKey
+	err := r.db.WithContext(ctx).Order("created_at DESC, id DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *jwtKeyRepository) Rotate(ctx context.Context, key *models.JWTSigningKey, retiredAt time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.JWTSigningKey{}).Where("retired_at IS NULL").Update("retired_at", retiredAt.UTC()).Error; err != nil {
+			return err
+		}
+		if key.RetiredAt != nil {
+			retired := key.RetiredAt.UTC()
+			key.RetiredAt = &retired
+		}
+		return tx.Create(key).Error
+	})
+}
+
+func (r *jwtKeyRepository) DeleteRetiredBefore(ctx context.Context, t time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("retired_at IS NOT NULL AND retired_at < ?", t.UTC()).Delete(&models.JWTSigningKey{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *jwtKeyRepository) DeleteRetired(ctx context.Context) (int64, error) {
+	result := r.db.WithContext(ctx).Where("retired_at IS NOT NULL").Delete(&models.JWTSigningKey{})
+	return result.RowsAffected, result.Error
+}
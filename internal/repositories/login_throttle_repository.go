@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LoginThrottleRepository mendefinisikan kontrak untuk penghitung percobaan login gagal.
+type LoginThrottleRepository interface {
+	// Find mengembalikan gorm.ErrRecordNotFound jika key belum pernah gagal login.
+	Find(ctx context.Context, key string) (*models.LoginThrottle, error)
+	Save(ctx context.Context, throttle *models.LoginThrottle) error
+	Delete(ctx context.Context, key string) error
+	// FindLocked mengambil semua key yang masih terkunci pada waktu t.
+	FindLocked(ctx context.Context, t time.Time) ([]models.LoginThrottle, error)
+}
+
+type loginThrottleRepository struct {
+	db *gorm.DB
+}
+
+// NewLoginThrottleRepository adalah factory untuk LoginThrottleRepository.
+func NewLoginThrottleRepository(db *gorm.DB) LoginThrottleRepository {
+	return &loginThrottleRepository{db: db}
+}
+
+func (r *loginThrottleRepository) Find(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	var throttle models.LoginThrottle
+	if err := r.db.WithContext(ctx).Where("key = ?", key).First(&throttle).Error; err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *loginThrottleRepository) Save(ctx context.Context, throttle *models.LoginThrottle) error {
+	// Disimpan dalam UTC agar perbandingan teks waktu di SQLite konsisten
+	throttle.LastFailureAt = throttle.LastFailureAt.UTC()
+	if throttle.LockedUntil != nil {
+		lockedUntil := throttle.LockedUntil.UTC()
+		throttle.LockedUntil = &lockedUntil
+	}
+	return r.db.WithContext(ctx).Save(throttle).Error
+}
+
+func (r *loginThrottleRepository) Delete(ctx context.Context, key string) error {
+	return r.db.WithContext(ctx).Where("key = ?", key).Delete(&models.LoginThrottle{}).Error
+}
+
+func (r *loginThrottleRepository) FindLocked(ctx context.Context, t time.Time) ([]models.LoginThrottle, error) {
+	var throttles []models.LoginThrottle
+	err := r.db.WithContext(ctx).Where("locked_until > ?", t.UTC()).Order("locked_until desc").Find(&throttles).Error
+	return throttles, err
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"fmt"
 	"simdokpol/internal/models"
 	"time"
@@ -19,18 +20,52 @@ type ItemCompositionStat struct {
 	Count      int    `gorm:"column:count"`
 }
 
+type ReguCount struct {
+	Regu  string `gorm:"column:regu"`
+	Count int    `gorm:"column:count"`
+}
+
+// DocumentScope membatasi dokumen yang dapat dilihat seorang pengguna.
+// All mengabaikan batasan; Regu yang terisi membuka dokumen regu tersebut;
+// selain itu hanya dokumen milik OperatorID yang terlihat.
+type DocumentScope struct {
+	All        bool
+	OperatorID uint
+	Regu       string
+}
+
+// Allows memeriksa apakah dokumen termasuk dalam cakupan.
+func (sc DocumentScope) Allows(doc *models.LostDocument) bool {
+	if sc.All || doc.OperatorID == sc.OperatorID {
+		return true
+	}
+	return sc.Regu != "" && doc.Regu == sc.Regu
+}
+
+func (sc DocumentScope) apply(db *gorm.DB) *gorm.DB {
+	if sc.All {
+		return db
+	}
+	if sc.Regu != "" {
+		return db.Where("(lost_documents.regu = ? OR lost_documents.operator_id = ?)", sc.Regu, sc.OperatorID)
+	}
+	return db.Where("lost_documents.operator_id = ?", sc.OperatorID)
+}
+
 type LostDocumentRepository interface {
-	Create(tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error)
-	FindByID(id uint) (*models.LostDocument, error)
-	FindAll(query string, statusFilter string, archiveDurationDays int) ([]models.LostDocument, error)
-	SearchGlobal(query string) ([]models.LostDocument, error)
-	Update(tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error)
-	Delete(tx *gorm.DB, id uint) error
-	GetLastDocumentOfYear(year int) (*models.LostDocument, error)
-	CountByDateRange(start time.Time, end time.Time) (int64, error)
-	GetMonthlyIssuanceForYear(year int) ([]MonthlyCount, error)
-	GetItemCompositionStats() ([]ItemCompositionStat, error)
-	FindExpiringDocumentsForUser(userID uint, expiryDateStart time.Time, expiryDateEnd time.Time) ([]models.LostDocument, error) // <-- METHOD BARU
+	Create(ctx context.Context, tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error)
+	FindByID(ctx context.Context, id uint) (*models.LostDocument, error)
+	FindAll(ctx context.Context, query string, statusFilter string, archiveDurationDays int, scope DocumentScope) ([]models.LostDocument, error)
+	SearchGlobal(ctx context.Context, query string, scope DocumentScope) ([]models.LostDocument, error)
+	FindByReguInRange(ctx context.Context, regu string, start time.Time, end time.Time, scope DocumentScope) ([]models.LostDocument, error)
+	CountByReguInRange(ctx context.Context, start time.Time, end time.Time) ([]ReguCount, error)
+	Update(ctx context.Context, tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error)
+	Delete(ctx context.Context, tx *gorm.DB, id uint) error
+	GetLastDocumentOfYear(ctx context.Context, year int) (*models.LostDocument, error)
+	CountByDateRange(ctx context.Context, start time.Time, end time.Time) (int64, error)
+	GetMonthlyIssuanceForYear(ctx context.Context, year int) ([]MonthlyCount, error)
+	GetItemCompositionStats(ctx context.Context) ([]ItemCompositionStat, error)
+	FindExpiringDocumentsForUser(ctx context.Context, userID uint, expiryDateStart time.Time, expiryDateEnd time.Time) ([]models.LostDocument, error) // <-- METHOD BARU
 }
 
 type lostDocumentRepository struct {
@@ -42,9 +77,9 @@ func NewLostDocumentRepository(db *gorm.DB) LostDocumentRepository {
 }
 
 // === FUNGSI BARU UNTUK NOTIFIKASI ===
-func (r *lostDocumentRepository) FindExpiringDocumentsForUser(userID uint, expiryDateStart time.Time, expiryDateEnd time.Time) ([]models.LostDocument, error) {
+func (r *lostDocumentRepository) FindExpiringDocumentsForUser(ctx context.Context, userID uint, expiryDateStart time.Time, expiryDateEnd time.Time) ([]models.LostDocument, error) {
 	var docs []models.LostDocument
-	err := r.db.
+	err := r.db.WithContext(ctx).
 		Where("operator_id = ?", userID).
 		Where("tanggal_laporan BETWEEN ? AND ?", expiryDateStart, expiryDateEnd).
 		Order("tanggal_laporan asc").
@@ -53,18 +88,18 @@ func (r *lostDocumentRepository) FindExpiringDocumentsForUser(userID uint, expir
 }
 // === AKHIR FUNGSI BARU ===
 
-func (r *lostDocumentRepository) CountByDateRange(start time.Time, end time.Time) (int64, error) {
+func (r *lostDocumentRepository) CountByDateRange(ctx context.Context, start time.Time, end time.Time) (int64, error) {
 	var count int64
-	err := r.db.Model(&models.LostDocument{}).Where("tanggal_laporan BETWEEN ? AND ?", start, end).Count(&count).Error
+	err := r.db.WithContext(ctx).Model(&models.LostDocument{}).Where("tanggal_laporan BETWEEN ? AND ?", start, end).Count(&count).Error
 	if err != nil {
 		return 0, err
 	}
 	return count, nil
 }
 
-func (r *lostDocumentRepository) FindAll(query string, statusFilter string, archiveDurationDays int) ([]models.LostDocument, error) {
+func (r *lostDocumentRepository) FindAll(ctx context.Context, query string, statusFilter string, archiveDurationDays int, scope DocumentScope) ([]models.LostDocument, error) {
 	var docs []models.LostDocument
-	db := r.db.
+	db := r.db.WithContext(ctx).
 		Preload("Resident").
 		Preload("LostItems").
 		Preload("PetugasPelapor").
@@ -72,6 +107,8 @@ func (r *lostDocumentRepository) FindAll(query string, statusFilter string, arch
 		Preload("Operator").
 		Order("tanggal_laporan desc")
 
+	db = scope.apply(db)
+
 	archiveDate := time.Now().Add(-time.Duration(archiveDurationDays) * 24 * time.Hour)
 	if statusFilter == "archived" {
 		db = db.Where("tanggal_laporan <= ?", archiveDate)
@@ -92,15 +129,16 @@ func (r *lostDocumentRepository) FindAll(query string, statusFilter string, arch
 	return docs, nil
 }
 
-func (r *lostDocumentRepository) SearchGlobal(query string) ([]models.LostDocument, error) {
+func (r *lostDocumentRepository) SearchGlobal(ctx context.Context, query string, scope DocumentScope) ([]models.LostDocument, error) {
 	var docs []models.LostDocument
-	db := r.db.
+	db := r.db.WithContext(ctx).
 		Preload("Resident").
 		Preload("LostItems").
 		Preload("PetugasPelapor").
 		Preload("PejabatPersetuju").
 		Preload("Operator").
 		Order("tanggal_laporan desc")
+	db = scope.apply(db)
 
 	if query != "" {
 		searchQuery := fmt.Sprintf("%%%s%%", query)
@@ -117,10 +155,10 @@ func (r *lostDocumentRepository) SearchGlobal(query string) ([]models.LostDocume
 	return docs, nil
 }
 
-func (r *lostDocumentRepository) Create(tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error) {
-	db := r.db
+func (r *lostDocumentRepository) Create(ctx context.Context, tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error) {
+	db := r.db.WithContext(ctx)
 	if tx != nil {
-		db = tx
+		db = tx.WithContext(ctx)
 	}
 	if err := db.Create(doc).Error; err != nil {
 		return nil, err
@@ -128,19 +166,19 @@ func (r *lostDocumentRepository) Create(tx *gorm.DB, doc *models.LostDocument) (
 	return doc, nil
 }
 
-func (r *lostDocumentRepository) FindByID(id uint) (*models.LostDocument, error) {
+func (r *lostDocumentRepository) FindByID(ctx context.Context, id uint) (*models.LostDocument, error) {
 	var doc models.LostDocument
-	err := r.db.Preload("Resident").Preload("LostItems").Preload("PetugasPelapor").Preload("PejabatPersetuju").Preload("Operator").Preload("LastUpdatedBy").First(&doc, id).Error
+	err := r.db.WithContext(ctx).Preload("Resident").Preload("LostItems").Preload("PetugasPelapor").Preload("PejabatPersetuju").Preload("Operator").Preload("LastUpdatedBy").First(&doc, id).Error
 	if err != nil {
 		return nil, err
 	}
 	return &doc, nil
 }
 
-func (r *lostDocumentRepository) Update(tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error) {
-	db := r.db
+func (r *lostDocumentRepository) Update(ctx context.Context, tx *gorm.DB, doc *models.LostDocument) (*models.LostDocument, error) {
+	db := r.db.WithContext(ctx)
 	if tx != nil {
-		db = tx
+		db = tx.WithContext(ctx)
 	}
 	if err := db.Session(&gorm.Session{FullSaveAssociations: true}).Updates(doc).Error; err != nil {
 		return nil, err
@@ -148,33 +186,59 @@ func (r *lostDocumentRepository) Update(tx *gorm.DB, doc *models.LostDocument) (
 	return doc, nil
 }
 
-func (r *lostDocumentRepository) Delete(tx *gorm.DB, id uint) error {
-	db := r.db
+func (r *lostDocumentRepository) Delete(ctx context.Context, tx *gorm.DB, id uint) error {
+	db := r.db.WithContext(ctx)
 	if tx != nil {
-		db = tx
+		db = tx.WithContext(ctx)
 	}
 	return db.Delete(&models.LostDocument{}, id).Error
 }
 
-func (r *lostDocumentRepository) GetLastDocumentOfYear(year int) (*models.LostDocument, error) {
+func (r *lostDocumentRepository) GetLastDocumentOfYear(ctx context.Context, year int) (*models.LostDocument, error) {
 	var doc models.LostDocument
 	startOfYear := time.Date(year, 1, 1, 0, 0, 0, 0, time.UTC)
 	endOfYear := startOfYear.AddDate(1, 0, 0).Add(-time.Nanosecond)
-	err := r.db.Unscoped().Where("created_at BETWEEN ? AND ?", startOfYear, endOfYear).Where("nomor_surat NOT LIKE ?", "DELETED_%").Order("id desc").First(&doc).Error
+	err := r.db.WithContext(ctx).Unscoped().Where("created_at BETWEEN ? AND ?", startOfYear, endOfYear).Where("nomor_surat NOT LIKE ?", "DELETED_%").Order("id desc").First(&doc).Error
 	if err != nil {
 		return nil, err
 	}
 	return &doc, nil
 }
 
-func (r *lostDocumentRepository) GetMonthlyIssuanceForYear(year int) ([]MonthlyCount, error) {
+func (r *lostDocumentRepository) GetMonthlyIssuanceForYear(ctx context.Context, year int) ([]MonthlyCount, error) {
 	var results []MonthlyCount
-	err := r.db.Model(&models.LostDocument{}).Select("CAST(strftime('%Y', tanggal_laporan) AS INTEGER) as year, CAST(strftime('%m', tanggal_laporan) AS INTEGER) as month, COUNT(id) as count").Where("CAST(strftime('%Y', tanggal_laporan) AS INTEGER) = ?", year).Group("year, month").Order("month asc").Scan(&results).Error
+	err := r.db.WithContext(ctx).Model(&models.LostDocument{}).Select("CAST(strftime('%Y', tanggal_laporan) AS INTEGER) as year, CAST(strftime('%m', tanggal_laporan) AS INTEGER) as month, COUNT(id) as count").Where("CAST(strftime('%Y', tanggal_laporan) AS INTEGER) = ?", year).Group("year, month").Order("month asc").Scan(&results).Error
 	return results, err
 }
 
-func (r *lostDocumentRepository) GetItemCompositionStats() ([]ItemCompositionStat, error) {
+func (r *lostDocumentRepository) GetItemCompositionStats(ctx context.Context) ([]ItemCompositionStat, error) {
 	var results []ItemCompositionStat
-	err := r.db.Model(&models.LostItem{}).Select("nama_barang, COUNT(id) as count").Group("nama_barang").Order("count desc").Scan(&results).Error
+	err := r.db.WithContext(ctx).Model(&models.LostItem{}).Select("nama_barang, COUNT(id) as count").Group("nama_barang").Order("count desc").Scan(&results).Error
 	return results, err
-}
\ No newline at end of file
+}
+// FindByReguInRange mengambil dokumen sebuah regu yang dibuat atau diperbarui dalam rentang waktu,
+// dipakai untuk serah terima antar regu jaga.
+func (r *lostDocumentRepository) FindByReguInRange(ctx context.Context, regu string, start time.Time, end time.Time, scope DocumentScope) ([]models.LostDocument, error) {
+	var docs []models.LostDocument
+	db := r.db.WithContext(ctx).
+		Preload("Resident").
+		Preload("LostItems").
+		Preload("Operator").
+		Preload("LastUpdatedBy").
+		Where("lost_documents.regu = ?", regu).
+		Where("((tanggal_laporan BETWEEN ? AND ?) OR (updated_at BETWEEN ? AND ?))", start, end, start, end).
+		Order("tanggal_laporan asc")
+	err := scope.apply(db).Find(&docs).Error
+	return docs, err
+}
+
+func (r *lostDocumentRepository) CountByReguInRange(ctx context.Context, start time.Time, end time.Time) ([]ReguCount, error) {
+	var results []ReguCount
+	err := r.db.WithContext(ctx).Model(&models.LostDocument{}).
+		Select("regu, COUNT(id) as count").
+		Where("tanggal_laporan BETWEEN ? AND ?", start, end).
+		Group("regu").
+		Order("regu asc").
+		Scan(&results).Error
+	return results, err
+}
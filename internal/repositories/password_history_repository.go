@@ -0,0 +1,41 @@
+package repositories
+
+import (
+	"context"
+	"simdokpol/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// PasswordHistoryRepository mendefinisikan kontrak untuk riwayat hash kata sandi pengguna.
+type PasswordHistoryRepository interface {
+	Create(ctx context.Context, entry *models.PasswordHistory) error
+	// FindRecent mengambil paling banyak limit riwayat terbaru milik pengguna.
+	FindRecent(ctx context.Context, userID uint, limit int) ([]models.PasswordHistory, error)
+	// Prune menghapus riwayat lama sehingga hanya keep entri terbaru yang tersisa.
+	Prune(ctx context.Context, userID uint, keep int) error
+}
+
+type passwordHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewPasswordHistoryRepository adalah factory untuk PasswordHistoryRepository.
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepository {
+	return &passwordHistoryRepository{db: db}
+}
+
+func (r *passwordHistoryRepository) Create(ctx context.Context, entry *models.PasswordHistory) error {
+	return r.db.WithContext(ctx).Create(entry).Error
+}
+
+func (r *passwordHistoryRepository) FindRecent(ctx context.Context, userID uint, limit int) ([]models.PasswordHistory, error) {
+	var entries []models.PasswordHistory
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("id desc").Limit(limit).Find(&entries).Error
+	return entries, err
+}
+
+func (r *passwordHistoryRepository) Prune(ctx context.Context, userID uint, keep int) error {
+	recent := r.db.WithContext(ctx).Model(&models.PasswordHistory{}).Select("id").Where("user_id = ?", userID).Order("id desc").Limit(keep)
+	return r.db.WithContext(ctx).Where("user_id = ? AND id NOT IN (?)", userID, recent).Delete(&models.PasswordHistory{}).Error
+}
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCodeRepository mendefinisikan kontrak untuk kode pemulihan 2FA.
+type RecoveryCodeRepository interface {
+	// Replace menghapus semua kode milik pengguna lalu menyimpan kode baru dalam satu transaksi.
+	Replace(ctx context.Context, userID uint, codeHashes []string) error
+	FindUnusedByUser(ctx context.Context, userID uint) ([]models.RecoveryCode, error)
+	// MarkUsed menandai kode sebagai terpakai. Nilai false berarti kode sudah lebih dulu
+	// dipakai oleh request lain.
+	MarkUsed(ctx context.Context, id uint, t time.Time) (bool, error)
+	DeleteByUser(ctx context.Context, userID uint) error
+}
+
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewRecoveryCodeRepository adalah factory untuk RecoveryCodeRepository.
+func NewRecoveryCodeRepository(db *gorm.DB) RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+func (r *recoveryCodeRepository) Replace(ctx context.Context, userID uint, codeHashes []string) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		codes := make([]models.RecoveryCode, len(codeHashes))
+		for i, hash := range codeHashes {
+			codes[i] = models.RecoveryCode{UserID: userID, CodeHash: hash}
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+func (r *recoveryCodeRepository) FindUnusedByUser(ctx context.Context, userID uint) ([]models.RecoveryCode, error) {
+	var codes []models.RecoveryCode
+	err := r.db.WithContext(ctx).Where("user_id = ? AND used_at IS NULL", userID).Find(&codes).Error
+	return codes, err
+}
+
+func (r *recoveryCodeRepository) MarkUsed(ctx context.Context, id uint, t time.Time) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.RecoveryCode{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", t.UTC())
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *recoveryCodeRepository) DeleteByUser(ctx context.Context, userID uint) error {
+	return r.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
@@ -1,6 +1,7 @@
 package repositories
 
 import (
+	"context"
 	"simdokpol/internal/models"
 
 	"gorm.io/gorm"
@@ -9,9 +10,9 @@ import (
 // ResidentRepository mendefinisikan kontrak untuk operasi data penduduk.
 type ResidentRepository interface {
 	// FindByNIK mencari penduduk berdasarkan NIK. Menggunakan transaksi jika disediakan.
-	FindByNIK(tx *gorm.DB, nik string) (*models.Resident, error)
+	FindByNIK(ctx context.Context, tx *gorm.DB, nik string) (*models.Resident, error)
 	// Create menyimpan data penduduk baru. Menggunakan transaksi jika disediakan.
-	Create(tx *gorm.DB, resident *models.Resident) (*models.Resident, error)
+	Create(ctx context.Context, tx *gorm.DB, resident *models.Resident) (*models.Resident, error)
 }
 
 type residentRepository struct {
@@ -23,10 +24,10 @@ func NewResidentRepository(db *gorm.DB) ResidentRepository {
 	return &residentRepository{db: db}
 }
 
-func (r *residentRepository) FindByNIK(tx *gorm.DB, nik string) (*models.Resident, error) {
-	db := r.db
+func (r *residentRepository) FindByNIK(ctx context.Context, tx *gorm.DB, nik string) (*models.Resident, error) {
+	db := r.db.WithContext(ctx)
 	if tx != nil {
-		db = tx
+		db = tx.WithContext(ctx)
 	}
 	var resident models.Resident
 	if err := db.Where("nik = ?", nik).First(&resident).Error; err != nil {
@@ -35,10 +36,10 @@ func (r *residentRepository) FindByNIK(tx *gorm.DB, nik string) (*models.Residen
 	return &resident, nil
 }
 
-func (r *residentRepository) Create(tx *gorm.DB, resident *models.Resident) (*models.Resident, error) {
-	db := r.db
+func (r *residentRepository) Create(ctx context.Context, tx *gorm.DB, resident *models.Resident) (*models.Resident, error) {
+	db := r.db.WithContext(ctx)
 	if tx != nil {
-		db = tx
+		db = tx.WithContext(ctx)
 	}
 	if err := db.Create(resident).Error; err != nil {
 		return nil, err
@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"simdokpol/internal/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// SessionRepository mendefinisikan kontrak untuk operasi data sesi login.
+// Semua waktu disimpan dalam UTC agar perbandingan teks di SQLite konsisten.
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	FindByID(ctx context.Context, id string) (*models.Session, error)
+	// FindActiveByUser mengambil sesi pengguna yang belum dicabut dan belum kedaluwarsa pada waktu t.
+	FindActiveByUser(ctx context.Context, userID uint, t time.Time) ([]models.Session, error)
+	Touch(ctx context.Context, id string, t time.Time) error
+	Revoke(ctx context.Context, id string, t time.Time) error
+	// RevokeAllForUser mencabut semua sesi aktif pengguna kecuali exceptID (boleh kosong).
+	RevokeAllForUser(ctx context.Context, userID uint, exceptID string, t time.Time) (int64, error)
+	// DeleteExpiredBefore menghapus sesi yang kedaluwarsa sebelum waktu t.
+	DeleteExpiredBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+// NewSessionRepository adalah factory untuk SessionRepository.
+func NewSessionRepository(db *gorm.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	session.LastSeenAt = session.LastSeenAt.UTC()
+	session.ExpiresAt = session.ExpiresAt.UTC()
+	return r.db.WithContext(ctx).Omit("User").Create(session).Error
+}
+
+func (r *sessionRepository) FindByID(ctx context.Context, id string) (*models.Session, error) {
+	var session models.Session
+	if err := r.db.WithContext(ctx).Where("id = ?", id).First(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) FindActiveByUser(ctx context.Context, userID uint, t time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, t.UTC()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, id string, t time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).Where("id = ?", id).Update("last_seen_at", t.UTC()).Error
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, id string, t time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).Where("id = ? AND revoked_at IS NULL", id).Update("revoked_at", t.UTC()).Error
+}
+
+func (r *sessionRepository) RevokeAllForUser(ctx context.Context, userID uint, exceptID string, t time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL AND expires_at > ?", userID, exceptID, t.UTC()).
+		Update("revoked_at", t.UTC())
+	return result.RowsAffected, result.Error
+}
+
+func (r *sessionRepository) DeleteExpiredBefore(ctx context.Context, t time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("expires_at < ?", t.UTC()).Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
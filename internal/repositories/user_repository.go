@@ -1,20 +1,29 @@
 package repositories
 
 import (
+	"context"
 	"simdokpol/internal/models"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
-	Create(user *models.User) error
-	FindAll(statusFilter string) ([]models.User, error)
-	FindByID(id uint) (*models.User, error)
-	FindByNRP(nrp string) (*models.User, error)
-	FindOperators() ([]models.User, error)
-	Update(user *models.User) error
-	Delete(id uint) error
-	Restore(id uint) error
-	CountAll() (int64, error)
+	Create(ctx context.Context, user *models.User) error
+	FindAll(ctx context.Context, statusFilter string) ([]models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindByNRP(ctx context.Context, nrp string) (*models.User, error)
+	FindOperators(ctx context.Context) ([]models.User, error)
+	Update(ctx context.Context, user *models.User) error
+	Delete(ctx context.Context, id uint) error
+	Restore(ctx context.Context, id uint) error
+	CountAll(ctx context.Context) (int64, error)
+	// UpdateTOTPLastStep menyimpan langkah TOTP terakhir yang diterima hanya jika lebih baru dari
+	// yang tersimpan. Nilai false berarti kode sudah lebih dulu diterima oleh request lain.
+	UpdateTOTPLastStep(ctx context.Context, userID uint, step int64) (bool, error)
+	// CreateInitialSuperAdmin membuat Super Admin pertama dan menyimpan configs dalam satu
+	// transaksi. Mengembalikan false tanpa menyimpan apa pun jika Super Admin sudah ada,
+	// termasuk yang sudah dinonaktifkan.
+	CreateInitialSuperAdmin(ctx context.Context, user *models.User, configs map[string]string) (bool, error)
 }
 
 type userRepository struct {
@@ -25,13 +34,13 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 	return &userRepository{db: db}
 }
 
-func (r *userRepository) Create(user *models.User) error {
-	return r.db.Create(user).Error
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
 }
 
-func (r *userRepository) FindAll(statusFilter string) ([]models.User, error) {
+func (r *userRepository) FindAll(ctx context.Context, statusFilter string) ([]models.User, error) {
 	var users []models.User
-	db := r.db.Order("nama_lengkap asc")
+	db := r.db.WithContext(ctx).Order("nama_lengkap asc")
 	if statusFilter == "inactive" {
 		db = db.Unscoped().Where("deleted_at IS NOT NULL")
 	}
@@ -39,45 +48,76 @@ func (r *userRepository) FindAll(statusFilter string) ([]models.User, error) {
 	return users, err
 }
 
-func (r *userRepository) FindByID(id uint) (*models.User, error) {
+func (r *userRepository) FindByID(ctx context.Context, id uint) (*models.User, error) {
 	var user models.User
-	if err := r.db.Unscoped().First(&user, id).Error; err != nil {
+	if err := r.db.WithContext(ctx).Unscoped().First(&user, id).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindByNRP(nrp string) (*models.User, error) {
+func (r *userRepository) FindByNRP(ctx context.Context, nrp string) (*models.User, error) {
 	var user models.User
 	// Gunakan Unscoped() agar bisa menemukan pengguna yang sudah di-soft delete
-	if err := r.db.Unscoped().Where("nrp = ?", nrp).First(&user).Error; err != nil {
+	if err := r.db.WithContext(ctx).Unscoped().Where("nrp = ?", nrp).First(&user).Error; err != nil {
 		return nil, err
 	}
 	return &user, nil
 }
 
-func (r *userRepository) FindOperators() ([]models.User, error) {
+func (r *userRepository) FindOperators(ctx context.Context) ([]models.User, error) {
 	var users []models.User
-	err := r.db.Where("peran = ?", "OPERATOR").Order("nama_lengkap asc").Find(&users).Error
+	err := r.db.WithContext(ctx).Where("peran IN ?", []string{models.RoleOperator, models.RoleKanit}).Order("nama_lengkap asc").Find(&users).Error
 	return users, err
 }
 
-func (r *userRepository) Update(user *models.User) error {
-	return r.db.Save(user).Error
+func (r *userRepository) Update(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Save(user).Error
 }
 
-func (r *userRepository) Delete(id uint) error {
-	return r.db.Delete(&models.User{}, id).Error
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.User{}, id).Error
 }
 
-func (r *userRepository) Restore(id uint) error {
-	return r.db.Unscoped().Model(&models.User{}).Where("id = ?", id).Update("deleted_at", nil).Error
+func (r *userRepository) Restore(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&models.User{}).Where("id = ?", id).Update("deleted_at", nil).Error
 }
 
-func (r *userRepository) CountAll() (int64, error) {
+func (r *userRepository) CountAll(ctx context.Context) (int64, error) {
 	var count int64
-	if err := r.db.Model(&models.User{}).Count(&count).Error; err != nil {
+	if err := r.db.WithContext(ctx).Model(&models.User{}).Count(&count).Error; err != nil {
 		return 0, err
 	}
 	return count, nil
-}
\ No newline at end of file
+}
+func (r *userRepository) UpdateTOTPLastStep(ctx context.Context, userID uint, step int64) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("id = ? AND totp_last_step < ?", userID, step).UpdateColumn("totp_last_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *userRepository) CreateInitialSuperAdmin(ctx context.Context, user *models.User, configs map[string]string) (bool, error) {
+	created := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&models.User{}).Where("peran = ?", models.RoleSuperAdmin).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		if err := tx.Create(user).Error; err != nil {
+			return err
+		}
+		for key, value := range configs {
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "key"}},
+				DoUpdates: clause.AssignmentColumns([]string{"value"}),
+			}).Create(&models.Configuration{Key: key, Value: value}).Error; err != nil {
+				return err
+			}
+		}
+		created = true
+		return nil
+	})
+	return created && err == nil, err
+}
@@ -0,0 +1,39 @@
+// Package reqctx menyimpan data milik satu request, yaitu ID request dan pengguna yang
+// melakukan aksi, di dalam context.Context agar dapat diteruskan ke service, log aplikasi,
+// dan log audit tanpa parameter tambahan.
+package reqctx
+
+import "context"
+
+type requestIDKey struct{}
+
+type actorIDKey struct{}
+
+// WithRequestID menyimpan ID request di context.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID mengambil ID request dari context, atau string kosong jika tidak ada.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// WithActor menyimpan ID pengguna yang melakukan aksi di context.
+func WithActor(ctx context.Context, userID uint) context.Context {
+	return context.WithValue(ctx, actorIDKey{}, userID)
+}
+
+// ActorID mengambil ID pengguna yang melakukan aksi. Nilai 0 berarti aksi dari sistem atau
+// CLI, yang dicatat ke log aplikasi karena tidak ada pengguna untuk log audit.
+func ActorID(ctx context.Context) uint {
+	if ctx == nil {
+		return 0
+	}
+	actorID, _ := ctx.Value(actorIDKey{}).(uint)
+	return actorID
+}
@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/reqctx"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// APITokenPrefix menandai API token sehingga AuthMiddleware dapat membedakannya dari JWT.
+	APITokenPrefix = "sdp_"
+	// MaxAPITokenDays adalah masa berlaku terpanjang sebuah API token.
+	MaxAPITokenDays = 730
+	// apiTokenPrefixLength adalah panjang awalan token yang disimpan untuk ditampilkan di daftar.
+	apiTokenPrefixLength = len(APITokenPrefix) + 8
+	// apiTokenTouchInterval membatasi penulisan last_used_at agar setiap request tidak menulis ke database.
+	apiTokenTouchInterval = time.Minute
+)
+
+type APITokenService interface {
+	// Create membuat API token untuk ownerID. Token asli hanya dikembalikan sekali dan
+	// tidak dapat diambil lagi.
+	Create(ctx context.Context, ownerID uint, req dto.CreateAPITokenRequest) (*dto.APITokenCreated, error)
+	// FindByUser mengambil token milik seorang pengguna; userID 0 mengambil token semua pengguna.
+	FindByUser(ctx context.Context, userID uint) ([]models.APIToken, error)
+	// Revoke mencabut token. Jika ownerID bukan 0, token harus milik ownerID.
+	Revoke(ctx context.Context, id uint, ownerID uint) error
+	// Authenticate memvalidasi token dari header Authorization dan mengembalikan pemiliknya
+	// dengan Scopes terisi sesuai scope token.
+	Authenticate(ctx context.Context, plaintext string, clientIP string) (*models.APIToken, *models.User, error)
+}
+
+type apiTokenService struct {
+	tokenRepo    repositories.APITokenRepository
+	userRepo     repositories.UserRepository
+	auditService AuditLogService
+}
+
+func NewAPITokenService(tokenRepo repositories.APITokenRepository, userRepo repositories.UserRepository, auditService AuditLogService) APITokenService {
+	return &apiTokenService{
+		tokenRepo:    tokenRepo,
+		userRepo:     userRepo,
+		auditService: auditService,
+	}
+}
+
+func (s *apiTokenService) Create(ctx context.Context, ownerID uint, req dto.CreateAPITokenRequest) (*dto.APITokenCreated, error) {
+	actorID := reqctx.ActorID(ctx)
+	owner, err := s.userRepo.FindByID(ctx, ownerID)
+	if err != nil || owner.DeletedAt.Valid {
+		return nil, ErrNotFound
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("%w: nama wajib diisi, maksimal 100 karakter", ErrInvalidAPITokenRequest)
+	}
+	if req.ExpiresInDays < 1 || req.ExpiresInDays > MaxAPITokenDays {
+		return nil, fmt.Errorf("%w: masa berlaku harus antara 1 dan %d hari", ErrInvalidAPITokenRequest, MaxAPITokenDays)
+	}
+	var scopes []string
+	for _, scope := range req.Scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || slices.Contains(scopes, scope) {
+			continue
+		}
+		if !slices.Contains(models.AllPermissions, scope) || !models.RoleHasPermission(owner.Peran, scope) {
+			return nil, fmt.Errorf("%w: scope %s tidak dimiliki peran %s", ErrInvalidAPITokenRequest, scope, owner.Peran)
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: pilih minimal satu scope", ErrInvalidAPITokenRequest)
+	}
+
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	plaintext := APITokenPrefix + hex.EncodeToString(raw)
+
+	token := &models.APIToken{
+		UserID:      owner.ID,
+		Name:        name,
+		Prefix:      plaintext[:apiTokenPrefixLength],
+		TokenHash:   hashAPIToken(plaintext),
+		Scopes:      strings.Join(scopes, ","),
+		ExpiresAt:   time.Now().Add(time.Duration(req.ExpiresInDays) * 24 * time.Hour),
+		CreatedByID: actorID,
+	}
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, err
+	}
+	token.User = *owner
+
+	s.auditService.LogActivity(ctx, actorID, models.AuditCreateAPIToken, fmt.Sprintf("API token '%s' (%s) dibuat untuk %s (NRP: %s) dengan scope %s, berlaku sampai %s",
+		token.Name, token.Prefix, owner.NamaLengkap, owner.NRP, token.Scopes, token.ExpiresAt.Format("2006-01-02")))
+
+	return &dto.APITokenCreated{Token: plaintext, APIToken: token}, nil
+}
+
+func (s *apiTokenService) FindByUser(ctx context.Context, userID uint) ([]models.APIToken, error) {
+	return s.tokenRepo.FindByUser(ctx, userID)
+}
+
+func (s *apiTokenService) Revoke(ctx context.Context, id uint, ownerID uint) error {
+	token, err := s.tokenRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if ownerID != 0 && token.UserID != ownerID {
+		return ErrNotFound
+	}
+
+	revoked, err := s.tokenRepo.Revoke(ctx, id, time.Now())
+	if err != nil || !revoked {
+		return err
+	}
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditRevokeAPIToken, fmt.Sprintf("API token '%s' (%s) milik pengguna id %d dicabut", token.Name, token.Prefix, token.UserID))
+	return nil
+}
+
+func (s *apiTokenService) Authenticate(ctx context.Context, plaintext string, clientIP string) (*models.APIToken, *models.User, error) {
+	token, err := s.tokenRepo.FindByHash(ctx, hashAPIToken(plaintext))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrAPITokenInvalid
+		}
+		return nil, nil, err
+	}
+	now := time.Now()
+	if token.RevokedAt != nil || now.After(token.ExpiresAt) {
+		return nil, nil, ErrAPITokenInvalid
+	}
+	user, err := s.userRepo.FindByID(ctx, token.UserID)
+	if err != nil || user.DeletedAt.Valid {
+		return nil, nil, ErrAPITokenInvalid
+	}
+
+	user.Scopes = []string{}
+	if token.Scopes != "" {
+		user.Scopes = strings.Split(token.Scopes, ",")
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenTouchInterval || token.LastUsedIP != clientIP {
+		if err := s.tokenRepo.TouchLastUsed(ctx, token.ID, now, clientIP); err != nil {
+			slog.WarnContext(ctx, "Gagal mencatat pemakaian API token", "api_token_id", token.ID, "error", err)
+		}
+	}
+	return token, user, nil
+}
+
+func hashAPIToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"simdokpol/internal/reqctx"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestAPITokenService_Authenticate(t *testing.T) {
+	owner := &models.User{ID: 7, NRP: "12345", Peran: models.RoleOperator}
+
+	mockTokenRepo := new(mocks.APITokenRepository)
+	mockUserRepo := new(mocks.UserRepository)
+	mockAudit := new(mocks.AuditLogService)
+	mockUserRepo.On("FindByID", mock.Anything, uint(7)).Return(owner, nil)
+	mockAudit.On("LogActivity", mock.Anything, uint(1), models.AuditCreateAPIToken, mock.Anything).Return()
+	service := NewAPITokenService(mockTokenRepo, mockUserRepo, mockAudit)
+	adminCtx := reqctx.WithActor(context.Background(), 1)
+
+	var stored *models.APIToken
+	mockTokenRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.APIToken")).Run(func(args mock.Arguments) {
+		stored = args.Get(1).(*models.APIToken)
+	}).Return(nil)
+
+	t.Run("Scope di Luar Peran Ditolak", func(t *testing.T) {
+		_, err := service.Create(adminCtx, 7, dto.CreateAPITokenRequest{Name: "Skrip", Scopes: []string{models.PermUserManage}, ExpiresInDays: 30})
+		assert.ErrorIs(t, err, ErrInvalidAPITokenRequest)
+	})
+
+	created, err := service.Create(adminCtx, 7, dto.CreateAPITokenRequest{Name: "Skrip", Scopes: []string{models.PermDocumentRead}, ExpiresInDays: 30})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(created.Token, APITokenPrefix))
+	assert.NotContains(t, stored.TokenHash, created.Token)
+
+	mockTokenRepo.On("FindByHash", mock.Anything, stored.TokenHash).Return(stored, nil)
+	mockTokenRepo.On("FindByHash", mock.Anything, mock.Anything).Return(nil, gorm.ErrRecordNotFound)
+	mockTokenRepo.On("TouchLastUsed", mock.Anything, stored.ID, mock.Anything, "10.0.0.1").Return(nil)
+
+	t.Run("Token Valid Dibatasi Scope", func(t *testing.T) {
+		_, user, err := service.Authenticate(context.Background(), created.Token, "10.0.0.1")
+		assert.NoError(t, err)
+		assert.True(t, user.HasPermission(models.PermDocumentRead))
+		assert.False(t, user.HasPermission(models.PermDocumentCreate))
+	})
+
+	t.Run("Token Tidak Dikenal Ditolak", func(t *testing.T) {
+		_, _, err := service.Authenticate(context.Background(), APITokenPrefix+"salah", "10.0.0.1")
+		assert.ErrorIs(t, err, ErrAPITokenInvalid)
+	})
+
+	t.Run("Token Kedaluwarsa Ditolak", func(t *testing.T) {
+		stored.ExpiresAt = time.Now().Add(-time.Minute)
+		_, _, err := service.Authenticate(context.Background(), created.Token, "10.0.0.1")
+		assert.ErrorIs(t, err, ErrAPITokenInvalid)
+		stored.ExpiresAt = time.Now().Add(time.Hour)
+	})
+
+	t.Run("Token Dicabut Ditolak", func(t *testing.T) {
+		now := time.Now()
+		stored.RevokedAt = &now
+		_, _, err := service.Authenticate(context.Background(), created.Token, "10.0.0.1")
+		assert.ErrorIs(t, err, ErrAPITokenInvalid)
+	})
+}
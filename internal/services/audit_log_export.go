@@ -0,0 +1,424 @@
+package services
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Format ekspor log audit yang didukung.
+const (
+	AuditExportCSV   = "csv"
+	AuditExportJSONL = "jsonl"
+)
+
+// auditSigningKeyFile adalah nama file kunci privat Ed25519 untuk menandatangani ekspor.
+const auditSigningKeyFile = "audit-signing.key"
+
+// ErrUnsupportedExportFormat dikembalikan saat format ekspor tidak dikenal.
+var ErrUnsupportedExportFormat = errors.New("format ekspor tidak didukung, gunakan csv atau jsonl")
+
+func (s *auditLogService) Export(ctx context.Context, start time.Time, end time.Time, format string, w io.Writer) error {
+	switch format {
+	case AuditExportCSV:
+		return s.exportCSV(ctx, start, end, w)
+	case AuditExportJSONL:
+		return s.exportJSONL(ctx, start, end, w)
+	default:
+		return ErrUnsupportedExportFormat
+	}
+}
+
+// forEachInRange memanggil fn untuk setiap entri dalam rentang waktu sesuai urutan ID. Entri yang
+// sudah dipindahkan kebijakan retensi dibaca dari berkas arsip yang beririsan dengan rentang,
+// lalu sisanya dibaca bertahap per batch dari database.
+func (s *auditLogService) forEachInRange(ctx context.Context, start time.Time, end time.Time, fn func(record dto.AuditExportRecord) error) error {
+	archives, err := s.repo.FindArchives(ctx)
+	if err != nil {
+		return err
+	}
+	for _, archive := range archives {
+		if archive.FirstTimestamp != nil && archive.LastTimestamp != nil &&
+			(archive.LastTimestamp.Before(start) || archive.FirstTimestamp.After(end)) {
+			continue
+		}
+		err := s.forEachArchiveRecord(archive, func(record dto.AuditExportRecord) error {
+			if record.Timestamp.Before(start) || record.Timestamp.After(end) {
+				return nil
+			}
+			return fn(record)
+		})
+		if err != nil {
+			return fmt.Errorf("gagal membaca berkas arsip %s: %w", archive.FileName, err)
+		}
+	}
+
+	var afterID uint
+	for {
+		batch, err := s.repo.FindInRange(ctx, start, end, afterID, auditVerifyBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		for i := range batch {
+			if err := fn(auditLogToRecord(&batch[i])); err != nil {
+				return err
+			}
+			afterID = batch[i].ID
+		}
+	}
+}
+
+func (s *auditLogService) exportCSV(ctx context.Context, start time.Time, end time.Time, w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"id", "timestamp", "user_id", "nrp", "nama_lengkap", "aksi", "detail", "prev_hash", "hash", "request_id"}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	err := s.forEachInRange(ctx, start, end, func(r dto.AuditExportRecord) error {
+		return writer.Write([]string{
+			strconv.FormatUint(uint64(r.ID), 10),
+			r.Timestamp.Format(time.RFC3339Nano),
+			strconv.FormatUint(uint64(r.UserID), 10),
+			r.NRP,
+			r.NamaLengkap,
+			r.Aksi,
+			r.Detail,
+			r.PrevHash,
+			r.Hash,
+			r.RequestID,
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportJSONL menulis satu entri per baris lalu menutup berkas dengan baris tanda tangan
+// Ed25519 atas SHA256 seluruh baris entri, sehingga pemeriksa dapat memastikan berkas
+// tidak diubah setelah diekspor.
+func (s *auditLogService) exportJSONL(ctx context.Context, start time.Time, end time.Time, w io.Writer) error {
+	privateKey, err := s.loadOrCreateSigningKey()
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.New()
+	out := io.MultiWriter(w, digest)
+	encoder := json.NewEncoder(out)
+
+	entries := 0
+	err = s.forEachInRange(ctx, start, end, func(r dto.AuditExportRecord) error {
+		entries++
+		return encoder.Encode(r)
+	})
+	if err != nil {
+		return err
+	}
+
+	sum := digest.Sum(nil)
+	trailer := dto.AuditExportSignature{
+		Type:      "signature",
+		Algorithm: "Ed25519",
+		SHA256:    hex.EncodeToString(sum),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(privateKey, sum)),
+		Entries:   entries,
+	}
+	return json.NewEncoder(w).Encode(trailer)
+}
+
+func (s *auditLogService) SigningKey() (*dto.AuditSigningKey, error) {
+	privateKey, err := s.loadOrCreateSigningKey()
+	if err != nil {
+		return nil, err
+	}
+	publicKey := privateKey.Public().(ed25519.PublicKey)
+	fingerprint := sha256.Sum256(publicKey)
+	return &dto.AuditSigningKey{
+		Algorithm:   "Ed25519",
+		PublicKey:   base64.StdEncoding.EncodeToString(publicKey),
+		Fingerprint: hex.EncodeToString(fingerprint[:]),
+	}, nil
+}
+
+// loadOrCreateSigningKey memuat kunci privat penandatangan ekspor, atau membuatnya jika belum ada.
+func (s *auditLogService) loadOrCreateSigningKey() (ed25519.PrivateKey, error) {
+	keyPath := filepath.Join(s.storageDir, auditSigningKeyFile)
+
+	content, err := os.ReadFile(keyPath)
+	if err == nil {
+		seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("file kunci penandatangan %s tidak valid", keyPath)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("gagal membaca kunci penandatangan: %w", err)
+	}
+
+	seed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(s.storageDir, 0755); err != nil {
+		return nil, fmt.Errorf("gagal membuat direktori audit di '%s': %w", s.storageDir, err)
+	}
+	if err := os.WriteFile(keyPath, []byte(base64.StdEncoding.EncodeToString(seed)), 0600); err != nil {
+		return nil, fmt.Errorf("gagal menyimpan kunci penandatangan: %w", err)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+func (s *auditLogService) ApplyRetention(ctx context.Context, retentionMonths int) ([]models.AuditArchive, error) {
+	if retentionMonths <= 0 {
+		return nil, nil
+	}
+
+	cutoff := time.Now().AddDate(0, -retentionMonths, 0)
+	lastID, err := s.repo.FindLastIDBefore(ctx, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	var archives []models.AuditArchive
+	var afterID uint
+	for afterID < lastID {
+		archive, err := s.archiveChunk(ctx, afterID, lastID)
+		if err != nil {
+			return archives, err
+		}
+		if archive == nil {
+			break
+		}
+		archives = append(archives, *archive)
+		afterID = archive.LastID
+	}
+	return archives, nil
+}
+
+// archiveChunk memindahkan paling banyak auditArchiveChunkSize entri setelah afterID hingga
+// lastID ke satu berkas arsip. Entri ditulis langsung ke berkas per batch sehingga retensi
+// pertama pada tabel besar tidak memuat seluruh entri ke memori.
+func (s *auditLogService) archiveChunk(ctx context.Context, afterID uint, lastID uint) (*models.AuditArchive, error) {
+	writer, err := s.newArchiveWriter()
+	if err != nil {
+		return nil, err
+	}
+	for writer.archive.EntryCount < auditArchiveChunkSize && afterID < lastID {
+		limit := min(auditVerifyBatchSize, auditArchiveChunkSize-writer.archive.EntryCount)
+		batch, err := s.repo.FindBatchAfter(ctx, afterID, limit)
+		if err != nil {
+			writer.abort()
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for i := range batch {
+			if batch[i].ID > lastID {
+				afterID = lastID
+				break
+			}
+			if err := writer.write(auditLogToRecord(&batch[i])); err != nil {
+				writer.abort()
+				return nil, err
+			}
+			afterID = batch[i].ID
+		}
+	}
+	if writer.archive.EntryCount == 0 {
+		writer.abort()
+		return nil, nil
+	}
+
+	archive, err := writer.finish()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ArchiveUpTo(ctx, archive.LastID, archive); err != nil {
+		os.Remove(filepath.Join(s.storageDir, auditArchiveSubdir, archive.FileName))
+		return nil, fmt.Errorf("gagal mencatat arsip log audit: %w", err)
+	}
+	return archive, nil
+}
+
+// auditArchiveWriter menulis entri ke berkas JSON Lines terkompresi gzip. Berkas ditulis ke file
+// sementara lalu di-rename secara atomik setelah ID entri pertama dan terakhir diketahui.
+type auditArchiveWriter struct {
+	dir     string
+	file    *os.File
+	digest  hash.Hash
+	gz      *gzip.Writer
+	encoder *json.Encoder
+	archive models.AuditArchive
+}
+
+func (s *auditLogService) newArchiveWriter() (*auditArchiveWriter, error) {
+	archiveDir := filepath.Join(s.storageDir, auditArchiveSubdir)
+	if err := os.MkdirAll(archiveDir, 0755); err != nil {
+		return nil, fmt.Errorf("gagal membuat direktori arsip di '%s': %w", archiveDir, err)
+	}
+	file, err := os.CreateTemp(archiveDir, "audit-archive-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat berkas arsip: %w", err)
+	}
+	w := &auditArchiveWriter{dir: archiveDir, file: file, digest: sha256.New()}
+	w.gz = gzip.NewWriter(io.MultiWriter(file, w.digest))
+	w.encoder = json.NewEncoder(w.gz)
+	return w, nil
+}
+
+func (w *auditArchiveWriter) write(record dto.AuditExportRecord) error {
+	if err := w.encoder.Encode(record); err != nil {
+		return err
+	}
+	a := &w.archive
+	if a.EntryCount == 0 {
+		a.FirstID = record.ID
+		a.FirstTimestamp, a.LastTimestamp = new(time.Time), new(time.Time)
+		*a.FirstTimestamp, *a.LastTimestamp = record.Timestamp, record.Timestamp
+	}
+	a.LastID = record.ID
+	a.LastHash = record.Hash
+	a.EntryCount++
+	if record.Timestamp.Before(*a.FirstTimestamp) {
+		*a.FirstTimestamp = record.Timestamp
+	}
+	if record.Timestamp.After(*a.LastTimestamp) {
+		*a.LastTimestamp = record.Timestamp
+	}
+	return nil
+}
+
+func (w *auditArchiveWriter) abort() {
+	w.file.Close()
+	os.Remove(w.file.Name())
+}
+
+// finish menutup berkas, memberi nama akhir sesuai rentang ID, dan mengembalikan catatan arsip.
+func (w *auditArchiveWriter) finish() (*models.AuditArchive, error) {
+	if err := w.gz.Close(); err != nil {
+		w.abort()
+		return nil, err
+	}
+	if err := w.file.Sync(); err != nil {
+		w.abort()
+		return nil, err
+	}
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return nil, err
+	}
+
+	archive := w.archive
+	archive.FileName = fmt.Sprintf("audit-archive-%d-%d.jsonl.gz", archive.FirstID, archive.LastID)
+	archive.FileSHA256 = hex.EncodeToString(w.digest.Sum(nil))
+	if err := os.Rename(w.file.Name(), filepath.Join(w.dir, archive.FileName)); err != nil {
+		os.Remove(w.file.Name())
+		return nil, fmt.Errorf("gagal memfinalisasi berkas arsip: %w", err)
+	}
+	return &archive, nil
+}
+
+// forEachArchiveRecord memanggil fn untuk setiap entri berkas arsip setelah memastikan SHA256
+// berkas sesuai catatan di database. Berkas dibaca bertahap agar arsip besar tidak dimuat
+// seluruhnya ke memori.
+func (s *auditLogService) forEachArchiveRecord(archive models.AuditArchive, fn func(record dto.AuditExportRecord) error) error {
+	path := filepath.Join(s.storageDir, auditArchiveSubdir, archive.FileName)
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	digest := sha256.New()
+	if _, err := io.Copy(digest, file); err != nil {
+		return err
+	}
+	if hex.EncodeToString(digest.Sum(nil)) != archive.FileSHA256 {
+		return errors.New("SHA256 berkas tidak cocok dengan catatan arsip")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+
+	gz, err := gzip.NewReader(bufio.NewReader(file))
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	entries := 0
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record dto.AuditExportRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return err
+		}
+		entries++
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if entries != archive.EntryCount {
+		return fmt.Errorf("jumlah entri %d tidak sesuai catatan arsip (%d)", entries, archive.EntryCount)
+	}
+	return nil
+}
+
+func auditLogToRecord(entry *models.AuditLog) dto.AuditExportRecord {
+	return dto.AuditExportRecord{
+		ID:          entry.ID,
+		Timestamp:   entry.Timestamp,
+		UserID:      entry.UserID,
+		NRP:         entry.User.NRP,
+		NamaLengkap: entry.User.NamaLengkap,
+		Aksi:        entry.Aksi,
+		Detail:      entry.Detail,
+		PrevHash:    entry.PrevHash,
+		Hash:        entry.Hash,
+		RequestID:   entry.RequestID,
+	}
+}
+
+func recordToAuditLog(record dto.AuditExportRecord) *models.AuditLog {
+	return &models.AuditLog{
+		ID:        record.ID,
+		UserID:    record.UserID,
+		Aksi:      record.Aksi,
+		Detail:    record.Detail,
+		Timestamp: record.Timestamp,
+		PrevHash:  record.PrevHash,
+		Hash:      record.Hash,
+		RequestID: record.RequestID,
+	}
+}
@@ -1,37 +1,415 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
 	"simdokpol/internal/models"
+	"simdokpol/internal/reqctx"
 	"simdokpol/internal/repositories"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"gorm.io/gorm"
 )
 
+const (
+	// auditVerifyBatchSize adalah jumlah entri yang dibaca per langkah saat menelusuri rantai.
+	auditVerifyBatchSize = 500
+
+	// auditQueueSize adalah kapasitas antrean tulis log audit. Jika penuh,
+	// LogActivity menulis entri langsung (backpressure) alih-alih membuang entri.
+	auditQueueSize = 256
+
+	// auditMaxAttempts dan auditRetryBaseDelay mengatur percobaan ulang saat
+	// penulisan gagal, misalnya karena database SQLite sedang terkunci.
+	auditMaxAttempts    = 5
+	auditRetryBaseDelay = 100 * time.Millisecond
+
+	// Subdirektori di bawah storageDir untuk file jangkar dan berkas arsip retensi.
+	auditAnchorSubdir  = "anchors"
+	auditArchiveSubdir = "archive"
+
+	// auditLegacyAnchorDir adalah direktori jangkar versi lama, bersebelahan dengan storageDir.
+	// Jangkar di sana tetap dibaca agar rantai yang dijangkarkan sebelum pindah lokasi tetap diperiksa.
+	auditLegacyAnchorDir = "audit-anchors"
+)
+
+// auditArchiveChunkSize adalah jumlah entri maksimal per berkas arsip retensi, sehingga retensi
+// pertama kali pada tabel besar berjalan bertahap dengan memori terbatas.
+var auditArchiveChunkSize = 10000
+
+// ErrAuditQueueTimeout dikembalikan oleh Close jika antrean log audit tidak
+// selesai dikosongkan sebelum batas waktu.
+var ErrAuditQueueTimeout = errors.New("batas waktu pengosongan antrean log audit terlampaui")
+
+// errAuditChainBroken menghentikan pembacaan berkas arsip saat rantai hash sudah dinyatakan putus.
+var errAuditChainBroken = errors.New("rantai hash log audit putus")
+
 type AuditLogService interface {
-	LogActivity(userID uint, action string, details string)
-	FindAll() ([]models.AuditLog, error)
+	// LogActivity memasukkan entri ke antrean tulis berurutan dan langsung kembali.
+	// ID request dari ctx ikut disimpan pada entri.
+	LogActivity(ctx context.Context, userID uint, action string, details string)
+	// LogActivityTx menulis entri di dalam transaksi pemanggil sehingga entri
+	// ikut di-commit atau di-rollback bersama perubahan data yang dicatatnya.
+	LogActivityTx(ctx context.Context, tx *gorm.DB, userID uint, action string, details string) error
+	FindAll(ctx context.Context) ([]models.AuditLog, error)
+	VerifyChain(ctx context.Context) (*dto.AuditChainReport, error)
+	ExportAnchor(ctx context.Context) (string, error)
+	SealLegacyEntries(ctx context.Context) (int, error)
+	// Export menulis log audit dalam rentang waktu ke w sebagai CSV atau JSON Lines bertanda tangan.
+	Export(ctx context.Context, start time.Time, end time.Time, format string, w io.Writer) error
+	// SigningKey mengembalikan kunci publik penanda tangan ekspor JSON Lines. Kunci ini
+	// dipublikasikan terpisah dari berkas ekspor agar pemeriksa dapat menyimpannya (pin).
+	SigningKey() (*dto.AuditSigningKey, error)
+	// ApplyRetention memindahkan entri yang lebih tua dari retentionMonths bulan ke berkas arsip
+	// terkompresi, paling banyak auditArchiveChunkSize entri per berkas. Mengembalikan arsip yang
+	// berhasil dibuat, termasuk saat terjadi kesalahan di tengah proses.
+	ApplyRetention(ctx context.Context, retentionMonths int) ([]models.AuditArchive, error)
+	// Close menghentikan antrean dan menunggu semua entri tertunda ditulis.
+	Close(timeout time.Duration) error
+	// FailureCount mengembalikan jumlah entri yang gagal ditulis setelah semua percobaan ulang.
+	FailureCount() int64
 }
 
 type auditLogService struct {
-	repo repositories.AuditLogRepository
+	repo       repositories.AuditLogRepository
+	storageDir string
+
+	// mu melindungi closed agar tidak ada pengiriman ke antrean yang sudah ditutup.
+	// Pengiriman di bawah mu tidak pernah menunggu, sehingga Close tidak tertahan
+	// oleh pemanggil LogActivity saat antrean penuh.
+	mu       sync.RWMutex
+	closed   bool
+	queue    chan *models.AuditLog
+	done     chan struct{}
+	failures atomic.Int64
 }
 
-func NewAuditLogService(repo repositories.AuditLogRepository) AuditLogService {
-	return &auditLogService{repo: repo}
+// NewAuditLogService membuat AuditLogService dan menjalankan worker antrean tulisnya.
+// storageDir adalah direktori tempat file jangkar (checkpoint), berkas arsip retensi,
+// dan kunci penandatangan ekspor disimpan.
+func NewAuditLogService(repo repositories.AuditLogRepository, storageDir string) AuditLogService {
+	s := &auditLogService{
+		repo:       repo,
+		storageDir: storageDir,
+		queue:      make(chan *models.AuditLog, auditQueueSize),
+		done:       make(chan struct{}),
+	}
+	go s.runWriter()
+	return s
 }
 
-// LogActivity berjalan sebagai goroutine agar tidak memblokir proses utama.
-func (s *auditLogService) LogActivity(userID uint, action string, details string) {
-	go func() {
-		logEntry := &models.AuditLog{
-			UserID:    userID,
-			Aksi:      action,
-			Detail:    details,
-			Timestamp: time.Now(),
+func (s *auditLogService) LogActivity(ctx context.Context, userID uint, action string, details string) {
+	entry := newAuditEntry(ctx, userID, action, details)
+
+	s.mu.RLock()
+	queued := false
+	if !s.closed {
+		select {
+		case s.queue <- entry:
+			queued = true
+		default:
 		}
-		_ = s.repo.Create(logEntry)
-	}()
+	}
+	s.mu.RUnlock()
+
+	// Antrean penuh atau sudah ditutup (saat shutdown): tulis langsung agar entri tidak hilang.
+	if !queued {
+		s.writeWithRetry(entry)
+	}
+}
+
+func (s *auditLogService) LogActivityTx(ctx context.Context, tx *gorm.DB, userID uint, action string, details string) error {
+	if err := s.repo.Create(ctx, tx, newAuditEntry(ctx, userID, action, details)); err != nil {
+		return fmt.Errorf("gagal menulis log audit: %w", err)
+	}
+	return nil
+}
+
+func (s *auditLogService) Close(timeout time.Duration) error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.queue)
+	}
+	s.mu.Unlock()
+
+	select {
+	case <-s.done:
+		return nil
+	case <-time.After(timeout):
+		return ErrAuditQueueTimeout
+	}
 }
 
-func (s *auditLogService) FindAll() ([]models.AuditLog, error) {
-	return s.repo.FindAll()
-}
\ No newline at end of file
+func (s *auditLogService) FailureCount() int64 {
+	return s.failures.Load()
+}
+
+// runWriter adalah satu-satunya penulis antrean, sehingga entri tersimpan sesuai urutan masuk.
+func (s *auditLogService) runWriter() {
+	defer close(s.done)
+	for entry := range s.queue {
+		s.writeWithRetry(entry)
+	}
+}
+
+// writeWithRetry tidak memakai context request karena worker menulis setelah request selesai;
+// ID request sudah tersimpan di entri.
+func (s *auditLogService) writeWithRetry(entry *models.AuditLog) {
+	ctx := context.Background()
+	var err error
+	for attempt := 0; attempt < auditMaxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(auditRetryBaseDelay << (attempt - 1))
+		}
+		if err = s.repo.Create(ctx, nil, entry); err == nil {
+			return
+		}
+	}
+	s.failures.Add(1)
+	slog.ErrorContext(reqctx.WithRequestID(ctx, entry.RequestID), "Gagal menulis log audit setelah semua percobaan",
+		"attempts", auditMaxAttempts, "aksi", entry.Aksi, "user_id", entry.UserID, "error", err)
+}
+
+func newAuditEntry(ctx context.Context, userID uint, action string, details string) *models.AuditLog {
+	return &models.AuditLog{
+		UserID:    userID,
+		Aksi:      action,
+		Detail:    details,
+		Timestamp: time.Now(),
+		RequestID: reqctx.RequestID(ctx),
+	}
+}
+
+func (s *auditLogService) FindAll(ctx context.Context) ([]models.AuditLog, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// VerifyChain menelusuri seluruh rantai dari entri tertua di berkas arsip hingga entri
+// terbaru di database, melaporkan mata rantai pertama yang rusak, dan mencocokkan
+// rantai dengan semua file jangkar yang tersimpan.
+func (s *auditLogService) VerifyChain(ctx context.Context) (*dto.AuditChainReport, error) {
+	report := &dto.AuditChainReport{Valid: true, CheckedAt: time.Now()}
+
+	anchors, err := s.loadAnchors()
+	if err != nil {
+		return nil, err
+	}
+	walker := &auditChainWalker{report: report, anchors: make(map[uint]dto.AuditAnchor, len(anchors))}
+	for _, anchor := range anchors {
+		walker.anchors[anchor.LastID] = anchor
+	}
+
+	archives, err := s.repo.FindArchives(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, archive := range archives {
+		err := s.forEachArchiveRecord(archive, func(record dto.AuditExportRecord) error {
+			if !walker.check(recordToAuditLog(record)) {
+				return errAuditChainBroken
+			}
+			return nil
+		})
+		if errors.Is(err, errAuditChainBroken) {
+			return report, nil
+		}
+		if err != nil {
+			markChainBroken(report, archive.FirstID, fmt.Sprintf("berkas arsip %s tidak dapat diverifikasi: %v", archive.FileName, err))
+			return report, nil
+		}
+		report.ArchivesChecked++
+	}
+
+	var afterID uint
+	for {
+		batch, err := s.repo.FindBatchAfter(ctx, afterID, auditVerifyBatchSize)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for i := range batch {
+			if !walker.check(&batch[i]) {
+				return report, nil
+			}
+			afterID = batch[i].ID
+		}
+	}
+
+	// Jangkar yang tidak pernah dijumpai berarti entri yang dirujuknya sudah hilang
+	for _, anchor := range anchors {
+		if _, pending := walker.anchors[anchor.LastID]; !pending {
+			continue
+		}
+		markChainBroken(report, anchor.LastID, fmt.Sprintf("entri ID %d tercatat di jangkar %s tetapi tidak ditemukan", anchor.LastID, anchor.CreatedAt.Format(time.RFC3339)))
+		return report, nil
+	}
+
+	return report, nil
+}
+
+// auditChainWalker memeriksa entri satu per satu sesuai urutan rantai.
+type auditChainWalker struct {
+	report   *dto.AuditChainReport
+	anchors  map[uint]dto.AuditAnchor
+	prevHash string
+}
+
+func (w *auditChainWalker) check(entry *models.AuditLog) bool {
+	if entry.PrevHash != w.prevHash {
+		markChainBroken(w.report, entry.ID, "prev_hash tidak cocok dengan hash entri sebelumnya (entri dihapus atau disisipkan)")
+		return false
+	}
+	if entry.ComputeHash() != entry.Hash {
+		markChainBroken(w.report, entry.ID, "isi entri tidak cocok dengan hash yang tersimpan (entri telah diubah)")
+		return false
+	}
+	if anchor, ok := w.anchors[entry.ID]; ok {
+		if anchor.LastHash != entry.Hash {
+			markChainBroken(w.report, entry.ID, fmt.Sprintf("hash entri tidak cocok dengan jangkar %s (rantai ditulis ulang)", anchor.CreatedAt.Format(time.RFC3339)))
+			return false
+		}
+		delete(w.anchors, entry.ID)
+		w.report.AnchorsChecked++
+	}
+
+	w.prevHash = entry.Hash
+	w.report.EntriesChecked++
+	w.report.LastID = entry.ID
+	w.report.LastHash = entry.Hash
+	return true
+}
+
+// markChainBroken menandai laporan verifikasi sebagai rusak pada entri tertentu.
+func markChainBroken(report *dto.AuditChainReport, id uint, reason string) {
+	report.Valid = false
+	report.BrokenAtID = id
+	report.Reason = reason
+}
+
+// ExportAnchor menulis hash entri terakhir ke file jangkar baru dan mengembalikan path-nya.
+func (s *auditLogService) ExportAnchor(ctx context.Context) (string, error) {
+	last, err := s.repo.FindLast(ctx)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return "", errors.New("log audit masih kosong, tidak ada yang perlu dijangkarkan")
+		}
+		return "", err
+	}
+	count, err := s.repo.Count(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	anchorDir := filepath.Join(s.storageDir, auditAnchorSubdir)
+	if err := os.MkdirAll(anchorDir, 0755); err != nil {
+		return "", fmt.Errorf("gagal membuat direktori jangkar di '%s': %w", anchorDir, err)
+	}
+
+	anchor := dto.AuditAnchor{
+		LastID:     last.ID,
+		LastHash:   last.Hash,
+		EntryCount: count,
+		CreatedAt:  time.Now(),
+	}
+	content, err := json.MarshalIndent(anchor, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	anchorPath := filepath.Join(anchorDir, fmt.Sprintf("audit-anchor-%s.json", anchor.CreatedAt.Format("20060102-150405")))
+	if err := os.WriteFile(anchorPath, content, 0644); err != nil {
+		return "", fmt.Errorf("gagal menulis file jangkar: %w", err)
+	}
+	return anchorPath, nil
+}
+
+// SealLegacyEntries menghitung hash untuk entri lama yang dibuat sebelum rantai hash diperkenalkan.
+func (s *auditLogService) SealLegacyEntries(ctx context.Context) (int, error) {
+	archives, err := s.repo.FindArchives(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	sealed := 0
+	var afterID uint
+	prevHash := ""
+	if len(archives) > 0 {
+		prevHash = archives[len(archives)-1].LastHash
+	}
+	for {
+		batch, err := s.repo.FindBatchAfter(ctx, afterID, auditVerifyBatchSize)
+		if err != nil {
+			return sealed, err
+		}
+		if len(batch) == 0 {
+			return sealed, nil
+		}
+
+		for i := range batch {
+			entry := &batch[i]
+			if entry.Hash == "" {
+				entry.PrevHash = prevHash
+				entry.Hash = entry.ComputeHash()
+				if err := s.repo.UpdateHash(ctx, entry.ID, entry.PrevHash, entry.Hash); err != nil {
+					return sealed, err
+				}
+				sealed++
+			}
+			prevHash = entry.Hash
+			afterID = entry.ID
+		}
+	}
+}
+
+// loadAnchors membaca file jangkar dari direktori saat ini dan direktori versi lama.
+// File dengan nama yang sama di kedua lokasi hanya dibaca sekali.
+func (s *auditLogService) loadAnchors() ([]dto.AuditAnchor, error) {
+	anchorDirs := []string{
+		filepath.Join(s.storageDir, auditAnchorSubdir),
+		filepath.Join(filepath.Dir(s.storageDir), auditLegacyAnchorDir),
+	}
+
+	var anchors []dto.AuditAnchor
+	seen := map[string]bool{}
+	for _, anchorDir := range anchorDirs {
+		entries, err := os.ReadDir(anchorDir)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("gagal membaca direktori jangkar: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.IsDir() || !strings.HasPrefix(entry.Name(), "audit-anchor-") || filepath.Ext(entry.Name()) != ".json" || seen[entry.Name()] {
+				continue
+			}
+			seen[entry.Name()] = true
+			content, err := os.ReadFile(filepath.Join(anchorDir, entry.Name()))
+			if err != nil {
+				return nil, fmt.Errorf("gagal membaca file jangkar %s: %w", entry.Name(), err)
+			}
+			var anchor dto.AuditAnchor
+			if err := json.Unmarshal(content, &anchor); err != nil {
+				return nil, fmt.Errorf("file jangkar %s tidak valid: %w", entry.Name(), err)
+			}
+			anchors = append(anchors, anchor)
+		}
+	}
+	sort.Slice(anchors, func(i, j int) bool { return anchors[i].LastID < anchors[j].LastID })
+	return anchors, nil
+}
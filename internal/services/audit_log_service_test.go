@@ -0,0 +1,377 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"simdokpol/internal/reqctx"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+// buildAuditChain membuat rantai entri log audit yang valid untuk keperluan pengujian.
+func buildAuditChain(n int) []models.AuditLog {
+	chain := make([]models.AuditLog, 0, n)
+	prevHash := ""
+	base := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	for i := 0; i < n; i++ {
+		entry := models.AuditLog{
+			ID:        uint(i + 1),
+			UserID:    1,
+			Aksi:      models.AuditCreateDocument,
+			Detail:    "Membuat surat keterangan hilang baru",
+			Timestamp: base.Add(time.Duration(i) * time.Minute),
+			PrevHash:  prevHash,
+		}
+		entry.Hash = entry.ComputeHash()
+		prevHash = entry.Hash
+		chain = append(chain, entry)
+	}
+	return chain
+}
+
+func TestAuditLogService_VerifyChain(t *testing.T) {
+	testCases := []struct {
+		name           string
+		tamper         func(chain []models.AuditLog) []models.AuditLog
+		expectValid    bool
+		expectBrokenAt uint
+	}{
+		{
+			name:        "Rantai Utuh",
+			tamper:      func(chain []models.AuditLog) []models.AuditLog { return chain },
+			expectValid: true,
+		},
+		{
+			name: "Detail Entri Diubah",
+			tamper: func(chain []models.AuditLog) []models.AuditLog {
+				chain[1].Detail = "Detail yang dimanipulasi"
+				return chain
+			},
+			expectValid:    false,
+			expectBrokenAt: 2,
+		},
+		{
+			name: "Entri di Tengah Dihapus",
+			tamper: func(chain []models.AuditLog) []models.AuditLog {
+				return append(chain[:1], chain[2:]...)
+			},
+			expectValid:    false,
+			expectBrokenAt: 3,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			chain := tc.tamper(buildAuditChain(3))
+
+			mockRepo := new(mocks.AuditLogRepository)
+			mockRepo.On("FindArchives", mock.Anything).Return([]models.AuditArchive{}, nil).Once()
+			mockRepo.On("FindBatchAfter", mock.Anything, uint(0), auditVerifyBatchSize).Return(chain, nil).Once()
+			if tc.expectValid {
+				mockRepo.On("FindBatchAfter", mock.Anything, chain[len(chain)-1].ID, auditVerifyBatchSize).Return([]models.AuditLog{}, nil).Once()
+			}
+
+			service := NewAuditLogService(mockRepo, t.TempDir())
+			report, err := service.VerifyChain(context.Background())
+
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectValid, report.Valid)
+			assert.Equal(t, tc.expectBrokenAt, report.BrokenAtID)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuditLogService_VerifyChainLegacyAnchors(t *testing.T) {
+	chain := buildAuditChain(3)
+	root := t.TempDir()
+	// Jangkar versi lama disimpan di <exeDir>/audit-anchors, bukan <exeDir>/audit/anchors
+	legacyDir := filepath.Join(root, auditLegacyAnchorDir)
+	assert.NoError(t, os.MkdirAll(legacyDir, 0755))
+	content, _ := json.Marshal(dto.AuditAnchor{LastID: 2, LastHash: "hash-sebelum-ditulis-ulang", EntryCount: 2, CreatedAt: time.Now()})
+	assert.NoError(t, os.WriteFile(filepath.Join(legacyDir, "audit-anchor-20250101-080000.json"), content, 0644))
+
+	mockRepo := new(mocks.AuditLogRepository)
+	mockRepo.On("FindArchives", mock.Anything).Return([]models.AuditArchive{}, nil).Once()
+	mockRepo.On("FindBatchAfter", mock.Anything, uint(0), auditVerifyBatchSize).Return(chain, nil).Once()
+
+	report, err := NewAuditLogService(mockRepo, filepath.Join(root, "audit")).VerifyChain(context.Background())
+
+	assert.NoError(t, err)
+	assert.False(t, report.Valid)
+	assert.Equal(t, uint(2), report.BrokenAtID)
+}
+
+func TestAuditLogService_ApplyRetention(t *testing.T) {
+	chain := buildAuditChain(3)
+	storageDir := t.TempDir()
+
+	mockRepo := new(mocks.AuditLogRepository)
+	mockRepo.On("FindLastIDBefore", mock.Anything, mock.AnythingOfType("time.Time")).Return(uint(2), nil).Once()
+	mockRepo.On("FindBatchAfter", mock.Anything, uint(0), auditVerifyBatchSize).Return(chain, nil).Once()
+	mockRepo.On("ArchiveUpTo", mock.Anything, uint(2), mock.AnythingOfType("*models.AuditArchive")).Return(nil).Once()
+
+	service := NewAuditLogService(mockRepo, storageDir)
+	archives, err := service.ApplyRetention(context.Background(), 1)
+
+	assert.NoError(t, err)
+	if !assert.Len(t, archives, 1) {
+		return
+	}
+	archive := archives[0]
+	assert.Equal(t, uint(1), archive.FirstID)
+	assert.Equal(t, uint(2), archive.LastID)
+	assert.Equal(t, chain[1].Hash, archive.LastHash)
+	assert.Equal(t, 2, archive.EntryCount)
+	mockRepo.AssertExpectations(t)
+
+	// Rantai harus tetap terverifikasi utuh melintasi berkas arsip dan sisa entri di database.
+	verifyRepo := new(mocks.AuditLogRepository)
+	verifyRepo.On("FindArchives", mock.Anything).Return(archives, nil).Once()
+	verifyRepo.On("FindBatchAfter", mock.Anything, uint(0), auditVerifyBatchSize).Return(chain[2:], nil).Once()
+	verifyRepo.On("FindBatchAfter", mock.Anything, uint(3), auditVerifyBatchSize).Return([]models.AuditLog{}, nil).Once()
+
+	report, err := NewAuditLogService(verifyRepo, storageDir).VerifyChain(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	assert.Equal(t, 1, report.ArchivesChecked)
+	assert.Equal(t, 3, report.EntriesChecked)
+	verifyRepo.AssertExpectations(t)
+}
+
+func TestAuditLogService_ApplyRetentionChunks(t *testing.T) {
+	previous := auditArchiveChunkSize
+	auditArchiveChunkSize = 2
+	defer func() { auditArchiveChunkSize = previous }()
+
+	chain := buildAuditChain(5)
+	storageDir := t.TempDir()
+
+	// Entri diarsipkan per berkas berisi paling banyak dua entri hingga entri ID 5
+	mockRepo := new(mocks.AuditLogRepository)
+	mockRepo.On("FindLastIDBefore", mock.Anything, mock.AnythingOfType("time.Time")).Return(uint(5), nil).Once()
+	mockRepo.On("FindBatchAfter", mock.Anything, uint(0), 2).Return(chain[0:2], nil).Once()
+	mockRepo.On("FindBatchAfter", mock.Anything, uint(2), 2).Return(chain[2:4], nil).Once()
+	mockRepo.On("FindBatchAfter", mock.Anything, uint(4), 2).Return(chain[4:], nil).Once()
+	mockRepo.On("ArchiveUpTo", mock.Anything, mock.AnythingOfType("uint"), mock.AnythingOfType("*models.AuditArchive")).Return(nil).Times(3)
+
+	archives, err := NewAuditLogService(mockRepo, storageDir).ApplyRetention(context.Background(), 1)
+
+	assert.NoError(t, err)
+	if !assert.Len(t, archives, 3) {
+		return
+	}
+	assert.Equal(t, []uint{2, 4, 5}, []uint{archives[0].LastID, archives[1].LastID, archives[2].LastID})
+	assert.Equal(t, 1, archives[2].EntryCount)
+	mockRepo.AssertExpectations(t)
+
+	files, _ := filepath.Glob(filepath.Join(storageDir, auditArchiveSubdir, "*"))
+	assert.Len(t, files, 3, "file sementara tidak boleh tertinggal")
+
+	verifyRepo := new(mocks.AuditLogRepository)
+	verifyRepo.On("FindArchives", mock.Anything).Return(archives, nil).Once()
+	verifyRepo.On("FindBatchAfter", mock.Anything, uint(0), auditVerifyBatchSize).Return([]models.AuditLog{}, nil).Once()
+
+	report, err := NewAuditLogService(verifyRepo, storageDir).VerifyChain(context.Background())
+	assert.NoError(t, err)
+	assert.True(t, report.Valid, report.Reason)
+	assert.Equal(t, 3, report.ArchivesChecked)
+}
+
+func TestAuditLogService_ExportJSONLSignature(t *testing.T) {
+	chain := buildAuditChain(2)
+	mockRepo := new(mocks.AuditLogRepository)
+	mockRepo.On("FindArchives", mock.Anything).Return([]models.AuditArchive{}, nil).Once()
+	mockRepo.On("FindInRange", mock.Anything, mock.Anything, mock.Anything, uint(0), auditVerifyBatchSize).Return(chain, nil).Once()
+	mockRepo.On("FindInRange", mock.Anything, mock.Anything, mock.Anything, uint(2), auditVerifyBatchSize).Return([]models.AuditLog{}, nil).Once()
+
+	service := NewAuditLogService(mockRepo, t.TempDir())
+	var buf bytes.Buffer
+	assert.NoError(t, service.Export(context.Background(), time.Time{}, time.Now(), AuditExportJSONL, &buf))
+
+	content := buf.String()
+	split := strings.LastIndex(strings.TrimSuffix(content, "\n"), "\n") + 1
+	body, trailerLine := content[:split], content[split:]
+	// Kunci publik tidak boleh ada di berkas agar berkas yang diubah tidak bisa ditandatangani ulang
+	assert.NotContains(t, trailerLine, "public_key")
+
+	var trailer dto.AuditExportSignature
+	assert.NoError(t, json.Unmarshal([]byte(trailerLine), &trailer))
+	assert.Equal(t, 2, trailer.Entries)
+
+	key, err := service.SigningKey()
+	assert.NoError(t, err)
+	publicKey, _ := base64.StdEncoding.DecodeString(key.PublicKey)
+	signature, _ := base64.StdEncoding.DecodeString(trailer.Signature)
+	sum := sha256.Sum256([]byte(body))
+	assert.Equal(t, hex.EncodeToString(sum[:]), trailer.SHA256)
+	assert.True(t, ed25519.Verify(publicKey, sum[:], signature))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAuditLogService_ExportAfterRetention(t *testing.T) {
+	chain := buildAuditChain(3)
+	storageDir := t.TempDir()
+
+	retentionRepo := new(mocks.AuditLogRepository)
+	retentionRepo.On("FindLastIDBefore", mock.Anything, mock.AnythingOfType("time.Time")).Return(uint(2), nil).Once()
+	retentionRepo.On("FindBatchAfter", mock.Anything, uint(0), auditVerifyBatchSize).Return(chain, nil).Once()
+	retentionRepo.On("ArchiveUpTo", mock.Anything, uint(2), mock.AnythingOfType("*models.AuditArchive")).Return(nil).Once()
+	archives, err := NewAuditLogService(retentionRepo, storageDir).ApplyRetention(context.Background(), 1)
+	assert.NoError(t, err)
+	if !assert.Len(t, archives, 1) {
+		return
+	}
+	archive := archives[0]
+	assert.True(t, archive.FirstTimestamp.Equal(chain[0].Timestamp))
+	assert.True(t, archive.LastTimestamp.Equal(chain[1].Timestamp))
+
+	testCases := []struct {
+		name        string
+		start       time.Time
+		end         time.Time
+		expectedIDs []uint
+	}{
+		// Entri yang sudah diarsipkan tetap ikut diekspor sebelum entri di database
+		{name: "Rentang Mencakup Arsip dan Database", start: chain[0].Timestamp, end: chain[2].Timestamp, expectedIDs: []uint{1, 2, 3}},
+		{name: "Rentang Sebagian Arsip", start: chain[1].Timestamp, end: chain[1].Timestamp, expectedIDs: []uint{2}},
+		{name: "Rentang Setelah Arsip", start: chain[2].Timestamp, end: chain[2].Timestamp.Add(time.Hour), expectedIDs: []uint{3}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var live []models.AuditLog
+			if !chain[2].Timestamp.Before(tc.start) && !chain[2].Timestamp.After(tc.end) {
+				live = chain[2:]
+			}
+			mockRepo := new(mocks.AuditLogRepository)
+			mockRepo.On("FindArchives", mock.Anything).Return(archives, nil).Once()
+			mockRepo.On("FindInRange", mock.Anything, tc.start, tc.end, uint(0), auditVerifyBatchSize).Return(live, nil).Once()
+			if len(live) > 0 {
+				mockRepo.On("FindInRange", mock.Anything, tc.start, tc.end, uint(3), auditVerifyBatchSize).Return([]models.AuditLog{}, nil).Once()
+			}
+
+			var buf bytes.Buffer
+			assert.NoError(t, NewAuditLogService(mockRepo, storageDir).Export(context.Background(), tc.start, tc.end, AuditExportJSONL, &buf))
+
+			lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+			var ids []uint
+			for _, line := range lines[:len(lines)-1] {
+				var record dto.AuditExportRecord
+				assert.NoError(t, json.Unmarshal([]byte(line), &record))
+				ids = append(ids, record.ID)
+			}
+			assert.Equal(t, tc.expectedIDs, ids)
+
+			var trailer dto.AuditExportSignature
+			assert.NoError(t, json.Unmarshal([]byte(lines[len(lines)-1]), &trailer))
+			assert.Equal(t, len(tc.expectedIDs), trailer.Entries)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+
+	t.Run("Arsip Rusak Menggagalkan Ekspor", func(t *testing.T) {
+		tampered := archive
+		tampered.FileSHA256 = strings.Repeat("0", 64)
+		mockRepo := new(mocks.AuditLogRepository)
+		mockRepo.On("FindArchives", mock.Anything).Return([]models.AuditArchive{tampered}, nil).Once()
+
+		var buf bytes.Buffer
+		err := NewAuditLogService(mockRepo, storageDir).Export(context.Background(), chain[0].Timestamp, chain[2].Timestamp, AuditExportJSONL, &buf)
+		assert.Error(t, err)
+		mockRepo.AssertNotCalled(t, "FindInRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestAuditLogService_CloseWithFullQueue(t *testing.T) {
+	mockRepo := new(mocks.AuditLogRepository)
+
+	// Penulis tertahan (misalnya database terkunci) sampai release ditutup.
+	release := make(chan struct{})
+	entered := make(chan struct{}, auditQueueSize+2)
+	var mu sync.Mutex
+	written := 0
+	mockRepo.On("Create", mock.Anything, (*gorm.DB)(nil), mock.AnythingOfType("*models.AuditLog")).
+		Run(func(args mock.Arguments) {
+			entered <- struct{}{}
+			<-release
+			mu.Lock()
+			written++
+			mu.Unlock()
+		}).
+		Return(nil)
+
+	service := NewAuditLogService(mockRepo, t.TempDir())
+	ctx := context.Background()
+	// Satu entri diambil worker, sisanya memenuhi antrean.
+	service.LogActivity(ctx, 1, models.AuditUpdateUser, "antre")
+	<-entered
+	for i := 0; i < auditQueueSize; i++ {
+		service.LogActivity(ctx, 1, models.AuditUpdateUser, "antre")
+	}
+	// Antrean penuh: entri berikutnya ditulis langsung oleh pemanggil dan ikut tertahan.
+	go service.LogActivity(ctx, 1, models.AuditUpdateUser, "langsung")
+	<-entered
+
+	closed := make(chan error, 1)
+	go func() { closed <- service.Close(100 * time.Millisecond) }()
+	select {
+	case err := <-closed:
+		assert.ErrorIs(t, err, ErrAuditQueueTimeout)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close tidak boleh tertahan oleh LogActivity saat antrean penuh")
+	}
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return written == auditQueueSize+2
+	}, 2*time.Second, 10*time.Millisecond, "Tidak ada entri yang boleh hilang")
+}
+
+func TestAuditLogService_LogActivityQueue(t *testing.T) {
+	mockRepo := new(mocks.AuditLogRepository)
+
+	var written []string
+	var requestIDs []string
+	// Entri kedua gagal sekali (misalnya database terkunci) lalu berhasil saat dicoba ulang.
+	mockRepo.On("Create", mock.Anything, (*gorm.DB)(nil), mock.MatchedBy(func(l *models.AuditLog) bool { return l.Detail == "2" })).
+		Return(errors.New("database is locked")).Once()
+	mockRepo.On("Create", mock.Anything, (*gorm.DB)(nil), mock.AnythingOfType("*models.AuditLog")).
+		Run(func(args mock.Arguments) {
+			entry := args.Get(2).(*models.AuditLog)
+			written = append(written, entry.Detail)
+			requestIDs = append(requestIDs, entry.RequestID)
+		}).
+		Return(nil)
+
+	service := NewAuditLogService(mockRepo, t.TempDir())
+	ctx := reqctx.WithRequestID(context.Background(), "req-123")
+	for _, detail := range []string{"1", "2", "3"} {
+		service.LogActivity(ctx, 1, models.AuditUpdateUser, detail)
+	}
+
+	assert.NoError(t, service.Close(5*time.Second))
+	assert.Equal(t, []string{"1", "2", "3"}, written, "Entri harus ditulis sesuai urutan masuk")
+	assert.Equal(t, []string{"req-123", "req-123", "req-123"}, requestIDs, "ID request dari context harus tersimpan di entri audit")
+	assert.Equal(t, int64(0), service.FailureCount())
+}
@@ -1,61 +1,478 @@
 package services
 
 import (
+	"context"
 	"errors"
-	"os"
+	"fmt"
+	"log/slog"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/metrics"
+	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
+	"simdokpol/internal/reqctx"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
-var JWTSecretKey = []byte(os.Getenv("JWT_SECRET_KEY"))
+// Kebijakan penguncian login. Setelah batas percobaan gagal terlampaui, key dikunci
+// selama loginBaseLockout yang berlipat dua untuk setiap kegagalan berikutnya,
+// maksimal loginMaxLockout. Penghitung direset jika tidak ada kegagalan selama loginFailureWindow.
+const (
+	loginMaxFailuresPerNRP = 5
+	loginMaxFailuresPerIP  = 20
+	loginBaseLockout       = time.Minute
+	loginMaxLockout        = time.Hour
+	loginFailureWindow     = 15 * time.Minute
+	// twoFactorChallengeLifetime adalah batas waktu antara langkah kata sandi dan langkah kode 2FA.
+	twoFactorChallengeLifetime = 5 * time.Minute
+	twoFactorChallengePurpose  = "2fa"
+)
+
+// LoginLockedError dikembalikan saat NRP atau alamat IP sedang dikunci
+// karena terlalu banyak percobaan login gagal.
+type LoginLockedError struct {
+	Until time.Time
+}
+
+func (e *LoginLockedError) Error() string {
+	wait := time.Until(e.Until).Round(time.Second)
+	if wait < time.Second {
+		wait = time.Second
+	}
+	return fmt.Sprintf("terlalu banyak percobaan login gagal, coba lagi dalam %s", wait)
+}
+
+func (e *LoginLockedError) Unwrap() error { return ErrLoginLocked }
 
 type AuthService interface {
-	Login(nrp string, password string) (string, error)
+	// Login memverifikasi kredensial, membuat sesi di sisi server, dan mengembalikan token JWT
+	// dengan klaim jti berisi ID sesi. clientIP dipakai untuk penghitung percobaan gagal per IP
+	// dan dicatat di log audit bersama userAgent pada sesi. Jika pengguna memakai atau wajib
+	// memakai 2FA, hasilnya berupa ChallengeToken untuk VerifyTwoFactor, bukan token sesi.
+	Login(ctx context.Context, nrp string, password string, clientIP string, userAgent string) (*dto.LoginResult, error)
+	// VerifyTwoFactor menyelesaikan login dengan kode TOTP atau kode pemulihan. Bagi pengguna
+	// yang belum terdaftar, kode pertama sekaligus mengaktifkan 2FA.
+	VerifyTwoFactor(ctx context.Context, challengeToken string, code string, clientIP string, userAgent string) (*dto.LoginResult, error)
+	// BeginTwoFactorEnrollment menyiapkan QR pendaftaran bagi pengguna yang wajib 2FA
+	// tetapi belum mendaftar, sebelum ia bisa masuk.
+	BeginTwoFactorEnrollment(ctx context.Context, challengeToken string) (*dto.TwoFactorSetup, error)
+	// Logout mencabut sesi yang dirujuk token. Token yang sudah kedaluwarsa tetap diterima.
+	Logout(ctx context.Context, tokenString string) error
+	// FindLockouts mengambil semua NRP dan alamat IP yang sedang terkunci.
+	FindLockouts(ctx context.Context) ([]models.LoginThrottle, error)
+	// UnlockUser menghapus penguncian dan penghitung percobaan gagal milik seorang pengguna.
+	UnlockUser(ctx context.Context, userID uint) error
+	// Unlock menghapus penguncian berdasarkan key, misalnya "ip:192.168.1.10".
+	Unlock(ctx context.Context, key string) error
 }
 
 type authService struct {
-	userRepo repositories.UserRepository
+	userRepo         repositories.UserRepository
+	authenticator    Authenticator
+	throttleRepo     repositories.LoginThrottleRepository
+	sessionService   SessionService
+	twoFactorService TwoFactorService
+	jwtKeys          JWTKeyService
+	auditService     AuditLogService
+	// mu menjadikan pemeriksaan penguncian dan kenaikan penghitung satu langkah, sehingga login
+	// yang bersamaan tidak bisa sama-sama lolos pemeriksaan sebelum penghitung naik
+	mu sync.Mutex
+	// lockedLogged mencatat akhir penguncian yang percobaannya sudah dicatat di log audit, per key
+	// NRP, agar akun yang terkunci tidak membanjiri rantai log audit. Dijaga oleh mu.
+	lockedLogged map[string]time.Time
+}
+
+// loginAttempt adalah percobaan login yang sudah dicatat sebagai gagal sebelum kredensial
+// diverifikasi. lockedUntil berisi penguncian yang dipicu oleh percobaan ini, per key.
+type loginAttempt struct {
+	nrp         string
+	clientIP    string
+	lockedUntil map[string]*time.Time
 }
 
-func NewAuthService(userRepo repositories.UserRepository) AuthService {
-	return &authService{userRepo: userRepo}
+// NewAuthService membuat AuthService. authenticator menentukan cara verifikasi kata sandi,
+// misalnya NewLocalAuthenticator atau NewLDAPAuthenticator.
+func NewAuthService(userRepo repositories.UserRepository, authenticator Authenticator, throttleRepo repositories.LoginThrottleRepository, sessionService SessionService, twoFactorService TwoFactorService, jwtKeys JWTKeyService, auditService AuditLogService) AuthService {
+	return &authService{
+		userRepo:         userRepo,
+		authenticator:    authenticator,
+		throttleRepo:     throttleRepo,
+		sessionService:   sessionService,
+		twoFactorService: twoFactorService,
+		jwtKeys:          jwtKeys,
+		auditService:     auditService,
+		lockedLogged:     make(map[string]time.Time),
+	}
 }
 
-func (s *authService) Login(nrp string, password string) (string, error) {
-	// 1. Cari pengguna berdasarkan NRP, termasuk yang non-aktif
-	user, err := s.userRepo.FindByNRP(nrp)
+func nrpThrottleKey(nrp string) string { return "nrp:" + nrp }
+func ipThrottleKey(ip string) string   { return "ip:" + ip }
+
+func (s *authService) Login(ctx context.Context, nrp string, password string, clientIP string, userAgent string) (*dto.LoginResult, error) {
+	// 1. Tolak lebih awal jika NRP atau IP sedang dikunci, atau catat percobaan ini sebagai gagal
+	attempt, err := s.reserveAttempt(ctx, nrp, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	// 2. Verifikasi kredensial melalui authenticator (lokal atau direktori LDAP)
+	user, err := s.authenticator.Authenticate(ctx, nrp, password)
 	if err != nil {
-		// Jika tidak ditemukan sama sekali, kembalikan error biasa
-		if err == gorm.ErrRecordNotFound {
-			return "", errors.New("NRP atau kata sandi salah")
+		if !errors.Is(err, ErrInvalidCredentials) {
+			s.refundAttempt(ctx, attempt)
+			return nil, err
 		}
-		return "", err
+		if user == nil {
+			// NRP tidak terdaftar tidak memiliki pengguna yang dapat dirujuk log audit
+			slog.WarnContext(ctx, "Login gagal untuk NRP tidak terdaftar", "nrp", nrp, "client_ip", clientIP)
+		} else {
+			s.auditService.LogActivity(ctx, user.ID, models.AuditLoginFailed, fmt.Sprintf("Login gagal dari IP %s: kata sandi salah", clientIP))
+		}
+		return nil, s.failAttempt(ctx, attempt, ErrInvalidCredentials)
 	}
+	s.refundAttempt(ctx, attempt)
 
-	// 2. Periksa apakah akun tersebut non-aktif (soft deleted)
+	// 3. Tolak akun yang non-aktif (soft deleted)
 	if user.DeletedAt.Valid {
-		return "", errors.New("Akun Anda tidak aktif. Silakan hubungi Super Admin")
+		s.auditService.LogActivity(ctx, user.ID, models.AuditLoginFailed, fmt.Sprintf("Login gagal dari IP %s: akun tidak aktif", clientIP))
+		metrics.LoginAttempt(metrics.LoginFailure)
+		return nil, errors.New("Akun Anda tidak aktif. Silakan hubungi Super Admin")
+	}
+
+	// 4. Pengguna dengan 2FA aktif atau wajib 2FA melanjutkan ke langkah kode
+	if user.TOTPEnabled || s.twoFactorService.IsRequired(ctx, user) {
+		challenge, err := s.issueChallenge(ctx, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		return &dto.LoginResult{
+			TwoFactorRequired:  true,
+			EnrollmentRequired: !user.TOTPEnabled,
+			ChallengeToken:     challenge,
+		}, nil
+	}
+
+	token, err := s.completeLogin(ctx, user, clientIP, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.LoginResult{Token: token}, nil
+}
+
+func (s *authService) VerifyTwoFactor(ctx context.Context, challengeToken string, code string, clientIP string, userAgent string) (*dto.LoginResult, error) {
+	user, err := s.parseChallenge(ctx, challengeToken)
+	if err != nil {
+		return nil, err
+	}
+	attempt, err := s.reserveAttempt(ctx, user.NRP, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.LoginResult{}
+	if user.TOTPEnabled {
+		err = s.twoFactorService.Verify(ctx, user, code)
+	} else {
+		result.RecoveryCodes, err = s.twoFactorService.Enable(ctx, user.ID, code)
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			s.auditService.LogActivity(ctx, user.ID, models.AuditLoginFailed, fmt.Sprintf("Login gagal dari IP %s: kode verifikasi dua faktor salah", clientIP))
+			return nil, s.failAttempt(ctx, attempt, err)
+		}
+		s.refundAttempt(ctx, attempt)
+		if errors.Is(err, ErrTwoFactorNotEnabled) {
+			// Pendaftaran belum dimulai atau 2FA baru saja direset
+			return nil, ErrTwoFactorChallengeInvalid
+		}
+		return nil, err
+	}
+	s.refundAttempt(ctx, attempt)
+
+	result.Token, err = s.completeLogin(ctx, user, clientIP, userAgent)
+	if err != nil {
+		return nil, err
 	}
+	return result, nil
+}
 
-	// 3. Jika aktif, lanjutkan verifikasi kata sandi
-	err = bcrypt.CompareHashAndPassword([]byte(user.KataSandi), []byte(password))
+func (s *authService) BeginTwoFactorEnrollment(ctx context.Context, challengeToken string) (*dto.TwoFactorSetup, error) {
+	user, err := s.parseChallenge(ctx, challengeToken)
 	if err != nil {
-		return "", errors.New("NRP atau kata sandi salah")
+		return nil, err
+	}
+	return s.twoFactorService.BeginEnrollment(ctx, user.ID)
+}
+
+// completeLogin mereset penghitung NRP, mencatat login, lalu membuat sesi dan token JWT.
+func (s *authService) completeLogin(ctx context.Context, user *models.User, clientIP string, userAgent string) (string, error) {
+	// Penghitung IP sengaja tidak direset agar satu akun yang valid tidak bisa dipakai
+	// untuk menghapus jejak tebakan terhadap akun lain.
+	if err := s.throttleRepo.Delete(ctx, nrpThrottleKey(user.NRP)); err != nil {
+		slog.WarnContext(ctx, "Gagal mereset penghitung login", "nrp", user.NRP, "error", err)
 	}
+	s.auditService.LogActivity(ctx, user.ID, models.AuditLoginSuccess, fmt.Sprintf("Login berhasil dari IP %s", clientIP))
+	metrics.LoginAttempt(metrics.LoginSuccess)
 
-	// 4. Buat token jika semua verifikasi berhasil
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+	session, err := s.sessionService.Create(ctx, user.ID, clientIP, userAgent)
+	if err != nil {
+		return "", err
+	}
+	return s.jwtKeys.Sign(ctx, jwt.MapClaims{
 		"userID": user.ID,
 		"role":   user.Peran,
-		"exp":    time.Now().Add(time.Hour * 24).Unix(),
+		"jti":    session.ID,
+		"exp":    session.ExpiresAt.Unix(),
+	})
+}
+
+// issueChallenge membuat token berumur pendek yang membuktikan langkah kata sandi sudah lolos.
+// Token ini tidak memiliki jti sehingga ditolak oleh AuthMiddleware.
+func (s *authService) issueChallenge(ctx context.Context, userID uint) (string, error) {
+	return s.jwtKeys.Sign(ctx, jwt.MapClaims{
+		"userID":  userID,
+		"purpose": twoFactorChallengePurpose,
+		"exp":     time.Now().Add(twoFactorChallengeLifetime).Unix(),
 	})
-	tokenString, err := token.SignedString(JWTSecretKey)
+}
+
+func (s *authService) parseChallenge(ctx context.Context, challengeToken string) (*models.User, error) {
+	token, err := s.jwtKeys.Parse(ctx, challengeToken)
 	if err != nil {
-		return "", err
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || claims["purpose"] != twoFactorChallengePurpose {
+		return nil, ErrTwoFactorChallengeInvalid
 	}
-	return tokenString, nil
-}
\ No newline at end of file
+	userID, _ := claims["userID"].(float64)
+	user, err := s.userRepo.FindByID(ctx, uint(userID))
+	if err != nil || user.DeletedAt.Valid {
+		return nil, ErrTwoFactorChallengeInvalid
+	}
+	return user, nil
+}
+
+// reserveAttempt memeriksa penguncian NRP dan IP lalu langsung menaikkan penghitung keduanya
+// dalam satu langkah di bawah mu, sebelum kredensial diverifikasi. Percobaan yang ternyata
+// benar dikembalikan dengan refundAttempt; yang salah diselesaikan dengan failAttempt.
+func (s *authService) reserveAttempt(ctx context.Context, nrp string, clientIP string) (*loginAttempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	until, err := s.lockedUntil(ctx, nrpThrottleKey(nrp), ipThrottleKey(clientIP))
+	if err != nil {
+		return nil, err
+	}
+	if until != nil {
+		s.logLockedAttempt(ctx, nrp, clientIP, *until)
+		metrics.LoginAttempt(metrics.LoginLocked)
+		return nil, &LoginLockedError{Until: *until}
+	}
+
+	attempt := &loginAttempt{nrp: nrp, clientIP: clientIP, lockedUntil: map[string]*time.Time{}}
+	for _, limit := range []struct {
+		key         string
+		maxFailures int
+	}{
+		{nrpThrottleKey(nrp), loginMaxFailuresPerNRP},
+		{ipThrottleKey(clientIP), loginMaxFailuresPerIP},
+	} {
+		lockedUntil, err := s.incrementFailures(ctx, limit.key, limit.maxFailures)
+		if err != nil {
+			slog.ErrorContext(ctx, "Gagal mencatat percobaan login", "throttle_key", limit.key, "error", err)
+			continue
+		}
+		attempt.lockedUntil[limit.key] = lockedUntil
+	}
+	return attempt, nil
+}
+
+// failAttempt menyelesaikan percobaan yang gagal. Jika percobaan ini memicu penguncian,
+// LoginLockedError dikembalikan; selain itu loginErr dikembalikan apa adanya.
+func (s *authService) failAttempt(ctx context.Context, attempt *loginAttempt, loginErr error) error {
+	metrics.LoginAttempt(metrics.LoginFailure)
+
+	var lockedUntil *time.Time
+	for _, until := range attempt.lockedUntil {
+		if until != nil && (lockedUntil == nil || until.After(*lockedUntil)) {
+			lockedUntil = until
+		}
+	}
+	if lockedUntil != nil {
+		slog.WarnContext(ctx, "Login dikunci", "nrp", attempt.nrp, "client_ip", attempt.clientIP, "locked_until", lockedUntil.Format(time.RFC3339))
+		return &LoginLockedError{Until: *lockedUntil}
+	}
+	return loginErr
+}
+
+// refundAttempt membatalkan kenaikan penghitung dari reserveAttempt untuk percobaan yang
+// kredensialnya benar atau yang gagal bukan karena kredensial, termasuk penguncian yang dipicunya.
+func (s *authService) refundAttempt(ctx context.Context, attempt *loginAttempt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, lockedUntil := range attempt.lockedUntil {
+		throttle, err := s.throttleRepo.Find(ctx, key)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				slog.WarnContext(ctx, "Gagal mengembalikan penghitung login", "throttle_key", key, "error", err)
+			}
+			continue
+		}
+		if throttle.Failures > 0 {
+			throttle.Failures--
+		}
+		if lockedUntil != nil && throttle.LockedUntil != nil && throttle.LockedUntil.Equal(*lockedUntil) {
+			throttle.LockedUntil = nil
+		}
+		if throttle.Failures == 0 && throttle.LockedUntil == nil {
+			err = s.throttleRepo.Delete(ctx, key)
+		} else {
+			err = s.throttleRepo.Save(ctx, throttle)
+		}
+		if err != nil {
+			slog.WarnContext(ctx, "Gagal mengembalikan penghitung login", "throttle_key", key, "error", err)
+		}
+	}
+}
+
+// logLockedAttempt mencatat percobaan login saat NRP atau IP terkunci. Untuk NRP terdaftar,
+// hanya percobaan pertama dalam setiap periode penguncian yang ditulis ke log audit.
+// Dipanggil dengan mu terkunci.
+func (s *authService) logLockedAttempt(ctx context.Context, nrp string, clientIP string, until time.Time) {
+	key := nrpThrottleKey(nrp)
+	if logged, ok := s.lockedLogged[key]; ok && logged.Equal(until) {
+		return
+	}
+	now := time.Now()
+	for k, logged := range s.lockedLogged {
+		if logged.Before(now) {
+			delete(s.lockedLogged, k)
+		}
+	}
+	s.lockedLogged[key] = until
+	s.logFailure(ctx, nrp, clientIP, fmt.Sprintf("akun atau alamat IP sedang dikunci hingga %s, percobaan berikutnya selama penguncian tidak dicatat", until.Format(time.RFC3339)))
+}
+
+func (s *authService) Logout(ctx context.Context, tokenString string) error {
+	token, err := s.jwtKeys.Parse(ctx, tokenString, jwt.WithoutClaimsValidation())
+	if err != nil {
+		return err
+	}
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return ErrSessionInvalid
+	}
+	sessionID, _ := claims["jti"].(string)
+	userID, _ := claims["userID"].(float64)
+	if sessionID == "" {
+		return ErrSessionInvalid
+	}
+	if err := s.sessionService.End(ctx, sessionID, uint(userID)); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+// logFailure mencatat login gagal ke log audit jika NRP terdaftar, atau ke log server jika tidak.
+func (s *authService) logFailure(ctx context.Context, nrp string, clientIP string, reason string) {
+	if user, err := s.userRepo.FindByNRP(ctx, nrp); err == nil {
+		s.auditService.LogActivity(ctx, user.ID, models.AuditLoginFailed, fmt.Sprintf("Login gagal dari IP %s: %s", clientIP, reason))
+		return
+	}
+	slog.WarnContext(ctx, "Login gagal untuk NRP tidak terdaftar", "nrp", nrp, "client_ip", clientIP, "reason", reason)
+}
+
+// lockedUntil mengembalikan waktu berakhirnya penguncian terlama di antara key yang diberikan.
+func (s *authService) lockedUntil(ctx context.Context, keys ...string) (*time.Time, error) {
+	now := time.Now()
+	var latest *time.Time
+	for _, key := range keys {
+		throttle, err := s.throttleRepo.Find(ctx, key)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) && (latest == nil || throttle.LockedUntil.After(*latest)) {
+			latest = throttle.LockedUntil
+		}
+	}
+	return latest, nil
+}
+
+func (s *authService) incrementFailures(ctx context.Context, key string, maxFailures int) (*time.Time, error) {
+	now := time.Now()
+	throttle, err := s.throttleRepo.Find(ctx, key)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, err
+		}
+		throttle = &models.LoginThrottle{Key: key}
+	}
+
+	// Kegagalan lama yang sudah lewat jendela waktu tidak dihitung lagi. Jendela dihitung
+	// sejak penguncian berakhir agar penguncian panjang tidak langsung mereset backoff.
+	lastActivity := throttle.LastFailureAt
+	if throttle.LockedUntil != nil && throttle.LockedUntil.After(lastActivity) {
+		lastActivity = *throttle.LockedUntil
+	}
+	if now.Sub(lastActivity) > loginFailureWindow {
+		throttle.Failures = 0
+	}
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	throttle.LockedUntil = nil
+
+	if throttle.Failures >= maxFailures {
+		lockout := loginBaseLockout << uint(throttle.Failures-maxFailures)
+		if lockout > loginMaxLockout || lockout <= 0 {
+			lockout = loginMaxLockout
+		}
+		until := now.Add(lockout)
+		throttle.LockedUntil = &until
+	}
+
+	if err := s.throttleRepo.Save(ctx, throttle); err != nil {
+		return nil, err
+	}
+	return throttle.LockedUntil, nil
+}
+
+func (s *authService) FindLockouts(ctx context.Context) ([]models.LoginThrottle, error) {
+	return s.throttleRepo.FindLocked(ctx, time.Now())
+}
+
+func (s *authService) UnlockUser(ctx context.Context, userID uint) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if err := s.throttleRepo.Delete(ctx, nrpThrottleKey(user.NRP)); err != nil {
+		return err
+	}
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditUnlockAccount, fmt.Sprintf("Membuka kunci login pengguna %s (NRP: %s)", user.NamaLengkap, user.NRP))
+	return nil
+}
+
+func (s *authService) Unlock(ctx context.Context, key string) error {
+	if _, err := s.throttleRepo.Find(ctx, key); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	if err := s.throttleRepo.Delete(ctx, key); err != nil {
+		return err
+	}
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditUnlockAccount, fmt.Sprintf("Membuka kunci login untuk %s", key))
+	return nil
+}
@@ -1,13 +1,17 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"simdokpol/internal/mocks"
 	"simdokpol/internal/models"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
@@ -35,9 +39,6 @@ func TestAuthService_Login(t *testing.T) {
 		DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}, // Akun non-aktif
 	}
 
-	// Atur secret key untuk JWT (hanya untuk testing)
-	JWTSecretKey = []byte("test-secret")
-
 	// Definisikan semua test case
 	testCases := []struct {
 		name          string
@@ -54,7 +55,7 @@ func TestAuthService_Login(t *testing.T) {
 			setupMock: func(mockRepo *mocks.UserRepository) {
 				// Harapkan method FindByNRP dipanggil dengan NRP "12345"
 				// dan kembalikan mockUser tanpa error
-				mockRepo.On("FindByNRP", "12345").Return(mockUser, nil)
+				mockRepo.On("FindByNRP", mock.Anything, "12345").Return(mockUser, nil)
 			},
 			expectToken:   true,
 			expectedError: "",
@@ -64,7 +65,7 @@ func TestAuthService_Login(t *testing.T) {
 			nrp:         "12345",
 			password:    "password-salah",
 			setupMock: func(mockRepo *mocks.UserRepository) {
-				mockRepo.On("FindByNRP", "12345").Return(mockUser, nil)
+				mockRepo.On("FindByNRP", mock.Anything, "12345").Return(mockUser, nil)
 			},
 			expectToken:   false,
 			expectedError: "NRP atau kata sandi salah",
@@ -75,7 +76,7 @@ func TestAuthService_Login(t *testing.T) {
 			password:    "password123",
 			setupMock: func(mockRepo *mocks.UserRepository) {
 				// Harapkan FindByNRP mengembalikan error gorm.ErrRecordNotFound
-				mockRepo.On("FindByNRP", "00000").Return(nil, gorm.ErrRecordNotFound)
+				mockRepo.On("FindByNRP", mock.Anything, "00000").Return(nil, gorm.ErrRecordNotFound)
 			},
 			expectToken:   false,
 			expectedError: "NRP atau kata sandi salah",
@@ -85,7 +86,7 @@ func TestAuthService_Login(t *testing.T) {
 			nrp:         "54321",
 			password:    "password123",
 			setupMock: func(mockRepo *mocks.UserRepository) {
-				mockRepo.On("FindByNRP", "54321").Return(mockInactiveUser, nil)
+				mockRepo.On("FindByNRP", mock.Anything, "54321").Return(mockInactiveUser, nil)
 			},
 			expectToken:   false,
 			expectedError: "Akun Anda tidak aktif. Silakan hubungi Super Admin",
@@ -96,7 +97,7 @@ func TestAuthService_Login(t *testing.T) {
 			password:    "password123",
 			setupMock: func(mockRepo *mocks.UserRepository) {
 				// Simulasikan error internal server
-				mockRepo.On("FindByNRP", "12345").Return(nil, errors.New("koneksi database error"))
+				mockRepo.On("FindByNRP", mock.Anything, "12345").Return(nil, errors.New("koneksi database error"))
 			},
 			expectToken:   false,
 			expectedError: "koneksi database error",
@@ -108,23 +109,36 @@ func TestAuthService_Login(t *testing.T) {
 		t.Run(tc.name, func(t *testing.T) {
 			// 1. Buat instance mock repository baru untuk setiap test
 			mockUserRepo := new(mocks.UserRepository)
+			mockThrottleRepo := new(mocks.LoginThrottleRepository)
+			mockAuditService := new(mocks.AuditLogService)
 			
 			// 2. Setup mock sesuai definisi test case
 			tc.setupMock(mockUserRepo)
+			// Belum ada percobaan gagal sebelumnya
+			mockThrottleRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(nil, gorm.ErrRecordNotFound)
+			mockThrottleRepo.On("Save", mock.Anything, mock.AnythingOfType("*models.LoginThrottle")).Return(nil).Maybe()
+			mockThrottleRepo.On("Delete", mock.Anything, "nrp:"+tc.nrp).Return(nil).Maybe()
+			mockAuditService.On("LogActivity", mock.Anything, mock.AnythingOfType("uint"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Maybe()
+			mockSessionService := new(mocks.SessionService)
+			mockSessionService.On("Create", mock.Anything, mock.AnythingOfType("uint"), "192.168.1.10", "test-agent").
+				Return(&models.Session{ID: "sesi-1", ExpiresAt: time.Now().Add(SessionLifetime)}, nil).Maybe()
+
+			mockTwoFactorService := new(mocks.TwoFactorService)
+			mockTwoFactorService.On("IsRequired", mock.Anything, mock.AnythingOfType("*models.User")).Return(false).Maybe()
 
 			// 3. Buat instance AuthService dengan mock repository
-			authService := NewAuthService(mockUserRepo)
+			authService := NewAuthService(mockUserRepo, NewLocalAuthenticator(mockUserRepo), mockThrottleRepo, mockSessionService, mockTwoFactorService, newTestJWTKeyService(), mockAuditService)
 
 			// 4. Panggil method Login yang ingin di-test
-			token, err := authService.Login(tc.nrp, tc.password)
+			result, err := authService.Login(context.Background(), tc.nrp, tc.password, "192.168.1.10", "test-agent")
 
 			// 5. Lakukan assertion (pemeriksaan hasil)
 			if tc.expectToken {
 				assert.NoError(t, err, "Seharusnya tidak ada error")
-				assert.NotEmpty(t, token, "Token seharusnya tidak kosong")
+				assert.NotEmpty(t, result.Token, "Token seharusnya tidak kosong")
 			} else {
 				assert.Error(t, err, "Seharusnya ada error")
-				assert.Empty(t, token, "Token seharusnya kosong")
+				assert.Nil(t, result, "Hasil login seharusnya kosong")
 				assert.Equal(t, tc.expectedError, err.Error(), "Pesan error tidak sesuai")
 			}
 			
@@ -132,4 +146,194 @@ func TestAuthService_Login(t *testing.T) {
 			mockUserRepo.AssertExpectations(t)
 		})
 	}
-}
\ No newline at end of file
+}
+func TestAuthService_LoginLockout(t *testing.T) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &models.User{ID: 1, NRP: "12345", KataSandi: string(hashedPassword), Peran: models.RoleOperator}
+	recentFailure := time.Now().Add(-time.Minute)
+
+	t.Run("Kegagalan Kelima Mengunci NRP", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		mockAuditService := new(mocks.AuditLogService)
+
+		mockUserRepo.On("FindByNRP", mock.Anything, "12345").Return(user, nil).Once()
+		mockThrottleRepo.On("Find", mock.Anything, "nrp:12345").Return(&models.LoginThrottle{Key: "nrp:12345", Failures: 4, LastFailureAt: recentFailure}, nil)
+		mockThrottleRepo.On("Find", mock.Anything, "ip:10.0.0.5").Return(nil, gorm.ErrRecordNotFound)
+		mockThrottleRepo.On("Save", mock.Anything, mock.MatchedBy(func(l *models.LoginThrottle) bool {
+			return l.Key == "nrp:12345" && l.Failures == 5 && l.LockedUntil != nil
+		})).Return(nil).Once()
+		mockThrottleRepo.On("Save", mock.Anything, mock.MatchedBy(func(l *models.LoginThrottle) bool {
+			return l.Key == "ip:10.0.0.5" && l.Failures == 1 && l.LockedUntil == nil
+		})).Return(nil).Once()
+		mockAuditService.On("LogActivity", mock.Anything, uint(1), models.AuditLoginFailed, "Login gagal dari IP 10.0.0.5: kata sandi salah").Once()
+
+		authService := NewAuthService(mockUserRepo, NewLocalAuthenticator(mockUserRepo), mockThrottleRepo, new(mocks.SessionService), new(mocks.TwoFactorService), newTestJWTKeyService(), mockAuditService)
+		_, err := authService.Login(context.Background(), "12345", "salah", "10.0.0.5", "test-agent")
+
+		assert.ErrorIs(t, err, ErrLoginLocked)
+		mockThrottleRepo.AssertExpectations(t)
+		mockAuditService.AssertExpectations(t)
+	})
+
+	t.Run("NRP Terkunci Menolak Kata Sandi Benar", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockThrottleRepo := new(mocks.LoginThrottleRepository)
+		mockAuditService := new(mocks.AuditLogService)
+
+		lockedUntil := time.Now().Add(time.Minute)
+		mockThrottleRepo.On("Find", mock.Anything, "nrp:12345").Return(&models.LoginThrottle{Key: "nrp:12345", Failures: 5, LastFailureAt: recentFailure, LockedUntil: &lockedUntil}, nil)
+		mockThrottleRepo.On("Find", mock.Anything, "ip:10.0.0.5").Return(nil, gorm.ErrRecordNotFound)
+		mockUserRepo.On("FindByNRP", mock.Anything, "12345").Return(user, nil).Once()
+		mockAuditService.On("LogActivity", mock.Anything, uint(1), models.AuditLoginFailed, mock.AnythingOfType("string")).Once()
+
+		authService := NewAuthService(mockUserRepo, NewLocalAuthenticator(mockUserRepo), mockThrottleRepo, new(mocks.SessionService), new(mocks.TwoFactorService), newTestJWTKeyService(), mockAuditService)
+		result, err := authService.Login(context.Background(), "12345", "password123", "10.0.0.5", "test-agent")
+
+		var lockedErr *LoginLockedError
+		assert.ErrorAs(t, err, &lockedErr)
+		assert.Nil(t, result)
+
+		// Percobaan berikutnya selama penguncian yang sama tidak ditulis lagi ke log audit
+		_, err = authService.Login(context.Background(), "12345", "password123", "10.0.0.5", "test-agent")
+		assert.ErrorAs(t, err, &lockedErr)
+		mockThrottleRepo.AssertNotCalled(t, "Save", mock.Anything, mock.Anything)
+		mockAuditService.AssertExpectations(t)
+	})
+
+	t.Run("Percobaan Bersamaan Tidak Melewati Batas", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "12345").Return(user, nil)
+		mockAuditService := new(mocks.AuditLogService)
+		mockAuditService.On("LogActivity", mock.Anything, mock.AnythingOfType("uint"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Maybe()
+		authenticator := &countingAuthenticator{Authenticator: NewLocalAuthenticator(mockUserRepo)}
+
+		authService := NewAuthService(mockUserRepo, authenticator, newMemoryThrottleRepo(), new(mocks.SessionService), new(mocks.TwoFactorService), newTestJWTKeyService(), mockAuditService)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				authService.Login(context.Background(), "12345", "salah", "10.0.0.5", "test-agent")
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int64(loginMaxFailuresPerNRP), authenticator.calls.Load())
+	})
+
+	t.Run("Login Benar Tidak Menambah Penghitung IP", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "12345").Return(user, nil)
+		mockAuditService := new(mocks.AuditLogService)
+		mockAuditService.On("LogActivity", mock.Anything, mock.AnythingOfType("uint"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Maybe()
+		mockSessionService := new(mocks.SessionService)
+		mockSessionService.On("Create", mock.Anything, uint(1), "10.0.0.5", "test-agent").
+			Return(&models.Session{ID: "sesi-1", ExpiresAt: time.Now().Add(SessionLifetime)}, nil)
+		mockTwoFactorService := new(mocks.TwoFactorService)
+		mockTwoFactorService.On("IsRequired", mock.Anything, user).Return(false)
+		throttleRepo := newMemoryThrottleRepo()
+
+		authService := NewAuthService(mockUserRepo, NewLocalAuthenticator(mockUserRepo), throttleRepo, mockSessionService, mockTwoFactorService, newTestJWTKeyService(), mockAuditService)
+		_, err := authService.Login(context.Background(), "12345", "password123", "10.0.0.5", "test-agent")
+
+		assert.NoError(t, err)
+		_, err = throttleRepo.Find(context.Background(), "ip:10.0.0.5")
+		assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+	})
+}
+
+// countingAuthenticator menghitung berapa kali kredensial benar-benar diverifikasi.
+type countingAuthenticator struct {
+	Authenticator
+	calls atomic.Int64
+}
+
+func (a *countingAuthenticator) Authenticate(ctx context.Context, nrp string, password string) (*models.User, error) {
+	a.calls.Add(1)
+	return a.Authenticator.Authenticate(ctx, nrp, password)
+}
+
+// memoryThrottleRepo adalah LoginThrottleRepository di memori untuk pengujian login bersamaan.
+type memoryThrottleRepo struct {
+	mu        sync.Mutex
+	throttles map[string]models.LoginThrottle
+}
+
+func newMemoryThrottleRepo() *memoryThrottleRepo {
+	return &memoryThrottleRepo{throttles: map[string]models.LoginThrottle{}}
+}
+
+func (r *memoryThrottleRepo) Find(ctx context.Context, key string) (*models.LoginThrottle, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	throttle, ok := r.throttles[key]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &throttle, nil
+}
+
+func (r *memoryThrottleRepo) Save(ctx context.Context, throttle *models.LoginThrottle) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.throttles[throttle.Key] = *throttle
+	return nil
+}
+
+func (r *memoryThrottleRepo) Delete(ctx context.Context, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.throttles, key)
+	return nil
+}
+
+func (r *memoryThrottleRepo) FindLocked(ctx context.Context, t time.Time) ([]models.LoginThrottle, error) {
+	return nil, nil
+}
+
+func TestAuthService_LoginTwoFactor(t *testing.T) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte("password123"), bcrypt.MinCost)
+	assert.NoError(t, err)
+	user := &models.User{ID: 1, NRP: "12345", KataSandi: string(hashedPassword), Peran: models.RoleKanit, TOTPEnabled: true}
+
+	mockUserRepo := new(mocks.UserRepository)
+	mockThrottleRepo := new(mocks.LoginThrottleRepository)
+	mockSessionService := new(mocks.SessionService)
+	mockTwoFactorService := new(mocks.TwoFactorService)
+	mockAuditService := new(mocks.AuditLogService)
+
+	mockUserRepo.On("FindByNRP", mock.Anything, "12345").Return(user, nil)
+	mockUserRepo.On("FindByID", mock.Anything, uint(1)).Return(user, nil)
+	mockThrottleRepo.On("Find", mock.Anything, mock.AnythingOfType("string")).Return(nil, gorm.ErrRecordNotFound)
+	mockThrottleRepo.On("Save", mock.Anything, mock.AnythingOfType("*models.LoginThrottle")).Return(nil)
+	mockThrottleRepo.On("Delete", mock.Anything, "nrp:12345").Return(nil).Once()
+	mockTwoFactorService.On("Verify", mock.Anything, user, "123456").Return(nil).Once()
+	mockSessionService.On("Create", mock.Anything, uint(1), "10.0.0.5", "test-agent").
+		Return(&models.Session{ID: "sesi-1", ExpiresAt: time.Now().Add(SessionLifetime)}, nil).Once()
+	mockAuditService.On("LogActivity", mock.Anything, uint(1), models.AuditLoginSuccess, mock.AnythingOfType("string")).Once()
+
+	authService := NewAuthService(mockUserRepo, NewLocalAuthenticator(mockUserRepo), mockThrottleRepo, mockSessionService, mockTwoFactorService, newTestJWTKeyService(), mockAuditService)
+
+	// Langkah pertama tidak boleh membuat sesi
+	first, err := authService.Login(context.Background(), "12345", "password123", "10.0.0.5", "test-agent")
+	assert.NoError(t, err)
+	assert.True(t, first.TwoFactorRequired)
+	assert.False(t, first.EnrollmentRequired)
+	assert.Empty(t, first.Token)
+	mockSessionService.AssertNotCalled(t, "Create", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+
+	// Token challenge tidak bisa dipakai sebagai token sesi, dan sebaliknya
+	_, err = authService.VerifyTwoFactor(context.Background(), "bukan-token", "123456", "10.0.0.5", "test-agent")
+	assert.ErrorIs(t, err, ErrTwoFactorChallengeInvalid)
+
+	second, err := authService.VerifyTwoFactor(context.Background(), first.ChallengeToken, "123456", "10.0.0.5", "test-agent")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, second.Token)
+
+	mockThrottleRepo.AssertExpectations(t)
+	mockSessionService.AssertExpectations(t)
+	mockTwoFactorService.AssertExpectations(t)
+}
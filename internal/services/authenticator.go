@@ -0,0 +1,44 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Authenticator memverifikasi NRP dan kata sandi untuk AuthService.Login.
+type Authenticator interface {
+	// Authenticate mengembalikan pengguna yang kredensialnya valid. Kredensial yang salah
+	// dikembalikan sebagai ErrInvalidCredentials, dengan pengguna tetap terisi jika NRP-nya
+	// dikenal agar kegagalan bisa dicatat di log audit. Pemeriksaan akun non-aktif, penguncian,
+	// dan 2FA tetap dilakukan oleh AuthService.
+	Authenticate(ctx context.Context, nrp string, password string) (*models.User, error)
+}
+
+type localAuthenticator struct {
+	userRepo repositories.UserRepository
+}
+
+// NewLocalAuthenticator membuat Authenticator bawaan yang mencocokkan kata sandi bcrypt di database.
+func NewLocalAuthenticator(userRepo repositories.UserRepository) Authenticator {
+	return &localAuthenticator{userRepo: userRepo}
+}
+
+func (a *localAuthenticator) Authenticate(ctx context.Context, nrp string, password string) (*models.User, error) {
+	// Pengguna yang sudah di-soft delete tetap dicari agar Login bisa menjelaskan statusnya
+	user, err := a.userRepo.FindByNRP(ctx, nrp)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidCredentials
+		}
+		return nil, err
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.KataSandi), []byte(password)); err != nil {
+		return user, ErrInvalidCredentials
+	}
+	return user, nil
+}
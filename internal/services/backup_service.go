@@ -1,18 +1,25 @@
 package services
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
 	"simdokpol/internal/config"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/metrics"
 	"simdokpol/internal/models"
+	"simdokpol/internal/reqctx"
 	"strings"
 	"time"
 )
 
+// BackupService menyalin file database SQLite. Aksi tanpa aktor di ctx berasal dari CLI dan
+// dicatat ke log aplikasi karena tidak ada pengguna untuk log audit.
 type BackupService interface {
-	CreateBackup(actorID uint) (backupPath string, err error)
-	RestoreBackup(uploadedFile io.Reader, actorID uint) error
+	CreateBackup(ctx context.Context) (backupPath string, err error)
+	RestoreBackup(ctx context.Context, uploadedFile io.Reader) error
 }
 
 type backupService struct {
@@ -29,27 +36,42 @@ func NewBackupService(cfg *config.Config, configService ConfigService, auditServ
 	}
 }
 
+// backupFilePattern adalah pola nama file backup yang dibuat CreateBackup.
+const backupFilePattern = "backup-simdokpol-*.db"
+
 func (s *backupService) getCleanDBPath() string {
-	dsnParts := strings.Split(s.cfg.DBDSN, "?")
+	return dbFilePath(s.cfg.DBDSN)
+}
+
+// dbFilePath mengambil path file SQLite dari DSN dengan membuang parameter query.
+func dbFilePath(dsn string) string {
+	dsnParts := strings.Split(dsn, "?")
 	return dsnParts[0]
 }
 
-func (s *backupService) CreateBackup(actorID uint) (string, error) {
+// backupDirectory mengembalikan folder backup dari pengaturan, atau folder bawaan jika kosong.
+func backupDirectory(appConfig *dto.AppConfig) string {
+	if appConfig.BackupPath == "" {
+		return "./backups"
+	}
+	return appConfig.BackupPath
+}
+
+func (s *backupService) CreateBackup(ctx context.Context) (backupPath string, err error) {
+	defer func(start time.Time) { metrics.ObserveBackup(metrics.BackupCreate, err, time.Since(start)) }(time.Now())
+
 	sourcePath := s.getCleanDBPath()
 
 	if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
 		return "", fmt.Errorf("file database sumber tidak ditemukan di: %s (path asli dari DSN: %s)", sourcePath, s.cfg.DBDSN)
 	}
 
-	appConfig, err := s.configService.GetConfig()
+	appConfig, err := s.configService.GetConfig(ctx)
 	if err != nil {
 		return "", fmt.Errorf("gagal mendapatkan konfigurasi aplikasi: %w", err)
 	}
-	
-	backupDir := appConfig.BackupPath
-	if backupDir == "" {
-		backupDir = "./backups"
-	}
+
+	backupDir := backupDirectory(appConfig)
 
 	if err := os.MkdirAll(backupDir, 0755); err != nil {
 		return "", fmt.Errorf("gagal membuat direktori backup di '%s': %w", backupDir, err)
@@ -75,12 +97,14 @@ func (s *backupService) CreateBackup(actorID uint) (string, error) {
 		return "", fmt.Errorf("gagal menyalin data ke file backup: %w", err)
 	}
 
-	s.auditService.LogActivity(actorID, models.AuditBackupCreated, fmt.Sprintf("Membuat file backup baru: %s", destinationPath))
+	s.logActivity(ctx, models.AuditBackupCreated, fmt.Sprintf("Membuat file backup baru: %s", destinationPath))
 
 	return destinationPath, nil
 }
 
-func (s *backupService) RestoreBackup(uploadedFile io.Reader, actorID uint) error {
+func (s *backupService) RestoreBackup(ctx context.Context, uploadedFile io.Reader) (err error) {
+	defer func(start time.Time) { metrics.ObserveBackup(metrics.BackupRestore, err, time.Since(start)) }(time.Now())
+
 	targetPath := s.getCleanDBPath()
 
 	if _, err := os.Stat(targetPath); err == nil {
@@ -114,7 +138,18 @@ func (s *backupService) RestoreBackup(uploadedFile io.Reader, actorID uint) erro
 		return fmt.Errorf("gagal menyalin data dari file yang diunggah: %w", err)
 	}
 
-	s.auditService.LogActivity(actorID, models.AuditRestoreFromFile, "Database dipulihkan dari file backup.")
+	s.logActivity(ctx, models.AuditRestoreFromFile, "Database dipulihkan dari file backup.")
+	if err := s.configService.Reload(ctx); err != nil {
+		slog.WarnContext(ctx, "Pengaturan dari database hasil restore belum dimuat", "error", err)
+	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+func (s *backupService) logActivity(ctx context.Context, action string, details string) {
+	if actorID := reqctx.ActorID(ctx); actorID != 0 {
+		s.auditService.LogActivity(ctx, actorID, action, details)
+		return
+	}
+	slog.InfoContext(ctx, details, "aksi", action)
+}
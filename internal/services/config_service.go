@@ -1,9 +1,14 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
 	"simdokpol/internal/dto" // <-- IMPORT BARU
 	"simdokpol/internal/repositories"
 	"strconv"
+	"sync"
 	"time"
 
 	"gorm.io/gorm"
@@ -13,54 +18,144 @@ const IsSetupCompleteKey = "is_setup_complete"
 
 // DEFINISI AppConfig DIPINDAHKAN KE internal/dto/config_dto.go
 
+// ConfigListener menerima pengaturan sebelum dan sesudah perubahan. previous bernilai nil jika
+// pengaturan lama tidak dapat dibaca. Keduanya adalah salinan milik listener. Alias dipakai
+// agar mock di paket lain dapat memenuhi interface tanpa mengimpor paket services.
+type ConfigListener = func(previous, current *dto.AppConfig)
+
 type ConfigService interface {
-	IsSetupComplete() (bool, error)
-	GetConfig() (*dto.AppConfig, error) // <-- DIUBAH
-	SaveConfig(configData map[string]string) error
-	GetLocation() (*time.Location, error)
+	IsSetupComplete(ctx context.Context) (bool, error)
+	// GetConfig mengembalikan salinan pengaturan yang tersimpan di cache, sehingga pemanggil
+	// bebas mengubahnya tanpa memengaruhi pemanggil lain.
+	GetConfig(ctx context.Context) (*dto.AppConfig, error)
+	// GetSettings mengembalikan salinan nilai baku semua kunci pada skema pengaturan,
+	// dengan nilai bawaan untuk kunci yang belum tersimpan.
+	GetSettings(ctx context.Context) (map[string]string, error)
+	// SaveConfig menyimpan pengaturan, memuat ulang cache, lalu memberi tahu semua listener.
+	SaveConfig(ctx context.Context, configData map[string]string) error
+	GetLocation(ctx context.Context) (*time.Location, error)
+	// Reload membuang cache dan membaca ulang pengaturan dari database, misalnya setelah restore.
+	Reload(ctx context.Context) error
+	// Subscribe mendaftarkan listener yang dipanggil setiap kali pengaturan berubah.
+	// Listener dipanggil secara berurutan di goroutine penyimpan dan tidak boleh lama memblokir.
+	// Fungsi yang dikembalikan menghapus pendaftaran tersebut.
+	Subscribe(listener ConfigListener) (unsubscribe func())
+}
+
+// configSnapshot adalah hasil baca pengaturan yang tidak pernah diubah setelah dibuat.
+type configSnapshot struct {
+	config *dto.AppConfig
+	// values berisi semua kunci pada skema pengaturan dalam bentuk baku.
+	values      map[string]string
+	location    *time.Location
+	locationErr error
 }
 
 type configService struct {
-	configRepo     repositories.ConfigRepository
-	cachedLocation *time.Location
-	cachedConfig   *dto.AppConfig // <-- DIUBAH
+	configRepo repositories.ConfigRepository
+
+	// saveMu menyerialkan penyimpanan agar listener menerima perubahan sesuai urutan.
+	saveMu sync.Mutex
+	// mu melindungi snapshot, generation, dan listeners. generation naik setiap kali cache
+	// dibuang, sehingga hasil baca yang dimulai sebelum penyimpanan tidak menimpa cache baru.
+	mu         sync.RWMutex
+	snapshot   *configSnapshot
+	generation uint64
+	listeners  map[uint64]ConfigListener
+	nextID     uint64
 }
 
 func NewConfigService(configRepo repositories.ConfigRepository) ConfigService {
-	return &configService{configRepo: configRepo}
+	return &configService{configRepo: configRepo, listeners: make(map[uint64]ConfigListener)}
 }
 
-func (s *configService) SaveConfig(configData map[string]string) error {
-	s.cachedLocation = nil
-	s.cachedConfig = nil 
-	return s.configRepo.SetMultiple(configData)
+func (s *configService) SaveConfig(ctx context.Context, configData map[string]string) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	previous, err := s.load(ctx)
+	if err != nil {
+		slog.WarnContext(ctx, "Gagal membaca pengaturan sebelum disimpan", "error", err)
+	}
+	if err := s.configRepo.SetMultiple(ctx, configData); err != nil {
+		return err
+	}
+	// Pengaturan sudah tersimpan; kegagalan memuat ulang hanya menunda pembaruan cache.
+	if err := s.reload(ctx, previous); err != nil {
+		slog.WarnContext(ctx, "Gagal memuat ulang pengaturan setelah disimpan", "error", err)
+	}
+	return nil
 }
 
-func (s *configService) GetLocation() (*time.Location, error) {
-	if s.cachedLocation != nil {
-		return s.cachedLocation, nil
+func (s *configService) Reload(ctx context.Context) error {
+	s.saveMu.Lock()
+	defer s.saveMu.Unlock()
+
+	previous, _ := s.load(ctx)
+	if err := s.reload(ctx, previous); err != nil {
+		return fmt.Errorf("gagal memuat ulang pengaturan: %w", err)
 	}
+	return nil
+}
+
+// reload membuang cache, membaca ulang pengaturan, dan memberi tahu listener jika ada yang
+// berubah. Pemanggil harus memegang saveMu. Jika pembacaan gagal, cache tetap kosong sehingga
+// pemanggil berikutnya mencoba membaca lagi.
+func (s *configService) reload(ctx context.Context, previous *configSnapshot) error {
+	s.mu.Lock()
+	s.snapshot = nil
+	s.generation++
+	s.mu.Unlock()
 
-	config, err := s.GetConfig()
+	current, err := s.load(ctx)
 	if err != nil {
-		return time.UTC, err
+		return err
+	}
+	if previous != nil && *previous.config == *current.config {
+		return nil
+	}
+
+	s.mu.RLock()
+	listeners := make([]ConfigListener, 0, len(s.listeners))
+	for _, listener := range s.listeners {
+		listeners = append(listeners, listener)
+	}
+	s.mu.RUnlock()
+
+	for _, listener := range listeners {
+		var before *dto.AppConfig
+		if previous != nil {
+			before = copyAppConfig(previous.config)
+		}
+		listener(before, copyAppConfig(current.config))
 	}
+	return nil
+}
 
-	if config.ZonaWaktu == "" {
-		return time.UTC, nil
+func (s *configService) Subscribe(listener ConfigListener) func() {
+	s.mu.Lock()
+	id := s.nextID
+	s.nextID++
+	s.listeners[id] = listener
+	s.mu.Unlock()
+
+	return func() {
+		s.mu.Lock()
+		delete(s.listeners, id)
+		s.mu.Unlock()
 	}
+}
 
-	loc, err := time.LoadLocation(config.ZonaWaktu)
+func (s *configService) GetLocation(ctx context.Context) (*time.Location, error) {
+	snapshot, err := s.load(ctx)
 	if err != nil {
 		return time.UTC, err
 	}
-
-	s.cachedLocation = loc
-	return s.cachedLocation, nil
+	return snapshot.location, snapshot.locationErr
 }
 
-func (s *configService) IsSetupComplete() (bool, error) {
-	config, err := s.configRepo.Get(IsSetupCompleteKey)
+func (s *configService) IsSetupComplete(ctx context.Context) (bool, error) {
+	config, err := s.configRepo.Get(ctx, IsSetupCompleteKey)
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return false, nil
@@ -70,33 +165,91 @@ func (s *configService) IsSetupComplete() (bool, error) {
 	return config.Value == "true", nil
 }
 
-func (s *configService) GetConfig() (*dto.AppConfig, error) { // <-- DIUBAH
-	if s.cachedConfig != nil {
-		return s.cachedConfig, nil
+func (s *configService) GetConfig(ctx context.Context) (*dto.AppConfig, error) {
+	snapshot, err := s.load(ctx)
+	if err != nil {
+		return nil, err
 	}
+	return copyAppConfig(snapshot.config), nil
+}
 
-	allConfigs, err := s.configRepo.GetAll()
+func (s *configService) GetSettings(ctx context.Context) (map[string]string, error) {
+	snapshot, err := s.load(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return maps.Clone(snapshot.values), nil
+}
 
-	archiveDays, _ := strconv.Atoi(allConfigs["archive_duration_days"])
+// load mengembalikan snapshot dari cache atau membacanya dari database jika cache kosong.
+func (s *configService) load(ctx context.Context) (*configSnapshot, error) {
+	s.mu.RLock()
+	snapshot, generation := s.snapshot, s.generation
+	s.mu.RUnlock()
+	if snapshot != nil {
+		return snapshot, nil
+	}
+
+	allConfigs, err := s.configRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snapshot = newConfigSnapshot(allConfigs)
+
+	s.mu.Lock()
+	if s.generation == generation {
+		s.snapshot = snapshot
+	}
+	s.mu.Unlock()
+	return snapshot, nil
+}
+
+func newConfigSnapshot(allConfigs map[string]string) *configSnapshot {
+	values := resolveSettings(allConfigs)
+	atoi := func(key string) int {
+		n, _ := strconv.Atoi(values[key])
+		return n
+	}
 
-	// Gunakan dto.AppConfig
 	appConfig := &dto.AppConfig{
-		IsSetupComplete:     allConfigs[IsSetupCompleteKey] == "true",
-		KopBaris1:           allConfigs["kop_baris_1"],
-		KopBaris2:           allConfigs["kop_baris_2"],
-		KopBaris3:           allConfigs["kop_baris_3"],
-		NamaKantor:          allConfigs["nama_kantor"],
-		TempatSurat:         allConfigs["tempat_surat"],
-		FormatNomorSurat:    allConfigs["format_nomor_surat"],
-		NomorSuratTerakhir:  allConfigs["nomor_surat_terakhir"],
-		ZonaWaktu:           allConfigs["zona_waktu"],
-		BackupPath:          allConfigs["backup_path"],
-		ArchiveDurationDays: archiveDays,
-	}
-
-	s.cachedConfig = appConfig
-	return appConfig, nil
-}
\ No newline at end of file
+		IsSetupComplete:       values[IsSetupCompleteKey] == "true",
+		KopBaris1:             values["kop_baris_1"],
+		KopBaris2:             values["kop_baris_2"],
+		KopBaris3:             values["kop_baris_3"],
+		NamaKantor:            values["nama_kantor"],
+		TempatSurat:           values["tempat_surat"],
+		FormatNomorSurat:      values["format_nomor_surat"],
+		NomorSuratTerakhir:    values["nomor_surat_terakhir"],
+		ZonaWaktu:             values["zona_waktu"],
+		BackupPath:            values["backup_path"],
+		ArchiveDurationDays:   atoi("archive_duration_days"),
+		AuditRetentionMonths:  atoi("audit_retention_months"),
+		DocumentVisibility:    values["document_visibility"],
+		SessionIdleMinutes:    atoi("session_idle_minutes"),
+		TwoFactorRoles:        values["two_factor_roles"],
+		PasswordMinLength:     atoi("password_min_length"),
+		PasswordRequireUpper:  values["password_require_upper"] == "true",
+		PasswordRequireLower:  values["password_require_lower"] == "true",
+		PasswordRequireDigit:  values["password_require_digit"] == "true",
+		PasswordRequireSymbol: values["password_require_symbol"] == "true",
+		PasswordHistoryCount:  atoi("password_history_count"),
+		PasswordMaxAgeDays:    atoi("password_max_age_days"),
+		JWTRotationDays:       atoi("jwt_rotation_days"),
+		JWTKeyGraceHours:      atoi("jwt_key_grace_hours"),
+	}
+
+	snapshot := &configSnapshot{config: appConfig, values: values, location: time.UTC}
+	if appConfig.ZonaWaktu != "" {
+		if loc, err := time.LoadLocation(appConfig.ZonaWaktu); err != nil {
+			snapshot.locationErr = err
+		} else {
+			snapshot.location = loc
+		}
+	}
+	return snapshot
+}
+
+func copyAppConfig(appConfig *dto.AppConfig) *dto.AppConfig {
+	copied := *appConfig
+	return &copied
+}
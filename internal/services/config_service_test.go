@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestConfigService_GetConfig(t *testing.T) {
+	mockRepo := new(mocks.ConfigRepository)
+	mockRepo.On("GetAll", mock.Anything).Return(map[string]string{
+		"archive_duration_days": "15",
+		"zona_waktu":            "Asia/Jakarta",
+	}, nil).Once()
+	service := NewConfigService(mockRepo)
+	ctx := context.Background()
+
+	first, err := service.GetConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 15*24*time.Hour, first.ArchiveDuration())
+
+	// Mengubah hasil GetConfig tidak boleh memengaruhi cache milik pemanggil lain.
+	first.ArchiveDurationDays = 99
+	second, err := service.GetConfig(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, 15, second.ArchiveDurationDays)
+
+	loc, err := service.GetLocation(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Jakarta", loc.String())
+
+	mockRepo.AssertNumberOfCalls(t, "GetAll", 1)
+}
+
+func TestConfigService_GetSettings(t *testing.T) {
+	mockRepo := new(mocks.ConfigRepository)
+	mockRepo.On("GetAll", mock.Anything).Return(map[string]string{
+		"archive_duration_days": "abc",
+		"session_idle_minutes":  "45",
+		"document_visibility":   "semua",
+	}, nil)
+	service := NewConfigService(mockRepo)
+
+	values, err := service.GetSettings(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, values, len(SettingsSchema()))
+	// Nilai tersimpan yang tidak valid diganti nilai bawaan dari skema.
+	assert.Equal(t, "15", values["archive_duration_days"])
+	assert.Equal(t, "45", values["session_idle_minutes"])
+	assert.Equal(t, "own", values["document_visibility"])
+	assert.Equal(t, "30", values["jwt_rotation_days"])
+
+	appConfig, err := service.GetConfig(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 15, appConfig.ArchiveDurationDays)
+	assert.Equal(t, 45*time.Minute, appConfig.SessionIdleTimeout())
+}
+
+func TestValidateSettings(t *testing.T) {
+	testCases := []struct {
+		name     string
+		settings map[string]string
+		fields   []string
+		expected map[string]string
+	}{
+		{
+			name:     "Nilai Valid Dinormalisasi",
+			settings: map[string]string{"archive_duration_days": " 30 ", "password_require_upper": "1", "two_factor_roles": "super_admin, kanit", "zona_waktu": "Asia/Jayapura"},
+			expected: map[string]string{"archive_duration_days": "30", "password_require_upper": "true", "two_factor_roles": "SUPER_ADMIN,KANIT", "zona_waktu": "Asia/Jayapura"},
+		},
+		{name: "Angka Tidak Valid", settings: map[string]string{"archive_duration_days": "abc"}, fields: []string{"archive_duration_days"}},
+		{name: "Di Luar Rentang", settings: map[string]string{"jwt_key_grace_hours": "0", "password_min_length": "200"}, fields: []string{"jwt_key_grace_hours", "password_min_length"}},
+		{name: "Kunci Tidak Dikenal", settings: map[string]string{"sembarang": "x"}, fields: []string{"sembarang"}},
+		{name: "Kunci Hanya-Baca", settings: map[string]string{IsSetupCompleteKey: "false"}, fields: []string{IsSetupCompleteKey}},
+		{name: "Nilai Di Luar Pilihan", settings: map[string]string{"document_visibility": "semua"}, fields: []string{"document_visibility"}},
+		{name: "Wajib Diisi", settings: map[string]string{"nama_kantor": "  "}, fields: []string{"nama_kantor"}},
+		{name: "Peran Tidak Dikenal", settings: map[string]string{"two_factor_roles": "ADMIN"}, fields: []string{"two_factor_roles"}},
+		{name: "Format Nomor Surat Salah", settings: map[string]string{"format_nomor_surat": "SKH/%d/%d"}, fields: []string{"format_nomor_surat"}},
+		{name: "Zona Waktu Tidak Dikenal", settings: map[string]string{"zona_waktu": "Asia/Bandung"}, fields: []string{"zona_waktu"}},
+		{name: "Path Backup Traversal", settings: map[string]string{"backup_path": "../rahasia"}, fields: []string{"backup_path"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateSettings(tc.settings)
+			if len(tc.fields) == 0 {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.expected, tc.settings)
+				return
+			}
+			assert.ErrorIs(t, err, ErrInvalidSettings)
+			var validationErr *SettingsValidationError
+			if assert.ErrorAs(t, err, &validationErr) {
+				assert.Len(t, validationErr.Fields, len(tc.fields))
+				for _, field := range tc.fields {
+					assert.Contains(t, validationErr.Fields, field)
+				}
+			}
+		})
+	}
+}
+
+func TestConfigService_SaveConfig(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("Memberi Tahu Listener Saat Pengaturan Berubah", func(t *testing.T) {
+		mockRepo := new(mocks.ConfigRepository)
+		mockRepo.On("GetAll", mock.Anything).Return(map[string]string{"audit_retention_months": "12"}, nil).Once()
+		mockRepo.On("SetMultiple", mock.Anything, map[string]string{"audit_retention_months": "6"}).Return(nil)
+		mockRepo.On("GetAll", mock.Anything).Return(map[string]string{"audit_retention_months": "6"}, nil).Once()
+		service := NewConfigService(mockRepo)
+
+		var calls []int
+		unsubscribe := service.Subscribe(func(previous, current *dto.AppConfig) {
+			calls = append(calls, previous.AuditRetentionMonths, current.AuditRetentionMonths)
+		})
+		defer unsubscribe()
+
+		assert.NoError(t, service.SaveConfig(ctx, map[string]string{"audit_retention_months": "6"}))
+		assert.Equal(t, []int{12, 6}, calls)
+
+		appConfig, err := service.GetConfig(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 6, appConfig.AuditRetentionMonths)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("Tidak Memberi Tahu Jika Nilai Sama atau Sudah Berhenti Berlangganan", func(t *testing.T) {
+		mockRepo := new(mocks.ConfigRepository)
+		mockRepo.On("GetAll", mock.Anything).Return(map[string]string{"nama_kantor": "POLSEK"}, nil)
+		mockRepo.On("SetMultiple", mock.Anything, mock.Anything).Return(nil)
+		service := NewConfigService(mockRepo)
+
+		notified := 0
+		unsubscribe := service.Subscribe(func(previous, current *dto.AppConfig) { notified++ })
+
+		assert.NoError(t, service.SaveConfig(ctx, map[string]string{"nama_kantor": "POLSEK"}))
+		assert.Equal(t, 0, notified)
+
+		unsubscribe()
+		mockRepo.ExpectedCalls = nil
+		mockRepo.On("SetMultiple", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("GetAll", mock.Anything).Return(map[string]string{"nama_kantor": "POLRES"}, nil)
+		assert.NoError(t, service.SaveConfig(ctx, map[string]string{"nama_kantor": "POLRES"}))
+		assert.Equal(t, 0, notified)
+	})
+
+	t.Run("Aman Dipakai Bersamaan", func(t *testing.T) {
+		mockRepo := new(mocks.ConfigRepository)
+		mockRepo.On("GetAll", mock.Anything).Return(map[string]string{"zona_waktu": "Asia/Makassar"}, nil)
+		mockRepo.On("SetMultiple", mock.Anything, mock.Anything).Return(nil)
+		service := NewConfigService(mockRepo)
+
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := service.GetConfig(ctx)
+				assert.NoError(t, err)
+				_, err = service.GetLocation(ctx)
+				assert.NoError(t, err)
+			}()
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, service.SaveConfig(ctx, map[string]string{"zona_waktu": "Asia/Makassar"}))
+			}()
+		}
+		wg.Wait()
+	})
+}
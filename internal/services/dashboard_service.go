@@ -1,6 +1,7 @@
 package services
 
 import (
+	"context"
 	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
 	"time"
@@ -24,11 +25,19 @@ type PieChartDataDTO struct {
 	BackgroundColors []string `json:"background_colors"`
 }
 
+// ReguStatDTO adalah jumlah dokumen yang diterbitkan sebuah regu.
+type ReguStatDTO struct {
+	Regu        string `json:"regu"`
+	DocsToday   int    `json:"docs_today"`
+	DocsMonthly int    `json:"docs_monthly"`
+}
+
 type DashboardService interface {
-	GetDashboardStats() (*DashboardStatsDTO, error)
-	GetMonthlyIssuanceChartData() (*ChartDataDTO, error)
-	GetItemCompositionPieChartData() (*PieChartDataDTO, error)
-	GetExpiringDocumentsForUser(userID uint, notificationWindowDays int) ([]models.LostDocument, error) // <-- METHOD BARU
+	GetDashboardStats(ctx context.Context) (*DashboardStatsDTO, error)
+	GetMonthlyIssuanceChartData(ctx context.Context) (*ChartDataDTO, error)
+	GetItemCompositionPieChartData(ctx context.Context) (*PieChartDataDTO, error)
+	GetReguBreakdown(ctx context.Context) ([]ReguStatDTO, error)
+	GetExpiringDocumentsForUser(ctx context.Context, userID uint, notificationWindowDays int) ([]models.LostDocument, error) // <-- METHOD BARU
 }
 
 type dashboardService struct {
@@ -46,18 +55,18 @@ func NewDashboardService(docRepo repositories.LostDocumentRepository, userRepo r
 }
 
 // === FUNGSI BARU UNTUK NOTIFIKASI ===
-func (s *dashboardService) GetExpiringDocumentsForUser(userID uint, notificationWindowDays int) ([]models.LostDocument, error) {
-	appConfig, err := s.configService.GetConfig()
+func (s *dashboardService) GetExpiringDocumentsForUser(ctx context.Context, userID uint, notificationWindowDays int) ([]models.LostDocument, error) {
+	appConfig, err := s.configService.GetConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
-	loc, err := s.configService.GetLocation()
+	loc, err := s.configService.GetLocation(ctx)
 	if err != nil {
 		loc = time.UTC
 	}
 	now := time.Now().In(loc)
 
-	archiveDuration := time.Duration(appConfig.ArchiveDurationDays) * 24 * time.Hour
+	archiveDuration := appConfig.ArchiveDuration()
 	notificationWindow := time.Duration(notificationWindowDays) * 24 * time.Hour
 
 	// Menghitung rentang waktu. Kita mencari dokumen yang tanggal laporannya berada di antara:
@@ -67,12 +76,12 @@ func (s *dashboardService) GetExpiringDocumentsForUser(userID uint, notification
 	expiryDateStart := now.Add(-archiveDuration)
 	expiryDateEnd := expiryDateStart.Add(notificationWindow)
 
-	return s.docRepo.FindExpiringDocumentsForUser(userID, expiryDateStart, expiryDateEnd)
+	return s.docRepo.FindExpiringDocumentsForUser(ctx, userID, expiryDateStart, expiryDateEnd)
 }
 // === AKHIR FUNGSI BARU ===
 
-func (s *dashboardService) GetDashboardStats() (*DashboardStatsDTO, error) {
-	loc, err := s.configService.GetLocation()
+func (s *dashboardService) GetDashboardStats(ctx context.Context) (*DashboardStatsDTO, error) {
+	loc, err := s.configService.GetLocation(ctx)
 	if err != nil {
 		loc = time.UTC
 	}
@@ -80,26 +89,26 @@ func (s *dashboardService) GetDashboardStats() (*DashboardStatsDTO, error) {
 
 	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
 	endOfDay := startOfDay.AddDate(0, 0, 1).Add(-time.Nanosecond)
-	docsToday, err := s.docRepo.CountByDateRange(startOfDay, endOfDay)
+	docsToday, err := s.docRepo.CountByDateRange(ctx, startOfDay, endOfDay)
 	if err != nil {
 		return nil, err
 	}
 
 	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
 	endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Nanosecond)
-	docsMonthly, err := s.docRepo.CountByDateRange(startOfMonth, endOfMonth)
+	docsMonthly, err := s.docRepo.CountByDateRange(ctx, startOfMonth, endOfMonth)
 	if err != nil {
 		return nil, err
 	}
 
 	startOfYear := time.Date(now.Year(), 1, 1, 0, 0, 0, 0, loc)
 	endOfYear := startOfYear.AddDate(1, 0, 0).Add(-time.Nanosecond)
-	docsYearly, err := s.docRepo.CountByDateRange(startOfYear, endOfYear)
+	docsYearly, err := s.docRepo.CountByDateRange(ctx, startOfYear, endOfYear)
 	if err != nil {
 		return nil, err
 	}
 
-	activeUsers, err := s.userRepo.CountAll()
+	activeUsers, err := s.userRepo.CountAll(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -114,14 +123,14 @@ func (s *dashboardService) GetDashboardStats() (*DashboardStatsDTO, error) {
 	return stats, nil
 }
 
-func (s *dashboardService) GetMonthlyIssuanceChartData() (*ChartDataDTO, error) {
-	loc, err := s.configService.GetLocation()
+func (s *dashboardService) GetMonthlyIssuanceChartData(ctx context.Context) (*ChartDataDTO, error) {
+	loc, err := s.configService.GetLocation(ctx)
 	if err != nil {
 		loc = time.UTC
 	}
 	currentYear := time.Now().In(loc).Year()
 
-	counts, err := s.docRepo.GetMonthlyIssuanceForYear(currentYear)
+	counts, err := s.docRepo.GetMonthlyIssuanceForYear(ctx, currentYear)
 	if err != nil {
 		return nil, err
 	}
@@ -138,8 +147,8 @@ func (s *dashboardService) GetMonthlyIssuanceChartData() (*ChartDataDTO, error)
 	return &ChartDataDTO{Labels: labels, Data: data}, nil
 }
 
-func (s *dashboardService) GetItemCompositionPieChartData() (*PieChartDataDTO, error) {
-	stats, err := s.docRepo.GetItemCompositionStats()
+func (s *dashboardService) GetItemCompositionPieChartData(ctx context.Context) (*PieChartDataDTO, error) {
+	stats, err := s.docRepo.GetItemCompositionStats(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -171,4 +180,48 @@ func (s *dashboardService) GetItemCompositionPieChartData() (*PieChartDataDTO, e
 		Data:             data,
 		BackgroundColors: finalColors,
 	}, nil
-}
\ No newline at end of file
+}
+// GetReguBreakdown menghitung dokumen hari ini dan bulan ini per regu.
+// Dokumen tanpa regu (dibuat oleh pengguna yang tidak terdaftar di regu mana pun) dikelompokkan sebagai "-".
+func (s *dashboardService) GetReguBreakdown(ctx context.Context) ([]ReguStatDTO, error) {
+	loc, err := s.configService.GetLocation(ctx)
+	if err != nil {
+		loc = time.UTC
+	}
+	now := time.Now().In(loc)
+
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	endOfDay := startOfDay.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	startOfMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, loc)
+	endOfMonth := startOfMonth.AddDate(0, 1, 0).Add(-time.Nanosecond)
+
+	monthly, err := s.docRepo.CountByReguInRange(ctx, startOfMonth, endOfMonth)
+	if err != nil {
+		return nil, err
+	}
+	today, err := s.docRepo.CountByReguInRange(ctx, startOfDay, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make([]ReguStatDTO, 0, len(monthly))
+	index := make(map[string]int, len(monthly))
+	for _, m := range monthly {
+		regu := m.Regu
+		if regu == "" {
+			regu = "-"
+		}
+		index[regu] = len(stats)
+		stats = append(stats, ReguStatDTO{Regu: regu, DocsMonthly: m.Count})
+	}
+	for _, d := range today {
+		regu := d.Regu
+		if regu == "" {
+			regu = "-"
+		}
+		if i, ok := index[regu]; ok {
+			stats[i].DocsToday = d.Count
+		}
+	}
+	return stats, nil
+}
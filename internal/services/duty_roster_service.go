@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/reqctx"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DutyRosterService interface {
+	Create(ctx context.Context, input dto.DutyRosterInput) (*models.DutyRoster, error)
+	Update(ctx context.Context, id uint, input dto.DutyRosterInput) (*models.DutyRoster, error)
+	Delete(ctx context.Context, id uint) error
+	FindByID(ctx context.Context, id uint) (*models.DutyRoster, error)
+	// FindInRange mengambil jadwal untuk tampilan kalender, dari dan sampai dalam format YYYY-MM-DD.
+	FindInRange(ctx context.Context, from string, to string, regu string) ([]models.DutyRoster, error)
+	// FindCurrent mengambil jadwal yang sedang berlangsung menurut zona waktu kantor.
+	// Jika regu diisi dan regu tersebut sedang berjaga, jadwal regu itu yang diutamakan.
+	FindCurrent(ctx context.Context, regu string) (*models.DutyRoster, error)
+}
+
+type dutyRosterService struct {
+	rosterRepo    repositories.DutyRosterRepository
+	userRepo      repositories.UserRepository
+	auditService  AuditLogService
+	configService ConfigService
+}
+
+func NewDutyRosterService(rosterRepo repositories.DutyRosterRepository, userRepo repositories.UserRepository, auditService AuditLogService, configService ConfigService) DutyRosterService {
+	return &dutyRosterService{
+		rosterRepo:    rosterRepo,
+		userRepo:      userRepo,
+		auditService:  auditService,
+		configService: configService,
+	}
+}
+
+func (s *dutyRosterService) Create(ctx context.Context, input dto.DutyRosterInput) (*models.DutyRoster, error) {
+	roster := &models.DutyRoster{}
+	if err := s.apply(ctx, roster, input); err != nil {
+		return nil, err
+	}
+	if err := s.rosterRepo.Create(ctx, roster); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditCreateRoster, fmt.Sprintf("Membuat jadwal jaga regu %s shift %s tanggal %s", roster.Regu, roster.Shift, roster.Tanggal))
+	return s.rosterRepo.FindByID(ctx, roster.ID)
+}
+
+func (s *dutyRosterService) Update(ctx context.Context, id uint, input dto.DutyRosterInput) (*models.DutyRoster, error) {
+	roster, err := s.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(ctx, roster, input); err != nil {
+		return nil, err
+	}
+	if err := s.rosterRepo.Update(ctx, roster); err != nil {
+		return nil, err
+	}
+
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditUpdateRoster, fmt.Sprintf("Memperbarui jadwal jaga ID %d (regu %s shift %s tanggal %s)", roster.ID, roster.Regu, roster.Shift, roster.Tanggal))
+	return s.rosterRepo.FindByID(ctx, roster.ID)
+}
+
+func (s *dutyRosterService) Delete(ctx context.Context, id uint) error {
+	roster, err := s.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.rosterRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditDeleteRoster, fmt.Sprintf("Menghapus jadwal jaga regu %s shift %s tanggal %s", roster.Regu, roster.Shift, roster.Tanggal))
+	return nil
+}
+
+func (s *dutyRosterService) FindByID(ctx context.Context, id uint) (*models.DutyRoster, error) {
+	roster, err := s.rosterRepo.FindByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return roster, nil
+}
+
+func (s *dutyRosterService) FindInRange(ctx context.Context, from string, to string, regu string) ([]models.DutyRoster, error) {
+	if _, err := time.Parse("2006-01-02", from); err != nil {
+		return nil, ErrInvalidRosterTime
+	}
+	if _, err := time.Parse("2006-01-02", to); err != nil {
+		return nil, ErrInvalidRosterTime
+	}
+	return s.rosterRepo.FindInRange(ctx, from, to, regu)
+}
+
+func (s *dutyRosterService) FindCurrent(ctx context.Context, regu string) (*models.DutyRoster, error) {
+	loc, err := s.configService.GetLocation(ctx)
+	if err != nil {
+		loc = time.UTC
+	}
+	rosters, err := s.rosterRepo.FindActiveAt(ctx, time.Now().In(loc))
+	if err != nil {
+		return nil, err
+	}
+	if len(rosters) == 0 {
+		return nil, ErrNotFound
+	}
+	for i := range rosters {
+		if regu != "" && rosters[i].Regu == regu {
+			return &rosters[i], nil
+		}
+	}
+	return &rosters[0], nil
+}
+
+// apply memvalidasi masukan lalu menyalinnya ke roster. Jam mulai dan selesai
+// dihitung dalam zona waktu kantor.
+func (s *dutyRosterService) apply(ctx context.Context, roster *models.DutyRoster, input dto.DutyRosterInput) error {
+	loc, err := s.configService.GetLocation(ctx)
+	if err != nil {
+		loc = time.UTC
+	}
+	start, err := time.ParseInLocation("2006-01-02 15:04", input.Tanggal+" "+input.JamMulai, loc)
+	if err != nil {
+		return ErrInvalidRosterTime
+	}
+	end, err := time.ParseInLocation("2006-01-02 15:04", input.Tanggal+" "+input.JamSelesai, loc)
+	if err != nil {
+		return ErrInvalidRosterTime
+	}
+	if !end.After(start) {
+		end = end.AddDate(0, 0, 1)
+	}
+
+	// FindByID juga mengembalikan pengguna yang sudah dinonaktifkan, jadi DeletedAt diperiksa
+	reporter, err := s.userRepo.FindByID(ctx, input.PetugasPelaporID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidRosterOfficer
+		}
+		return err
+	}
+	if reporter.DeletedAt.Valid {
+		return ErrInvalidRosterOfficer
+	}
+	if err := checkApprover(ctx, s.userRepo, input.PejabatPersetujuID); err != nil {
+		return err
+	}
+
+	regu := strings.ToUpper(strings.TrimSpace(input.Regu))
+	overlapping, err := s.rosterRepo.CountOverlapping(ctx, regu, start, end, roster.ID)
+	if err != nil {
+		return err
+	}
+	if overlapping > 0 {
+		return ErrRosterOverlap
+	}
+
+	roster.Tanggal = input.Tanggal
+	roster.Shift = strings.ToUpper(strings.TrimSpace(input.Shift))
+	roster.Regu = regu
+	roster.MulaiPada = start
+	roster.SelesaiPada = end
+	roster.PetugasPelaporID = input.PetugasPelaporID
+	roster.PejabatPersetujuID = input.PejabatPersetujuID
+	roster.Keterangan = input.Keterangan
+	return nil
+}
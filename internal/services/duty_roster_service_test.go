@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"simdokpol/internal/reqctx"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+func TestDutyRosterService_Create(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	input := dto.DutyRosterInput{
+		Tanggal:            "2025-01-31",
+		Shift:              "malam",
+		Regu:               "ii",
+		JamMulai:           "20:00",
+		JamSelesai:         "08:00",
+		PetugasPelaporID:   2,
+		PejabatPersetujuID: 3,
+	}
+	expectedStart := time.Date(2025, 1, 31, 20, 0, 0, 0, loc)
+	expectedEnd := time.Date(2025, 2, 1, 8, 0, 0, 0, loc)
+
+	testCases := []struct {
+		name        string
+		overlapping int64
+		expectedErr error
+	}{
+		{name: "Sukses - Shift Malam Berakhir Keesokan Hari"},
+		{name: "Gagal - Beririsan dengan Jadwal Regu Lain", overlapping: 1, expectedErr: ErrRosterOverlap},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRosterRepo := new(mocks.DutyRosterRepository)
+			mockUserRepo := new(mocks.UserRepository)
+			mockAuditService := new(mocks.AuditLogService)
+			mockConfigService := new(mocks.ConfigService)
+
+			mockConfigService.On("GetLocation", mock.Anything).Return(loc, nil)
+			mockUserRepo.On("FindByID", mock.Anything, uint(2)).Return(&models.User{ID: 2}, nil).Once()
+			mockUserRepo.On("FindByID", mock.Anything, uint(3)).Return(&models.User{ID: 3, Peran: models.RoleKanit}, nil).Once()
+			mockRosterRepo.On("CountOverlapping", mock.Anything, "II", expectedStart, expectedEnd, uint(0)).Return(tc.overlapping, nil).Once()
+
+			if tc.expectedErr == nil {
+				mockRosterRepo.On("Create", mock.Anything, mock.MatchedBy(func(r *models.DutyRoster) bool {
+					return r.Shift == "MALAM" && r.Regu == "II" && r.SelesaiPada.Equal(expectedEnd)
+				})).Return(nil).Run(func(args mock.Arguments) { args.Get(1).(*models.DutyRoster).ID = 5 }).Once()
+				mockRosterRepo.On("FindByID", mock.Anything, uint(5)).Return(&models.DutyRoster{ID: 5}, nil).Once()
+				mockAuditService.On("LogActivity", mock.Anything, uint(1), models.AuditCreateRoster, mock.AnythingOfType("string")).Once()
+			}
+
+			service := NewDutyRosterService(mockRosterRepo, mockUserRepo, mockAuditService, mockConfigService)
+			roster, err := service.Create(reqctx.WithActor(context.Background(), 1), input)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+				assert.Nil(t, roster)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, uint(5), roster.ID)
+			}
+			mockRosterRepo.AssertExpectations(t)
+			mockAuditService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestDutyRosterService_CreateInvalidOfficers(t *testing.T) {
+	loc, _ := time.LoadLocation("Asia/Jakarta")
+	input := dto.DutyRosterInput{Tanggal: "2025-01-31", Shift: "pagi", Regu: "I", JamMulai: "08:00", JamSelesai: "20:00", PetugasPelaporID: 2, PejabatPersetujuID: 3}
+	deleted := gorm.DeletedAt{Time: time.Now(), Valid: true}
+
+	testCases := []struct {
+		name        string
+		reporter    *models.User
+		approver    *models.User
+		expectedErr error
+	}{
+		{name: "Pelapor Non-aktif", reporter: &models.User{ID: 2, DeletedAt: deleted}, expectedErr: ErrInvalidRosterOfficer},
+		{name: "Persetuju Non-aktif", reporter: &models.User{ID: 2}, approver: &models.User{ID: 3, Peran: models.RoleKanit, DeletedAt: deleted}, expectedErr: ErrInvalidApprover},
+		{name: "Persetuju Tanpa Hak Menyetujui", reporter: &models.User{ID: 2}, approver: &models.User{ID: 3, Peran: models.RoleOperator}, expectedErr: ErrInvalidApprover},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRosterRepo := new(mocks.DutyRosterRepository)
+			mockUserRepo := new(mocks.UserRepository)
+			mockConfigService := new(mocks.ConfigService)
+
+			mockConfigService.On("GetLocation", mock.Anything).Return(loc, nil)
+			mockUserRepo.On("FindByID", mock.Anything, uint(2)).Return(tc.reporter, nil).Once()
+			if tc.approver != nil {
+				mockUserRepo.On("FindByID", mock.Anything, uint(3)).Return(tc.approver, nil).Once()
+			}
+
+			service := NewDutyRosterService(mockRosterRepo, mockUserRepo, nil, mockConfigService)
+			roster, err := service.Create(reqctx.WithActor(context.Background(), 1), input)
+
+			assert.ErrorIs(t, err, tc.expectedErr)
+			assert.Nil(t, roster)
+			mockUserRepo.AssertExpectations(t)
+			mockRosterRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
@@ -11,6 +11,9 @@ package services
 import "errors"
 
 var (
+	// ErrSetupComplete dikembalikan saat setup awal dijalankan padahal Super Admin sudah ada.
+	ErrSetupComplete = errors.New("aplikasi sudah dikonfigurasi")
+
 	// ErrAccessDenied dikembalikan ketika seorang pengguna mencoba melakukan aksi
 	// yang tidak diizinkan oleh hak aksesnya.
 	ErrAccessDenied = errors.New("akses ditolak")
@@ -27,7 +30,91 @@ var (
 	// memiliki akun yang berstatus non-aktif (soft-deleted).
 	ErrAccountInactive = errors.New("akun Anda tidak aktif, silakan hubungi Super Admin")
 
+	// ErrLoginLocked dikembalikan (melalui LoginLockedError) saat NRP atau alamat IP
+	// dikunci sementara karena terlalu banyak percobaan login gagal.
+	ErrLoginLocked = errors.New("login dikunci sementara")
+
+	// ErrSessionInvalid dikembalikan saat sesi login tidak ditemukan, telah dicabut,
+	// kedaluwarsa, atau melewati batas idle.
+	ErrSessionInvalid = errors.New("sesi tidak valid atau telah berakhir, silakan login kembali")
+
+	// ErrInvalidTwoFactorCode dikembalikan saat kode TOTP atau kode pemulihan salah,
+	// kedaluwarsa, atau sudah pernah dipakai.
+	ErrInvalidTwoFactorCode = errors.New("kode verifikasi dua faktor salah atau sudah dipakai")
+
+	// ErrTwoFactorChallengeInvalid dikembalikan saat token langkah kedua login tidak valid
+	// atau sudah kedaluwarsa.
+	ErrTwoFactorChallengeInvalid = errors.New("waktu verifikasi dua faktor habis, silakan login kembali")
+
+	// ErrTwoFactorAlreadyEnabled dikembalikan saat memulai pendaftaran 2FA padahal 2FA sudah aktif.
+	ErrTwoFactorAlreadyEnabled = errors.New("verifikasi dua faktor sudah aktif")
+
+	// ErrTwoFactorNotEnabled dikembalikan saat aksi membutuhkan 2FA yang aktif atau pendaftaran
+	// yang sudah dimulai.
+	ErrTwoFactorNotEnabled = errors.New("verifikasi dua faktor belum aktif")
+
+	// ErrTwoFactorRequired dikembalikan saat pengguna mencoba menonaktifkan 2FA
+	// yang diwajibkan untuk perannya.
+	ErrTwoFactorRequired = errors.New("verifikasi dua faktor wajib untuk peran Anda dan tidak dapat dinonaktifkan")
+
+	// ErrPasswordPolicy dikembalikan (melalui PasswordPolicyError) saat kata sandi baru
+	// tidak memenuhi kebijakan kata sandi.
+	ErrPasswordPolicy = errors.New("kata sandi tidak memenuhi kebijakan")
+
+	// ErrPasswordChangeRequired dikembalikan saat pengguna harus mengganti kata sandi
+	// (sementara atau kedaluwarsa) sebelum dapat memakai fitur lain.
+	ErrPasswordChangeRequired = errors.New("Anda wajib mengganti kata sandi terlebih dahulu")
+
+	// ErrAPITokenInvalid dikembalikan saat API token tidak dikenal, sudah dicabut,
+	// kedaluwarsa, atau pemiliknya tidak aktif.
+	ErrAPITokenInvalid = errors.New("API token tidak valid, sudah dicabut, atau kedaluwarsa")
+
+	// ErrInvalidAPITokenRequest dikembalikan saat nama, masa berlaku, atau scope API token
+	// tidak valid, termasuk scope yang tidak dimiliki peran pemilik token.
+	ErrInvalidAPITokenRequest = errors.New("permintaan API token tidak valid")
+
+	// ErrDirectoryAccessDenied dikembalikan saat kredensial direktori valid tetapi pengguna
+	// tidak termasuk grup LDAP yang dipetakan ke peran mana pun.
+	ErrDirectoryAccessDenied = errors.New("akun direktori Anda tidak termasuk grup yang diizinkan mengakses aplikasi")
+
+	// ErrLocalAccountConflict dikembalikan saat NRP di direktori LDAP sudah dipakai akun lokal.
+	// Akun lokal tidak diambil alih agar entri direktori tidak dapat mengganti perannya.
+	ErrLocalAccountConflict = errors.New("NRP ini terdaftar sebagai akun lokal. Login dengan kata sandi lokal atau hubungi Super Admin")
+
+	// ErrDirectoryManagedAccount dikembalikan saat mengubah kata sandi atau data diri pengguna
+	// yang dikelola oleh direktori LDAP.
+	ErrDirectoryManagedAccount = errors.New("kata sandi dan data diri akun ini dikelola oleh direktori LDAP")
+
 	// ErrOldPasswordMismatch dikembalikan saat mengubah kata sandi tetapi
 	// kata sandi lama yang dimasukkan tidak cocok.
 	ErrOldPasswordMismatch = errors.New("kata sandi saat ini yang Anda masukkan salah")
+
+	// ErrInvalidSettings dikembalikan saat nilai pengaturan sistem yang akan disimpan tidak valid.
+	ErrInvalidSettings = errors.New("pengaturan tidak valid")
+
+	// ErrInvalidRole dikembalikan saat peran yang diberikan tidak dikenal sistem.
+	ErrInvalidRole = errors.New("peran tidak valid")
+
+	// ErrSelfRoleChange dikembalikan saat pengguna mencoba mengubah perannya sendiri,
+	// yang dapat mengunci administrator keluar dari sistem.
+	ErrSelfRoleChange = errors.New("tidak dapat mengubah peran akun sendiri")
+
+	// ErrInvalidRosterTime dikembalikan saat tanggal atau jam jadwal jaga tidak valid.
+	ErrInvalidRosterTime = errors.New("tanggal atau jam jadwal jaga tidak valid")
+
+	// ErrInvalidRosterOfficer dikembalikan saat petugas pelapor pada jadwal jaga tidak ditemukan
+	// atau sudah dinonaktifkan.
+	ErrInvalidRosterOfficer = errors.New("petugas pelapor tidak ditemukan atau sudah tidak aktif")
+
+	// ErrRosterOverlap dikembalikan saat jadwal jaga beririsan dengan jadwal lain
+	// milik regu yang sama.
+	ErrRosterOverlap = errors.New("jadwal jaga beririsan dengan jadwal regu yang sudah ada")
+
+	// ErrOfficerRequired dikembalikan saat petugas pelapor atau pejabat persetuju tidak
+	// dipilih dan tidak ada jadwal jaga regu operator yang berlangsung untuk dijadikan nilai bawaan.
+	ErrOfficerRequired = errors.New("petugas pelapor dan pejabat persetuju wajib diisi karena tidak ada jadwal jaga regu Anda yang berlangsung")
+
+	// ErrInvalidApprover dikembalikan saat pejabat persetuju yang dipilih tidak aktif atau
+	// tidak memiliki hak akses document.approve.
+	ErrInvalidApprover = errors.New("pejabat persetuju harus pengguna aktif yang berwenang menyetujui dokumen")
 )
\ No newline at end of file
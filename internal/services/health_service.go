@@ -0,0 +1,261 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"simdokpol/internal/config"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/utils"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Nama pemeriksaan kesiapan yang dilaporkan oleh /readyz.
+const (
+	ReadinessCheckDatabase   = "database"
+	ReadinessCheckMigrations = "migrations"
+	ReadinessCheckSetup      = "setup"
+	ReadinessCheckBackupDisk = "backup_disk"
+)
+
+// backupSpaceFactor adalah kelipatan ukuran database yang harus tersedia di folder backup,
+// agar backup berikutnya tidak memenuhi disk.
+const backupSpaceFactor = 2
+
+// HealthService memeriksa kesiapan instance dan mengumpulkan informasi diagnostik.
+type HealthService interface {
+	Readiness(ctx context.Context) *dto.ReadinessReport
+	Diagnostics(ctx context.Context) (*dto.DiagnosticsReport, error)
+}
+
+type healthService struct {
+	db                       *gorm.DB
+	cfg                      *config.Config
+	configService            ConfigService
+	expectedMigrationVersion uint
+	appVersion               string
+	startedAt                time.Time
+}
+
+// NewHealthService membuat HealthService. expectedMigrationVersion adalah versi migrasi
+// tertinggi yang dibawa oleh executable ini.
+func NewHealthService(db *gorm.DB, cfg *config.Config, configService ConfigService, expectedMigrationVersion uint, appVersion string) HealthService {
+	return &healthService{
+		db:                       db,
+		cfg:                      cfg,
+		configService:            configService,
+		expectedMigrationVersion: expectedMigrationVersion,
+		appVersion:               appVersion,
+		startedAt:                time.Now(),
+	}
+}
+
+func (s *healthService) Readiness(ctx context.Context) *dto.ReadinessReport {
+	checks := []dto.ReadinessCheck{
+		s.checkDatabase(ctx),
+		s.checkMigrations(ctx),
+		s.checkSetup(ctx),
+		s.checkBackupDisk(ctx),
+	}
+
+	report := &dto.ReadinessReport{Ready: true, Checks: checks}
+	for _, check := range checks {
+		if !check.OK {
+			report.Ready = false
+		}
+	}
+	return report
+}
+
+func (s *healthService) checkDatabase(ctx context.Context) dto.ReadinessCheck {
+	check := dto.ReadinessCheck{Name: ReadinessCheckDatabase}
+	sqlDB, err := s.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	if err != nil {
+		check.Detail = fmt.Sprintf("database tidak dapat dihubungi: %v", err)
+		return check
+	}
+	check.OK = true
+	check.Detail = "database dapat dihubungi"
+	return check
+}
+
+func (s *healthService) checkMigrations(ctx context.Context) dto.ReadinessCheck {
+	check := dto.ReadinessCheck{Name: ReadinessCheckMigrations}
+	version, dirty, err := s.migrationVersion(ctx)
+	switch {
+	case err != nil:
+		check.Detail = fmt.Sprintf("gagal membaca versi migrasi: %v", err)
+	case dirty:
+		check.Detail = fmt.Sprintf("migrasi versi %d berstatus dirty", version)
+	case version != s.expectedMigrationVersion:
+		check.Detail = fmt.Sprintf("versi migrasi %d, seharusnya %d", version, s.expectedMigrationVersion)
+	default:
+		check.OK = true
+		check.Detail = fmt.Sprintf("versi migrasi %d", version)
+	}
+	return check
+}
+
+func (s *healthService) checkSetup(ctx context.Context) dto.ReadinessCheck {
+	check := dto.ReadinessCheck{Name: ReadinessCheckSetup}
+	complete, err := s.configService.IsSetupComplete(ctx)
+	switch {
+	case err != nil:
+		check.Detail = fmt.Sprintf("gagal memeriksa status setup: %v", err)
+	case !complete:
+		check.Detail = "setup awal belum diselesaikan"
+	default:
+		check.OK = true
+		check.Detail = "setup awal sudah selesai"
+	}
+	return check
+}
+
+func (s *healthService) checkBackupDisk(ctx context.Context) dto.ReadinessCheck {
+	check := dto.ReadinessCheck{Name: ReadinessCheckBackupDisk}
+
+	backupDir := "./backups"
+	if appConfig, err := s.configService.GetConfig(ctx); err == nil {
+		backupDir = backupDirectory(appConfig)
+	}
+
+	dbSize := fileSize(dbFilePath(s.cfg.DBDSN))
+	required := uint64(dbSize) * backupSpaceFactor
+
+	free, err := utils.FreeDiskSpace(nearestExistingDir(backupDir))
+	if err != nil {
+		check.Detail = fmt.Sprintf("gagal memeriksa ruang disk folder backup '%s': %v", backupDir, err)
+		return check
+	}
+	check.Detail = fmt.Sprintf("ruang kosong %d byte di '%s', dibutuhkan minimal %d byte", free, backupDir, required)
+	check.OK = free >= required
+	return check
+}
+
+func (s *healthService) Diagnostics(ctx context.Context) (*dto.DiagnosticsReport, error) {
+	dbPath := dbFilePath(s.cfg.DBDSN)
+	report := &dto.DiagnosticsReport{
+		AppVersion:  s.appVersion,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		StartedAt:   s.startedAt,
+		DBPath:      dbPath,
+		DBSizeBytes: fileSize(dbPath),
+		BcryptCost:  s.cfg.BcryptCost,
+	}
+
+	version, dirty, err := s.migrationVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membaca versi migrasi: %w", err)
+	}
+	report.MigrationVersion = version
+	report.MigrationDirty = dirty
+
+	counts, err := s.tableRowCounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.TableRowCounts = counts
+
+	appConfig, err := s.configService.GetConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gagal mendapatkan konfigurasi aplikasi: %w", err)
+	}
+	report.BackupPath = backupDirectory(appConfig)
+	report.LastBackupFile, report.LastBackupAt = latestBackup(report.BackupPath)
+	report.Readiness = s.Readiness(ctx).Checks
+
+	return report, nil
+}
+
+// migrationVersion membaca versi dari tabel schema_migrations milik golang-migrate.
+func (s *healthService) migrationVersion(ctx context.Context) (uint, bool, error) {
+	var row struct {
+		Version uint
+		Dirty   bool
+	}
+	result := s.db.WithContext(ctx).Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&row)
+	if result.Error != nil {
+		return 0, false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, false, errors.New("belum ada migrasi yang dijalankan")
+	}
+	return row.Version, row.Dirty, nil
+}
+
+func (s *healthService) tableRowCounts(ctx context.Context) (map[string]int64, error) {
+	var tables []string
+	if err := s.db.WithContext(ctx).Raw("SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name").Scan(&tables).Error; err != nil {
+		return nil, fmt.Errorf("gagal mengambil daftar tabel: %w", err)
+	}
+
+	counts := make(map[string]int64, len(tables))
+	for _, table := range tables {
+		var count int64
+		quoted := `"` + strings.ReplaceAll(table, `"`, `""`) + `"`
+		if err := s.db.WithContext(ctx).Raw("SELECT COUNT(*) FROM " + quoted).Scan(&count).Error; err != nil {
+			return nil, fmt.Errorf("gagal menghitung baris tabel %s: %w", table, err)
+		}
+		counts[table] = count
+	}
+	return counts, nil
+}
+
+// latestBackup mencari file backup terbaru di folder backup berdasarkan waktu modifikasi.
+func latestBackup(backupDir string) (string, *time.Time) {
+	matches, err := filepath.Glob(filepath.Join(backupDir, backupFilePattern))
+	if err != nil {
+		return "", nil
+	}
+
+	var latestFile string
+	var latestAt *time.Time
+	for _, match := range matches {
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		modTime := info.ModTime()
+		if latestAt == nil || modTime.After(*latestAt) {
+			latestFile = filepath.Base(match)
+			latestAt = &modTime
+		}
+	}
+	return latestFile, latestAt
+}
+
+// fileSize mengembalikan ukuran file, atau 0 jika file tidak ada.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
+
+// nearestExistingDir naik ke folder induk sampai menemukan folder yang sudah ada, karena folder
+// backup baru dibuat saat backup pertama.
+func nearestExistingDir(dir string) string {
+	dir = filepath.Clean(dir)
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
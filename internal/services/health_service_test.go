@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"simdokpol/internal/config"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// newTestHealthDB membuat database SQLite sementara dengan tabel schema_migrations pada versi tertentu.
+func newTestHealthDB(t *testing.T, version uint, dirty bool) (*gorm.DB, string) {
+	dbPath := filepath.Join(t.TempDir(), "simdokpol.db")
+	db, err := gorm.Open(sqlite.Open(dbPath), &gorm.Config{})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+
+	assert.NoError(t, db.Exec("CREATE TABLE schema_migrations (version uint64, dirty bool)").Error)
+	assert.NoError(t, db.Exec("INSERT INTO schema_migrations (version, dirty) VALUES (?, ?)", version, dirty).Error)
+	assert.NoError(t, db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY)").Error)
+	assert.NoError(t, db.Exec("INSERT INTO users (id) VALUES (1), (2)").Error)
+	return db, dbPath
+}
+
+func findCheck(report *dto.ReadinessReport, name string) dto.ReadinessCheck {
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	return dto.ReadinessCheck{}
+}
+
+func TestHealthService_Readiness(t *testing.T) {
+	testCases := []struct {
+		name          string
+		version       uint
+		dirty         bool
+		setupComplete bool
+		failedChecks  []string
+	}{
+		{name: "Siap", version: 12, setupComplete: true},
+		{name: "Versi Migrasi Tertinggal", version: 11, setupComplete: true, failedChecks: []string{ReadinessCheckMigrations}},
+		{name: "Migrasi Dirty", version: 12, dirty: true, setupComplete: true, failedChecks: []string{ReadinessCheckMigrations}},
+		{name: "Setup Belum Selesai", version: 12, setupComplete: false, failedChecks: []string{ReadinessCheckSetup}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			db, dbPath := newTestHealthDB(t, tc.version, tc.dirty)
+			mockConfig := new(mocks.ConfigService)
+			mockConfig.On("IsSetupComplete", mock.Anything).Return(tc.setupComplete, nil)
+			mockConfig.On("GetConfig", mock.Anything).Return(&dto.AppConfig{BackupPath: filepath.Join(filepath.Dir(dbPath), "backups")}, nil)
+
+			service := NewHealthService(db, &config.Config{DBDSN: dbPath + "?_foreign_keys=on"}, mockConfig, 12, "test")
+			report := service.Readiness(context.Background())
+
+			assert.Equal(t, len(tc.failedChecks) == 0, report.Ready)
+			assert.True(t, findCheck(report, ReadinessCheckDatabase).OK)
+			assert.True(t, findCheck(report, ReadinessCheckBackupDisk).OK)
+			for _, name := range tc.failedChecks {
+				assert.False(t, findCheck(report, name).OK, name)
+				assert.NotEmpty(t, findCheck(report, name).Detail, name)
+				// Laporan untuk /readyz tidak boleh membawa rincian
+				assert.Empty(t, findCheck(report.WithoutDetails(), name).Detail, name)
+			}
+		})
+	}
+}
+
+func TestHealthService_Diagnostics(t *testing.T) {
+	db, dbPath := newTestHealthDB(t, 12, false)
+	backupDir := filepath.Join(filepath.Dir(dbPath), "backups")
+	assert.NoError(t, os.MkdirAll(backupDir, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(backupDir, "backup-simdokpol-2025-01-01_10-00-00.db"), []byte("x"), 0644))
+
+	mockConfig := new(mocks.ConfigService)
+	mockConfig.On("GetConfig", mock.Anything).Return(&dto.AppConfig{BackupPath: backupDir}, nil)
+	mockConfig.On("IsSetupComplete", mock.Anything).Return(true, nil)
+
+	service := NewHealthService(db, &config.Config{DBDSN: dbPath, BcryptCost: 11}, mockConfig, 12, "v1.2.3")
+	report, err := service.Diagnostics(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.2.3", report.AppVersion)
+	assert.Equal(t, uint(12), report.MigrationVersion)
+	assert.Equal(t, 11, report.BcryptCost)
+	assert.Positive(t, report.DBSizeBytes)
+	assert.Equal(t, int64(2), report.TableRowCounts["users"])
+	assert.Equal(t, int64(1), report.TableRowCounts["schema_migrations"])
+	assert.Equal(t, "backup-simdokpol-2025-01-01_10-00-00.db", report.LastBackupFile)
+	assert.NotNil(t, report.LastBackupAt)
+	assert.Len(t, report.Readiness, 4)
+}
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/reqctx"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// DefaultJWTRotationDays dipakai jika interval rotasi belum pernah diatur.
+	DefaultJWTRotationDays = 30
+	// DefaultJWTKeyGraceHours sama dengan masa berlaku sesi agar rotasi terjadwal
+	// tidak memutus sesi yang sedang berjalan.
+	DefaultJWTKeyGraceHours = int(SessionLifetime / time.Hour)
+	// legacyJWTKeyID adalah kid untuk token yang dibuat sebelum key ring ada (tanpa header kid).
+	legacyJWTKeyID = "legacy"
+	// jwtKeyCacheTTL membatasi seberapa lama rotasi dari proses lain (misalnya CLI)
+	// belum terlihat oleh server.
+	jwtKeyCacheTTL = time.Minute
+)
+
+type JWTKeyService interface {
+	// Sign menandatangani klaim dengan kunci aktif dan menyertakan kid pada header token.
+	// Kunci pertama dibuat otomatis jika key ring masih kosong.
+	Sign(ctx context.Context, claims jwt.MapClaims) (string, error)
+	// Parse memverifikasi token dengan kunci yang dirujuk header kid. Kunci yang sudah
+	// digantikan tetap diterima selama masa tenggang.
+	Parse(ctx context.Context, tokenString string, options ...jwt.ParserOption) (*jwt.Token, error)
+	// Rotate membuat kunci aktif baru. Jika immediate bernilai true, semua kunci lama langsung
+	// dihapus sehingga setiap pengguna harus login ulang. Tanpa aktor di ctx berarti aksi sistem atau CLI.
+	Rotate(ctx context.Context, immediate bool) (*dto.JWTKeyInfo, error)
+	// RotateIfDue mengganti kunci aktif yang umurnya melewati interval rotasi dan menghapus
+	// kunci yang masa tenggangnya sudah habis.
+	RotateIfDue(ctx context.Context) (bool, error)
+	FindAll(ctx context.Context) ([]dto.JWTKeyInfo, error)
+}
+
+type jwtKeyService struct {
+	keyRepo       repositories.JWTKeyRepository
+	configService ConfigService
+	auditService  AuditLogService
+	// masterSecret adalah JWT_SECRET_KEY. Kunci HMAC tiap kid diturunkan darinya sehingga
+	// bahan kunci di database saja tidak cukup untuk memalsukan token.
+	masterSecret []byte
+
+	mu       sync.RWMutex
+	keys     []models.JWTSigningKey
+	loadedAt time.Time
+	// rotateMu mencegah dua rotasi berjalan bersamaan, termasuk pembuatan kunci pertama.
+	rotateMu sync.Mutex
+}
+
+func NewJWTKeyService(keyRepo repositories.JWTKeyRepository, configService ConfigService, auditService AuditLogService, masterSecret string) JWTKeyService {
+	return &jwtKeyService{
+		keyRepo:       keyRepo,
+		configService: configService,
+		auditService:  auditService,
+		masterSecret:  []byte(masterSecret),
+	}
+}
+
+func (s *jwtKeyService) Sign(ctx context.Context, claims jwt.MapClaims) (string, error) {
+	key, err := s.activeKey(ctx)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(s.signingKey(key))
+}
+
+func (s *jwtKeyService) Parse(ctx context.Context, tokenString string, options ...jwt.ParserOption) (*jwt.Token, error) {
+	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("signing method tidak terduga: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			kid = legacyJWTKeyID
+		}
+		key, err := s.findValidKey(ctx, kid)
+		if err != nil {
+			return nil, err
+		}
+		return s.signingKey(key), nil
+	}, options...)
+}
+
+func (s *jwtKeyService) Rotate(ctx context.Context, immediate bool) (*dto.JWTKeyInfo, error) {
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
+	key, err := s.rotate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	details := fmt.Sprintf("Kunci penandatanganan JWT diganti, kid baru %s.", key.KID)
+	if immediate {
+		if _, err := s.keyRepo.DeleteRetired(ctx); err != nil {
+			return nil, err
+		}
+		details += " Kunci lama langsung dicabut sehingga semua pengguna harus login ulang."
+	}
+	if _, err := s.load(ctx, true); err != nil {
+		return nil, err
+	}
+
+	if actorID := reqctx.ActorID(ctx); actorID != 0 {
+		s.auditService.LogActivity(ctx, actorID, models.AuditRotateJWTKey, details)
+	} else {
+		slog.InfoContext(ctx, details, "kid", key.KID)
+	}
+	info := s.describe(*key, time.Now(), s.gracePeriod(ctx))
+	return &info, nil
+}
+
+func (s *jwtKeyService) RotateIfDue(ctx context.Context) (bool, error) {
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+
+	keys, err := s.load(ctx, true)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	rotated := false
+
+	rotationInterval := time.Duration(DefaultJWTRotationDays) * 24 * time.Hour
+	if appConfig, err := s.configService.GetConfig(ctx); err == nil {
+		rotationInterval = appConfig.JWTRotationInterval()
+	}
+	active := findActiveKey(keys)
+	if active == nil || (rotationInterval > 0 && now.Sub(active.CreatedAt) > rotationInterval) {
+		key, err := s.rotate(ctx)
+		if err != nil {
+			return false, err
+		}
+		slog.InfoContext(ctx, "Kunci penandatanganan JWT diganti otomatis", "kid", key.KID)
+		rotated = true
+	}
+
+	if purged, err := s.keyRepo.DeleteRetiredBefore(ctx, now.Add(-s.gracePeriod(ctx))); err != nil {
+		slog.WarnContext(ctx, "Gagal menghapus kunci JWT kedaluwarsa", "error", err)
+	} else if purged > 0 {
+		slog.InfoContext(ctx, "Kunci JWT yang melewati masa tenggang dihapus", "count", purged)
+	}
+	if _, err := s.load(ctx, true); err != nil {
+		return rotated, err
+	}
+	return rotated, nil
+}
+
+func (s *jwtKeyService) FindAll(ctx context.Context) ([]dto.JWTKeyInfo, error) {
+	keys, err := s.load(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	grace := s.gracePeriod(ctx)
+	infos := make([]dto.JWTKeyInfo, len(keys))
+	for i, key := range keys {
+		infos[i] = s.describe(key, now, grace)
+	}
+	return infos, nil
+}
+
+// rotate menyimpan kunci aktif baru dan mempensiunkan kunci sebelumnya. Saat key ring masih
+// kosong, JWT_SECRET_KEY lama dicatat sebagai kunci legacy yang sudah pensiun agar token
+// yang terbit sebelum pembaruan tetap diterima selama masa tenggang. Pemanggil harus memegang rotateMu.
+func (s *jwtKeyService) rotate(ctx context.Context) (*models.JWTSigningKey, error) {
+	keys, err := s.load(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+
+	if len(keys) == 0 {
+		legacy := &models.JWTSigningKey{KID: legacyJWTKeyID, CreatedAt: now, RetiredAt: &now}
+		if err := s.keyRepo.Rotate(ctx, legacy, now); err != nil {
+			return nil, err
+		}
+	}
+
+	kid, err := randomHex(8)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, err
+	}
+	key := &models.JWTSigningKey{KID: kid, Secret: secret, CreatedAt: now}
+	if err := s.keyRepo.Rotate(ctx, key, now); err != nil {
+		return nil, err
+	}
+	if _, err := s.load(ctx, true); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// activeKey mengembalikan kunci aktif, membuat kunci pertama jika belum ada.
+func (s *jwtKeyService) activeKey(ctx context.Context) (*models.JWTSigningKey, error) {
+	keys, err := s.load(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if active := findActiveKey(keys); active != nil {
+		return active, nil
+	}
+
+	s.rotateMu.Lock()
+	defer s.rotateMu.Unlock()
+	// Periksa ulang karena request lain bisa saja sudah membuat kunci
+	if keys, err = s.load(ctx, true); err != nil {
+		return nil, err
+	}
+	if active := findActiveKey(keys); active != nil {
+		return active, nil
+	}
+	return s.rotate(ctx)
+}
+
+func (s *jwtKeyService) findValidKey(ctx context.Context, kid string) (*models.JWTSigningKey, error) {
+	keys, err := s.load(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	for i := range keys {
+		if keys[i].KID != kid {
+			continue
+		}
+		if keys[i].RetiredAt != nil && time.Since(*keys[i].RetiredAt) > s.gracePeriod(ctx) {
+			return nil, fmt.Errorf("kunci JWT %s sudah kedaluwarsa", kid)
+		}
+		return &keys[i], nil
+	}
+	return nil, fmt.Errorf("kunci JWT %s tidak dikenal", kid)
+}
+
+// load membaca key ring dari cache, atau dari database jika cache kosong, kedaluwarsa, atau force.
+func (s *jwtKeyService) load(ctx context.Context, force bool) ([]models.JWTSigningKey, error) {
+	s.mu.RLock()
+	if !force && s.keys != nil && time.Since(s.loadedAt) < jwtKeyCacheTTL {
+		keys := s.keys
+		s.mu.RUnlock()
+		return keys, nil
+	}
+	s.mu.RUnlock()
+
+	keys, err := s.keyRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.keys = keys
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	return keys, nil
+}
+
+func (s *jwtKeyService) gracePeriod(ctx context.Context) time.Duration {
+	if appConfig, err := s.configService.GetConfig(ctx); err == nil {
+		return appConfig.JWTKeyGracePeriod()
+	}
+	return time.Duration(DefaultJWTKeyGraceHours) * time.Hour
+}
+
+// signingKey menurunkan kunci HMAC dari JWT_SECRET_KEY dan bahan acak milik kid.
+// Kunci legacy memakai JWT_SECRET_KEY apa adanya seperti sebelum key ring ada.
+func (s *jwtKeyService) signingKey(key *models.JWTSigningKey) []byte {
+	if key.Secret == "" {
+		return s.masterSecret
+	}
+	mac := hmac.New(sha256.New, s.masterSecret)
+	mac.Write([]byte(key.KID + ":" + key.Secret))
+	return mac.Sum(nil)
+}
+
+func (s *jwtKeyService) describe(key models.JWTSigningKey, now time.Time, grace time.Duration) dto.JWTKeyInfo {
+	info := dto.JWTKeyInfo{KID: key.KID, Status: dto.JWTKeyStatusActive, CreatedAt: key.CreatedAt, RetiredAt: key.RetiredAt}
+	if key.RetiredAt != nil {
+		validUntil := key.RetiredAt.Add(grace)
+		info.ValidUntil = &validUntil
+		info.Status = dto.JWTKeyStatusGrace
+		if now.After(validUntil) {
+			info.Status = dto.JWTKeyStatusExpired
+		}
+	}
+	return info
+}
+
+// findActiveKey mengembalikan kunci terbaru yang belum pensiun. keys harus terurut dari yang terbaru.
+func findActiveKey(keys []models.JWTSigningKey) *models.JWTSigningKey {
+	for i := range keys {
+		if keys[i].RetiredAt == nil {
+			return &keys[i]
+		}
+	}
+	return nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
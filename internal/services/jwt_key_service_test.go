@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const testJWTMasterSecret = "test-secret"
+
+// newTestJWTKeyService membuat JWTKeyService dengan satu kunci aktif untuk pengujian.
+func newTestJWTKeyService() JWTKeyService {
+	return newJWTKeyServiceWithKeys(testJWTMasterSecret, models.JWTSigningKey{KID: "aktif", Secret: "bahan-aktif", CreatedAt: time.Now()})
+}
+
+func newJWTKeyServiceWithKeys(masterSecret string, keys ...models.JWTSigningKey) JWTKeyService {
+	mockRepo := new(mocks.JWTKeyRepository)
+	mockRepo.On("FindAll", mock.Anything).Return(keys, nil)
+	mockConfig := new(mocks.ConfigService)
+	mockConfig.On("GetConfig", mock.Anything).Return(&dto.AppConfig{JWTRotationDays: 30, JWTKeyGraceHours: 24}, nil)
+	return NewJWTKeyService(mockRepo, mockConfig, new(mocks.AuditLogService), masterSecret)
+}
+
+func TestJWTKeyService_Parse(t *testing.T) {
+	now := time.Now()
+	retiredRecently := now.Add(-time.Hour)
+	retiredLongAgo := now.Add(-48 * time.Hour)
+
+	newKey := models.JWTSigningKey{KID: "baru", Secret: "bahan-baru", CreatedAt: now}
+	oldKey := models.JWTSigningKey{KID: "lama", Secret: "bahan-lama", CreatedAt: now.Add(-30 * 24 * time.Hour)}
+	staleKey := models.JWTSigningKey{KID: "usang", Secret: "bahan-usang", CreatedAt: now.Add(-60 * 24 * time.Hour)}
+	legacyKey := models.JWTSigningKey{KID: legacyJWTKeyID, CreatedAt: retiredRecently, RetiredAt: &retiredRecently}
+
+	signWith := func(t *testing.T, masterSecret string, key models.JWTSigningKey) string {
+		token, err := newJWTKeyServiceWithKeys(masterSecret, key).Sign(context.Background(), jwt.MapClaims{"userID": 1})
+		assert.NoError(t, err)
+		return token
+	}
+	oldToken := signWith(t, testJWTMasterSecret, oldKey)
+	staleToken := signWith(t, testJWTMasterSecret, staleKey)
+	foreignToken := signWith(t, "secret-lain", newKey)
+	legacyToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"userID": 1}).SignedString([]byte(testJWTMasterSecret))
+	assert.NoError(t, err)
+
+	oldKey.RetiredAt = &retiredRecently
+	staleKey.RetiredAt = &retiredLongAgo
+	service := newJWTKeyServiceWithKeys(testJWTMasterSecret, newKey, oldKey, staleKey, legacyKey)
+
+	t.Run("Token Baru Memakai Kunci Aktif", func(t *testing.T) {
+		tokenString, err := service.Sign(context.Background(), jwt.MapClaims{"userID": 1})
+		assert.NoError(t, err)
+		token, err := service.Parse(context.Background(), tokenString)
+		assert.NoError(t, err)
+		assert.Equal(t, "baru", token.Header["kid"])
+	})
+
+	t.Run("Kunci Lama Diterima Selama Masa Tenggang", func(t *testing.T) {
+		_, err := service.Parse(context.Background(), oldToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Token Tanpa kid Diterima Lewat Kunci Legacy", func(t *testing.T) {
+		_, err := service.Parse(context.Background(), legacyToken)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Kunci Melewati Masa Tenggang Ditolak", func(t *testing.T) {
+		_, err := service.Parse(context.Background(), staleToken)
+		assert.Error(t, err)
+	})
+
+	t.Run("Token dengan JWT_SECRET_KEY Lain Ditolak", func(t *testing.T) {
+		_, err := service.Parse(context.Background(), foreignToken)
+		assert.Error(t, err)
+	})
+}
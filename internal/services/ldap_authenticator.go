@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"simdokpol/internal/config"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"gorm.io/gorm"
+)
+
+// ldapPasswordPlaceholder disimpan sebagai kata sandi pengguna hasil provisi LDAP. Nilainya
+// bukan hash bcrypt sehingga tidak pernah cocok dengan kata sandi apa pun secara lokal.
+const ldapPasswordPlaceholder = "!ldap"
+
+// ldapConn adalah bagian dari *ldap.Conn yang dipakai authenticator, agar koneksi dapat
+// diganti dengan direktori tiruan saat pengujian.
+type ldapConn interface {
+	Bind(username, password string) error
+	Search(searchRequest *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close() error
+}
+
+type ldapAuthenticator struct {
+	cfg          config.LDAPConfig
+	userRepo     repositories.UserRepository
+	fallback     Authenticator
+	auditService AuditLogService
+	dial         func() (ldapConn, error)
+}
+
+// NewLDAPAuthenticator membuat Authenticator yang memverifikasi kata sandi dengan bind ke
+// direktori LDAP, menentukan peran dari keanggotaan grup, lalu membuat atau memperbarui data
+// pengguna (nama, pangkat, jabatan, peran). NRP yang tidak ditemukan di direktori diteruskan
+// ke fallback, sehingga akun lokal seperti admin dari proses setup tetap bisa login.
+func NewLDAPAuthenticator(cfg config.LDAPConfig, userRepo repositories.UserRepository, fallback Authenticator, auditService AuditLogService) Authenticator {
+	a := &ldapAuthenticator{
+		cfg:          cfg,
+		userRepo:     userRepo,
+		fallback:     fallback,
+		auditService: auditService,
+	}
+	a.dial = a.dialServer
+	return a
+}
+
+func (a *ldapAuthenticator) Authenticate(ctx context.Context, nrp string, password string) (*models.User, error) {
+	// Bind dengan kata sandi kosong dianggap "unauthenticated bind" oleh banyak server dan selalu berhasil
+	if password == "" {
+		return nil, ErrInvalidCredentials
+	}
+
+	conn, err := a.dial()
+	if err != nil {
+		return a.authenticateOffline(ctx, nrp, password, fmt.Errorf("gagal terhubung ke server LDAP: %w", err))
+	}
+	defer conn.Close()
+
+	if a.cfg.BindDN != "" {
+		if err := conn.Bind(a.cfg.BindDN, a.cfg.BindPassword); err != nil {
+			return a.authenticateOffline(ctx, nrp, password, fmt.Errorf("bind akun layanan LDAP gagal: %w", err))
+		}
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		a.cfg.BaseDN, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(a.cfg.Timeout.Seconds()), false,
+		fmt.Sprintf(a.cfg.UserFilter, ldap.EscapeFilter(nrp)),
+		[]string{a.cfg.AttrName, a.cfg.AttrPangkat, a.cfg.AttrJabatan, a.cfg.AttrGroups},
+		nil,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("pencarian pengguna LDAP gagal: %w", err)
+	}
+	switch len(result.Entries) {
+	case 0:
+		return a.authenticateFallback(ctx, nrp, password)
+	case 1:
+	default:
+		return nil, fmt.Errorf("NRP %s cocok dengan %d entri direktori", nrp, len(result.Entries))
+	}
+	entry := result.Entries[0]
+
+	// Akun lokal yang NRP-nya juga ada di direktori tetap login dengan kata sandi lokal
+	if a.fallback != nil {
+		if user, err := a.fallback.Authenticate(ctx, nrp, password); err == nil && user.AuthSource != models.AuthSourceLDAP {
+			return user, nil
+		}
+	}
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			user, _ := a.userRepo.FindByNRP(ctx, nrp)
+			return user, ErrInvalidCredentials
+		}
+		return nil, fmt.Errorf("bind LDAP sebagai %s gagal: %w", entry.DN, err)
+	}
+
+	role := a.mapRole(entry.GetAttributeValues(a.cfg.AttrGroups))
+	if role == "" {
+		slog.WarnContext(ctx, "Login LDAP ditolak: tidak termasuk grup yang dipetakan ke peran", "nrp", nrp)
+		return nil, ErrDirectoryAccessDenied
+	}
+	return a.provision(ctx, nrp, entry, role)
+}
+
+// authenticateFallback meneruskan NRP yang tidak ada di direktori ke authenticator lokal.
+// Pengguna hasil provisi LDAP yang sudah dihapus dari direktori tidak boleh lolos lewat jalur ini.
+func (a *ldapAuthenticator) authenticateFallback(ctx context.Context, nrp string, password string) (*models.User, error) {
+	if a.fallback == nil {
+		return nil, ErrInvalidCredentials
+	}
+	user, err := a.fallback.Authenticate(ctx, nrp, password)
+	if user != nil && user.AuthSource == models.AuthSourceLDAP {
+		return user, ErrInvalidCredentials
+	}
+	return user, err
+}
+
+// authenticateOffline dipakai saat direktori tidak dapat dihubungi. Akun lokal tetap bisa login
+// lewat fallback, sedangkan pengguna hasil provisi LDAP mendapat kesalahan direktori tanpa
+// dihitung sebagai percobaan kata sandi yang salah.
+func (a *ldapAuthenticator) authenticateOffline(ctx context.Context, nrp string, password string, dirErr error) (*models.User, error) {
+	slog.WarnContext(ctx, "Direktori LDAP tidak dapat dipakai, login dicoba dengan akun lokal", "nrp", nrp, "error", dirErr)
+	if a.fallback == nil {
+		return nil, dirErr
+	}
+	user, err := a.fallback.Authenticate(ctx, nrp, password)
+	if user != nil && user.AuthSource == models.AuthSourceLDAP {
+		return nil, dirErr
+	}
+	return user, err
+}
+
+// mapRole mengembalikan peran dari pemetaan grup pertama yang dimiliki pengguna.
+func (a *ldapAuthenticator) mapRole(groups []string) string {
+	for _, mapping := range a.cfg.GroupRoles {
+		for _, group := range groups {
+			// DN tidak membedakan huruf besar dan kecil
+			if strings.EqualFold(strings.TrimSpace(group), mapping.GroupDN) {
+				return mapping.Role
+			}
+		}
+	}
+	return a.cfg.DefaultRole
+}
+
+// provision membuat pengguna baru atau menyelaraskan data pengguna hasil provisi LDAP dengan
+// direktori. Akun lokal dengan NRP yang sama tidak pernah diambil alih.
+func (a *ldapAuthenticator) provision(ctx context.Context, nrp string, entry *ldap.Entry, role string) (*models.User, error) {
+	user, err := a.userRepo.FindByNRP(ctx, nrp)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if user != nil && user.AuthSource != models.AuthSourceLDAP {
+		slog.WarnContext(ctx, "Login LDAP ditolak: NRP sudah dipakai akun lokal", "nrp", nrp, "user_id", user.ID)
+		a.auditService.LogActivity(ctx, user.ID, models.AuditLoginFailed, fmt.Sprintf("Login LDAP ditolak: NRP %s sudah terdaftar sebagai akun lokal dengan peran %s, akun tidak diambil alih oleh direktori.", nrp, user.Peran))
+		return nil, ErrLocalAccountConflict
+	}
+	// Akun yang dinonaktifkan admin tetap ditolak oleh AuthService.Login
+	if user != nil && user.DeletedAt.Valid {
+		return user, nil
+	}
+
+	isNew := user == nil
+	if isNew {
+		user = &models.User{NRP: nrp, NamaLengkap: nrp, KataSandi: ldapPasswordPlaceholder}
+	}
+	oldRole := user.Peran
+	user.AuthSource = models.AuthSourceLDAP
+	user.Peran = role
+	if name := entry.GetAttributeValue(a.cfg.AttrName); name != "" {
+		user.NamaLengkap = strings.ToUpper(name)
+	}
+	if pangkat := entry.GetAttributeValue(a.cfg.AttrPangkat); pangkat != "" {
+		user.Pangkat = strings.ToUpper(pangkat)
+	}
+	if jabatan := entry.GetAttributeValue(a.cfg.AttrJabatan); jabatan != "" {
+		user.Jabatan = strings.ToUpper(jabatan)
+	}
+
+	if isNew {
+		if err := a.userRepo.Create(ctx, user); err != nil {
+			return nil, err
+		}
+		a.auditService.LogActivity(ctx, user.ID, models.AuditCreateUser, fmt.Sprintf("Pengguna '%s' (NRP: %s) dibuat otomatis dari direktori LDAP dengan peran %s.", user.NamaLengkap, user.NRP, role))
+		return user, nil
+	}
+
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	if oldRole != role {
+		a.auditService.LogActivity(ctx, user.ID, models.AuditAssignRole, fmt.Sprintf("Peran pengguna '%s' (NRP: %s) diselaraskan dengan grup direktori LDAP dari %s menjadi %s.", user.NamaLengkap, user.NRP, oldRole, role))
+	}
+	return user, nil
+}
+
+func (a *ldapAuthenticator) dialServer() (ldapConn, error) {
+	serverURL, err := url.Parse(a.cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{ServerName: serverURL.Hostname(), InsecureSkipVerify: a.cfg.InsecureSkipVerify}
+
+	conn, err := ldap.DialURL(a.cfg.URL,
+		ldap.DialWithDialer(&net.Dialer{Timeout: a.cfg.Timeout}),
+		ldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(a.cfg.Timeout)
+	if a.cfg.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
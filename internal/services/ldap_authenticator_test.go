@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"simdokpol/internal/config"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const testAdminGroupDN = "cn=simdokpol-admin,ou=groups,dc=polri,dc=go,dc=id"
+
+// fakeLDAPDirectory adalah direktori tiruan di dalam proses yang meniru bind dan pencarian berdasarkan uid.
+type fakeLDAPDirectory struct {
+	passwords map[string]string // DN -> kata sandi
+	entries   []*ldap.Entry
+}
+
+func (d *fakeLDAPDirectory) Bind(username, password string) error {
+	if expected, ok := d.passwords[username]; ok && expected == password {
+		return nil
+	}
+	return ldap.NewError(ldap.LDAPResultInvalidCredentials, nil)
+}
+
+func (d *fakeLDAPDirectory) Search(req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	result := &ldap.SearchResult{}
+	for _, entry := range d.entries {
+		if strings.Contains(req.Filter, "(uid="+entry.GetAttributeValue("uid")+")") {
+			result.Entries = append(result.Entries, entry)
+		}
+	}
+	return result, nil
+}
+
+func (d *fakeLDAPDirectory) Close() error { return nil }
+
+func TestLDAPAuthenticator_Authenticate(t *testing.T) {
+	directory := &fakeLDAPDirectory{
+		passwords: map[string]string{
+			"cn=svc,dc=polri,dc=go,dc=id":              "rahasia-layanan",
+			"uid=11111,ou=people,dc=polri,dc=go,dc=id": "sandi-direktori",
+			"uid=22222,ou=people,dc=polri,dc=go,dc=id": "sandi-direktori",
+		},
+		entries: []*ldap.Entry{
+			ldap.NewEntry("uid=11111,ou=people,dc=polri,dc=go,dc=id", map[string][]string{
+				"uid": {"11111"}, "cn": {"Budi Santoso"}, "employeeType": {"Ipda"}, "title": {"Kanit SPKT"},
+				"memberOf": {"CN=SIMDOKPOL-Admin,OU=Groups,DC=polri,DC=go,DC=id"},
+			}),
+			ldap.NewEntry("uid=22222,ou=people,dc=polri,dc=go,dc=id", map[string][]string{
+				"uid": {"22222"}, "cn": {"Tanpa Grup"},
+			}),
+		},
+	}
+	cfg := config.LDAPConfig{
+		BindDN: "cn=svc,dc=polri,dc=go,dc=id", BindPassword: "rahasia-layanan",
+		BaseDN: "dc=polri,dc=go,dc=id", UserFilter: "(&(objectClass=person)(uid=%s))",
+		AttrName: "cn", AttrPangkat: "employeeType", AttrJabatan: "title", AttrGroups: "memberOf",
+		GroupRoles: []config.LDAPGroupRole{{GroupDN: testAdminGroupDN, Role: models.RoleSuperAdmin}},
+	}
+	localHash, _ := bcrypt.GenerateFromPassword([]byte("sandi-lokal"), bcrypt.MinCost)
+
+	newAuthenticator := func(mockUserRepo *mocks.UserRepository) Authenticator {
+		mockAudit := new(mocks.AuditLogService)
+		mockAudit.On("LogActivity", mock.Anything, mock.AnythingOfType("uint"), mock.AnythingOfType("string"), mock.AnythingOfType("string")).Maybe()
+		a := NewLDAPAuthenticator(cfg, mockUserRepo, NewLocalAuthenticator(mockUserRepo), mockAudit).(*ldapAuthenticator)
+		a.dial = func() (ldapConn, error) { return directory, nil }
+		return a
+	}
+
+	t.Run("Login Pertama Membuat Pengguna dari Direktori", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "11111").Return(nil, gorm.ErrRecordNotFound)
+		mockUserRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil).Once()
+
+		user, err := newAuthenticator(mockUserRepo).Authenticate(context.Background(), "11111", "sandi-direktori")
+		assert.NoError(t, err)
+		assert.Equal(t, "BUDI SANTOSO", user.NamaLengkap)
+		assert.Equal(t, "IPDA", user.Pangkat)
+		assert.Equal(t, "KANIT SPKT", user.Jabatan)
+		assert.Equal(t, models.RoleSuperAdmin, user.Peran)
+		assert.Equal(t, models.AuthSourceLDAP, user.AuthSource)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Pengguna Lama Diselaraskan dengan Direktori", func(t *testing.T) {
+		existing := &models.User{ID: 5, NRP: "11111", NamaLengkap: "NAMA LAMA", Peran: models.RoleOperator, AuthSource: models.AuthSourceLDAP}
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "11111").Return(existing, nil)
+		mockUserRepo.On("Update", mock.Anything, existing).Return(nil).Once()
+
+		user, err := newAuthenticator(mockUserRepo).Authenticate(context.Background(), "11111", "sandi-direktori")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(5), user.ID)
+		assert.Equal(t, models.RoleSuperAdmin, user.Peran)
+		assert.Equal(t, models.AuthSourceLDAP, user.AuthSource)
+		mockUserRepo.AssertExpectations(t)
+	})
+
+	t.Run("Akun Lokal dengan NRP Sama Tidak Diambil Alih", func(t *testing.T) {
+		existing := &models.User{ID: 1, NRP: "11111", NamaLengkap: "ADMIN SETUP", KataSandi: string(localHash), Peran: models.RoleSuperAdmin, AuthSource: models.AuthSourceLocal}
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "11111").Return(existing, nil)
+		mockAudit := new(mocks.AuditLogService)
+		mockAudit.On("LogActivity", mock.Anything, uint(1), models.AuditLoginFailed, mock.AnythingOfType("string")).Once()
+		a := NewLDAPAuthenticator(cfg, mockUserRepo, NewLocalAuthenticator(mockUserRepo), mockAudit).(*ldapAuthenticator)
+		a.dial = func() (ldapConn, error) { return directory, nil }
+
+		user, err := a.Authenticate(context.Background(), "11111", "sandi-lokal")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), user.ID)
+
+		user, err = a.Authenticate(context.Background(), "11111", "sandi-direktori")
+		assert.Nil(t, user)
+		assert.ErrorIs(t, err, ErrLocalAccountConflict)
+		assert.Equal(t, models.RoleSuperAdmin, existing.Peran)
+		assert.Equal(t, models.AuthSourceLocal, existing.AuthSource)
+		mockUserRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+		mockAudit.AssertExpectations(t)
+	})
+
+	t.Run("Kata Sandi Direktori Salah", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "11111").Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := newAuthenticator(mockUserRepo).Authenticate(context.Background(), "11111", "salah")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		mockUserRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("Kata Sandi Kosong Ditolak", func(t *testing.T) {
+		_, err := newAuthenticator(new(mocks.UserRepository)).Authenticate(context.Background(), "11111", "")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("Tanpa Grup yang Dipetakan Ditolak", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "22222").Return(nil, gorm.ErrRecordNotFound)
+
+		_, err := newAuthenticator(mockUserRepo).Authenticate(context.Background(), "22222", "sandi-direktori")
+		assert.ErrorIs(t, err, ErrDirectoryAccessDenied)
+	})
+
+	t.Run("NRP di Luar Direktori Memakai Akun Lokal", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "99999").Return(&models.User{ID: 1, NRP: "99999", KataSandi: string(localHash), AuthSource: models.AuthSourceLocal}, nil)
+
+		user, err := newAuthenticator(mockUserRepo).Authenticate(context.Background(), "99999", "sandi-lokal")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), user.ID)
+	})
+
+	t.Run("Pengguna LDAP yang Dihapus dari Direktori Ditolak", func(t *testing.T) {
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "88888").Return(&models.User{ID: 2, NRP: "88888", KataSandi: string(localHash), AuthSource: models.AuthSourceLDAP}, nil)
+
+		_, err := newAuthenticator(mockUserRepo).Authenticate(context.Background(), "88888", "sandi-lokal")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	t.Run("Direktori Tidak Tersedia Tetap Mengizinkan Akun Lokal", func(t *testing.T) {
+		dialErr := errors.New("dial tcp 10.0.0.5:636: connection refused")
+		mockUserRepo := new(mocks.UserRepository)
+		mockUserRepo.On("FindByNRP", mock.Anything, "99999").Return(&models.User{ID: 1, NRP: "99999", KataSandi: string(localHash), AuthSource: models.AuthSourceLocal}, nil)
+		mockUserRepo.On("FindByNRP", mock.Anything, "11111").Return(&models.User{ID: 5, NRP: "11111", KataSandi: ldapPasswordPlaceholder, AuthSource: models.AuthSourceLDAP}, nil)
+		a := newAuthenticator(mockUserRepo).(*ldapAuthenticator)
+		a.dial = func() (ldapConn, error) { return nil, dialErr }
+
+		user, err := a.Authenticate(context.Background(), "99999", "sandi-lokal")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), user.ID)
+
+		user, err = a.Authenticate(context.Background(), "11111", "sandi-direktori")
+		assert.Nil(t, user)
+		assert.ErrorIs(t, err, dialErr)
+		assert.NotErrorIs(t, err, ErrInvalidCredentials)
+	})
+}
@@ -1,24 +1,32 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"gorm.io/gorm"
-	"log"
+	"log/slog"
+	"simdokpol/internal/metrics"
+	"simdokpol/internal/dto"
 	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
+	"simdokpol/internal/reqctx"
 	"strconv"
 	"strings"
 	"time"
 )
 
 type LostDocumentService interface {
-	CreateLostDocument(residentData models.Resident, items []models.LostItem, operatorID uint, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error)
-	UpdateLostDocument(docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint, loggedInUserID uint) (*models.LostDocument, error)
-	FindAll(query string, statusFilter string) ([]models.LostDocument, error)
-	SearchGlobal(query string) ([]models.LostDocument, error)
-	FindByID(id uint, actorID uint) (*models.LostDocument, error)
-	DeleteLostDocument(id uint, loggedInUserID uint) error
+	CreateLostDocument(ctx context.Context, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error)
+	UpdateLostDocument(ctx context.Context, docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error)
+	FindAll(ctx context.Context, query string, statusFilter string) ([]models.LostDocument, error)
+	SearchGlobal(ctx context.Context, query string) ([]models.LostDocument, error)
+	FindByID(ctx context.Context, id uint) (*models.LostDocument, error)
+	DeleteLostDocument(ctx context.Context, id uint) error
+	// GetHandover mengambil dokumen yang dibuat atau diperbarui regu tertentu dalam rentang jam jaga.
+	// Regu lain hanya dapat diminta oleh pengguna yang boleh melihat semua dokumen, dan hasilnya
+	// tetap dibatasi cakupan visibilitas pengguna.
+	GetHandover(ctx context.Context, regu string, start time.Time, end time.Time) (*dto.HandoverReport, error)
 }
 
 type lostDocumentService struct {
@@ -26,38 +34,44 @@ type lostDocumentService struct {
 	docRepo       repositories.LostDocumentRepository
 	residentRepo  repositories.ResidentRepository
 	userRepo      repositories.UserRepository
+	rosterRepo    repositories.DutyRosterRepository
 	auditService  AuditLogService
 	configService ConfigService
 }
 
-func NewLostDocumentService(db *gorm.DB, docRepo repositories.LostDocumentRepository, residentRepo repositories.ResidentRepository, userRepo repositories.UserRepository, auditService AuditLogService, configService ConfigService) LostDocumentService {
+func NewLostDocumentService(db *gorm.DB, docRepo repositories.LostDocumentRepository, residentRepo repositories.ResidentRepository, userRepo repositories.UserRepository, rosterRepo repositories.DutyRosterRepository, auditService AuditLogService, configService ConfigService) LostDocumentService {
 	return &lostDocumentService{
 		db:            db,
 		docRepo:       docRepo,
 		residentRepo:  residentRepo,
 		userRepo:      userRepo,
+		rosterRepo:    rosterRepo,
 		auditService:  auditService,
 		configService: configService,
 	}
 }
 
-func (s *lostDocumentService) FindByID(id uint, actorID uint) (*models.LostDocument, error) {
-	doc, err := s.docRepo.FindByID(id)
+func (s *lostDocumentService) FindByID(ctx context.Context, id uint) (*models.LostDocument, error) {
+	doc, err := s.docRepo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 
-	actor, err := s.userRepo.FindByID(actorID)
+	actor, err := s.userRepo.FindByID(ctx, reqctx.ActorID(ctx))
 	if err != nil {
 		return nil, errors.New("pengguna tidak valid")
 	}
 
-	if actor.Peran != models.RoleSuperAdmin && doc.OperatorID != actorID {
-		return nil, errors.New("akses ditolak")
+	scope, err := s.readScope(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.Allows(doc) {
+		return nil, ErrAccessDenied
 	}
 
-	appConfig, _ := s.configService.GetConfig()
-	archiveDuration := time.Duration(appConfig.ArchiveDurationDays) * 24 * time.Hour
+	appConfig, _ := s.configService.GetConfig(ctx)
+	archiveDuration := appConfig.ArchiveDuration()
 
 	if doc.Status == "DITERBITKAN" && time.Now().After(doc.TanggalLaporan.Add(archiveDuration)) {
 		doc.Status = "DIARSIPKAN"
@@ -66,8 +80,45 @@ func (s *lostDocumentService) FindByID(id uint, actorID uint) (*models.LostDocum
 	return doc, nil
 }
 
-func (s *lostDocumentService) generateDocumentNumber() (string, error) {
-	loc, err := s.configService.GetLocation()
+// visibilityScope menghitung cakupan dokumen berdasarkan pengaturan visibilitas sistem.
+func (s *lostDocumentService) visibilityScope(ctx context.Context, actor *models.User) (repositories.DocumentScope, error) {
+	appConfig, err := s.configService.GetConfig(ctx)
+	if err != nil {
+		return repositories.DocumentScope{}, err
+	}
+	scope := repositories.DocumentScope{OperatorID: actor.ID}
+	switch appConfig.DocumentVisibility {
+	case models.VisibilityAll:
+		scope.All = true
+	case models.VisibilityRegu:
+		scope.Regu = actor.Regu
+	}
+	return scope, nil
+}
+
+// readScope adalah cakupan dokumen yang boleh dilihat; hak akses document.read_all membuka semuanya.
+func (s *lostDocumentService) readScope(ctx context.Context, actor *models.User) (repositories.DocumentScope, error) {
+	if actor.HasPermission(models.PermDocumentReadAll) {
+		return repositories.DocumentScope{All: true, OperatorID: actor.ID}, nil
+	}
+	return s.visibilityScope(ctx, actor)
+}
+
+// canModify memeriksa apakah pengguna boleh memperbarui dokumen. Selain pemilik dan pemegang
+// hak akses document.manage_all, rekan dalam cakupan visibilitas dapat menindaklanjuti dokumen.
+func (s *lostDocumentService) canModify(ctx context.Context, actor *models.User, doc *models.LostDocument) (bool, error) {
+	if actor.HasPermission(models.PermDocumentManageAll) || doc.OperatorID == actor.ID {
+		return true, nil
+	}
+	scope, err := s.visibilityScope(ctx, actor)
+	if err != nil {
+		return false, err
+	}
+	return scope.Allows(doc), nil
+}
+
+func (s *lostDocumentService) generateDocumentNumber(ctx context.Context) (string, error) {
+	loc, err := s.configService.GetLocation(ctx)
 	if err != nil {
 		loc = time.UTC
 	}
@@ -76,7 +127,7 @@ func (s *lostDocumentService) generateDocumentNumber() (string, error) {
 	month := int(now.Month())
 	monthRoman := intToRoman(month)
 	lastNumFromDB := 0
-	lastDoc, err := s.docRepo.GetLastDocumentOfYear(year)
+	lastDoc, err := s.docRepo.GetLastDocumentOfYear(ctx, year)
 	if err != nil && err != gorm.ErrRecordNotFound {
 		return "", err
 	}
@@ -89,13 +140,12 @@ func (s *lostDocumentService) generateDocumentNumber() (string, error) {
 		}
 	}
 	lastNumFromConfig := 0
-	appConfig, err := s.configService.GetConfig()
+	appConfig, err := s.configService.GetConfig(ctx)
 	if err != nil {
-		log.Printf("PERINGATAN: Tidak dapat memuat konfigurasi untuk penomoran surat: %v", err)
-	} else {
-		if num, err := strconv.Atoi(appConfig.NomorSuratTerakhir); err == nil {
-			lastNumFromConfig = num
-		}
+		return "", fmt.Errorf("gagal memuat format nomor surat: %w", err)
+	}
+	if num, err := strconv.Atoi(appConfig.NomorSuratTerakhir); err == nil {
+		lastNumFromConfig = num
 	}
 	trueLastNumber := 0
 	if lastNumFromDB > lastNumFromConfig {
@@ -107,10 +157,26 @@ func (s *lostDocumentService) generateDocumentNumber() (string, error) {
 	return fmt.Sprintf(appConfig.FormatNomorSurat, runningNumber, monthRoman, year), nil
 }
 
-func (s *lostDocumentService) CreateLostDocument(residentData models.Resident, items []models.LostItem, operatorID uint, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error) {
+func (s *lostDocumentService) CreateLostDocument(ctx context.Context, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error) {
+	operatorID := reqctx.ActorID(ctx)
+	operator, err := s.userRepo.FindByID(ctx, operatorID)
+	if err != nil {
+		return nil, errors.New("pengguna tidak valid")
+	}
+
+	if petugasPelaporID == 0 || pejabatPersetujuID == 0 {
+		petugasPelaporID, pejabatPersetujuID = s.defaultOfficers(ctx, operator.Regu, petugasPelaporID, pejabatPersetujuID)
+		if petugasPelaporID == 0 || pejabatPersetujuID == 0 {
+			return nil, ErrOfficerRequired
+		}
+	}
+	if err := checkApprover(ctx, s.userRepo, pejabatPersetujuID); err != nil {
+		return nil, err
+	}
+
 	var createdDocID uint
 	var finalDocNumber string
-	err := s.db.Transaction(func(tx *gorm.DB) error {
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		var existingResident models.Resident
 		err := tx.Where("nama_lengkap = ? AND tanggal_lahir = ?", residentData.NamaLengkap, residentData.TanggalLahir).First(&existingResident).Error
 		if err == gorm.ErrRecordNotFound {
@@ -118,7 +184,7 @@ func (s *lostDocumentService) CreateLostDocument(residentData models.Resident, i
 			// Untuk produksi, alur ini mungkin perlu disempurnakan,
 			// misalnya dengan menyimpan sebagai draf atau integrasi dengan data kependudukan.
 			residentData.NIK = fmt.Sprintf("TEMP%d", time.Now().UnixNano())
-			newResident, createErr := s.residentRepo.Create(tx, &residentData)
+			newResident, createErr := s.residentRepo.Create(ctx, tx, &residentData)
 			if createErr != nil {
 				return createErr
 			}
@@ -126,12 +192,12 @@ func (s *lostDocumentService) CreateLostDocument(residentData models.Resident, i
 		} else if err != nil {
 			return err
 		}
-		docNumber, err := s.generateDocumentNumber()
+		docNumber, err := s.generateDocumentNumber(ctx)
 		if err != nil {
 			return err
 		}
 		finalDocNumber = docNumber
-		loc, err := s.configService.GetLocation()
+		loc, err := s.configService.GetLocation(ctx)
 		if err != nil {
 			loc = time.UTC
 		}
@@ -145,40 +211,95 @@ func (s *lostDocumentService) CreateLostDocument(residentData models.Resident, i
 			PetugasPelaporID:   petugasPelaporID,
 			PejabatPersetujuID: &pejabatPersetujuID,
 			OperatorID:         operatorID,
+			Regu:               operator.Regu,
 			TanggalPersetujuan: &now,
 			LostItems:          items,
 		}
-		created, err := s.docRepo.Create(tx, newDoc)
+		created, err := s.docRepo.Create(ctx, tx, newDoc)
 		if err != nil {
 			return err
 		}
 		createdDocID = created.ID
-		return nil
+		// Log audit ditulis di transaksi yang sama agar dokumen dan catatannya commit bersamaan
+		return s.auditService.LogActivityTx(ctx, tx, operatorID, models.AuditCreateDocument, fmt.Sprintf("Membuat surat keterangan hilang baru dengan nomor: %s", finalDocNumber))
 	})
 	if err != nil {
 		return nil, err
 	}
-	s.auditService.LogActivity(operatorID, models.AuditCreateDocument, fmt.Sprintf("Membuat surat keterangan hilang baru dengan nomor: %s", finalDocNumber))
-	finalDoc, err := s.docRepo.FindByID(createdDocID)
+	metrics.DocumentEvent(metrics.DocumentCreated)
+	finalDoc, err := s.docRepo.FindByID(ctx, createdDocID)
 	if err != nil {
 		return nil, err
 	}
 	return finalDoc, nil
 }
 
-func (s *lostDocumentService) UpdateLostDocument(docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint, loggedInUserID uint) (*models.LostDocument, error) {
+// defaultOfficers mengisi petugas pelapor dan pejabat persetuju yang kosong dari jadwal jaga
+// regu operator yang sedang berlangsung menurut zona waktu kantor. Jadwal regu lain tidak
+// dipakai, sehingga nilai yang kosong tetap kosong jika regu operator tidak sedang berjaga.
+func (s *lostDocumentService) defaultOfficers(ctx context.Context, regu string, petugasPelaporID uint, pejabatPersetujuID uint) (uint, uint) {
+	loc, err := s.configService.GetLocation(ctx)
+	if err != nil {
+		loc = time.UTC
+	}
+	rosters, err := s.rosterRepo.FindActiveAt(ctx, time.Now().In(loc))
+	if err != nil {
+		slog.WarnContext(ctx, "Gagal memuat jadwal jaga aktif", "error", err)
+		return petugasPelaporID, pejabatPersetujuID
+	}
+
+	var onDuty *models.DutyRoster
+	for i := range rosters {
+		if rosters[i].Regu == regu {
+			onDuty = &rosters[i]
+			break
+		}
+	}
+	if onDuty == nil {
+		return petugasPelaporID, pejabatPersetujuID
+	}
+	if petugasPelaporID == 0 {
+		petugasPelaporID = onDuty.PetugasPelaporID
+	}
+	if pejabatPersetujuID == 0 {
+		pejabatPersetujuID = onDuty.PejabatPersetujuID
+	}
+	return petugasPelaporID, pejabatPersetujuID
+}
+
+// checkApprover memastikan pejabat persetuju adalah pengguna aktif dengan hak document.approve.
+func checkApprover(ctx context.Context, userRepo repositories.UserRepository, approverID uint) error {
+	approver, err := userRepo.FindByID(ctx, approverID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrInvalidApprover
+		}
+		return err
+	}
+	if approver.DeletedAt.Valid || !approver.HasPermission(models.PermDocumentApprove) {
+		return ErrInvalidApprover
+	}
+	return nil
+}
+
+func (s *lostDocumentService) UpdateLostDocument(ctx context.Context, docID uint, residentData models.Resident, items []models.LostItem, lokasiHilang string, petugasPelaporID uint, pejabatPersetujuID uint) (*models.LostDocument, error) {
+	loggedInUserID := reqctx.ActorID(ctx)
 	var updatedDoc *models.LostDocument
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		existingDoc, err := s.docRepo.FindByID(docID)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		existingDoc, err := s.docRepo.FindByID(ctx, docID)
 		if err != nil {
 			return err
 		}
-		loggedInUser, err := s.userRepo.FindByID(loggedInUserID)
+		loggedInUser, err := s.userRepo.FindByID(ctx, loggedInUserID)
 		if err != nil {
 			return errors.New("pengguna tidak valid")
 		}
-		if loggedInUser.Peran != models.RoleSuperAdmin && existingDoc.OperatorID != loggedInUserID {
-			return errors.New("akses ditolak: Anda bukan pemilik dokumen ini")
+		allowed, err := s.canModify(ctx, loggedInUser, existingDoc)
+		if err != nil {
+			return err
+		}
+		if !allowed {
+			return ErrAccessDenied
 		}
 		existingDoc.Resident.NamaLengkap = residentData.NamaLengkap
 		existingDoc.Resident.TempatLahir = residentData.TempatLahir
@@ -188,39 +309,47 @@ func (s *lostDocumentService) UpdateLostDocument(docID uint, residentData models
 		existingDoc.Resident.Pekerjaan = residentData.Pekerjaan
 		existingDoc.Resident.Alamat = residentData.Alamat
 		existingDoc.LokasiHilang = lokasiHilang
-		existingDoc.PetugasPelaporID = petugasPelaporID
-		existingDoc.PejabatPersetujuID = &pejabatPersetujuID
+		// ID kosong berarti petugas tidak diubah
+		if petugasPelaporID != 0 {
+			existingDoc.PetugasPelaporID = petugasPelaporID
+		}
+		if pejabatPersetujuID != 0 {
+			if err := checkApprover(ctx, s.userRepo, pejabatPersetujuID); err != nil {
+				return err
+			}
+			existingDoc.PejabatPersetujuID = &pejabatPersetujuID
+		}
 		existingDoc.LastUpdatedByID = &loggedInUserID
 		if err := tx.Where("lost_document_id = ?", docID).Delete(&models.LostItem{}).Error; err != nil {
 			return err
 		}
 		existingDoc.LostItems = items
-		updatedDoc, err = s.docRepo.Update(tx, existingDoc)
+		updatedDoc, err = s.docRepo.Update(ctx, tx, existingDoc)
 		if err != nil {
 			return err
 		}
-		return nil
+		return s.auditService.LogActivityTx(ctx, tx, loggedInUserID, models.AuditUpdateDocument, fmt.Sprintf("Memperbarui dokumen dengan Nomor Surat: %s", updatedDoc.NomorSurat))
 	})
 	if err != nil {
 		return nil, err
 	}
-	s.auditService.LogActivity(loggedInUserID, models.AuditUpdateDocument, fmt.Sprintf("Memperbarui dokumen dengan Nomor Surat: %s", updatedDoc.NomorSurat))
 	return updatedDoc, nil
 }
 
-func (s *lostDocumentService) DeleteLostDocument(id uint, loggedInUserID uint) error {
+func (s *lostDocumentService) DeleteLostDocument(ctx context.Context, id uint) error {
 	var docToDelete models.LostDocument
-	if err := s.db.First(&docToDelete, id).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&docToDelete, id).Error; err != nil {
 		return errors.New("dokumen tidak ditemukan")
 	}
 	originalNomorSurat := docToDelete.NomorSurat
-	err := s.db.Transaction(func(tx *gorm.DB) error {
-		loggedInUser, err := s.userRepo.FindByID(loggedInUserID)
+	loggedInUserID := reqctx.ActorID(ctx)
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		loggedInUser, err := s.userRepo.FindByID(ctx, loggedInUserID)
 		if err != nil {
 			return errors.New("pengguna tidak valid")
 		}
-		if loggedInUser.Peran != models.RoleSuperAdmin && docToDelete.OperatorID != loggedInUserID {
-			return errors.New("akses ditolak: Anda bukan pemilik dokumen ini")
+		if !loggedInUser.HasPermission(models.PermDocumentManageAll) && docToDelete.OperatorID != loggedInUserID {
+			return fmt.Errorf("%w: Anda bukan pemilik dokumen ini", ErrAccessDenied)
 		}
 		modifiedNomorSurat := fmt.Sprintf("DELETED_%d_%s", time.Now().Unix(), docToDelete.NomorSurat)
 		if err := tx.Model(&models.LostDocument{}).Where("id = ?", id).Update("nomor_surat", modifiedNomorSurat).Error; err != nil {
@@ -229,21 +358,21 @@ func (s *lostDocumentService) DeleteLostDocument(id uint, loggedInUserID uint) e
 		if err := tx.Delete(&models.LostDocument{}, id).Error; err != nil {
 			return err
 		}
-		return nil
+		return s.auditService.LogActivityTx(ctx, tx, loggedInUserID, models.AuditDeleteDocument, fmt.Sprintf("Menghapus dokumen dengan Nomor Surat: %s", originalNomorSurat))
 	})
 	if err != nil {
 		return err
 	}
-	s.auditService.LogActivity(loggedInUserID, models.AuditDeleteDocument, fmt.Sprintf("Menghapus dokumen dengan Nomor Surat: %s", originalNomorSurat))
+	metrics.DocumentEvent(metrics.DocumentDeleted)
 	return nil
 }
 
-func (s *lostDocumentService) processDocsStatus(docs []models.LostDocument) ([]models.LostDocument, error) {
-	appConfig, err := s.configService.GetConfig()
+func (s *lostDocumentService) processDocsStatus(ctx context.Context, docs []models.LostDocument) ([]models.LostDocument, error) {
+	appConfig, err := s.configService.GetConfig(ctx)
 	if err != nil {
 		return nil, err
 	}
-	archiveDuration := time.Duration(appConfig.ArchiveDurationDays) * 24 * time.Hour
+	archiveDuration := appConfig.ArchiveDuration()
 
 	for i := range docs {
 		if docs[i].Status == "DITERBITKAN" && time.Now().After(docs[i].TanggalLaporan.Add(archiveDuration)) {
@@ -253,25 +382,74 @@ func (s *lostDocumentService) processDocsStatus(docs []models.LostDocument) ([]m
 	return docs, nil
 }
 
-func (s *lostDocumentService) SearchGlobal(query string) ([]models.LostDocument, error) {
-	docs, err := s.docRepo.SearchGlobal(query)
+func (s *lostDocumentService) SearchGlobal(ctx context.Context, query string) ([]models.LostDocument, error) {
+	actor, err := s.userRepo.FindByID(ctx, reqctx.ActorID(ctx))
+	if err != nil {
+		return nil, errors.New("pengguna tidak valid")
+	}
+	scope, err := s.readScope(ctx, actor)
 	if err != nil {
 		return nil, err
 	}
-	return s.processDocsStatus(docs)
+	docs, err := s.docRepo.SearchGlobal(ctx, query, scope)
+	if err != nil {
+		return nil, err
+	}
+	return s.processDocsStatus(ctx, docs)
 }
 
-func (s *lostDocumentService) FindAll(query string, statusFilter string) ([]models.LostDocument, error) {
-	appConfig, err := s.configService.GetConfig()
+func (s *lostDocumentService) FindAll(ctx context.Context, query string, statusFilter string) ([]models.LostDocument, error) {
+	appConfig, err := s.configService.GetConfig(ctx)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := s.userRepo.FindByID(ctx, reqctx.ActorID(ctx))
+	if err != nil {
+		return nil, errors.New("pengguna tidak valid")
+	}
+	scope, err := s.readScope(ctx, actor)
 	if err != nil {
 		return nil, err
 	}
 
-	docs, err := s.docRepo.FindAll(query, statusFilter, appConfig.ArchiveDurationDays)
+	docs, err := s.docRepo.FindAll(ctx, query, statusFilter, appConfig.ArchiveDurationDays, scope)
 	if err != nil {
 		return nil, err
 	}
-	return s.processDocsStatus(docs)
+	return s.processDocsStatus(ctx, docs)
+}
+
+func (s *lostDocumentService) GetHandover(ctx context.Context, regu string, start time.Time, end time.Time) (*dto.HandoverReport, error) {
+	actor, err := s.userRepo.FindByID(ctx, reqctx.ActorID(ctx))
+	if err != nil {
+		return nil, errors.New("pengguna tidak valid")
+	}
+	scope, err := s.readScope(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	if !scope.All && regu != actor.Regu {
+		return nil, ErrAccessDenied
+	}
+
+	docs, err := s.docRepo.FindByReguInRange(ctx, regu, start, end, scope)
+	if err != nil {
+		return nil, err
+	}
+	docs, err = s.processDocsStatus(ctx, docs)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &dto.HandoverReport{Regu: regu, Start: start, End: end, Documents: docs}
+	for _, doc := range docs {
+		if !doc.TanggalLaporan.Before(start) && !doc.TanggalLaporan.After(end) {
+			report.TotalCreated++
+		} else {
+			report.TotalUpdated++
+		}
+	}
+	return report, nil
 }
 
 func intToRoman(num int) string {
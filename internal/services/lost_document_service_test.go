@@ -1,11 +1,14 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"regexp"
 	"simdokpol/internal/dto"
 	"simdokpol/internal/mocks"
 	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/reqctx"
 	"testing"
 	"time"
 
@@ -56,11 +59,14 @@ func TestLostDocumentService_CreateLostDocument(t *testing.T) {
 		{
 			name: "Sukses - Membuat Dokumen dengan Penduduk Baru",
 			setupMocks: func(dbMock sqlmock.Sqlmock, docRepo *mocks.LostDocumentRepository, resRepo *mocks.ResidentRepository, userRepo *mocks.UserRepository, auditService *mocks.AuditLogService, configService *mocks.ConfigService) {
+				userRepo.On("FindByID", mock.Anything, operatorID).Return(&models.User{ID: operatorID, Regu: "II"}, nil).Once()
+				userRepo.On("FindByID", mock.Anything, pejabatPersetujuID).Return(&models.User{ID: pejabatPersetujuID, Peran: models.RoleKanit}, nil).Once()
+
 				// Tidak dibatasi karena bisa dipanggil beberapa kali
-				configService.On("GetLocation").Return(loc, nil)
+				configService.On("GetLocation", mock.Anything).Return(loc, nil)
 				
-				docRepo.On("GetLastDocumentOfYear", mock.AnythingOfType("int")).Return((*models.LostDocument)(nil), gorm.ErrRecordNotFound).Once()
-				configService.On("GetConfig").Return(mockConfig, nil).Once()
+				docRepo.On("GetLastDocumentOfYear", mock.Anything, mock.AnythingOfType("int")).Return((*models.LostDocument)(nil), gorm.ErrRecordNotFound).Once()
+				configService.On("GetConfig", mock.Anything).Return(mockConfig, nil).Once()
 
 				dbMock.ExpectBegin()
 				
@@ -69,30 +75,34 @@ func TestLostDocumentService_CreateLostDocument(t *testing.T) {
 					WithArgs(residentData.NamaLengkap, residentData.TanggalLahir).
 					WillReturnError(gorm.ErrRecordNotFound)
 
-				resRepo.On("Create", mock.AnythingOfType("*gorm.DB"), mock.AnythingOfType("*models.Resident")).
+				resRepo.On("Create", mock.Anything, mock.AnythingOfType("*gorm.DB"), mock.AnythingOfType("*models.Resident")).
 					Return(&models.Resident{ID: 1}, nil).Once()
 
-				docRepo.On("Create", mock.AnythingOfType("*gorm.DB"), mock.AnythingOfType("*models.LostDocument")).
+				// Regu operator disalin ke dokumen saat dibuat
+				docRepo.On("Create", mock.Anything, mock.AnythingOfType("*gorm.DB"), mock.MatchedBy(func(d *models.LostDocument) bool { return d.Regu == "II" })).
 					Return(&models.LostDocument{ID: 101}, nil).Once()
 
-				dbMock.ExpectCommit()
+				auditService.On("LogActivityTx", mock.Anything, mock.AnythingOfType("*gorm.DB"), operatorID, models.AuditCreateDocument, mock.AnythingOfType("string")).Return(nil).Once()
 
-				auditService.On("LogActivity", operatorID, models.AuditCreateDocument, mock.AnythingOfType("string")).Once()
+				dbMock.ExpectCommit()
 
 				finalDoc := &models.LostDocument{ID: 101, NomorSurat: "SKH/1/X/TUK.7.2.1/2025"}
-				docRepo.On("FindByID", uint(101)).Return(finalDoc, nil).Once()
+				docRepo.On("FindByID", mock.Anything, uint(101)).Return(finalDoc, nil).Once()
 			},
 			expectedError: false,
 		},
 		{
 			name: "Gagal - Error saat membuat penduduk",
 			setupMocks: func(dbMock sqlmock.Sqlmock, docRepo *mocks.LostDocumentRepository, resRepo *mocks.ResidentRepository, userRepo *mocks.UserRepository, auditService *mocks.AuditLogService, configService *mocks.ConfigService) {
+				userRepo.On("FindByID", mock.Anything, operatorID).Return(&models.User{ID: operatorID, Regu: "II"}, nil).Once()
+				userRepo.On("FindByID", mock.Anything, pejabatPersetujuID).Return(&models.User{ID: pejabatPersetujuID, Peran: models.RoleKanit}, nil).Once()
+
 				// Tidak dibatasi karena bisa dipanggil beberapa kali
-				configService.On("GetLocation").Return(loc, nil).Maybe()
+				configService.On("GetLocation", mock.Anything).Return(loc, nil).Maybe()
 				
 				// Jika GetLastDocumentOfYear dipanggil di awal
-				docRepo.On("GetLastDocumentOfYear", mock.AnythingOfType("int")).Return((*models.LostDocument)(nil), gorm.ErrRecordNotFound).Maybe()
-				configService.On("GetConfig").Return(mockConfig, nil).Maybe()
+				docRepo.On("GetLastDocumentOfYear", mock.Anything, mock.AnythingOfType("int")).Return((*models.LostDocument)(nil), gorm.ErrRecordNotFound).Maybe()
+				configService.On("GetConfig", mock.Anything).Return(mockConfig, nil).Maybe()
 				
 				dbMock.ExpectBegin()
 
@@ -101,7 +111,7 @@ func TestLostDocumentService_CreateLostDocument(t *testing.T) {
 					WithArgs(residentData.NamaLengkap, residentData.TanggalLahir).
 					WillReturnError(gorm.ErrRecordNotFound)
 					
-				resRepo.On("Create", mock.AnythingOfType("*gorm.DB"), mock.AnythingOfType("*models.Resident")).
+				resRepo.On("Create", mock.Anything, mock.AnythingOfType("*gorm.DB"), mock.AnythingOfType("*models.Resident")).
 					Return((*models.Resident)(nil), errors.New("database error")).Once()
 
 				dbMock.ExpectRollback()
@@ -121,9 +131,9 @@ func TestLostDocumentService_CreateLostDocument(t *testing.T) {
 
 			tc.setupMocks(dbMock, mockDocRepo, mockResRepo, mockUserRepo, mockAuditService, mockConfigService)
 
-			service := NewLostDocumentService(db, mockDocRepo, mockResRepo, mockUserRepo, mockAuditService, mockConfigService)
+			service := NewLostDocumentService(db, mockDocRepo, mockResRepo, mockUserRepo, new(mocks.DutyRosterRepository), mockAuditService, mockConfigService)
 
-			_, err := service.CreateLostDocument(residentData, items, operatorID, "Jalan Sudirman", petugasPelaporID, pejabatPersetujuID)
+			_, err := service.CreateLostDocument(reqctx.WithActor(context.Background(), operatorID), residentData, items, "Jalan Sudirman", petugasPelaporID, pejabatPersetujuID)
 
 			if tc.expectedError {
 				assert.Error(t, err)
@@ -140,4 +150,144 @@ func TestLostDocumentService_CreateLostDocument(t *testing.T) {
 			assert.NoError(t, dbMock.ExpectationsWereMet())
 		})
 	}
-}
\ No newline at end of file
+}
+func TestLostDocumentService_FindByIDVisibility(t *testing.T) {
+	// Dokumen milik operator lain di regu II
+	doc := &models.LostDocument{ID: 7, OperatorID: 20, Regu: "II", Status: models.StatusDiterbitkan, TanggalLaporan: time.Now()}
+
+	testCases := []struct {
+		name         string
+		actor        *models.User
+		visibility   string
+		expectDenied bool
+	}{
+		{name: "Cakupan Own - Operator Lain Ditolak", actor: &models.User{ID: 10, Peran: models.RoleOperator, Regu: "II"}, visibility: models.VisibilityOwn, expectDenied: true},
+		{name: "Cakupan Regu - Regu Sama Diizinkan", actor: &models.User{ID: 10, Peran: models.RoleOperator, Regu: "II"}, visibility: models.VisibilityRegu},
+		{name: "Cakupan Regu - Regu Berbeda Ditolak", actor: &models.User{ID: 10, Peran: models.RoleOperator, Regu: "I"}, visibility: models.VisibilityRegu, expectDenied: true},
+		{name: "Cakupan All - Semua Diizinkan", actor: &models.User{ID: 10, Peran: models.RoleOperator, Regu: "I"}, visibility: models.VisibilityAll},
+		{name: "Hak Akses read_all Mengabaikan Cakupan", actor: &models.User{ID: 10, Peran: models.RoleAuditor}, visibility: models.VisibilityOwn},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDocRepo := new(mocks.LostDocumentRepository)
+			mockUserRepo := new(mocks.UserRepository)
+			mockConfigService := new(mocks.ConfigService)
+
+			mockDocRepo.On("FindByID", mock.Anything, doc.ID).Return(doc, nil).Once()
+			mockUserRepo.On("FindByID", mock.Anything, tc.actor.ID).Return(tc.actor, nil).Once()
+			mockConfigService.On("GetConfig", mock.Anything).Return(&dto.AppConfig{DocumentVisibility: tc.visibility, ArchiveDurationDays: 15}, nil)
+
+			service := NewLostDocumentService(nil, mockDocRepo, nil, mockUserRepo, nil, nil, mockConfigService)
+			_, err := service.FindByID(reqctx.WithActor(context.Background(), tc.actor.ID), doc.ID)
+
+			if tc.expectDenied {
+				assert.ErrorIs(t, err, ErrAccessDenied)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLostDocumentService_GetHandoverScope(t *testing.T) {
+	start := time.Date(2025, 1, 31, 8, 0, 0, 0, time.UTC)
+	end := start.Add(12 * time.Hour)
+
+	testCases := []struct {
+		name          string
+		actor         *models.User
+		visibility    string
+		regu          string
+		expectedScope *repositories.DocumentScope
+	}{
+		{name: "Regu Lain Ditolak", actor: &models.User{ID: 10, Peran: models.RoleOperator, Regu: "I"}, visibility: models.VisibilityRegu, regu: "II"},
+		{name: "Regu Sendiri Dibatasi Cakupan Own", actor: &models.User{ID: 10, Peran: models.RoleOperator, Regu: "I"}, visibility: models.VisibilityOwn, regu: "I",
+			expectedScope: &repositories.DocumentScope{OperatorID: 10}},
+		{name: "Hak Akses read_all Boleh Regu Lain", actor: &models.User{ID: 10, Peran: models.RoleAuditor}, visibility: models.VisibilityOwn, regu: "II",
+			expectedScope: &repositories.DocumentScope{All: true, OperatorID: 10}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockDocRepo := new(mocks.LostDocumentRepository)
+			mockUserRepo := new(mocks.UserRepository)
+			mockConfigService := new(mocks.ConfigService)
+
+			mockUserRepo.On("FindByID", mock.Anything, tc.actor.ID).Return(tc.actor, nil).Once()
+			mockConfigService.On("GetConfig", mock.Anything).Return(&dto.AppConfig{DocumentVisibility: tc.visibility, ArchiveDurationDays: 15}, nil)
+			if tc.expectedScope != nil {
+				mockDocRepo.On("FindByReguInRange", mock.Anything, tc.regu, start, end, *tc.expectedScope).Return([]models.LostDocument{}, nil).Once()
+			}
+
+			service := NewLostDocumentService(nil, mockDocRepo, nil, mockUserRepo, nil, nil, mockConfigService)
+			report, err := service.GetHandover(reqctx.WithActor(context.Background(), tc.actor.ID), tc.regu, start, end)
+
+			if tc.expectedScope == nil {
+				assert.ErrorIs(t, err, ErrAccessDenied)
+				assert.Nil(t, report)
+				mockDocRepo.AssertNotCalled(t, "FindByReguInRange", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.regu, report.Regu)
+			}
+			mockDocRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestLostDocumentService_CreateLostDocument_NoRosterOnDuty(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rosters []models.DutyRoster
+	}{
+		{name: "Tidak Ada Jadwal Berlangsung", rosters: []models.DutyRoster{}},
+		// Petugas regu lain tidak boleh dipakai sebagai nilai bawaan
+		{name: "Hanya Regu Lain Yang Berjaga", rosters: []models.DutyRoster{{Regu: "II", PetugasPelaporID: 5, PejabatPersetujuID: 6}}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepo := new(mocks.UserRepository)
+			mockRosterRepo := new(mocks.DutyRosterRepository)
+			mockConfigService := new(mocks.ConfigService)
+
+			mockUserRepo.On("FindByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Regu: "I"}, nil).Once()
+			mockConfigService.On("GetLocation", mock.Anything).Return(time.UTC, nil)
+			mockRosterRepo.On("FindActiveAt", mock.Anything, mock.AnythingOfType("time.Time")).Return(tc.rosters, nil).Once()
+
+			service := NewLostDocumentService(nil, nil, nil, mockUserRepo, mockRosterRepo, nil, mockConfigService)
+			_, err := service.CreateLostDocument(reqctx.WithActor(context.Background(), 1), models.Resident{}, nil, "Pasar", 0, 0)
+
+			assert.ErrorIs(t, err, ErrOfficerRequired)
+			mockRosterRepo.AssertExpectations(t)
+			mockUserRepo.AssertNotCalled(t, "FindByID", mock.Anything, uint(6))
+		})
+	}
+}
+
+func TestLostDocumentService_CreateLostDocument_InvalidApprover(t *testing.T) {
+	testCases := []struct {
+		name     string
+		approver *models.User
+		err      error
+	}{
+		{name: "Tanpa Hak Menyetujui", approver: &models.User{ID: 3, Peran: models.RoleOperator}},
+		{name: "Pengguna Non-aktif", approver: &models.User{ID: 3, Peran: models.RoleKanit, DeletedAt: gorm.DeletedAt{Time: time.Now(), Valid: true}}},
+		{name: "Tidak Ditemukan", err: gorm.ErrRecordNotFound},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockUserRepo := new(mocks.UserRepository)
+			mockUserRepo.On("FindByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, Regu: "I"}, nil).Once()
+			mockUserRepo.On("FindByID", mock.Anything, uint(3)).Return(tc.approver, tc.err).Once()
+
+			service := NewLostDocumentService(nil, nil, nil, mockUserRepo, nil, nil, nil)
+			_, err := service.CreateLostDocument(reqctx.WithActor(context.Background(), 1), models.Resident{}, nil, "Pasar", 2, 3)
+
+			assert.ErrorIs(t, err, ErrInvalidApprover)
+			mockUserRepo.AssertExpectations(t)
+		})
+	}
+}
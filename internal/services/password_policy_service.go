@@ -0,0 +1,212 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// DefaultPasswordMinLength juga menjadi batas bawah yang boleh diatur.
+	DefaultPasswordMinLength = 8
+	// DefaultPasswordHistoryCount dipakai jika jumlah riwayat belum pernah diatur.
+	DefaultPasswordHistoryCount = 5
+	// MaxPasswordHistoryCount membatasi jumlah perbandingan bcrypt per penggantian kata sandi.
+	MaxPasswordHistoryCount = 24
+	// temporaryPasswordLength adalah panjang minimal kata sandi sementara.
+	temporaryPasswordLength = 12
+)
+
+// PasswordPolicyError berisi semua aturan kebijakan yang dilanggar oleh sebuah kata sandi.
+type PasswordPolicyError struct {
+	Violations []string
+}
+
+func (e *PasswordPolicyError) Error() string {
+	return "kata sandi " + strings.Join(e.Violations, ", ")
+}
+
+func (e *PasswordPolicyError) Unwrap() error { return ErrPasswordPolicy }
+
+type PasswordPolicyService interface {
+	// Validate memeriksa kata sandi baru milik user terhadap kebijakan. user.KataSandi
+	// harus berisi hash kata sandi saat ini (kosong untuk pengguna baru).
+	Validate(ctx context.Context, user *models.User, password string) error
+	// Remember menyimpan hash kata sandi ke riwayat dan memangkas riwayat yang melebihi batas.
+	Remember(ctx context.Context, userID uint, passwordHash string) error
+	// IsExpired menentukan apakah kata sandi pengguna sudah melewati masa berlaku.
+	IsExpired(ctx context.Context, user *models.User) bool
+	// GenerateTemporary membuat kata sandi acak yang memenuhi kebijakan.
+	GenerateTemporary(ctx context.Context) (string, error)
+}
+
+type passwordPolicyService struct {
+	historyRepo   repositories.PasswordHistoryRepository
+	configService ConfigService
+}
+
+func NewPasswordPolicyService(historyRepo repositories.PasswordHistoryRepository, configService ConfigService) PasswordPolicyService {
+	return &passwordPolicyService{historyRepo: historyRepo, configService: configService}
+}
+
+// config mengembalikan pengaturan kebijakan; jika gagal dibaca, nilai bawaan yang dipakai.
+func (s *passwordPolicyService) config(ctx context.Context) *dto.AppConfig {
+	appConfig, err := s.configService.GetConfig(ctx)
+	if err != nil {
+		return &dto.AppConfig{PasswordMinLength: DefaultPasswordMinLength, PasswordHistoryCount: DefaultPasswordHistoryCount}
+	}
+	return appConfig
+}
+
+func (s *passwordPolicyService) Validate(ctx context.Context, user *models.User, password string) error {
+	cfg := s.config(ctx)
+	var violations []string
+
+	minLength := max(cfg.PasswordMinLength, DefaultPasswordMinLength)
+	if utf8.RuneCountInString(password) < minLength {
+		violations = append(violations, fmt.Sprintf("minimal %d karakter", minLength))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			hasSymbol = true
+		}
+	}
+	if cfg.PasswordRequireUpper && !hasUpper {
+		violations = append(violations, "harus mengandung huruf besar")
+	}
+	if cfg.PasswordRequireLower && !hasLower {
+		violations = append(violations, "harus mengandung huruf kecil")
+	}
+	if cfg.PasswordRequireDigit && !hasDigit {
+		violations = append(violations, "harus mengandung angka")
+	}
+	if cfg.PasswordRequireSymbol && !hasSymbol {
+		violations = append(violations, "harus mengandung simbol")
+	}
+
+	if user.NRP != "" && strings.EqualFold(strings.TrimSpace(password), strings.TrimSpace(user.NRP)) {
+		violations = append(violations, "tidak boleh sama dengan NRP")
+	}
+
+	if len(violations) == 0 && user.ID != 0 && cfg.PasswordHistoryCount > 0 {
+		reused, err := s.isReused(ctx, user, password, min(cfg.PasswordHistoryCount, MaxPasswordHistoryCount))
+		if err != nil {
+			return err
+		}
+		if reused {
+			violations = append(violations, fmt.Sprintf("tidak boleh sama dengan %d kata sandi terakhir", cfg.PasswordHistoryCount))
+		}
+	}
+
+	if len(violations) > 0 {
+		return &PasswordPolicyError{Violations: violations}
+	}
+	return nil
+}
+
+func (s *passwordPolicyService) isReused(ctx context.Context, user *models.User, password string, count int) (bool, error) {
+	hashes := []string{user.KataSandi}
+	history, err := s.historyRepo.FindRecent(ctx, user.ID, count)
+	if err != nil {
+		return false, err
+	}
+	for _, entry := range history {
+		hashes = append(hashes, entry.PasswordHash)
+	}
+	for _, hash := range hashes {
+		if hash != "" && bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *passwordPolicyService) Remember(ctx context.Context, userID uint, passwordHash string) error {
+	count := min(s.config(ctx).PasswordHistoryCount, MaxPasswordHistoryCount)
+	if count <= 0 {
+		return nil
+	}
+	if err := s.historyRepo.Create(ctx, &models.PasswordHistory{UserID: userID, PasswordHash: passwordHash}); err != nil {
+		return err
+	}
+	return s.historyRepo.Prune(ctx, userID, count)
+}
+
+func (s *passwordPolicyService) IsExpired(ctx context.Context, user *models.User) bool {
+	maxAgeDays := s.config(ctx).PasswordMaxAgeDays
+	// Masa berlaku kata sandi pengguna LDAP diatur oleh direktori
+	if maxAgeDays <= 0 || user.AuthSource == models.AuthSourceLDAP {
+		return false
+	}
+	changedAt := user.CreatedAt
+	if user.PasswordChangedAt != nil {
+		changedAt = *user.PasswordChangedAt
+	}
+	return time.Since(changedAt) > time.Duration(maxAgeDays)*24*time.Hour
+}
+
+// Karakter yang mudah tertukar (0/O, 1/l/I) tidak dipakai agar kata sandi sementara
+// mudah disampaikan secara lisan.
+const (
+	temporaryUpper  = "ABCDEFGHJKLMNPQRSTUVWXYZ"
+	temporaryLower  = "abcdefghijkmnpqrstuvwxyz"
+	temporaryDigit  = "23456789"
+	temporarySymbol = "!@#$%*?"
+)
+
+func (s *passwordPolicyService) GenerateTemporary(ctx context.Context) (string, error) {
+	length := max(s.config(ctx).PasswordMinLength, temporaryPasswordLength)
+	all := temporaryUpper + temporaryLower + temporaryDigit + temporarySymbol
+
+	// Satu karakter dari setiap kelas agar lolos kebijakan apa pun, sisanya acak
+	password := make([]byte, 0, length)
+	for _, set := range []string{temporaryUpper, temporaryLower, temporaryDigit, temporarySymbol} {
+		c, err := randomChar(set)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for len(password) < length {
+		c, err := randomChar(all)
+		if err != nil {
+			return "", err
+		}
+		password = append(password, c)
+	}
+	for i := len(password) - 1; i > 0; i-- {
+		j, err := rand.Int(rand.Reader, big.NewInt(int64(i+1)))
+		if err != nil {
+			return "", err
+		}
+		password[i], password[j.Int64()] = password[j.Int64()], password[i]
+	}
+	return string(password), nil
+}
+
+func randomChar(set string) (byte, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(int64(len(set))))
+	if err != nil {
+		return 0, err
+	}
+	return set[n.Int64()], nil
+}
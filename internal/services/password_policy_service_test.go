@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordPolicyService_Validate(t *testing.T) {
+	currentHash, _ := bcrypt.GenerateFromPassword([]byte("Sandi-Lama1"), bcrypt.MinCost)
+	oldHash, _ := bcrypt.GenerateFromPassword([]byte("Sandi-Dulu1"), bcrypt.MinCost)
+	user := &models.User{ID: 1, NRP: "12345678", KataSandi: string(currentHash)}
+
+	mockConfig := new(mocks.ConfigService)
+	mockConfig.On("GetConfig", mock.Anything).Return(&dto.AppConfig{
+		PasswordMinLength:     10,
+		PasswordRequireUpper:  true,
+		PasswordRequireDigit:  true,
+		PasswordRequireSymbol: true,
+		PasswordHistoryCount:  3,
+	}, nil)
+	mockHistory := new(mocks.PasswordHistoryRepository)
+	mockHistory.On("FindRecent", mock.Anything, uint(1), 3).Return([]models.PasswordHistory{{UserID: 1, PasswordHash: string(oldHash)}}, nil)
+
+	service := NewPasswordPolicyService(mockHistory, mockConfig)
+
+	testCases := []struct {
+		name       string
+		password   string
+		violations int
+	}{
+		{name: "Memenuhi Kebijakan", password: "Sandi-Baru1"},
+		{name: "Terlalu Pendek dan Tanpa Simbol", password: "Abc1", violations: 2},
+		{name: "Sama dengan NRP", password: "12345678", violations: 4},
+		{name: "Sama dengan Kata Sandi Saat Ini", password: "Sandi-Lama1", violations: 1},
+		{name: "Sama dengan Riwayat", password: "Sandi-Dulu1", violations: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := service.Validate(context.Background(), user, tc.password)
+			if tc.violations == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrPasswordPolicy)
+			var policyErr *PasswordPolicyError
+			if assert.ErrorAs(t, err, &policyErr) {
+				assert.Len(t, policyErr.Violations, tc.violations)
+			}
+		})
+	}
+
+	t.Run("Kata Sandi Sementara Lolos Kebijakan", func(t *testing.T) {
+		password, err := service.GenerateTemporary(context.Background())
+		assert.NoError(t, err)
+		assert.NoError(t, service.Validate(context.Background(), &models.User{NRP: "12345678"}, password))
+	})
+}
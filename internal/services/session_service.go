@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/reqctx"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// SessionLifetime adalah masa berlaku absolut sebuah sesi sejak login.
+	SessionLifetime = 24 * time.Hour
+	// DefaultSessionIdleMinutes dipakai jika batas idle belum pernah diatur.
+	DefaultSessionIdleMinutes = 30
+	// sessionTouchInterval membatasi penulisan last_seen_at agar tidak terjadi di setiap request.
+	sessionTouchInterval = time.Minute
+	// sessionPurgeGrace adalah lama sesi kedaluwarsa disimpan untuk keperluan penelusuran.
+	sessionPurgeGrace = 7 * 24 * time.Hour
+)
+
+type SessionService interface {
+	Create(ctx context.Context, userID uint, clientIP string, userAgent string) (*models.Session, error)
+	// Validate memastikan sesi milik userID masih aktif, lalu memperbarui waktu terakhir terlihat.
+	Validate(ctx context.Context, sessionID string, userID uint) (*models.Session, error)
+	// FindByUser mengambil sesi aktif pengguna; currentID ditandai sebagai sesi saat ini.
+	FindByUser(ctx context.Context, userID uint, currentID string) ([]models.Session, error)
+	// Revoke mencabut salah satu sesi milik pengguna sendiri.
+	Revoke(ctx context.Context, sessionID string, userID uint) error
+	// End mengakhiri sesi saat pengguna logout.
+	End(ctx context.Context, sessionID string, userID uint) error
+	// RevokeAllForUser mencabut semua sesi pengguna kecuali exceptID (boleh kosong).
+	RevokeAllForUser(ctx context.Context, userID uint, exceptID string) (int64, error)
+	// ForceLogout mencabut semua sesi pengguna atas perintah admin.
+	ForceLogout(ctx context.Context, userID uint) (int64, error)
+	// PurgeExpired menghapus sesi yang sudah lama kedaluwarsa.
+	PurgeExpired(ctx context.Context) (int64, error)
+}
+
+type sessionService struct {
+	sessionRepo   repositories.SessionRepository
+	userRepo      repositories.UserRepository
+	auditService  AuditLogService
+	configService ConfigService
+}
+
+func NewSessionService(sessionRepo repositories.SessionRepository, userRepo repositories.UserRepository, auditService AuditLogService, configService ConfigService) SessionService {
+	return &sessionService{
+		sessionRepo:   sessionRepo,
+		userRepo:      userRepo,
+		auditService:  auditService,
+		configService: configService,
+	}
+}
+
+func (s *sessionService) Create(ctx context.Context, userID uint, clientIP string, userAgent string) (*models.Session, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	session := &models.Session{
+		ID:         hex.EncodeToString(id),
+		UserID:     userID,
+		IPAddress:  clientIP,
+		UserAgent:  userAgent,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(SessionLifetime),
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (s *sessionService) Validate(ctx context.Context, sessionID string, userID uint) (*models.Session, error) {
+	if sessionID == "" {
+		return nil, ErrSessionInvalid
+	}
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrSessionInvalid
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	if session.UserID != userID || session.RevokedAt != nil || !now.Before(session.ExpiresAt) {
+		return nil, ErrSessionInvalid
+	}
+
+	if idle := s.idleTimeout(ctx); idle > 0 && now.Sub(session.LastSeenAt) > idle {
+		if err := s.sessionRepo.Revoke(ctx, session.ID, now); err != nil {
+			slog.WarnContext(ctx, "Gagal mencabut sesi idle", "session_id", session.ID, "error", err)
+		}
+		return nil, ErrSessionInvalid
+	}
+
+	if now.Sub(session.LastSeenAt) >= sessionTouchInterval {
+		if err := s.sessionRepo.Touch(ctx, session.ID, now); err != nil {
+			slog.WarnContext(ctx, "Gagal memperbarui waktu aktivitas sesi", "session_id", session.ID, "error", err)
+		}
+		session.LastSeenAt = now
+	}
+	return session, nil
+}
+
+func (s *sessionService) idleTimeout(ctx context.Context) time.Duration {
+	appConfig, err := s.configService.GetConfig(ctx)
+	if err != nil {
+		return time.Duration(DefaultSessionIdleMinutes) * time.Minute
+	}
+	return appConfig.SessionIdleTimeout()
+}
+
+func (s *sessionService) FindByUser(ctx context.Context, userID uint, currentID string) ([]models.Session, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+	return sessions, nil
+}
+
+func (s *sessionService) Revoke(ctx context.Context, sessionID string, userID uint) error {
+	return s.revoke(ctx, sessionID, userID, models.AuditRevokeSession, "Mencabut sesi login dari IP %s")
+}
+
+func (s *sessionService) End(ctx context.Context, sessionID string, userID uint) error {
+	return s.revoke(ctx, sessionID, userID, models.AuditLogout, "Logout dari sesi dengan IP %s")
+}
+
+func (s *sessionService) revoke(ctx context.Context, sessionID string, userID uint, action string, detailFormat string) error {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	// Sesi pengguna lain diperlakukan seolah tidak ada agar ID sesi tidak bisa ditebak
+	if session.UserID != userID {
+		return ErrNotFound
+	}
+	if session.RevokedAt != nil {
+		return nil
+	}
+	if err := s.sessionRepo.Revoke(ctx, sessionID, time.Now()); err != nil {
+		return err
+	}
+	s.auditService.LogActivity(ctx, userID, action, fmt.Sprintf(detailFormat, session.IPAddress))
+	return nil
+}
+
+func (s *sessionService) RevokeAllForUser(ctx context.Context, userID uint, exceptID string) (int64, error) {
+	return s.sessionRepo.RevokeAllForUser(ctx, userID, exceptID, time.Now())
+}
+
+func (s *sessionService) ForceLogout(ctx context.Context, userID uint) (int64, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	revoked, err := s.sessionRepo.RevokeAllForUser(ctx, userID, "", time.Now())
+	if err != nil {
+		return 0, err
+	}
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditForceLogout, fmt.Sprintf("Memaksa logout pengguna '%s' (NRP: %s), %d sesi dicabut.", user.NamaLengkap, user.NRP, revoked))
+	return revoked, nil
+}
+
+func (s *sessionService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.sessionRepo.DeleteExpiredBefore(ctx, time.Now().Add(-sessionPurgeGrace))
+}
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"fmt"
+	"log/slog"
+	"simdokpol/internal/models"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SettingType adalah tipe nilai sebuah kunci pengaturan sistem. Semua nilai disimpan sebagai
+// teks di tabel configurations; tipe menentukan cara nilai divalidasi dan disajikan di API.
+type SettingType string
+
+const (
+	SettingTypeString SettingType = "string"
+	SettingTypeInt    SettingType = "int"
+	SettingTypeBool   SettingType = "bool"
+	// SettingTypeRoleList adalah daftar peran dipisah koma.
+	SettingTypeRoleList SettingType = "role_list"
+)
+
+// SettingDefinition menjelaskan satu kunci pengaturan: tipe, nilai bawaan, batas nilai, dan
+// apakah kunci boleh diubah lewat halaman Pengaturan atau CLI.
+type SettingDefinition struct {
+	Key     string      `json:"key"`
+	Type    SettingType `json:"type"`
+	Default string      `json:"default"`
+	// Min dan Max adalah batas inklusif untuk SettingTypeInt.
+	Min *int `json:"min,omitempty"`
+	Max *int `json:"max,omitempty"`
+	// MaxLength adalah panjang maksimal nilai SettingTypeString.
+	MaxLength int `json:"max_length,omitempty"`
+	// Allowed membatasi nilai SettingTypeString pada daftar ini.
+	Allowed  []string `json:"allowed,omitempty"`
+	Required bool     `json:"required"`
+	// ReadOnly berarti kunci hanya diubah oleh aplikasi sendiri, misalnya saat setup awal.
+	ReadOnly bool `json:"read_only"`
+
+	// check memeriksa aturan tambahan setelah tipe dan batas nilai terpenuhi.
+	check func(value string) error
+}
+
+// settingsSchema adalah daftar semua kunci pengaturan sistem sesuai urutan tampil.
+var settingsSchema = []SettingDefinition{
+	{Key: IsSetupCompleteKey, Type: SettingTypeBool, Default: "false", ReadOnly: true},
+	{Key: "kop_baris_1", Type: SettingTypeString, MaxLength: 100, Required: true},
+	{Key: "kop_baris_2", Type: SettingTypeString, MaxLength: 100, Required: true},
+	{Key: "kop_baris_3", Type: SettingTypeString, MaxLength: 100, Required: true},
+	{Key: "nama_kantor", Type: SettingTypeString, MaxLength: 100, Required: true},
+	{Key: "tempat_surat", Type: SettingTypeString, MaxLength: 100, Required: true},
+	{Key: "format_nomor_surat", Type: SettingTypeString, MaxLength: 100, Required: true, check: checkDocumentNumberFormat},
+	{Key: "nomor_surat_terakhir", Type: SettingTypeInt, Default: "0", Min: bound(0), Max: bound(999999)},
+	{Key: "zona_waktu", Type: SettingTypeString, MaxLength: 64, Required: true, check: checkTimeZone},
+	{Key: "backup_path", Type: SettingTypeString, MaxLength: 255, check: checkBackupPath},
+	{Key: "archive_duration_days", Type: SettingTypeInt, Default: "15", Min: bound(1), Max: bound(3650)},
+	{Key: "audit_retention_months", Type: SettingTypeInt, Default: "0", Min: bound(0), Max: bound(1200)},
+	{Key: "document_visibility", Type: SettingTypeString, Default: models.VisibilityOwn,
+		Allowed: []string{models.VisibilityOwn, models.VisibilityRegu, models.VisibilityAll}},
+	{Key: "session_idle_minutes", Type: SettingTypeInt, Default: strconv.Itoa(DefaultSessionIdleMinutes), Min: bound(0), Max: bound(7 * 24 * 60)},
+	{Key: "two_factor_roles", Type: SettingTypeRoleList},
+	{Key: "password_min_length", Type: SettingTypeInt, Default: strconv.Itoa(DefaultPasswordMinLength), Min: bound(DefaultPasswordMinLength), Max: bound(128)},
+	{Key: "password_require_upper", Type: SettingTypeBool, Default: "false"},
+	{Key: "password_require_lower", Type: SettingTypeBool, Default: "false"},
+	{Key: "password_require_digit", Type: SettingTypeBool, Default: "false"},
+	{Key: "password_require_symbol", Type: SettingTypeBool, Default: "false"},
+	{Key: "password_history_count", Type: SettingTypeInt, Default: strconv.Itoa(DefaultPasswordHistoryCount), Min: bound(0), Max: bound(MaxPasswordHistoryCount)},
+	{Key: "password_max_age_days", Type: SettingTypeInt, Default: "0", Min: bound(0), Max: bound(3650)},
+	{Key: "jwt_rotation_days", Type: SettingTypeInt, Default: strconv.Itoa(DefaultJWTRotationDays), Min: bound(0), Max: bound(3650)},
+	{Key: "jwt_key_grace_hours", Type: SettingTypeInt, Default: strconv.Itoa(DefaultJWTKeyGraceHours), Min: bound(1), Max: bound(24 * 30)},
+}
+
+var settingsByKey = func() map[string]*SettingDefinition {
+	byKey := make(map[string]*SettingDefinition, len(settingsSchema))
+	for i := range settingsSchema {
+		byKey[settingsSchema[i].Key] = &settingsSchema[i]
+	}
+	return byKey
+}()
+
+func bound(n int) *int { return &n }
+
+// SettingsValidationError berisi pesan kesalahan per kunci pengaturan.
+type SettingsValidationError struct {
+	Fields map[string]string
+}
+
+func (e *SettingsValidationError) Error() string {
+	keys := make([]string, 0, len(e.Fields))
+	for key := range e.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	messages := make([]string, len(keys))
+	for i, key := range keys {
+		messages[i] = key + " " + e.Fields[key]
+	}
+	return "pengaturan tidak valid: " + strings.Join(messages, "; ")
+}
+
+func (e *SettingsValidationError) Unwrap() error { return ErrInvalidSettings }
+
+// SettingsSchema mengembalikan definisi semua kunci pengaturan sesuai urutan tampil.
+func SettingsSchema() []SettingDefinition {
+	return append([]SettingDefinition(nil), settingsSchema...)
+}
+
+// LookupSetting mengembalikan definisi kunci pengaturan jika kunci dikenal.
+func LookupSetting(key string) (SettingDefinition, bool) {
+	def, ok := settingsByKey[key]
+	if !ok {
+		return SettingDefinition{}, false
+	}
+	return *def, true
+}
+
+// ValidateSettings memeriksa nilai pengaturan yang diubah lewat halaman Pengaturan, CLI, atau
+// setup awal sebelum disimpan. Kunci yang tidak dikenal atau hanya-baca ditolak, dan nilai
+// yang valid dinormalisasi langsung di dalam map (misalnya "1" menjadi "true" untuk bool).
+// Kesalahan dikembalikan sebagai *SettingsValidationError.
+func ValidateSettings(settings map[string]string) error {
+	fields := map[string]string{}
+	for key, value := range settings {
+		def, ok := settingsByKey[key]
+		switch {
+		case !ok:
+			fields[key] = "bukan kunci pengaturan yang dikenal"
+		case def.ReadOnly:
+			fields[key] = "hanya dapat diubah oleh aplikasi"
+		default:
+			normalized, err := def.Normalize(value)
+			if err != nil {
+				fields[key] = err.Error()
+				continue
+			}
+			settings[key] = normalized
+		}
+	}
+	if len(fields) > 0 {
+		return &SettingsValidationError{Fields: fields}
+	}
+	return nil
+}
+
+// Normalize memeriksa nilai terhadap definisi dan mengembalikan bentuk bakunya.
+func (d SettingDefinition) Normalize(value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" && (d.Required || d.Type == SettingTypeInt || d.Type == SettingTypeBool) {
+		return "", fmt.Errorf("wajib diisi")
+	}
+
+	switch d.Type {
+	case SettingTypeInt:
+		n, err := strconv.Atoi(value)
+		if err != nil || (d.Min != nil && n < *d.Min) || (d.Max != nil && n > *d.Max) {
+			return "", fmt.Errorf("harus berupa bilangan bulat antara %d dan %d", *d.Min, *d.Max)
+		}
+		value = strconv.Itoa(n)
+	case SettingTypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("harus bernilai true atau false")
+		}
+		value = strconv.FormatBool(b)
+	case SettingTypeRoleList:
+		var roles []string
+		for _, role := range strings.Split(value, ",") {
+			role = strings.ToUpper(strings.TrimSpace(role))
+			if role == "" {
+				continue
+			}
+			if !models.IsValidRole(role) {
+				return "", fmt.Errorf("berisi peran yang tidak dikenal: %s", role)
+			}
+			roles = append(roles, role)
+		}
+		value = strings.Join(roles, ",")
+	default:
+		if d.MaxLength > 0 && len([]rune(value)) > d.MaxLength {
+			return "", fmt.Errorf("maksimal %d karakter", d.MaxLength)
+		}
+		if len(d.Allowed) > 0 && !slices.Contains(d.Allowed, value) {
+			return "", fmt.Errorf("harus salah satu dari: %s", strings.Join(d.Allowed, ", "))
+		}
+	}
+
+	if d.check != nil && value != "" {
+		if err := d.check(value); err != nil {
+			return "", err
+		}
+	}
+	return value, nil
+}
+
+// TypedValue mengubah nilai baku menjadi tipe JSON yang sesuai untuk respons API.
+func (d SettingDefinition) TypedValue(value string) any {
+	switch d.Type {
+	case SettingTypeInt:
+		n, _ := strconv.Atoi(value)
+		return n
+	case SettingTypeBool:
+		return value == "true"
+	default:
+		return value
+	}
+}
+
+// resolveSettings mengisi semua kunci dari nilai tersimpan. Kunci yang belum ada memakai nilai
+// bawaan, begitu pula nilai tersimpan yang tidak lolos validasi (misalnya dari versi lama).
+func resolveSettings(stored map[string]string) map[string]string {
+	values := make(map[string]string, len(settingsSchema))
+	for _, def := range settingsSchema {
+		value, ok := stored[def.Key]
+		if !ok || value == "" {
+			values[def.Key] = def.Default
+			continue
+		}
+		normalized, err := def.Normalize(value)
+		if err != nil {
+			slog.Warn("Nilai pengaturan tidak valid, memakai nilai bawaan", "key", def.Key, "default", def.Default, "error", err)
+			normalized = def.Default
+		}
+		values[def.Key] = normalized
+	}
+	return values
+}
+
+func checkDocumentNumberFormat(value string) error {
+	// Nomor surat dibentuk dengan fmt.Sprintf(format, nomorUrut, bulanRomawi, tahun).
+	if strings.Contains(fmt.Sprintf(value, 1, "I", 2000), "%!") {
+		return fmt.Errorf("harus memakai %%d untuk nomor urut, %%s untuk bulan romawi, dan %%d untuk tahun sesuai urutan")
+	}
+	return nil
+}
+
+func checkTimeZone(value string) error {
+	if _, err := time.LoadLocation(value); err != nil {
+		return fmt.Errorf("bukan zona waktu yang dikenal, contoh: Asia/Jakarta")
+	}
+	return nil
+}
+
+func checkBackupPath(value string) error {
+	// Validasi keamanan sederhana untuk path traversal
+	if strings.Contains(value, "..") {
+		return fmt.Errorf("tidak boleh mengandung '..'")
+	}
+	return nil
+}
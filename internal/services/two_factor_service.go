@@ -0,0 +1,337 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image/png"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/models"
+	"simdokpol/internal/repositories"
+	"simdokpol/internal/reqctx"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Parameter TOTP mengikuti bawaan RFC 6238 agar kompatibel dengan semua aplikasi autentikator.
+const (
+	twoFactorIssuer   = "SIMDOKPOL"
+	totpPeriod        = 30
+	totpSkew          = 1
+	totpQRCodeSize    = 200
+	recoveryCodeCount = 10
+)
+
+type TwoFactorService interface {
+	Status(ctx context.Context, userID uint) (*dto.TwoFactorStatus, error)
+	// IsRequired menentukan apakah peran pengguna wajib memakai 2FA menurut pengaturan sistem.
+	IsRequired(ctx context.Context, user *models.User) bool
+	// BeginEnrollment membuat secret baru yang belum aktif sampai dikonfirmasi melalui Enable.
+	BeginEnrollment(ctx context.Context, userID uint) (*dto.TwoFactorSetup, error)
+	// Enable mengonfirmasi pendaftaran dengan kode TOTP pertama dan mengembalikan kode pemulihan.
+	Enable(ctx context.Context, userID uint, code string) ([]string, error)
+	// Disable menonaktifkan 2FA milik sendiri setelah kata sandi diverifikasi ulang.
+	Disable(ctx context.Context, userID uint, password string) error
+	// RegenerateRecoveryCodes mengganti semua kode pemulihan setelah kode TOTP diverifikasi.
+	RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error)
+	// Verify menerima kode TOTP atau kode pemulihan dari pengguna yang 2FA-nya aktif.
+	Verify(ctx context.Context, user *models.User, code string) error
+	// Reset menghapus 2FA pengguna atas perintah admin, misalnya saat perangkat hilang.
+	Reset(ctx context.Context, userID uint) error
+}
+
+type twoFactorService struct {
+	userRepo      repositories.UserRepository
+	recoveryRepo  repositories.RecoveryCodeRepository
+	auditService  AuditLogService
+	configService ConfigService
+}
+
+func NewTwoFactorService(userRepo repositories.UserRepository, recoveryRepo repositories.RecoveryCodeRepository, auditService AuditLogService, configService ConfigService) TwoFactorService {
+	return &twoFactorService{
+		userRepo:      userRepo,
+		recoveryRepo:  recoveryRepo,
+		auditService:  auditService,
+		configService: configService,
+	}
+}
+
+func (s *twoFactorService) findUser(ctx context.Context, userID uint) (*models.User, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return user, nil
+}
+
+func (s *twoFactorService) Status(ctx context.Context, userID uint) (*dto.TwoFactorStatus, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	status := &dto.TwoFactorStatus{Enabled: user.TOTPEnabled, Required: s.IsRequired(ctx, user)}
+	if user.TOTPEnabled {
+		codes, err := s.recoveryRepo.FindUnusedByUser(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		status.RecoveryCodesRemaining = len(codes)
+	}
+	return status, nil
+}
+
+func (s *twoFactorService) IsRequired(ctx context.Context, user *models.User) bool {
+	appConfig, err := s.configService.GetConfig(ctx)
+	if err != nil {
+		return false
+	}
+	return appConfig.RequiresTwoFactor(user.Peran)
+}
+
+func (s *twoFactorService) BeginEnrollment(ctx context.Context, userID uint) (*dto.TwoFactorSetup, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      twoFactorIssuer,
+		AccountName: user.NRP,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	img, err := key.Image(totpQRCodeSize, totpQRCodeSize)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+
+	user.TOTPSecret = key.Secret()
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	return &dto.TwoFactorSetup{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+		QRCode:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()),
+	}, nil
+}
+
+func (s *twoFactorService) Enable(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TOTPEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotEnabled
+	}
+
+	ok, err := s.verifyTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	user.TOTPEnabled = true
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.auditService.LogActivity(ctx, userID, models.AuditEnableTwoFactor, fmt.Sprintf("Mengaktifkan verifikasi dua faktor untuk akun %s (NRP: %s)", user.NamaLengkap, user.NRP))
+	return codes, nil
+}
+
+func (s *twoFactorService) Disable(ctx context.Context, userID uint, password string) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if s.IsRequired(ctx, user) {
+		return ErrTwoFactorRequired
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.KataSandi), []byte(password)); err != nil {
+		return ErrOldPasswordMismatch
+	}
+	if err := s.clear(ctx, user); err != nil {
+		return err
+	}
+	s.auditService.LogActivity(ctx, userID, models.AuditDisableTwoFactor, fmt.Sprintf("Menonaktifkan verifikasi dua faktor untuk akun %s (NRP: %s)", user.NamaLengkap, user.NRP))
+	return nil
+}
+
+func (s *twoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.TOTPEnabled {
+		return nil, ErrTwoFactorNotEnabled
+	}
+	ok, err := s.verifyTOTP(ctx, user, code)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	codes, err := s.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	s.auditService.LogActivity(ctx, userID, models.AuditRecoveryCodes, "Membuat ulang kode pemulihan verifikasi dua faktor")
+	return codes, nil
+}
+
+func (s *twoFactorService) Verify(ctx context.Context, user *models.User, code string) error {
+	if !user.TOTPEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+	if ok, err := s.verifyTOTP(ctx, user, code); err != nil || ok {
+		return err
+	}
+	if ok, err := s.useRecoveryCode(ctx, user, code); err != nil || ok {
+		return err
+	}
+	return ErrInvalidTwoFactorCode
+}
+
+func (s *twoFactorService) Reset(ctx context.Context, userID uint) error {
+	user, err := s.findUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if err := s.clear(ctx, user); err != nil {
+		return err
+	}
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditResetTwoFactor, fmt.Sprintf("Mereset verifikasi dua faktor pengguna %s (NRP: %s)", user.NamaLengkap, user.NRP))
+	return nil
+}
+
+func (s *twoFactorService) clear(ctx context.Context, user *models.User) error {
+	user.TOTPSecret = ""
+	user.TOTPEnabled = false
+	user.TOTPLastStep = 0
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	return s.recoveryRepo.DeleteByUser(ctx, user.ID)
+}
+
+// verifyTOTP mencocokkan kode dengan langkah waktu sekarang beserta satu langkah sebelum dan
+// sesudahnya. Langkah yang diterima disimpan sehingga kode yang sama tidak bisa dipakai ulang.
+func (s *twoFactorService) verifyTOTP(ctx context.Context, user *models.User, code string) (bool, error) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != int(otp.DigitsSix) || user.TOTPSecret == "" {
+		return false, nil
+	}
+
+	step := time.Now().Unix() / totpPeriod
+	for offset := int64(-totpSkew); offset <= totpSkew; offset++ {
+		candidate := step + offset
+		if candidate <= user.TOTPLastStep {
+			continue
+		}
+		expected, err := totp.GenerateCodeCustom(user.TOTPSecret, time.Unix(candidate*totpPeriod, 0), totp.ValidateOpts{
+			Period:    totpPeriod,
+			Digits:    otp.DigitsSix,
+			Algorithm: otp.AlgorithmSHA1,
+		})
+		if err != nil {
+			return false, err
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			// Langkah hanya dimajukan jika belum ada request lain yang menerima kode ini atau kode
+			// yang lebih baru, sehingga satu kode tidak dapat dipakai dua kali.
+			accepted, err := s.userRepo.UpdateTOTPLastStep(ctx, user.ID, candidate)
+			if err != nil || !accepted {
+				return false, err
+			}
+			user.TOTPLastStep = candidate
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *twoFactorService) useRecoveryCode(ctx context.Context, user *models.User, code string) (bool, error) {
+	hash := hashRecoveryCode(code)
+	codes, err := s.recoveryRepo.FindUnusedByUser(ctx, user.ID)
+	if err != nil {
+		return false, err
+	}
+	for _, rc := range codes {
+		if subtle.ConstantTimeCompare([]byte(rc.CodeHash), []byte(hash)) != 1 {
+			continue
+		}
+		used, err := s.recoveryRepo.MarkUsed(ctx, rc.ID, time.Now())
+		if err != nil || !used {
+			return false, err
+		}
+		s.auditService.LogActivity(ctx, user.ID, models.AuditRecoveryCodeUsed, fmt.Sprintf("Login memakai kode pemulihan, tersisa %d kode", len(codes)-1))
+		return true, nil
+	}
+	return false, nil
+}
+
+// replaceRecoveryCodes membuat kode pemulihan baru berformat XXXXX-XXXXX dan hanya menyimpan hash-nya.
+func (s *twoFactorService) replaceRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	raw := make([]byte, 7)
+	for i := range codes {
+		if _, err := rand.Read(raw); err != nil {
+			return nil, err
+		}
+		encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)[:10]
+		codes[i] = encoded[:5] + "-" + encoded[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	if err := s.recoveryRepo.Replace(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode menormalkan kode (tanpa tanda hubung, spasi, dan huruf kecil) sebelum di-hash.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTwoFactorService_Verify(t *testing.T) {
+	const secret = "JBSWY3DPEHPK3PXP"
+	user := &models.User{ID: 1, NRP: "12345", TOTPSecret: secret, TOTPEnabled: true}
+
+	mockUserRepo := new(mocks.UserRepository)
+	mockRecoveryRepo := new(mocks.RecoveryCodeRepository)
+	mockAuditService := new(mocks.AuditLogService)
+
+	// Kode pertama memajukan langkah; request lain dengan kode yang sama kalah pada update bersyarat
+	mockUserRepo.On("UpdateTOTPLastStep", mock.Anything, uint(1), mock.AnythingOfType("int64")).Return(true, nil).Once()
+	mockUserRepo.On("UpdateTOTPLastStep", mock.Anything, uint(1), mock.AnythingOfType("int64")).Return(false, nil)
+
+	service := NewTwoFactorService(mockUserRepo, mockRecoveryRepo, mockAuditService, new(mocks.ConfigService))
+
+	code, err := totp.GenerateCode(secret, time.Now())
+	assert.NoError(t, err)
+
+	t.Run("Kode TOTP Valid Diterima", func(t *testing.T) {
+		assert.NoError(t, service.Verify(context.Background(), user, code))
+		assert.NotZero(t, user.TOTPLastStep)
+	})
+
+	t.Run("Kode TOTP yang Sama Tidak Bisa Dipakai Ulang", func(t *testing.T) {
+		mockRecoveryRepo.On("FindUnusedByUser", mock.Anything, uint(1)).Return([]models.RecoveryCode{}, nil).Once()
+		assert.ErrorIs(t, service.Verify(context.Background(), user, code), ErrInvalidTwoFactorCode)
+	})
+
+	t.Run("Kode TOTP yang Sudah Diterima Request Lain Ditolak", func(t *testing.T) {
+		// Salinan lama pengguna belum melihat langkah yang sudah disimpan request lain
+		stale := &models.User{ID: 1, NRP: "12345", TOTPSecret: secret, TOTPEnabled: true}
+		mockRecoveryRepo.On("FindUnusedByUser", mock.Anything, uint(1)).Return([]models.RecoveryCode{}, nil).Once()
+		assert.ErrorIs(t, service.Verify(context.Background(), stale, code), ErrInvalidTwoFactorCode)
+		assert.Zero(t, stale.TOTPLastStep)
+	})
+
+	t.Run("Kode Pemulihan Dipakai Sekali", func(t *testing.T) {
+		stored := []models.RecoveryCode{{ID: 7, UserID: 1, CodeHash: hashRecoveryCode("ABCDE-FGHIJ")}}
+		mockRecoveryRepo.On("FindUnusedByUser", mock.Anything, uint(1)).Return(stored, nil).Once()
+		mockRecoveryRepo.On("MarkUsed", mock.Anything, uint(7), mock.AnythingOfType("time.Time")).Return(true, nil).Once()
+		mockAuditService.On("LogActivity", mock.Anything, uint(1), models.AuditRecoveryCodeUsed, mock.AnythingOfType("string")).Once()
+
+		// Huruf kecil dan tanpa tanda hubung tetap diterima
+		assert.NoError(t, service.Verify(context.Background(), user, "abcdefghij"))
+		mockRecoveryRepo.AssertExpectations(t)
+		mockAuditService.AssertExpectations(t)
+	})
+}
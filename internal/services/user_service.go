@@ -1,48 +1,112 @@
 package services
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"maps"
 	"simdokpol/internal/config"
 	"simdokpol/internal/models"
 	"simdokpol/internal/repositories"
+	"simdokpol/internal/reqctx"
 	"strings"
+	"sync"
+	"time"
 
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserService interface {
-	Create(user *models.User, actorID uint) error
-	FindAll(statusFilter string) ([]models.User, error)
-	FindByID(id uint) (*models.User, error)
-	FindOperators() ([]models.User, error)
-	Update(user *models.User, newPassword string, actorID uint) error
-	Deactivate(id uint, actorID uint) error
-	Activate(id uint, actorID uint) error
-	ChangePassword(userID uint, oldPassword, newPassword string) error
-	UpdateProfile(userID uint, dataToUpdate *models.User) (*models.User, error) // <-- METHOD BARU
+	// Create menyimpan pengguna baru dan mencatatnya di log audit atas nama aktor di context.
+	// Tanpa aktor (CLI), pembuatan akun adalah aksi sistem yang hanya dicatat di log aplikasi,
+	// tidak pernah atas nama pengguna baru itu sendiri. user.MustChangePassword dipertahankan
+	// agar akun dengan kata sandi sementara langsung tersimpan dengan kewajiban menggantinya.
+	Create(ctx context.Context, user *models.User) error
+	// CreateInitialSuperAdmin membuat Super Admin pertama saat setup awal, lalu menyimpan settings
+	// dan menandai setup selesai dalam transaksi yang sama. Kata sandi divalidasi sebelum apa pun
+	// disimpan. Mengembalikan ErrSetupComplete jika Super Admin sudah ada.
+	CreateInitialSuperAdmin(ctx context.Context, user *models.User, settings map[string]string) error
+	FindAll(ctx context.Context, statusFilter string) ([]models.User, error)
+	FindByID(ctx context.Context, id uint) (*models.User, error)
+	FindOperators(ctx context.Context) ([]models.User, error)
+	Update(ctx context.Context, user *models.User, newPassword string) error
+	Deactivate(ctx context.Context, id uint) error
+	Activate(ctx context.Context, id uint) error
+	// ChangePassword mengganti kata sandi lalu mencabut semua sesi lain milik pengguna;
+	// currentSessionID tetap aktif agar pengguna tidak ikut keluar.
+	ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string, currentSessionID string) error
+	UpdateProfile(ctx context.Context, userID uint, dataToUpdate *models.User) (*models.User, error) // <-- METHOD BARU
+	// AssignRole mengganti peran pengguna sehingga hak aksesnya ikut berubah.
+	AssignRole(ctx context.Context, userID uint, role string) (*models.User, error)
+	// IssueTemporaryPassword membuat kata sandi sekali pakai yang wajib diganti saat login
+	// berikutnya, lalu mencabut semua sesi pengguna. Tanpa aktor di context berarti aksi dari CLI.
+	IssueTemporaryPassword(ctx context.Context, userID uint) (string, error)
 }
 
 type userService struct {
-	userRepo     repositories.UserRepository
-	auditService AuditLogService
-	cfg          *config.Config
+	userRepo       repositories.UserRepository
+	sessionService SessionService
+	passwordPolicy PasswordPolicyService
+	auditService   AuditLogService
+	cfg            *config.Config
+
+	// setupMu menyerialkan setup awal agar request bersamaan tidak sama-sama membuat Super Admin.
+	setupMu sync.Mutex
 }
 
-func NewUserService(userRepo repositories.UserRepository, auditService AuditLogService, cfg *config.Config) UserService {
+func NewUserService(userRepo repositories.UserRepository, sessionService SessionService, passwordPolicy PasswordPolicyService, auditService AuditLogService, cfg *config.Config) UserService {
 	return &userService{
-		userRepo:     userRepo,
-		auditService: auditService,
-		cfg:          cfg,
+		userRepo:       userRepo,
+		sessionService: sessionService,
+		passwordPolicy: passwordPolicy,
+		auditService:   auditService,
+		cfg:            cfg,
+	}
+}
+
+// setPassword memvalidasi kata sandi baru terhadap kebijakan lalu menyimpan hash-nya pada user.
+// user.KataSandi harus berisi hash lama agar riwayat kata sandi dapat diperiksa.
+func (s *userService) setPassword(ctx context.Context, user *models.User, password string) error {
+	if err := s.passwordPolicy.Validate(ctx, user, password); err != nil {
+		return err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), s.cfg.BcryptCost)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	user.KataSandi = string(hashedPassword)
+	user.PasswordChangedAt = &now
+	user.MustChangePassword = false
+	return nil
+}
+
+// rememberPassword mencatat hash ke riwayat. Kegagalan hanya dicatat karena kata sandi sudah tersimpan.
+func (s *userService) rememberPassword(ctx context.Context, user *models.User) {
+	if err := s.passwordPolicy.Remember(ctx, user.ID, user.KataSandi); err != nil {
+		slog.WarnContext(ctx, "Gagal menyimpan riwayat kata sandi", "user_id", user.ID, "error", err)
+	}
+}
+
+// revokeSessions mencabut sesi pengguna setelah perubahan keamanan akun. Kegagalan hanya
+// dicatat karena perubahan akun sudah tersimpan.
+func (s *userService) revokeSessions(ctx context.Context, userID uint, exceptID string) {
+	if _, err := s.sessionService.RevokeAllForUser(ctx, userID, exceptID); err != nil {
+		slog.ErrorContext(ctx, "Gagal mencabut sesi pengguna", "user_id", userID, "error", err)
 	}
 }
 
 // === FUNGSI BARU UNTUK UPDATE PROFIL ===
-func (s *userService) UpdateProfile(userID uint, dataToUpdate *models.User) (*models.User, error) {
-	currentUser, err := s.userRepo.FindByID(userID)
+func (s *userService) UpdateProfile(ctx context.Context, userID uint, dataToUpdate *models.User) (*models.User, error) {
+	currentUser, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return nil, errors.New("pengguna tidak ditemukan")
 	}
+	// Data diri pengguna LDAP diselaraskan dari direktori setiap login
+	if currentUser.AuthSource == models.AuthSourceLDAP {
+		return nil, ErrDirectoryManagedAccount
+	}
 
 	// Logika Keamanan: Hanya perbarui field yang diizinkan untuk diubah oleh pengguna.
 	// Jabatan, Peran, dan Regu tidak disentuh.
@@ -50,138 +114,251 @@ func (s *userService) UpdateProfile(userID uint, dataToUpdate *models.User) (*mo
 	currentUser.NRP = dataToUpdate.NRP
 	currentUser.Pangkat = dataToUpdate.Pangkat
 
-	if err := s.userRepo.Update(currentUser); err != nil {
+	if err := s.userRepo.Update(ctx, currentUser); err != nil {
 		return nil, err
 	}
 
 	logDetails := fmt.Sprintf("Pengguna '%s' (NRP: %s) memperbarui data profilnya.", currentUser.NamaLengkap, currentUser.NRP)
-	s.auditService.LogActivity(userID, models.AuditUpdateUser, logDetails)
+	s.auditService.LogActivity(ctx, userID, models.AuditUpdateUser, logDetails)
 
 	return currentUser, nil
 }
 // === AKHIR FUNGSI BARU ===
 
 
-func (s *userService) ChangePassword(userID uint, oldPassword, newPassword string) error {
-	user, err := s.userRepo.FindByID(userID)
+func (s *userService) ChangePassword(ctx context.Context, userID uint, oldPassword, newPassword string, currentSessionID string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
 		return errors.New("pengguna tidak ditemukan")
 	}
+	if user.AuthSource == models.AuthSourceLDAP {
+		return ErrDirectoryManagedAccount
+	}
 
 	err = bcrypt.CompareHashAndPassword([]byte(user.KataSandi), []byte(oldPassword))
 	if err != nil {
-		return errors.New("kata sandi saat ini yang Anda masukkan salah")
+		return ErrOldPasswordMismatch
 	}
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), s.cfg.BcryptCost)
-	if err != nil {
+	if err := s.setPassword(ctx, user, newPassword); err != nil {
 		return err
 	}
-	user.KataSandi = string(hashedPassword)
 
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.Update(ctx, user); err != nil {
 		return err
 	}
+	s.rememberPassword(ctx, user)
+	s.revokeSessions(ctx, userID, currentSessionID)
 
 	logDetails := fmt.Sprintf("Pengguna '%s' (NRP: %s) mengubah kata sandinya sendiri.", user.NamaLengkap, user.NRP)
-	s.auditService.LogActivity(userID, models.AuditUpdateUser, logDetails)
+	s.auditService.LogActivity(ctx, userID, models.AuditUpdateUser, logDetails)
 
 	return nil
 }
 
 // ... (sisa fungsi Create, Update (admin), Deactivate, dll. tidak berubah) ...
-func (s *userService) Create(user *models.User, actorID uint) error {
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.KataSandi), s.cfg.BcryptCost)
-	if err != nil {
+func (s *userService) Create(ctx context.Context, user *models.User) error {
+	if !models.IsValidRole(user.Peran) {
+		return ErrInvalidRole
+	}
+	password, mustChange := user.KataSandi, user.MustChangePassword
+	user.KataSandi = ""
+	if err := s.setPassword(ctx, user, password); err != nil {
 		return err
 	}
-	user.KataSandi = string(hashedPassword)
-	if err := s.userRepo.Create(user); err != nil {
+	user.MustChangePassword = mustChange
+	if err := s.userRepo.Create(ctx, user); err != nil {
 		return err
 	}
+	s.rememberPassword(ctx, user)
 
-	logDetails := fmt.Sprintf("Pengguna baru '%s' (NRP: %s) telah dibuat.", user.NamaLengkap, user.NRP)
-	auditAction := models.AuditCreateUser
-
+	actorID := reqctx.ActorID(ctx)
 	if actorID == 0 {
-		actorID = user.ID
-		logDetails = fmt.Sprintf("Akun Super Admin pertama '%s' (NRP: %s) dibuat saat setup.", user.NamaLengkap, user.NRP)
-		auditAction = models.AuditSystemSetup
+		// Aksi sistem (CLI) tidak dicatat atas nama pengguna yang baru dibuat
+		slog.InfoContext(ctx, "Akun dibuat oleh sistem", "user_id", user.ID, "nrp", user.NRP, "nama_lengkap", user.NamaLengkap, "peran", user.Peran)
+		return nil
 	}
-	s.auditService.LogActivity(actorID, auditAction, logDetails)
+	s.auditService.LogActivity(ctx, actorID, models.AuditCreateUser, fmt.Sprintf("Pengguna baru '%s' (NRP: %s) telah dibuat.", user.NamaLengkap, user.NRP))
+
+	return nil
+}
 
+func (s *userService) CreateInitialSuperAdmin(ctx context.Context, user *models.User, settings map[string]string) error {
+	user.Peran = models.RoleSuperAdmin
+	password := user.KataSandi
+	user.KataSandi = ""
+	if err := s.setPassword(ctx, user, password); err != nil {
+		return err
+	}
+
+	configs := make(map[string]string, len(settings)+1)
+	maps.Copy(configs, settings)
+	configs[IsSetupCompleteKey] = "true"
+
+	s.setupMu.Lock()
+	created, err := s.userRepo.CreateInitialSuperAdmin(ctx, user, configs)
+	s.setupMu.Unlock()
+	if err != nil {
+		return err
+	}
+	if !created {
+		return ErrSetupComplete
+	}
+	s.rememberPassword(ctx, user)
 	return nil
 }
 
-func (s *userService) Update(user *models.User, newPassword string, actorID uint) error {
-	oldUser, err := s.userRepo.FindByID(user.ID)
+func (s *userService) Update(ctx context.Context, user *models.User, newPassword string) error {
+	oldUser, err := s.userRepo.FindByID(ctx, user.ID)
 	if err != nil {
 		return errors.New("pengguna tidak ditemukan untuk pembaruan")
 	}
 
-	if strings.TrimSpace(newPassword) != "" {
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), s.cfg.BcryptCost)
-		if err != nil {
+	// Peran hanya diubah melalui AssignRole, dan status 2FA hanya melalui TwoFactorService
+	user.Peran = oldUser.Peran
+	user.TOTPSecret = oldUser.TOTPSecret
+	user.TOTPEnabled = oldUser.TOTPEnabled
+	user.TOTPLastStep = oldUser.TOTPLastStep
+	user.KataSandi = oldUser.KataSandi
+	user.PasswordChangedAt = oldUser.PasswordChangedAt
+	user.MustChangePassword = oldUser.MustChangePassword
+	user.AuthSource = oldUser.AuthSource
+	user.CreatedAt = oldUser.CreatedAt
+
+	passwordChanged := strings.TrimSpace(newPassword) != ""
+	if passwordChanged && user.AuthSource == models.AuthSourceLDAP {
+		return ErrDirectoryManagedAccount
+	}
+	if passwordChanged {
+		if err := s.setPassword(ctx, user, newPassword); err != nil {
 			return err
 		}
-		user.KataSandi = string(hashedPassword)
-	} else {
-		user.KataSandi = oldUser.KataSandi
 	}
 
-	if err := s.userRepo.Update(user); err != nil {
+	if err := s.userRepo.Update(ctx, user); err != nil {
 		return err
 	}
+	if passwordChanged {
+		s.rememberPassword(ctx, user)
+		s.revokeSessions(ctx, user.ID, "")
+	}
 
 	logDetails := fmt.Sprintf("Data pengguna '%s' (NRP: %s) telah diperbarui.", user.NamaLengkap, user.NRP)
 	if newPassword != "" {
 		logDetails += " Termasuk perubahan kata sandi."
 	}
-	s.auditService.LogActivity(actorID, models.AuditUpdateUser, logDetails)
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditUpdateUser, logDetails)
 
 	return nil
 }
 
-func (s *userService) Deactivate(id uint, actorID uint) error {
-	user, err := s.userRepo.FindByID(id)
+func (s *userService) AssignRole(ctx context.Context, userID uint, role string) (*models.User, error) {
+	if !models.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if userID == reqctx.ActorID(ctx) {
+		return nil, ErrSelfRoleChange
+	}
+
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, ErrNotFound
+	}
+	oldRole := user.Peran
+	if oldRole == role {
+		return user, nil
+	}
+
+	user.Peran = role
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return nil, err
+	}
+
+	logDetails := fmt.Sprintf("Peran pengguna '%s' (NRP: %s) diubah dari %s menjadi %s.", user.NamaLengkap, user.NRP, oldRole, role)
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditAssignRole, logDetails)
+
+	return user, nil
+}
+
+func (s *userService) IssueTemporaryPassword(ctx context.Context, userID uint) (string, error) {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return "", ErrNotFound
+	}
+	if user.AuthSource == models.AuthSourceLDAP {
+		return "", ErrDirectoryManagedAccount
+	}
+
+	password, err := s.passwordPolicy.GenerateTemporary(ctx)
+	if err != nil {
+		return "", err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), s.cfg.BcryptCost)
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	user.KataSandi = string(hashedPassword)
+	user.PasswordChangedAt = &now
+	user.MustChangePassword = true
+
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return "", err
+	}
+	s.rememberPassword(ctx, user)
+	s.revokeSessions(ctx, userID, "")
+
+	logDetails := fmt.Sprintf("Kata sandi sementara diberikan kepada pengguna '%s' (NRP: %s).", user.NamaLengkap, user.NRP)
+	if actorID := reqctx.ActorID(ctx); actorID != 0 {
+		s.auditService.LogActivity(ctx, actorID, models.AuditTemporaryPassword, logDetails)
+	} else {
+		slog.InfoContext(ctx, "Kata sandi sementara diberikan oleh sistem", "user_id", user.ID, "nrp", user.NRP, "nama_lengkap", user.NamaLengkap)
+	}
+
+	return password, nil
+}
+
+func (s *userService) Deactivate(ctx context.Context, id uint) error {
+	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("pengguna tidak ditemukan")
 	}
 
-	if err := s.userRepo.Delete(id); err != nil {
+	if err := s.userRepo.Delete(ctx, id); err != nil {
 		return err
 	}
+	s.revokeSessions(ctx, id, "")
 
 	logDetails := fmt.Sprintf("Pengguna '%s' (NRP: %s) telah dinonaktifkan.", user.NamaLengkap, user.NRP)
-	s.auditService.LogActivity(actorID, models.AuditDeactivateUser, logDetails)
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditDeactivateUser, logDetails)
 
 	return nil
 }
 
-func (s *userService) Activate(id uint, actorID uint) error {
-	user, err := s.userRepo.FindByID(id)
+func (s *userService) Activate(ctx context.Context, id uint) error {
+	user, err := s.userRepo.FindByID(ctx, id)
 	if err != nil {
 		return errors.New("pengguna tidak ditemukan")
 	}
 
-	if err := s.userRepo.Restore(id); err != nil {
+	if err := s.userRepo.Restore(ctx, id); err != nil {
 		return err
 	}
 
 	logDetails := fmt.Sprintf("Pengguna '%s' (NRP: %s) telah diaktifkan kembali.", user.NamaLengkap, user.NRP)
-	s.auditService.LogActivity(actorID, models.AuditActivateUser, logDetails)
+	s.auditService.LogActivity(ctx, reqctx.ActorID(ctx), models.AuditActivateUser, logDetails)
 
 	return nil
 }
 
-func (s *userService) FindAll(statusFilter string) ([]models.User, error) {
-	return s.userRepo.FindAll(statusFilter)
+func (s *userService) FindAll(ctx context.Context, statusFilter string) ([]models.User, error) {
+	return s.userRepo.FindAll(ctx, statusFilter)
 }
 
-func (s *userService) FindByID(id uint) (*models.User, error) {
-	return s.userRepo.FindByID(id)
+func (s *userService) FindByID(ctx context.Context, id uint) (*models.User, error) {
+	return s.userRepo.FindByID(ctx, id)
 }
 
-func (s *userService) FindOperators() ([]models.User, error) {
-	return s.userRepo.FindOperators()
+func (s *userService) FindOperators(ctx context.Context) ([]models.User, error) {
+	return s.userRepo.FindOperators(ctx)
 }
\ No newline at end of file
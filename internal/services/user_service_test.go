@@ -0,0 +1,232 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"simdokpol/internal/config"
+	"simdokpol/internal/dto"
+	"simdokpol/internal/logging"
+	"simdokpol/internal/mocks"
+	"simdokpol/internal/models"
+	"simdokpol/internal/reqctx"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestUserService_AssignRole(t *testing.T) {
+	testCases := []struct {
+		name          string
+		userID        uint
+		role          string
+		setupMock     func(mockRepo *mocks.UserRepository, mockAudit *mocks.AuditLogService)
+		expectedError error
+	}{
+		{
+			name:   "Berhasil Mengubah Peran",
+			userID: 2,
+			role:   models.RoleAuditor,
+			setupMock: func(mockRepo *mocks.UserRepository, mockAudit *mocks.AuditLogService) {
+				mockRepo.On("FindByID", mock.Anything, uint(2)).Return(&models.User{ID: 2, Peran: models.RoleOperator}, nil).Once()
+				mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool { return u.Peran == models.RoleAuditor })).Return(nil).Once()
+				mockAudit.On("LogActivity", mock.Anything, uint(1), models.AuditAssignRole, mock.AnythingOfType("string")).Once()
+			},
+		},
+		{
+			name:          "Peran Tidak Dikenal",
+			userID:        2,
+			role:          "KOMANDAN",
+			setupMock:     func(mockRepo *mocks.UserRepository, mockAudit *mocks.AuditLogService) {},
+			expectedError: ErrInvalidRole,
+		},
+		{
+			name:          "Mengubah Peran Sendiri",
+			userID:        1,
+			role:          models.RoleViewer,
+			setupMock:     func(mockRepo *mocks.UserRepository, mockAudit *mocks.AuditLogService) {},
+			expectedError: ErrSelfRoleChange,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			mockAudit := new(mocks.AuditLogService)
+			tc.setupMock(mockRepo, mockAudit)
+
+			service := NewUserService(mockRepo, new(mocks.SessionService), nil, mockAudit, &config.Config{})
+			user, err := service.AssignRole(reqctx.WithActor(context.Background(), 1), tc.userID, tc.role)
+
+			if tc.expectedError != nil {
+				assert.ErrorIs(t, err, tc.expectedError)
+				assert.Nil(t, user)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tc.role, user.Peran)
+			}
+			mockRepo.AssertExpectations(t)
+			mockAudit.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserService_UpdateKeepsRole(t *testing.T) {
+	mockRepo := new(mocks.UserRepository)
+	mockAudit := new(mocks.AuditLogService)
+
+	mockRepo.On("FindByID", mock.Anything, uint(1)).Return(&models.User{ID: 1, NRP: "111", Peran: models.RoleOperator}, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(u *models.User) bool { return u.Peran == models.RoleOperator })).Return(nil).Once()
+	mockAudit.On("LogActivity", mock.Anything, uint(1), models.AuditUpdateUser, mock.AnythingOfType("string")).Once()
+
+	// Perubahan peran lewat Update diabaikan agar larangan mengubah peran sendiri di AssignRole tidak terlewati
+	service := NewUserService(mockRepo, new(mocks.SessionService), nil, mockAudit, &config.Config{})
+	user := &models.User{ID: 1, NRP: "111", NamaLengkap: "BUDI", Peran: models.RoleSuperAdmin}
+	err := service.Update(reqctx.WithActor(context.Background(), 1), user, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, models.RoleOperator, user.Peran)
+	mockRepo.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
+
+func TestUserService_CreateAuditActor(t *testing.T) {
+	testCases := []struct {
+		name    string
+		actorID uint
+	}{
+		{name: "Dibuat Admin Dicatat di Log Audit", actorID: 1},
+		// Aksi CLI dan setup awal tidak boleh tercatat atas nama akun yang baru dibuat
+		{name: "Dibuat Sistem Tidak Dicatat Atas Nama Pengguna Baru", actorID: 0},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			mockAudit := new(mocks.AuditLogService)
+			mockConfig := new(mocks.ConfigService)
+			mockConfig.On("GetConfig", mock.Anything).Return(&dto.AppConfig{PasswordMinLength: DefaultPasswordMinLength}, nil)
+
+			mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil).
+				Run(func(args mock.Arguments) { args.Get(1).(*models.User).ID = 7 }).Once()
+			if tc.actorID != 0 {
+				mockAudit.On("LogActivity", mock.Anything, tc.actorID, models.AuditCreateUser, mock.AnythingOfType("string")).Once()
+			}
+
+			policy := NewPasswordPolicyService(new(mocks.PasswordHistoryRepository), mockConfig)
+			service := NewUserService(mockRepo, new(mocks.SessionService), policy, mockAudit, &config.Config{BcryptCost: bcrypt.MinCost})
+			user := &models.User{NRP: "777", NamaLengkap: "ANI", Peran: models.RoleOperator, KataSandi: "Sandi-Baru1"}
+			err := service.Create(reqctx.WithActor(context.Background(), tc.actorID), user)
+
+			assert.NoError(t, err)
+			mockRepo.AssertExpectations(t)
+			mockAudit.AssertExpectations(t)
+			mockAudit.AssertNotCalled(t, "LogActivity", mock.Anything, uint(7), mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUserService_CreateKeepsMustChangePassword(t *testing.T) {
+	mockRepo := new(mocks.UserRepository)
+	mockConfig := new(mocks.ConfigService)
+	mockConfig.On("GetConfig", mock.Anything).Return(&dto.AppConfig{PasswordMinLength: DefaultPasswordMinLength}, nil)
+	// Kewajiban mengganti kata sandi harus sudah terpasang saat baris pengguna pertama kali disimpan
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(u *models.User) bool { return u.MustChangePassword })).Return(nil).Once()
+
+	policy := NewPasswordPolicyService(new(mocks.PasswordHistoryRepository), mockConfig)
+	service := NewUserService(mockRepo, new(mocks.SessionService), policy, new(mocks.AuditLogService), &config.Config{BcryptCost: bcrypt.MinCost})
+	user := &models.User{NRP: "777", NamaLengkap: "ANI", Peran: models.RoleOperator, KataSandi: "Sandi-Baru1", MustChangePassword: true}
+
+	assert.NoError(t, service.Create(context.Background(), user))
+	mockRepo.AssertExpectations(t)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUserService_CreateInitialSuperAdmin(t *testing.T) {
+	settings := map[string]string{"nama_kantor": "POLSEK CONTOH"}
+	testCases := []struct {
+		name        string
+		password    string
+		created     bool
+		expectRepo  bool
+		expectedErr error
+	}{
+		{name: "Berhasil Menandai Setup Selesai", password: "Sandi-Baru1", created: true, expectRepo: true},
+		// Kata sandi ditolak sebelum apa pun disimpan
+		{name: "Kata Sandi Lemah Tidak Menyimpan Apa Pun", password: "lemah", expectedErr: ErrPasswordPolicy},
+		{name: "Super Admin Sudah Ada", password: "Sandi-Baru1", created: false, expectRepo: true, expectedErr: ErrSetupComplete},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			mockRepo := new(mocks.UserRepository)
+			mockConfig := new(mocks.ConfigService)
+			mockConfig.On("GetConfig", mock.Anything).Return(&dto.AppConfig{PasswordMinLength: DefaultPasswordMinLength}, nil)
+			if tc.expectRepo {
+				mockRepo.On("CreateInitialSuperAdmin", mock.Anything, mock.MatchedBy(func(u *models.User) bool {
+					return u.Peran == models.RoleSuperAdmin && u.KataSandi != tc.password
+				}), map[string]string{"nama_kantor": "POLSEK CONTOH", IsSetupCompleteKey: "true"}).Return(tc.created, nil).Once()
+			}
+
+			policy := NewPasswordPolicyService(new(mocks.PasswordHistoryRepository), mockConfig)
+			service := NewUserService(mockRepo, new(mocks.SessionService), policy, new(mocks.AuditLogService), &config.Config{BcryptCost: bcrypt.MinCost})
+			user := &models.User{NRP: "777", NamaLengkap: "ANI", KataSandi: tc.password}
+			err := service.CreateInitialSuperAdmin(context.Background(), user, settings)
+
+			if tc.expectedErr != nil {
+				assert.ErrorIs(t, err, tc.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockRepo.AssertExpectations(t)
+			mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+			assert.NotContains(t, settings, IsSetupCompleteKey, "Map pengaturan milik pemanggil tidak boleh diubah")
+		})
+	}
+}
+
+func TestUserService_CreateBySystemLogsWithRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	previous := slog.Default()
+	slog.SetDefault(slog.New(logging.NewHandler(&buf, config.LogFormatJSON)))
+	defer slog.SetDefault(previous)
+
+	mockRepo := new(mocks.UserRepository)
+	mockConfig := new(mocks.ConfigService)
+	mockConfig.On("GetConfig", mock.Anything).Return(&dto.AppConfig{PasswordMinLength: DefaultPasswordMinLength}, nil)
+	mockRepo.On("Create", mock.Anything, mock.AnythingOfType("*models.User")).Return(nil).Once()
+
+	policy := NewPasswordPolicyService(new(mocks.PasswordHistoryRepository), mockConfig)
+	service := NewUserService(mockRepo, new(mocks.SessionService), policy, new(mocks.AuditLogService), &config.Config{BcryptCost: bcrypt.MinCost})
+	user := &models.User{NRP: "777", NamaLengkap: "ANI SUSANTI", Peran: models.RoleOperator, KataSandi: "Sandi-Baru1"}
+	assert.NoError(t, service.Create(reqctx.WithRequestID(context.Background(), "req-123"), user))
+
+	// Log service harus membawa request_id dari context, dan nama tidak boleh tertulis apa adanya
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	assert.Equal(t, "Akun dibuat oleh sistem", entry["msg"])
+	assert.Equal(t, "req-123", entry["request_id"])
+	assert.Equal(t, "777", entry["nrp"])
+	assert.NotContains(t, buf.String(), "ANI SUSANTI")
+}
+
+func TestUserService_DeactivateRevokesSessions(t *testing.T) {
+	mockRepo := new(mocks.UserRepository)
+	mockSession := new(mocks.SessionService)
+	mockAudit := new(mocks.AuditLogService)
+
+	mockRepo.On("FindByID", mock.Anything, uint(2)).Return(&models.User{ID: 2, NamaLengkap: "Budi", NRP: "222"}, nil).Once()
+	mockRepo.On("Delete", mock.Anything, uint(2)).Return(nil).Once()
+	mockSession.On("RevokeAllForUser", mock.Anything, uint(2), "").Return(int64(2), nil).Once()
+	mockAudit.On("LogActivity", mock.Anything, uint(1), models.AuditDeactivateUser, mock.AnythingOfType("string")).Once()
+
+	service := NewUserService(mockRepo, mockSession, nil, mockAudit, &config.Config{})
+	assert.NoError(t, service.Deactivate(reqctx.WithActor(context.Background(), 1), 2))
+
+	mockRepo.AssertExpectations(t)
+	mockSession.AssertExpectations(t)
+	mockAudit.AssertExpectations(t)
+}
@@ -0,0 +1,15 @@
+//go:build !windows
+
+package utils
+
+import "syscall"
+
+// FreeDiskSpace mengembalikan jumlah byte yang masih bisa ditulis pengguna biasa
+// pada sistem berkas tempat path berada.
+func FreeDiskSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), nil
+}
@@ -0,0 +1,30 @@
+//go:build windows
+
+package utils
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// FreeDiskSpace mengembalikan jumlah byte yang masih bisa ditulis pengguna saat ini
+// pada drive tempat path berada.
+func FreeDiskSpace(path string) (uint64, error) {
+	pathPtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var freeBytesAvailable uint64
+	ret, _, callErr := procGetDiskFreeSpaceEx.Call(
+		uintptr(unsafe.Pointer(pathPtr)),
+		uintptr(unsafe.Pointer(&freeBytesAvailable)),
+		0,
+		0,
+	)
+	if ret == 0 {
+		return 0, callErr
+	}
+	return freeBytesAvailable, nil
+}
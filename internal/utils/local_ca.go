@@ -0,0 +1,410 @@
+/**
+ * FILE: internal/utils/local_ca.go
+ *
+ * PURPOSE:
+ * Certificate Authority lokal untuk HTTPS di jaringan kantor (LAN).
+ * Membuat CA sekali saat pertama dijalankan, lalu menerbitkan dan memperbarui
+ * sertifikat server untuk domain virtual host, localhost, dan alamat IP LAN.
+ */
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Nama berkas di dalam direktori sertifikat
+	CACertFile     = "ca.crt"
+	caKeyFile      = "ca.key"
+	serverCertFile = "server.crt"
+	serverKeyFile  = "server.key"
+
+	caValidity = 10 * 365 * 24 * time.Hour
+	// serverValidity dijaga di bawah 398 hari agar diterima browser modern
+	serverValidity = 397 * 24 * time.Hour
+	// serverRenewBefore adalah sisa masa berlaku saat sertifikat server diterbitkan ulang
+	serverRenewBefore = 30 * 24 * time.Hour
+)
+
+// LocalCA mengelola CA lokal dan sertifikat server yang ditandatanganinya. Sertifikat server
+// disajikan lewat GetCertificate sehingga pembaruan berlaku tanpa restart server.
+type LocalCA struct {
+	dir   string
+	hosts []string
+
+	caCert *x509.Certificate
+	caKey  *ecdsa.PrivateKey
+
+	mu         sync.RWMutex
+	serverCert *tls.Certificate
+}
+
+// NewLocalCA membuat LocalCA yang menyimpan berkasnya di dir. hosts adalah nama DNS atau IP
+// tambahan di luar localhost dan IP LAN yang selalu dimasukkan ke sertifikat server.
+func NewLocalCA(dir string, hosts []string) *LocalCA {
+	return &LocalCA{dir: dir, hosts: hosts}
+}
+
+// CACertPath mengembalikan path sertifikat CA yang perlu dipasang di komputer klien.
+func (ca *LocalCA) CACertPath() string {
+	return filepath.Join(ca.dir, CACertFile)
+}
+
+// Ensure memuat atau membuat CA, lalu menerbitkan ulang sertifikat server jika belum ada,
+// hampir kedaluwarsa, tidak ditandatangani CA ini, atau tidak mencakup semua host saat ini
+// (misalnya IP LAN berubah karena DHCP).
+func (ca *LocalCA) Ensure() error {
+	if err := os.MkdirAll(ca.dir, 0700); err != nil {
+		return fmt.Errorf("gagal membuat direktori sertifikat: %w", err)
+	}
+	if ca.caCert == nil {
+		if err := ca.loadOrCreateCA(); err != nil {
+			return err
+		}
+	}
+
+	hosts := ca.serverHosts()
+	if reason := ca.constraintViolation(hosts); reason != "" {
+		slog.Warn("Sertifikat server berada di luar batasan nama CA lokal dan akan ditolak klien; hapus berkas CA untuk membuat CA baru lalu pasang ulang di komputer klien",
+			"reason", reason, "ca_cert", filepath.Join(ca.dir, CACertFile), "ca_key", filepath.Join(ca.dir, caKeyFile))
+	}
+	certPath := filepath.Join(ca.dir, serverCertFile)
+	keyPath := filepath.Join(ca.dir, serverKeyFile)
+
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		reason := ca.renewalReason(cert.Leaf, hosts)
+		if reason == "" {
+			ca.setServerCert(&cert)
+			return nil
+		}
+		log.Printf("INFO: Sertifikat server diterbitkan ulang: %s", reason)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		log.Printf("PERINGATAN: Sertifikat server tidak dapat dibaca, menerbitkan ulang: %v", err)
+	}
+
+	cert, err := ca.issueServerCert(hosts, certPath, keyPath)
+	if err != nil {
+		return err
+	}
+	ca.setServerCert(cert)
+	log.Printf("INFO: Sertifikat server untuk %v berlaku sampai %s", hosts, cert.Leaf.NotAfter.Format("2006-01-02"))
+	return nil
+}
+
+// Renew menerbitkan ulang sertifikat server tanpa menunggu masa berlakunya habis.
+func (ca *LocalCA) Renew() error {
+	if err := os.Remove(filepath.Join(ca.dir, serverCertFile)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("gagal menghapus sertifikat server lama: %w", err)
+	}
+	return ca.Ensure()
+}
+
+// RunRenewal memanggil Ensure secara berkala sampai ctx dibatalkan. Dijalankan sebagai goroutine.
+func (ca *LocalCA) RunRenewal(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := ca.Ensure(); err != nil {
+			log.Printf("PERINGATAN: Gagal memperbarui sertifikat server: %v", err)
+		}
+	}
+}
+
+// TLSConfig mengembalikan konfigurasi TLS untuk http.Server yang selalu memakai sertifikat server terbaru.
+func (ca *LocalCA) TLSConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			ca.mu.RLock()
+			defer ca.mu.RUnlock()
+			if ca.serverCert == nil {
+				return nil, errors.New("sertifikat server belum tersedia")
+			}
+			return ca.serverCert, nil
+		},
+	}
+}
+
+func (ca *LocalCA) setServerCert(cert *tls.Certificate) {
+	ca.mu.Lock()
+	ca.serverCert = cert
+	ca.mu.Unlock()
+}
+
+// serverHosts mengembalikan daftar host yang harus tercantum di sertifikat server, terurut dan tanpa duplikat.
+func (ca *LocalCA) serverHosts() []string {
+	seen := map[string]bool{}
+	var hosts []string
+	add := func(host string) {
+		if host != "" && !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	add("localhost")
+	add(LocalDomain)
+	add("127.0.0.1")
+	add("::1")
+	for _, host := range ca.hosts {
+		add(host)
+	}
+	for _, ip := range LANAddresses() {
+		add(ip)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// renewalReason mengembalikan alasan sertifikat server perlu diterbitkan ulang, atau string kosong.
+func (ca *LocalCA) renewalReason(leaf *x509.Certificate, hosts []string) string {
+	if time.Until(leaf.NotAfter) < serverRenewBefore {
+		return fmt.Sprintf("berakhir pada %s", leaf.NotAfter.Format("2006-01-02"))
+	}
+	if err := leaf.CheckSignatureFrom(ca.caCert); err != nil {
+		return "tidak ditandatangani CA lokal saat ini"
+	}
+	for _, host := range hosts {
+		if err := leaf.VerifyHostname(host); err != nil {
+			return fmt.Sprintf("host %s belum tercakup", host)
+		}
+	}
+	return ""
+}
+
+// nameConstraints mengembalikan domain dan rentang IP yang boleh ditandatangani CA: domain
+// virtual host, localhost, host tambahan, loopback, dan jaringan LAN (bukan hanya IP saat ini,
+// agar perubahan IP dari DHCP di jaringan yang sama tetap tercakup).
+func (ca *LocalCA) nameConstraints() ([]string, []*net.IPNet) {
+	dnsDomains := []string{LocalDomain, "localhost"}
+	ipRanges := []*net.IPNet{
+		{IP: net.IPv4(127, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)},
+		{IP: net.IPv6loopback, Mask: net.CIDRMask(128, 128)},
+	}
+	for _, host := range ca.hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			ipRanges = append(ipRanges, singleIPNet(ip))
+		} else if host != "" {
+			dnsDomains = append(dnsDomains, host)
+		}
+	}
+	for _, ipNet := range lanNetworks() {
+		ipRanges = append(ipRanges, &net.IPNet{IP: ipNet.IP.Mask(ipNet.Mask), Mask: ipNet.Mask})
+	}
+	return dnsDomains, ipRanges
+}
+
+// constraintViolation mengembalikan keterangan host yang berada di luar batasan nama CA,
+// atau string kosong. CA lama yang dibuat tanpa batasan nama mencakup semua host.
+func (ca *LocalCA) constraintViolation(hosts []string) string {
+	if len(ca.caCert.PermittedDNSDomains) == 0 && len(ca.caCert.PermittedIPRanges) == 0 {
+		return ""
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			if !slices.ContainsFunc(ca.caCert.PermittedIPRanges, func(ipNet *net.IPNet) bool { return ipNet.Contains(ip) }) {
+				return fmt.Sprintf("IP %s berada di luar jaringan yang diizinkan CA lokal", host)
+			}
+			continue
+		}
+		if !slices.ContainsFunc(ca.caCert.PermittedDNSDomains, func(domain string) bool {
+			return strings.EqualFold(host, domain) || strings.HasSuffix(strings.ToLower(host), "."+strings.ToLower(domain))
+		}) {
+			return fmt.Sprintf("host %s berada di luar domain yang diizinkan CA lokal", host)
+		}
+	}
+	return ""
+}
+
+func singleIPNet(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}
+}
+
+func (ca *LocalCA) loadOrCreateCA() error {
+	certPath := filepath.Join(ca.dir, CACertFile)
+	keyPath := filepath.Join(ca.dir, caKeyFile)
+
+	if pair, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		key, ok := pair.PrivateKey.(*ecdsa.PrivateKey)
+		if !ok {
+			return fmt.Errorf("kunci CA di %s bukan kunci ECDSA", keyPath)
+		}
+		ca.caCert, ca.caKey = pair.Leaf, key
+		if time.Now().After(ca.caCert.NotAfter) {
+			return fmt.Errorf("sertifikat CA lokal sudah kedaluwarsa pada %s; hapus %s dan %s untuk membuat CA baru",
+				ca.caCert.NotAfter.Format("2006-01-02"), certPath, keyPath)
+		}
+		return nil
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("gagal membaca CA lokal: %w", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("gagal membuat kunci CA: %w", err)
+	}
+	hostname, _ := os.Hostname()
+	now := time.Now()
+	permittedDNS, permittedIPs := ca.nameConstraints()
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject: pkix.Name{
+			CommonName:   fmt.Sprintf("SIMDOKPOL Local CA (%s)", hostname),
+			Organization: []string{"SIMDOKPOL"},
+		},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+		// CA hanya berlaku untuk nama dan jaringan server ini, sehingga kunci CA yang bocor
+		// tidak dapat dipakai untuk memalsukan situs lain di komputer klien
+		PermittedDNSDomainsCritical: true,
+		PermittedDNSDomains:         permittedDNS,
+		PermittedIPRanges:           permittedIPs,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("gagal membuat sertifikat CA: %w", err)
+	}
+	if err := writePEMFiles(certPath, der, keyPath, key); err != nil {
+		return err
+	}
+
+	ca.caCert, _ = x509.ParseCertificate(der)
+	ca.caKey = key
+	log.Printf("INFO: CA lokal baru dibuat di %s. Pasang berkas ini sebagai root tepercaya di komputer klien.", certPath)
+	return nil
+}
+
+func (ca *LocalCA) issueServerCert(hosts []string, certPath, keyPath string) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("gagal membuat kunci server: %w", err)
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{CommonName: LocalDomain, Organization: []string{"SIMDOKPOL"}},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(serverValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, host := range hosts {
+		if ip := net.ParseIP(host); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, host)
+		}
+	}
+	// Sertifikat server tidak boleh berlaku lebih lama dari CA penandatangannya
+	if template.NotAfter.After(ca.caCert.NotAfter) {
+		template.NotAfter = ca.caCert.NotAfter
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.caCert, &key.PublicKey, ca.caKey)
+	if err != nil {
+		return nil, fmt.Errorf("gagal menandatangani sertifikat server: %w", err)
+	}
+	if err := writePEMFiles(certPath, der, keyPath, key); err != nil {
+		return nil, err
+	}
+
+	cert, err := tls.LoadX509KeyPair(certPath, keyPath)
+	if err != nil {
+		return nil, fmt.Errorf("gagal memuat sertifikat server yang baru diterbitkan: %w", err)
+	}
+	return &cert, nil
+}
+
+// writePEMFiles menyimpan sertifikat (dapat dibaca semua) dan kunci privat (hanya pemilik) dalam format PEM.
+func writePEMFiles(certPath string, der []byte, keyPath string, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("gagal menyandikan kunci privat: %w", err)
+	}
+	var keyPEM bytes.Buffer
+	if err := pem.Encode(&keyPEM, &pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}); err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyPath, keyPEM.Bytes(), 0600); err != nil {
+		return fmt.Errorf("gagal menyimpan %s: %w", keyPath, err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	if err := os.WriteFile(certPath, certPEM, 0644); err != nil {
+		return fmt.Errorf("gagal menyimpan %s: %w", certPath, err)
+	}
+	return nil
+}
+
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		// crypto/rand tidak pernah gagal di platform yang didukung
+		panic(err)
+	}
+	return serial
+}
+
+// LANAddresses mengembalikan alamat IP unicast milik komputer ini yang dapat dijangkau dari
+// jaringan lokal, tanpa loopback dan link-local.
+func LANAddresses() []string {
+	var ips []string
+	for _, ipNet := range lanNetworks() {
+		ips = append(ips, ipNet.IP.String())
+	}
+	return ips
+}
+
+// lanNetworks mengembalikan alamat LAN komputer ini beserta mask jaringannya.
+func lanNetworks() []*net.IPNet {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		log.Printf("PERINGATAN: Gagal membaca alamat jaringan: %v", err)
+		return nil
+	}
+	var networks []*net.IPNet
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := ipNet.IP
+		if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsMulticast() || ip.IsUnspecified() {
+			continue
+		}
+		networks = append(networks, ipNet)
+	}
+	return networks
+}
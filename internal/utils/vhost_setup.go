@@ -290,12 +290,14 @@ func (v *VHostSetup) GetDomain() string {
 	return v.domain
 }
 
-// GetURL mengembalikan URL lengkap aplikasi
-func (v *VHostSetup) GetURL(port string) string {
-	if !strings.HasPrefix(port, ":") {
-		port = ":" + port
+// GetURL mengembalikan URL lengkap aplikasi untuk skema (http/https) dan port tertentu.
+// Port bawaan skema (80 atau 443) tidak dicantumkan.
+func (v *VHostSetup) GetURL(scheme string, port string) string {
+	port = strings.TrimPrefix(port, ":")
+	if (scheme == "http" && port == "80") || (scheme == "https" && port == "443") {
+		return fmt.Sprintf("%s://%s", scheme, v.domain)
 	}
-	return fmt.Sprintf("http://%s%s", v.domain, port)
+	return fmt.Sprintf("%s://%s:%s", scheme, v.domain, port)
 }
 
 // getTimestamp helper function untuk mendapatkan timestamp
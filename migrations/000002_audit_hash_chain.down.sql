@@ -0,0 +1,5 @@
+-- Menghapus kolom rantai hash dari audit_logs (Migrasi TURUN / Rollback)
+
+DROP INDEX `idx_audit_logs_hash`;
+ALTER TABLE `audit_logs` DROP COLUMN `hash`;
+ALTER TABLE `audit_logs` DROP COLUMN `prev_hash`;
@@ -0,0 +1,5 @@
+-- Menambahkan kolom rantai hash pada audit_logs (Migrasi NAIK)
+
+ALTER TABLE `audit_logs` ADD COLUMN `prev_hash` text NOT NULL DEFAULT '';
+ALTER TABLE `audit_logs` ADD COLUMN `hash` text NOT NULL DEFAULT '';
+CREATE INDEX `idx_audit_logs_hash` ON `audit_logs`(`hash`);
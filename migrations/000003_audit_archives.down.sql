@@ -0,0 +1,3 @@
+-- Menghapus tabel arsip log audit (Migrasi TURUN / Rollback)
+
+DROP TABLE `audit_archives`;
@@ -0,0 +1,13 @@
+-- Tabel pencatat berkas arsip log audit (Migrasi NAIK)
+
+CREATE TABLE `audit_archives` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `file_name` text NOT NULL,
+    `file_sha256` text NOT NULL,
+    `first_id` integer NOT NULL,
+    `last_id` integer NOT NULL,
+    `last_hash` text NOT NULL,
+    `entry_count` integer NOT NULL,
+    `created_at` datetime
+);
+CREATE INDEX `idx_audit_archives_last_id` ON `audit_archives`(`last_id`);
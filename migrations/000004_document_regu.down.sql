@@ -0,0 +1,4 @@
+-- Menghapus kolom regu dari lost_documents (Migrasi TURUN / Rollback)
+
+DROP INDEX `idx_lost_documents_regu`;
+ALTER TABLE `lost_documents` DROP COLUMN `regu`;
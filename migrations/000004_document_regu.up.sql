@@ -0,0 +1,6 @@
+-- Menyimpan regu operator pada saat dokumen dibuat (Migrasi NAIK)
+-- Regu disalin ke dokumen agar perpindahan regu petugas tidak mengubah riwayat dokumen.
+
+ALTER TABLE `lost_documents` ADD COLUMN `regu` text NOT NULL DEFAULT '';
+UPDATE `lost_documents` SET `regu` = COALESCE((SELECT `users`.`regu` FROM `users` WHERE `users`.`id` = `lost_documents`.`operator_id`), '');
+CREATE INDEX `idx_lost_documents_regu` ON `lost_documents`(`regu`);
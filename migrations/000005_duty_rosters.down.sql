@@ -0,0 +1,3 @@
+-- Menghapus tabel jadwal jaga regu (Migrasi TURUN / Rollback)
+
+DROP TABLE `duty_rosters`;
@@ -0,0 +1,21 @@
+-- Membuat tabel jadwal jaga regu (Migrasi NAIK)
+
+CREATE TABLE `duty_rosters` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `tanggal` text NOT NULL,
+    `shift` text NOT NULL,
+    `regu` text NOT NULL,
+    `mulai_pada` datetime NOT NULL,
+    `selesai_pada` datetime NOT NULL,
+    `petugas_pelapor_id` integer NOT NULL,
+    `pejabat_persetuju_id` integer NOT NULL,
+    `keterangan` text,
+    `created_at` datetime,
+    `updated_at` datetime,
+    FOREIGN KEY (`petugas_pelapor_id`) REFERENCES `users`(`id`),
+    FOREIGN KEY (`pejabat_persetuju_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX `idx_duty_rosters_tanggal` ON `duty_rosters`(`tanggal`);
+CREATE INDEX `idx_duty_rosters_regu` ON `duty_rosters`(`regu`);
+CREATE INDEX `idx_duty_rosters_mulai_pada` ON `duty_rosters`(`mulai_pada`);
+CREATE INDEX `idx_duty_rosters_selesai_pada` ON `duty_rosters`(`selesai_pada`);
@@ -0,0 +1,3 @@
+-- Menghapus tabel penghitung percobaan login gagal (Migrasi TURUN / Rollback)
+
+DROP TABLE `login_throttles`;
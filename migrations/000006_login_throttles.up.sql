@@ -0,0 +1,9 @@
+-- Membuat tabel penghitung percobaan login gagal (Migrasi NAIK)
+
+CREATE TABLE `login_throttles` (
+    `key` text PRIMARY KEY,
+    `failures` integer NOT NULL DEFAULT 0,
+    `last_failure_at` datetime NOT NULL,
+    `locked_until` datetime
+);
+CREATE INDEX `idx_login_throttles_locked_until` ON `login_throttles`(`locked_until`);
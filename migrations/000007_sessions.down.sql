@@ -0,0 +1,3 @@
+-- Menghapus tabel sesi login (Migrasi TURUN / Rollback)
+
+DROP TABLE `sessions`;
@@ -0,0 +1,15 @@
+-- Membuat tabel sesi login di sisi server (Migrasi NAIK)
+
+CREATE TABLE `sessions` (
+    `id` text PRIMARY KEY,
+    `user_id` integer NOT NULL,
+    `ip_address` text,
+    `user_agent` text,
+    `created_at` datetime,
+    `last_seen_at` datetime NOT NULL,
+    `expires_at` datetime NOT NULL,
+    `revoked_at` datetime,
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX `idx_sessions_user_id` ON `sessions`(`user_id`);
+CREATE INDEX `idx_sessions_expires_at` ON `sessions`(`expires_at`);
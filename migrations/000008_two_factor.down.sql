@@ -0,0 +1,6 @@
+-- Menghapus otentikasi dua faktor dan kode pemulihan (Migrasi TURUN / Rollback)
+
+DROP TABLE `recovery_codes`;
+ALTER TABLE `users` DROP COLUMN `totp_last_step`;
+ALTER TABLE `users` DROP COLUMN `totp_enabled`;
+ALTER TABLE `users` DROP COLUMN `totp_secret`;
@@ -0,0 +1,17 @@
+-- Menambahkan otentikasi dua faktor (TOTP) dan kode pemulihan (Migrasi NAIK)
+-- totp_secret terisi tetapi totp_enabled masih 0 berarti pendaftaran belum dikonfirmasi.
+-- totp_last_step menyimpan langkah waktu kode terakhir yang diterima untuk mencegah pemakaian ulang kode.
+
+ALTER TABLE `users` ADD COLUMN `totp_secret` text NOT NULL DEFAULT '';
+ALTER TABLE `users` ADD COLUMN `totp_enabled` numeric NOT NULL DEFAULT false;
+ALTER TABLE `users` ADD COLUMN `totp_last_step` integer NOT NULL DEFAULT 0;
+
+CREATE TABLE `recovery_codes` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `code_hash` text NOT NULL,
+    `used_at` datetime,
+    `created_at` datetime,
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX `idx_recovery_codes_user_id` ON `recovery_codes`(`user_id`);
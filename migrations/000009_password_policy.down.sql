@@ -0,0 +1,5 @@
+-- Menghapus kebijakan kata sandi dan riwayatnya (Migrasi TURUN / Rollback)
+
+DROP TABLE `password_histories`;
+ALTER TABLE `users` DROP COLUMN `must_change_password`;
+ALTER TABLE `users` DROP COLUMN `password_changed_at`;
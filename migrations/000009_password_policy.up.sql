@@ -0,0 +1,14 @@
+-- Menambahkan kebijakan kata sandi: masa berlaku, wajib ganti, dan riwayat (Migrasi NAIK)
+-- password_changed_at NULL berarti kata sandi belum pernah diganti sejak akun dibuat.
+
+ALTER TABLE `users` ADD COLUMN `password_changed_at` datetime;
+ALTER TABLE `users` ADD COLUMN `must_change_password` numeric NOT NULL DEFAULT false;
+
+CREATE TABLE `password_histories` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `password_hash` text NOT NULL,
+    `created_at` datetime,
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE INDEX `idx_password_histories_user_id` ON `password_histories`(`user_id`);
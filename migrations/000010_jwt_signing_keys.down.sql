@@ -0,0 +1,3 @@
+-- Menghapus key ring penandatanganan JWT (Migrasi TURUN / Rollback)
+
+DROP TABLE `jwt_signing_keys`;
@@ -0,0 +1,14 @@
+-- Menambahkan key ring penandatanganan JWT (Migrasi NAIK)
+-- kid dikirim pada header token untuk memilih kunci saat verifikasi.
+-- secret hanya berupa bahan acak; kunci HMAC sebenarnya diturunkan dari JWT_SECRET_KEY,
+-- sehingga salinan database saja tidak cukup untuk memalsukan token.
+-- retired_at terisi saat kunci digantikan; kunci lama tetap diterima selama masa tenggang.
+
+CREATE TABLE `jwt_signing_keys` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `kid` text NOT NULL,
+    `secret` text NOT NULL DEFAULT '',
+    `created_at` datetime,
+    `retired_at` datetime
+);
+CREATE UNIQUE INDEX `idx_jwt_signing_keys_kid` ON `jwt_signing_keys`(`kid`);
@@ -0,0 +1,3 @@
+-- Menghapus API token (Migrasi TURUN / Rollback)
+
+DROP TABLE `api_tokens`;
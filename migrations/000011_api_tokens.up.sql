@@ -0,0 +1,21 @@
+-- Menambahkan API token untuk integrasi antar sistem (Migrasi NAIK)
+-- Hanya hash SHA-256 token yang disimpan; prefix disimpan agar token dapat dikenali di daftar.
+-- scopes berisi daftar hak akses dipisah koma yang membatasi hak akses peran pemilik token.
+
+CREATE TABLE `api_tokens` (
+    `id` integer PRIMARY KEY AUTOINCREMENT,
+    `user_id` integer NOT NULL,
+    `name` text NOT NULL,
+    `prefix` text NOT NULL,
+    `token_hash` text NOT NULL,
+    `scopes` text NOT NULL DEFAULT '',
+    `expires_at` datetime NOT NULL,
+    `last_used_at` datetime,
+    `last_used_ip` text NOT NULL DEFAULT '',
+    `revoked_at` datetime,
+    `created_by_id` integer NOT NULL,
+    `created_at` datetime,
+    FOREIGN KEY (`user_id`) REFERENCES `users`(`id`)
+);
+CREATE UNIQUE INDEX `idx_api_tokens_token_hash` ON `api_tokens`(`token_hash`);
+CREATE INDEX `idx_api_tokens_user_id` ON `api_tokens`(`user_id`);
@@ -0,0 +1,3 @@
+-- Menghapus sumber autentikasi pengguna (Migrasi TURUN / Rollback)
+
+ALTER TABLE `users` DROP COLUMN `auth_source`;
@@ -0,0 +1,4 @@
+-- Menandai sumber autentikasi pengguna (Migrasi NAIK)
+-- 'local' memakai kata sandi bcrypt di database, 'ldap' diverifikasi ke direktori
+
+ALTER TABLE `users` ADD COLUMN `auth_source` text NOT NULL DEFAULT 'local';
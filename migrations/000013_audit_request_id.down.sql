@@ -0,0 +1,3 @@
+-- Menghapus ID request dari log audit (Migrasi TURUN / Rollback)
+
+ALTER TABLE `audit_logs` DROP COLUMN `request_id`;
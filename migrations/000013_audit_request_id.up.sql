@@ -0,0 +1,4 @@
+-- Menyimpan ID request HTTP pada log audit (Migrasi NAIK)
+-- Kosong untuk entri lama dan aksi dari sistem atau CLI
+
+ALTER TABLE `audit_logs` ADD COLUMN `request_id` text NOT NULL DEFAULT '';
@@ -0,0 +1,4 @@
+-- Menghapus rentang waktu berkas arsip log audit (Migrasi TURUN / Rollback)
+
+ALTER TABLE `audit_archives` DROP COLUMN `last_timestamp`;
+ALTER TABLE `audit_archives` DROP COLUMN `first_timestamp`;
@@ -0,0 +1,5 @@
+-- Menyimpan rentang waktu entri pada setiap berkas arsip log audit (Migrasi NAIK)
+-- Kosong untuk arsip lama; arsip tersebut selalu dibaca saat ekspor lalu disaring per entri
+
+ALTER TABLE `audit_archives` ADD COLUMN `first_timestamp` datetime;
+ALTER TABLE `audit_archives` ADD COLUMN `last_timestamp` datetime;
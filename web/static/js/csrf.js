@@ -0,0 +1,41 @@
+/*
+ * Menyertakan token CSRF (double-submit cookie) pada setiap request yang mengubah data.
+ * Server menolak POST/PUT/DELETE berbasis cookie tanpa header X-CSRF-Token yang cocok
+ * dengan cookie csrf_token. Harus dimuat setelah jQuery.
+ */
+(function () {
+    var SAFE_METHODS = /^(GET|HEAD|OPTIONS)$/i;
+
+    function csrfToken() {
+        var match = document.cookie.match(/(?:^|;\s*)csrf_token=([^;]+)/);
+        return match ? decodeURIComponent(match[1]) : '';
+    }
+
+    function isSameOrigin(url) {
+        var target = new URL(url, window.location.href);
+        return target.origin === window.location.origin;
+    }
+
+    if (window.jQuery) {
+        jQuery.ajaxPrefilter(function (options, originalOptions, jqXHR) {
+            if (!SAFE_METHODS.test(options.type) && isSameOrigin(options.url)) {
+                jqXHR.setRequestHeader('X-CSRF-Token', csrfToken());
+            }
+        });
+    }
+
+    if (window.fetch) {
+        var originalFetch = window.fetch;
+        window.fetch = function (input, init) {
+            init = init || {};
+            var method = init.method || (input instanceof Request ? input.method : 'GET');
+            var url = input instanceof Request ? input.url : String(input);
+            if (!SAFE_METHODS.test(method) && isSameOrigin(url)) {
+                var headers = new Headers(init.headers || (input instanceof Request ? input.headers : undefined));
+                headers.set('X-CSRF-Token', csrfToken());
+                init.headers = headers;
+            }
+            return originalFetch.call(this, input, init);
+        };
+    }
+})();
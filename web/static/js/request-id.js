@@ -0,0 +1,21 @@
+/*
+ * Menambahkan ID request ke pesan error dari API agar pengguna dapat menyebutkannya saat
+ * melaporkan masalah. Server menyertakan request_id pada setiap respons error; ID yang sama
+ * tercatat di log aplikasi dan log audit. Harus dimuat setelah jQuery.
+ */
+(function () {
+    if (!window.jQuery) {
+        return;
+    }
+
+    // Callback fail yang dipasang di prefilter berjalan sebelum callback error milik pemanggil,
+    // sehingga pesan sudah berisi ID saat ditampilkan.
+    jQuery.ajaxPrefilter(function (options, originalOptions, jqXHR) {
+        jqXHR.fail(function () {
+            var body = jqXHR.responseJSON;
+            if (body && body.error && body.request_id && body.error.indexOf(body.request_id) === -1) {
+                body.error += ' (ID: ' + body.request_id + ')';
+            }
+        });
+    });
+})();